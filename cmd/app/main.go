@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 
@@ -11,6 +12,8 @@ import (
 	notification "github.com/G9QBootcamp/qoli-survey/internal/notification/service"
 	"github.com/G9QBootcamp/qoli-survey/internal/router"
 	"github.com/G9QBootcamp/qoli-survey/internal/server"
+	surveyRepository "github.com/G9QBootcamp/qoli-survey/internal/survey/repository"
+	survey "github.com/G9QBootcamp/qoli-survey/internal/survey/service"
 	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
 )
 
@@ -45,6 +48,9 @@ func main() {
 	s := server.NewHttpServer()
 	router.RegisterRoutes(conf, dbService, s, logger, notificationService)
 
+	surveyService := survey.NewSurveyService(conf, surveyRepository.NewSurveyRepository(dbService, logger), logger, notificationService)
+	go surveyService.RunScheduler(context.Background(), survey.SchedulerInterval)
+
 	s.Start(fmt.Sprintf("%s:%d", conf.HTTP.Host, conf.HTTP.Port))
 
 }
@@ -56,6 +56,8 @@ type EmailConfig struct {
 	SMTPUser   string `yaml:"smtp_user"`
 	SMTPPass   string `yaml:"smtp_pass"`
 	FromEmail  string `yaml:"from_email"`
+	// InvitationURL is the link survey invitations point to, the invitation token is appended to it.
+	InvitationURL string `yaml:"invitation_url"`
 }
 
 var (
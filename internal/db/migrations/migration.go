@@ -1,6 +1,8 @@
 package migrations
 
 import (
+	"time"
+
 	authModels "github.com/G9QBootcamp/qoli-survey/internal/auth/models"
 	notificationModels "github.com/G9QBootcamp/qoli-survey/internal/notification/models"
 	surveyModels "github.com/G9QBootcamp/qoli-survey/internal/survey/models"
@@ -9,7 +11,10 @@ import (
 )
 
 func AutoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(&userModels.User{},
+	// surveys created before they had a status were live between their start and end times
+	backfillSurveyStatus := db.Migrator().HasTable(&surveyModels.Survey{}) && !db.Migrator().HasColumn(&surveyModels.Survey{}, "Status")
+
+	err := db.AutoMigrate(&userModels.User{},
 		&authModels.OTP{},
 		&userModels.Role{},
 		&userModels.Permission{},
@@ -18,10 +23,39 @@ func AutoMigrate(db *gorm.DB) error {
 		&userModels.VoteVisibility{},
 		&surveyModels.Question{},
 		&surveyModels.Choice{},
+		&surveyModels.GuestSession{},
 		&surveyModels.Vote{},
 		&surveyModels.UserSurveyParticipation{},
 		&notificationModels.Notification{},
 		&surveyModels.SurveyOption{},
+		&surveyModels.SurveyVersion{},
+		&surveyModels.BranchRule{},
+		&surveyModels.Section{},
+		&surveyModels.BankQuestion{},
+		&surveyModels.BankChoice{},
+		&surveyModels.QuestionTiming{},
+		&surveyModels.SurveyQuota{},
+		&surveyModels.QuotaPlace{},
+		&surveyModels.Audience{},
+		&surveyModels.AudienceMember{},
+		&surveyModels.Invitation{},
+		&surveyModels.ShareLink{},
 		&userModels.Transaction{},
 	)
+	if err != nil {
+		return err
+	}
+
+	if backfillSurveyStatus {
+		return migrateSurveyStatus(db, time.Now())
+	}
+	return nil
+}
+
+// migrateSurveyStatus sets the status of the surveys that existed before the status column, the
+// column default would leave them draft. A survey is closed once its end time passed and open
+// before, as a survey was answerable from its start time on.
+func migrateSurveyStatus(db *gorm.DB, now time.Time) error {
+	return db.Unscoped().Model(&surveyModels.Survey{}).Where("1 = 1").
+		Update("status", gorm.Expr("CASE WHEN end_time <= ? THEN ? ELSE ? END", now, surveyModels.SurveyStatusClosed, surveyModels.SurveyStatusOpen)).Error
 }
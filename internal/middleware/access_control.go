@@ -63,3 +63,22 @@ func CheckPermission(requiredPermission string, db db.DbService) echo.Middleware
 		}
 	}
 }
+
+// CheckPermissionOrTemplate lets every user through for template surveys and checks
+// requiredPermission like CheckPermission for all other surveys.
+func CheckPermissionOrTemplate(requiredPermission string, db db.DbService) echo.MiddlewareFunc {
+	check := CheckPermission(requiredPermission, db)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		checked := check(next)
+		return func(c echo.Context) error {
+			var survey surveyModels.Survey
+			if err := db.GetDb().First(&survey, "id = ?", c.Param("survey_id")).Error; err != nil {
+				return echo.NewHTTPError(http.StatusNotFound, map[string]string{"error": "survey not found"})
+			}
+			if survey.IsTemplate {
+				return next(c)
+			}
+			return checked(c)
+		}
+	}
+}
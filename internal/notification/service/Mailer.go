@@ -0,0 +1,49 @@
+package service
+
+import (
+	"fmt"
+	"net/smtp"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"golang.org/x/net/context"
+)
+
+// Mailer sends emails. Services take one so the way mails leave the system can be swapped.
+type Mailer interface {
+	Send(c context.Context, to string, subject string, body string) error
+}
+
+// NewMailer returns the smtp mailer, or a mailer that only logs the mails when no smtp server is configured.
+func NewMailer(conf *config.Config, logger logging.Logger) Mailer {
+	if conf.Email.SMTPServer == "" {
+		return &LogMailer{logger: logger}
+	}
+	return &SMTPMailer{conf: conf}
+}
+
+type SMTPMailer struct {
+	conf *config.Config
+}
+
+func (m *SMTPMailer) Send(c context.Context, to string, subject string, body string) error {
+	msg := []byte("To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"\r\n" +
+		body + "\r\n")
+
+	auth := smtp.PlainAuth("", m.conf.Email.SMTPUser, m.conf.Email.SMTPPass, m.conf.Email.SMTPServer)
+	if err := smtp.SendMail(m.conf.Email.SMTPServer+":"+m.conf.Email.SMTPPort, auth, m.conf.Email.FromEmail, []string{to}, msg); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+type LogMailer struct {
+	logger logging.Logger
+}
+
+func (m *LogMailer) Send(c context.Context, to string, subject string, body string) error {
+	m.logger.Info(logging.Internal, logging.Api, "email not sent, no smtp server is configured", map[logging.ExtraKey]interface{}{"to": to, "subject": subject})
+	return nil
+}
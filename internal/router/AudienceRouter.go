@@ -0,0 +1,31 @@
+package router
+
+import (
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/db"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/handler"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"github.com/labstack/echo/v4"
+)
+
+type AudienceRouter struct {
+	conf        *config.Config
+	db          db.DbService
+	serverGroup *echo.Group
+	handler     *handler.AudienceHandler
+	logger      logging.Logger
+}
+
+func NewAudienceRouter(conf *config.Config, db db.DbService, serverGroup *echo.Group, logger logging.Logger) *AudienceRouter {
+	return &AudienceRouter{conf: conf, db: db, serverGroup: serverGroup, handler: handler.NewAudienceHandler(conf, db, logger), logger: logger}
+}
+
+// RegisterRoutes adds the saved audiences, every user only sees their own.
+func (r *AudienceRouter) RegisterRoutes() {
+	g := r.serverGroup.Group("/audiences")
+	g.GET("", r.handler.GetAudiences)
+	g.POST("", r.handler.CreateAudience)
+	g.GET("/:audience_id", r.handler.GetAudience)
+	g.PUT("/:audience_id", r.handler.UpdateAudience)
+	g.DELETE("/:audience_id", r.handler.DeleteAudience)
+}
@@ -0,0 +1,30 @@
+package router
+
+import (
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/db"
+	middlewares "github.com/G9QBootcamp/qoli-survey/internal/middleware"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/handler"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"github.com/labstack/echo/v4"
+)
+
+type EligibilityRouter struct {
+	conf       *config.Config
+	db         db.DbService
+	routeGroup *echo.Group
+	handler    *handler.EligibilityHandler
+	logger     logging.Logger
+}
+
+func NewEligibilityRouter(conf *config.Config, db db.DbService, routeGroup *echo.Group, logger logging.Logger) *EligibilityRouter {
+	return &EligibilityRouter{conf: conf, db: db, routeGroup: routeGroup, handler: handler.NewEligibilityHandler(conf, db, logger), logger: logger}
+}
+
+func (r *EligibilityRouter) RegisterRoutes() {
+	g := r.routeGroup.Group("/:survey_id")
+	g.GET("/eligibility", r.handler.GetEligibility, middlewares.CheckPermission("view_survey", r.db))
+	g.PUT("/eligibility", r.handler.UpdateEligibility, middlewares.CheckPermission("edit_survey", r.db))
+	g.POST("/eligibility/preview", r.handler.PreviewEligibility, middlewares.CheckPermission("edit_survey", r.db))
+	g.GET("/eligibility/check", r.handler.CheckEligibility, middlewares.CheckPermission("vote", r.db))
+}
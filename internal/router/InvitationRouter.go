@@ -0,0 +1,37 @@
+package router
+
+import (
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/db"
+	middlewares "github.com/G9QBootcamp/qoli-survey/internal/middleware"
+	notification "github.com/G9QBootcamp/qoli-survey/internal/notification/service"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/handler"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"github.com/labstack/echo/v4"
+)
+
+type InvitationRouter struct {
+	conf        *config.Config
+	db          db.DbService
+	serverGroup *echo.Group
+	publicGroup *echo.Group
+	handler     *handler.InvitationHandler
+	logger      logging.Logger
+}
+
+func NewInvitationRouter(conf *config.Config, db db.DbService, serverGroup *echo.Group, publicGroup *echo.Group, logger logging.Logger, notificationService notification.INotificationService) *InvitationRouter {
+	return &InvitationRouter{conf: conf, db: db, serverGroup: serverGroup, publicGroup: publicGroup, handler: handler.NewInvitationHandler(conf, db, logger, notificationService), logger: logger}
+}
+
+// RegisterRoutes adds the invitations of surveys, inviting grants the vote permission so it needs
+// the permission to assign survey roles. The link in invitation emails is public.
+func (r *InvitationRouter) RegisterRoutes() {
+	g := r.serverGroup.Group("/surveys/:survey_id/invitations")
+	g.GET("", r.handler.GetInvitations, middlewares.CheckPermission("view_survey_roles", r.db))
+	g.POST("", r.handler.Invite, middlewares.CheckPermission("assign_and_remove_survey_roles", r.db))
+	g.POST("/:invitation_id/resend", r.handler.ResendInvitation, middlewares.CheckPermission("assign_and_remove_survey_roles", r.db))
+	g.DELETE("/:invitation_id", r.handler.DeleteInvitation, middlewares.CheckPermission("assign_and_remove_survey_roles", r.db))
+
+	r.serverGroup.POST("/invitations/:token/accept", r.handler.AcceptInvitation)
+	r.publicGroup.GET("/:token", r.handler.OpenInvitation)
+}
@@ -0,0 +1,32 @@
+package router
+
+import (
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/db"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/handler"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"github.com/labstack/echo/v4"
+)
+
+type QuestionBankRouter struct {
+	conf        *config.Config
+	db          db.DbService
+	serverGroup *echo.Group
+	handler     *handler.QuestionBankHandler
+	logger      logging.Logger
+}
+
+func NewQuestionBankRouter(conf *config.Config, db db.DbService, serverGroup *echo.Group, logger logging.Logger) *QuestionBankRouter {
+	return &QuestionBankRouter{conf: conf, db: db, serverGroup: serverGroup, handler: handler.NewQuestionBankHandler(conf, db, logger), logger: logger}
+}
+
+// RegisterRoutes adds the question bank, access is checked against the owner of each bank question.
+func (r *QuestionBankRouter) RegisterRoutes() {
+	g := r.serverGroup.Group("/question-bank")
+	g.GET("", r.handler.GetBankQuestions)
+	g.POST("", r.handler.CreateBankQuestion)
+	g.GET("/:bank_question_id", r.handler.GetBankQuestion)
+	g.PUT("/:bank_question_id", r.handler.UpdateBankQuestion)
+	g.DELETE("/:bank_question_id", r.handler.DeleteBankQuestion)
+	g.GET("/:bank_question_id/report", r.handler.GetBankQuestionReport)
+}
@@ -24,6 +24,8 @@ func NewQuestionRouter(conf *config.Config, db db.DbService, routeGroup *echo.Gr
 func (r *QuestionRouter) RegisterRoutes() {
 	g := r.routeGroup.Group("/:survey_id")
 	g.GET("/questions", r.handler.GetQuestions, middlewares.CheckPermission("view_survey", r.db))
+	g.POST("/questions", r.handler.AddQuestion, middlewares.CheckPermission("edit_survey", r.db))
+	g.PUT("/questions/order", r.handler.ReorderQuestions, middlewares.CheckPermission("edit_survey", r.db))
 	g.DELETE("/questions/:question_id", r.handler.DeleteQuestion, middlewares.CheckPermission("edit_survey", r.db))
 	g.GET("/questions/:question_id", r.handler.GetQuestion, middlewares.CheckPermission("view_survey", r.db))
 	g.PATCH("/questions/:question_id", r.handler.UpdateQuestion, middlewares.CheckPermission("edit_survey", r.db))
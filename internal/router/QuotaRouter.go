@@ -0,0 +1,30 @@
+package router
+
+import (
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/db"
+	middlewares "github.com/G9QBootcamp/qoli-survey/internal/middleware"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/handler"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"github.com/labstack/echo/v4"
+)
+
+type QuotaRouter struct {
+	conf       *config.Config
+	db         db.DbService
+	routeGroup *echo.Group
+	handler    *handler.QuotaHandler
+	logger     logging.Logger
+}
+
+func NewQuotaRouter(conf *config.Config, db db.DbService, routeGroup *echo.Group, logger logging.Logger) *QuotaRouter {
+	return &QuotaRouter{conf: conf, db: db, routeGroup: routeGroup, handler: handler.NewQuotaHandler(conf, db, logger), logger: logger}
+}
+
+func (r *QuotaRouter) RegisterRoutes() {
+	g := r.routeGroup.Group("/:survey_id")
+	g.GET("/quotas", r.handler.GetQuotas, middlewares.CheckPermission("view_survey", r.db))
+	g.POST("/quotas", r.handler.CreateQuota, middlewares.CheckPermission("edit_survey", r.db))
+	g.PATCH("/quotas/:quota_id", r.handler.UpdateQuota, middlewares.CheckPermission("edit_survey", r.db))
+	g.DELETE("/quotas/:quota_id", r.handler.DeleteQuota, middlewares.CheckPermission("edit_survey", r.db))
+}
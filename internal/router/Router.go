@@ -20,14 +20,24 @@ func RegisterRoutes(conf *config.Config, db db.DbService, server *server.Server,
 	surveyRouter := NewSurveyRouter(conf, db, apiGroup, logger, notificationService)
 	accessRouter := NewAccessRouter(conf, db, apiGroup, logger, notificationService)
 	notificationRouter := NewNotificationRouter(conf, db, apiGroup, logger, notificationService)
+	questionBankRouter := NewQuestionBankRouter(conf, db, apiGroup, logger)
+	audienceRouter := NewAudienceRouter(conf, db, apiGroup, logger)
 
 	authGroup := server.Echo.Group("/auth")
 	authRouter := NewAuthRouter(conf, db, authGroup, logger)
+	invitationGroup := server.Echo.Group("/invitations")
+	invitationRouter := NewInvitationRouter(conf, db, apiGroup, invitationGroup, logger, notificationService)
+	shareLinkGroup := server.Echo.Group("/s")
+	shareLinkRouter := NewShareLinkRouter(conf, db, apiGroup, shareLinkGroup, logger, notificationService)
 
 	authRouter.RegisterRoutes()
 	userRouter.RegisterRoutes(db)
 	surveyRouter.RegisterRoutes()
 	accessRouter.RegisterRoutes(db)
 	notificationRouter.RegisterRoutes()
+	questionBankRouter.RegisterRoutes()
+	audienceRouter.RegisterRoutes()
+	invitationRouter.RegisterRoutes()
+	shareLinkRouter.RegisterRoutes()
 	// Additional routers...
 }
@@ -0,0 +1,30 @@
+package router
+
+import (
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/db"
+	middlewares "github.com/G9QBootcamp/qoli-survey/internal/middleware"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/handler"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"github.com/labstack/echo/v4"
+)
+
+type SectionRouter struct {
+	conf       *config.Config
+	db         db.DbService
+	routeGroup *echo.Group
+	handler    *handler.SectionHandler
+	logger     logging.Logger
+}
+
+func NewSectionRouter(conf *config.Config, db db.DbService, routeGroup *echo.Group, logger logging.Logger) *SectionRouter {
+	return &SectionRouter{conf: conf, db: db, routeGroup: routeGroup, handler: handler.NewSectionHandler(conf, db, logger), logger: logger}
+}
+
+func (r *SectionRouter) RegisterRoutes() {
+	g := r.routeGroup.Group("/:survey_id")
+	g.GET("/sections", r.handler.GetSections, middlewares.CheckPermission("view_survey", r.db))
+	g.POST("/sections", r.handler.CreateSection, middlewares.CheckPermission("edit_survey", r.db))
+	g.PATCH("/sections/:section_id", r.handler.UpdateSection, middlewares.CheckPermission("edit_survey", r.db))
+	g.DELETE("/sections/:section_id", r.handler.DeleteSection, middlewares.CheckPermission("edit_survey", r.db))
+}
@@ -0,0 +1,47 @@
+package router
+
+import (
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/db"
+	middlewares "github.com/G9QBootcamp/qoli-survey/internal/middleware"
+	notification "github.com/G9QBootcamp/qoli-survey/internal/notification/service"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/handler"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"github.com/labstack/echo/v4"
+)
+
+type ShareLinkRouter struct {
+	conf                 *config.Config
+	db                   db.DbService
+	serverGroup          *echo.Group
+	publicGroup          *echo.Group
+	handler              *handler.ShareLinkHandler
+	participationHandler *handler.ParticipationHandler
+	logger               logging.Logger
+}
+
+func NewShareLinkRouter(conf *config.Config, db db.DbService, serverGroup *echo.Group, publicGroup *echo.Group, logger logging.Logger, notificationService notification.INotificationService) *ShareLinkRouter {
+	return &ShareLinkRouter{conf: conf, db: db, serverGroup: serverGroup, publicGroup: publicGroup,
+		handler:              handler.NewShareLinkHandler(conf, db, logger, notificationService),
+		participationHandler: handler.NewGuestParticipationHandler(conf, db, logger, notificationService),
+		logger:               logger,
+	}
+}
+
+// RegisterRoutes adds the share links of surveys. Guests open a share link and answer its survey
+// without signing in, the guest session is all they need. Guests answer over the websocket or a
+// request at a time, like users do.
+func (r *ShareLinkRouter) RegisterRoutes() {
+	g := r.serverGroup.Group("/surveys/:survey_id/share-link")
+	g.GET("", r.handler.GetShareLink, middlewares.CheckPermission("view_survey", r.db))
+	g.PUT("", r.handler.UpdateShareLink, middlewares.CheckPermission("edit_survey", r.db))
+
+	r.publicGroup.GET("/:slug", r.handler.OpenShareLink)
+	r.publicGroup.GET("/:slug/start", r.handler.StartGuestSurvey)
+	r.publicGroup.GET("/:slug/participations/:participation_id/resume", r.handler.ResumeGuestSurvey)
+	r.publicGroup.POST("/:slug/participations", r.participationHandler.StartParticipation)
+	r.publicGroup.GET("/:slug/participations/:participation_id/next", r.participationHandler.NextQuestion)
+	r.publicGroup.POST("/:slug/participations/:participation_id/answers", r.participationHandler.AnswerQuestion)
+	r.publicGroup.POST("/:slug/participations/:participation_id/back", r.participationHandler.GoBack)
+	r.publicGroup.POST("/:slug/participations/:participation_id/commit", r.participationHandler.CommitParticipation)
+}
@@ -11,45 +11,74 @@ import (
 )
 
 type SurveyRouter struct {
-	conf          *config.Config
-	db            db.DbService
-	serverGroup   *echo.Group
-	handler       *handler.SurveyHandler
-	reportHandler *handler.ReportHandler
-	logger        logging.Logger
+	conf                 *config.Config
+	db                   db.DbService
+	serverGroup          *echo.Group
+	handler              *handler.SurveyHandler
+	reportHandler        *handler.ReportHandler
+	participationHandler *handler.ParticipationHandler
+	logger               logging.Logger
 }
 
 func NewSurveyRouter(conf *config.Config, db db.DbService, serverGroup *echo.Group, logger logging.Logger, notificationService notification.INotificationService) *SurveyRouter {
 	return &SurveyRouter{conf: conf, db: db, serverGroup: serverGroup,
-		handler:       handler.NewSurveyHandler(conf, db, logger, notificationService),
-		reportHandler: handler.NewReportHandler(conf, db, logger),
-		logger:        logger,
+		handler:              handler.NewSurveyHandler(conf, db, logger, notificationService),
+		reportHandler:        handler.NewReportHandler(conf, db, logger),
+		participationHandler: handler.NewParticipationHandler(conf, db, logger, notificationService),
+		logger:               logger,
 	}
 }
 
 func (r *SurveyRouter) RegisterRoutes() {
 	g := r.serverGroup.Group("/surveys")
 	g.POST("", r.handler.CreateSurvey)
+	g.POST("/import", r.handler.ImportSurvey)
+	g.GET("/:survey_id/export", r.handler.ExportSurvey, middlewares.CheckPermission("view_survey", r.db))
 	g.DELETE("/:survey_id", r.handler.DeleteSurvey, middlewares.CheckPermission("edit_survey", r.db))
 	g.PATCH("/:survey_id", r.handler.UpdateSurvey, middlewares.CheckPermission("edit_survey", r.db))
-	g.GET("/:survey_id", r.handler.GetSurvey, middlewares.CheckPermission("view_survey", r.db))
+	g.POST("/:survey_id/status", r.handler.ChangeSurveyStatus, middlewares.CheckPermission("edit_survey", r.db))
+	g.GET("/:survey_id", r.handler.GetSurvey, middlewares.CheckPermissionOrTemplate("view_survey", r.db))
+	g.POST("/:survey_id/clone", r.handler.CloneSurvey, middlewares.CheckPermissionOrTemplate("view_survey", r.db))
 	g.GET("", r.handler.GetSurveys, middlewares.CheckPermission("view_survey", r.db))
-	g.GET("/:survey_id/start", r.handler.StartSurvey, middlewares.CheckPermission("vote", r.db), middlewares.CanUserVoteOnSurvey(r.db))
+	g.GET("/:survey_id/start", r.handler.StartSurvey, middlewares.CheckPermission("vote", r.db))
+	g.GET("/:survey_id/participations/:participation_id/resume", r.handler.ResumeSurvey, middlewares.CheckPermission("vote", r.db))
+	g.POST("/:survey_id/participations", r.participationHandler.StartParticipation, middlewares.CheckPermission("vote", r.db))
+	g.GET("/:survey_id/participations/:participation_id/next", r.participationHandler.NextQuestion, middlewares.CheckPermission("vote", r.db))
+	g.POST("/:survey_id/participations/:participation_id/answers", r.participationHandler.AnswerQuestion, middlewares.CheckPermission("vote", r.db))
+	g.POST("/:survey_id/participations/:participation_id/back", r.participationHandler.GoBack, middlewares.CheckPermission("vote", r.db))
+	g.POST("/:survey_id/participations/:participation_id/commit", r.participationHandler.CommitParticipation, middlewares.CheckPermission("vote", r.db))
 	g.GET("/:survey_id/reports", r.reportHandler.GetSurveyReport, middlewares.CheckPermission("view_survey_reports", r.db))
+	g.GET("/:survey_id/reports/versions/:version", r.reportHandler.GetVersionReport, middlewares.CheckPermission("view_survey_reports", r.db))
+	g.GET("/:survey_id/reports/sections", r.reportHandler.GetSectionReports, middlewares.CheckPermission("view_survey_reports", r.db))
+	g.POST("/:survey_id/reports/merge", r.reportHandler.GetMergedReport, middlewares.CheckPermission("view_survey_reports", r.db))
 	g.POST("/reports-to-csv", r.reportHandler.GenerateAllSurveysReport)
 	g.GET("/:survey_id/users/:user_id/votes", r.handler.GetUserVotes)
 	g.GET("/:survey_id/visible-vote-users", r.handler.GetVisibleVoteUsers)
 
 	g.DELETE("/:survey_id/votes/:vote_id", r.handler.DeleteVote, middlewares.CheckPermission("vote", r.db))
 	g.GET("/:survey_id/votes", r.handler.SurveyVotes, middlewares.CheckPermission("view_survey_results", r.db))
+	g.GET("/:survey_id/participations/:participation_id/order", r.handler.GetParticipationOrder, middlewares.CheckPermission("view_survey_results", r.db))
 
+	g.GET("/options/schema", r.handler.GetSurveyOptionSchemas)
 	g.POST("/:survey_id/options", r.handler.CreateSurveyOption, middlewares.CheckPermission("edit_survey", r.db))
 	g.DELETE("/:survey_id/options/:option_id", r.handler.DeleteSurveyOption, middlewares.CheckPermission("edit_survey", r.db))
 	g.PATCH("/:survey_id/options/:option_id", r.handler.UpdateSurveyOption, middlewares.CheckPermission("edit_survey", r.db))
 	g.GET("/:survey_id/options", r.handler.GetSurveyOptions, middlewares.CheckPermission("view_survey", r.db))
 
+	g.GET("/:survey_id/versions", r.handler.GetSurveyVersions, middlewares.CheckPermission("view_survey", r.db))
+	g.GET("/:survey_id/versions/:version", r.handler.GetSurveyVersion, middlewares.CheckPermission("view_survey", r.db))
+
+	g.GET("/:survey_id/rules", r.handler.GetBranchRules, middlewares.CheckPermission("view_survey", r.db))
+	g.PUT("/:survey_id/rules", r.handler.UpdateBranchRules, middlewares.CheckPermission("edit_survey", r.db))
+
 	g.POST("/upload", r.handler.UploadMedia)
 	questionRouter := NewQuestionRouter(r.conf, r.db, g, r.logger)
 	questionRouter.RegisterRoutes()
+	sectionRouter := NewSectionRouter(r.conf, r.db, g, r.logger)
+	sectionRouter.RegisterRoutes()
+	quotaRouter := NewQuotaRouter(r.conf, r.db, g, r.logger)
+	quotaRouter.RegisterRoutes()
+	eligibilityRouter := NewEligibilityRouter(r.conf, r.db, g, r.logger)
+	eligibilityRouter.RegisterRoutes()
 
 }
@@ -0,0 +1,46 @@
+package dto
+
+type RuleOperator string
+
+const (
+	OperatorEqual        RuleOperator = "eq"
+	OperatorNotEqual     RuleOperator = "neq"
+	OperatorGreater      RuleOperator = "gt"
+	OperatorGreaterEqual RuleOperator = "gte"
+	OperatorLess         RuleOperator = "lt"
+	OperatorLessEqual    RuleOperator = "lte"
+	OperatorContains     RuleOperator = "contains"
+	OperatorAnswered     RuleOperator = "answered"
+	OperatorNotAnswered  RuleOperator = "not_answered"
+)
+
+// RuleExpression is a condition over the answers given so far in a participation.
+// A node is either a group (and, or, not) or a comparison of the answer to question_id with value.
+// An empty expression always matches.
+type RuleExpression struct {
+	And        []RuleExpression `json:"and,omitempty"`
+	Or         []RuleExpression `json:"or,omitempty"`
+	Not        *RuleExpression  `json:"not,omitempty"`
+	QuestionID uint             `json:"question_id,omitempty"`
+	Operator   RuleOperator     `json:"operator,omitempty"`
+	Value      string           `json:"value,omitempty"`
+}
+
+func (e *RuleExpression) IsEmpty() bool {
+	return len(e.And) == 0 && len(e.Or) == 0 && e.Not == nil && e.QuestionID == 0 && e.Operator == "" && e.Value == ""
+}
+
+// BranchRule sends the participant to TargetQuestionID, or to the end of the survey,
+// when Condition matches after QuestionID is answered. Rules of a question are tried by priority.
+type BranchRule struct {
+	ID               uint           `json:"id"`
+	QuestionID       uint           `json:"question_id" validate:"required"`
+	Priority         int            `json:"priority"`
+	Condition        RuleExpression `json:"condition"`
+	TargetQuestionID uint           `json:"target_question_id"`
+	EndSurvey        bool           `json:"end_survey"`
+}
+
+type BranchRulesUpdateRequest struct {
+	Rules []BranchRule `json:"rules" validate:"dive"`
+}
@@ -0,0 +1,52 @@
+package dto
+
+// EligibilityRule decides who may take a survey. A group combines its rules with and or or, a
+// condition checks one fact of the participant by type:
+//
+//	city            the city is one of values
+//	age             the age from the date of birth is between min_age and max_age, either may be left out
+//	email_domain    the email address is at one of the domains of values
+//	account_age     the account is at least min_account_days old
+//	email_verified  the email address is verified
+//	audience        the participant is a member of the saved audience audience_id
+//	participated    the participant committed a participation in survey survey_id
+type EligibilityRule struct {
+	And            []EligibilityRule `json:"and,omitempty"`
+	Or             []EligibilityRule `json:"or,omitempty"`
+	Type           string            `json:"type,omitempty"`
+	Values         []string          `json:"values,omitempty"`
+	MinAge         *int              `json:"min_age,omitempty"`
+	MaxAge         *int              `json:"max_age,omitempty"`
+	MinAccountDays int               `json:"min_account_days,omitempty"`
+	AudienceID     uint              `json:"audience_id,omitempty"`
+	SurveyID       uint              `json:"survey_id,omitempty"`
+}
+
+// EligibilityRequest replaces the eligibility rule of a survey, a missing rule lets everyone take it.
+type EligibilityRequest struct {
+	Rule *EligibilityRule `json:"rule"`
+}
+
+// EligibilityResponse is the eligibility rule of a survey with the number of users it lets take the survey.
+type EligibilityResponse struct {
+	Rule          *EligibilityRule `json:"rule"`
+	EligibleUsers int64            `json:"eligible_users"`
+}
+
+// EligibilityCheckResponse tells a participant whether they can take a survey and why not.
+type EligibilityCheckResponse struct {
+	Eligible bool     `json:"eligible"`
+	Reasons  []string `json:"reasons,omitempty"`
+}
+
+type Audience struct {
+	ID      uint   `json:"audience_id"`
+	Name    string `json:"name"`
+	OwnerID uint   `json:"owner_id"`
+	UserIDs []uint `json:"user_ids"`
+}
+
+type AudienceRequest struct {
+	Name    string `json:"name" validate:"required"`
+	UserIDs []uint `json:"user_ids"`
+}
@@ -0,0 +1,50 @@
+package dto
+
+import "time"
+
+type Invitation struct {
+	ID          uint       `json:"invitation_id"`
+	SurveyID    uint       `json:"survey_id"`
+	Email       string     `json:"email"`
+	UserID      *uint      `json:"user_id,omitempty"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	OpenedAt    *time.Time `json:"opened_at,omitempty"`
+	AcceptedAt  *time.Time `json:"accepted_at,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	BouncedAt   *time.Time `json:"bounced_at,omitempty"`
+}
+
+// InvitationRequest invites the users of UserIDs and the owners of Emails, Message is added to the
+// invitation email. Invitations expire after ExpiresInHours, they never do when it is zero.
+type InvitationRequest struct {
+	Emails         []string `json:"emails"`
+	UserIDs        []uint   `json:"user_ids"`
+	Message        string   `json:"message"`
+	ExpiresInHours int      `json:"expires_in_hours" validate:"min=0"`
+}
+
+// InvitationResponse lists the invitations sent and the emails skipped because they were invited before.
+type InvitationResponse struct {
+	Invitations []*Invitation `json:"invitations"`
+	Skipped     []string      `json:"skipped,omitempty"`
+}
+
+// InvitationsResponse lists the invitations of a survey with the number of them in each status.
+type InvitationsResponse struct {
+	Invitations []*Invitation  `json:"invitations"`
+	Statuses    map[string]int `json:"statuses"`
+}
+
+// InvitationPreview is what the link of an invitation email shows before the invitee signs in.
+type InvitationPreview struct {
+	SurveyID    uint      `json:"survey_id"`
+	SurveyTitle string    `json:"survey_title"`
+	Email       string    `json:"email"`
+	Status      string    `json:"status"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+}
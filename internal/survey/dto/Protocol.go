@@ -0,0 +1,75 @@
+package dto
+
+// ProtocolV1 is the websocket subprotocol of version 1 of the typed participation protocol. A client
+// that does not ask for it keeps getting the untyped VoteResponse and PageResponse messages.
+const ProtocolV1 = "qoli-survey.v1"
+
+// MessageType tells what a message of the typed participation protocol carries.
+type MessageType string
+
+const (
+	MessageQuestion  MessageType = "question"
+	MessageError     MessageType = "error"
+	MessageProgress  MessageType = "progress"
+	MessageTimer     MessageType = "timer"
+	MessageCompleted MessageType = "completed"
+)
+
+const (
+	TimerQuestionExpired = "question_expired"
+)
+
+const (
+	OutcomeCommitted        = "committed"
+	OutcomeScreenedOut      = "screened_out"
+	OutcomeTimeLimitReached = "time_limit_reached"
+)
+
+// ProtocolMessage is the envelope of every message the server sends in the typed participation
+// protocol, Type tells which of the other fields are set:
+//
+//   - question: Question, or Section and Questions in page mode, with Progress and Timer
+//   - error: Error, the question on screen stays the same
+//   - progress: Progress after an answer was accepted, the next question follows
+//   - timer: Timer with the event that changed the time of the participant
+//   - completed: Outcome and the Result of a committed quiz, the participation is over
+type ProtocolMessage struct {
+	Version         int             `json:"version"`
+	Type            MessageType     `json:"type"`
+	ParticipationID uint            `json:"participation_id"`
+	Question        *Question       `json:"question,omitempty"`
+	Section         *Section        `json:"section,omitempty"`
+	Questions       []*Question     `json:"questions,omitempty"`
+	Resumed         bool            `json:"resumed,omitempty"`
+	Error           *ProtocolError  `json:"error,omitempty"`
+	Progress        *ProgressStatus `json:"progress,omitempty"`
+	Timer           *TimerStatus    `json:"timer,omitempty"`
+	Outcome         string          `json:"outcome,omitempty"`
+	Result          *QuizResult     `json:"result,omitempty"`
+}
+
+// ProtocolError is a rejected message, Code is stable whatever the language of Message. Errors has
+// the rejected answers of a page by question.
+type ProtocolError struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Errors  []PageError `json:"errors,omitempty"`
+}
+
+// ProgressStatus tells how far a participant got. Current is the number of the question on screen,
+// the first one of a page, and EstimatedTotal the number of questions along the path of the answers
+// given so far, which later answers can still change.
+type ProgressStatus struct {
+	Answered       int `json:"answered"`
+	Current        int `json:"current,omitempty"`
+	EstimatedTotal int `json:"estimated_total"`
+}
+
+// TimerStatus has the seconds left for the questions on screen, zero when they have no limit, and
+// for the whole participation. Event and QuestionIDs tell which questions ran out of time.
+type TimerStatus struct {
+	Event           string `json:"event,omitempty"`
+	QuestionIDs     []uint `json:"question_ids,omitempty"`
+	QuestionSeconds int    `json:"question_seconds"`
+	TotalSeconds    int    `json:"total_seconds"`
+}
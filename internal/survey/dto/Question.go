@@ -4,12 +4,107 @@ type GetQuestionsRequest struct {
 	SurveyId uint `json:"survey_id" validate:"required"`
 }
 
+type QuestionType string
+
+const (
+	TextQuestion           QuestionType = "text"
+	MultipleChoiceQuestion QuestionType = "multiple_choice"
+	RatingQuestion         QuestionType = "rating"
+	LikertQuestion         QuestionType = "likert"
+	NumericQuestion        QuestionType = "numeric"
+	DateQuestion           QuestionType = "date"
+	RankingQuestion        QuestionType = "ranking"
+	MatrixQuestion         QuestionType = "matrix"
+)
+
+// QuestionConfig is the per type configuration of a question:
+// rating uses Min (default 1) and Max, likert uses Labels, numeric uses Min, Max and Integer,
+// date uses MinDate and MaxDate (YYYY-MM-DD), ranking uses Items and matrix uses Rows and Columns.
+// Multiple choice questions with MultiSelect accept between MinSelections and MaxSelections choices,
+// a zero MaxSelections means no upper limit. Validation holds the rules answers must follow.
+// A multiple choice question with ChoicesFrom only offers the choices the participant picked on
+// that earlier question, matched by text. A Pinned question keeps its position when the questions
+// of its section are shuffled, RandomizeChoices overrides the choice randomization of the survey.
+// Weight multiplies the points of the question in quizzes, it is 1 when it is not set. TimeLimit is
+// the number of seconds a participant has to answer the question, the question is left unanswered
+// when it runs out.
+type QuestionConfig struct {
+	Min     *float64 `json:"min,omitempty"`
+	Max     *float64 `json:"max,omitempty"`
+	Integer bool     `json:"integer,omitempty"`
+	Labels  []string `json:"labels,omitempty"`
+	MinDate string   `json:"min_date,omitempty"`
+	MaxDate string   `json:"max_date,omitempty"`
+	Items   []string `json:"items,omitempty"`
+	Rows    []string `json:"rows,omitempty"`
+	Columns []string `json:"columns,omitempty"`
+
+	MultiSelect   bool `json:"multi_select,omitempty"`
+	MinSelections int  `json:"min_selections,omitempty"`
+	MaxSelections int  `json:"max_selections,omitempty"`
+	ChoicesFrom   uint `json:"choices_from,omitempty"`
+
+	Pinned           bool  `json:"pinned,omitempty"`
+	RandomizeChoices *bool `json:"randomize_choices,omitempty"`
+
+	Weight    *float64 `json:"weight,omitempty"`
+	TimeLimit int      `json:"time_limit,omitempty"`
+
+	Validation *AnswerValidation `json:"validation,omitempty"`
+}
+
+type AnswerFormat string
+
+const (
+	EmailFormat      AnswerFormat = "email"
+	PhoneFormat      AnswerFormat = "phone"
+	NationalIDFormat AnswerFormat = "national_id"
+)
+
+// AnswerValidation declares the rules an answer must follow. Questions are required unless
+// Optional is set, optional questions can be skipped with an empty answer. Length, Pattern,
+// Format and the numeric range Min and Max only apply to text questions. Message replaces
+// the message of any rule that is violated, MessageTranslations translates it.
+type AnswerValidation struct {
+	Optional  bool         `json:"optional,omitempty"`
+	MinLength int          `json:"min_length,omitempty"`
+	MaxLength int          `json:"max_length,omitempty"`
+	Pattern   string       `json:"pattern,omitempty"`
+	Min       *float64     `json:"min,omitempty"`
+	Max       *float64     `json:"max,omitempty"`
+	Format    AnswerFormat `json:"format,omitempty"`
+	Message   string       `json:"message,omitempty"`
+
+	MessageTranslations map[string]string `json:"message_translations,omitempty"`
+}
+
 type Question struct {
-	ID                uint     `json:"question_id"`
-	Text              string   `json:"text"`
-	HasMultipleChoice bool     `json:"has_multiple_choice"`
-	MediaUrl          string   `json:"media_url"`
-	Choices           []Choice `json:"choices"`
+	ID                uint              `json:"question_id"`
+	Text              string            `json:"text"`
+	TextTranslations  map[string]string `json:"text_translations,omitempty"`
+	Type              QuestionType      `json:"type"`
+	Config            QuestionConfig    `json:"config"`
+	HasMultipleChoice bool              `json:"has_multiple_choice"`
+	MediaUrl          string            `json:"media_url"`
+	SectionID         uint              `json:"section_id"`
+	BankQuestionID    uint              `json:"bank_question_id,omitempty"`
+	Choices           []Choice          `json:"choices"`
+}
+
+// QuestionType returns the type of the question, questions created before typed
+// questions existed only know whether they have choices.
+func (q *Question) QuestionType() QuestionType {
+	if q.HasMultipleChoice {
+		return MultipleChoiceQuestion
+	}
+	if q.Type != "" {
+		return q.Type
+	}
+	return TextQuestion
+}
+
+func (q *Question) IsMultiSelect() bool {
+	return q.QuestionType() == MultipleChoiceQuestion && q.Config.MultiSelect
 }
 
 type QuestionList []*Question
@@ -31,21 +126,41 @@ func (questions QuestionList) ToMap() (mapQuestions map[uint]*Question) {
 	return mapQuestions
 }
 
-type Answer string
-
-const NoAnswer Answer = "NoAnswer"
-
-type QuestionsAnswerMap []map[Answer]*Question
-
 type QuestionUpdateRequest struct {
-	Text              string                `json:"text" validate:"required"`
-	HasMultipleChoice bool                  `json:"has_multiple_choice"`
-	MediaUrl          string                `json:"media_url"`
-	Choices           []ChoiceUpdateRequest `json:"choices"`
+	Text              string            `json:"text" validate:"required"`
+	TextTranslations  map[string]string `json:"text_translations"`
+	Type              QuestionType      `json:"type"`
+	Config            QuestionConfig    `json:"config"`
+	HasMultipleChoice bool              `json:"has_multiple_choice"`
+	MediaUrl          string            `json:"media_url"`
+	// SectionID moves the question to another section, 0 takes it out of its section and leaving
+	// it out keeps the current one
+	SectionID *uint                 `json:"section_id"`
+	Choices   []ChoiceUpdateRequest `json:"choices"`
 }
 
+// ChoiceUpdateRequest updates the choice with ID, a choice without an ID updates the choice with
+// the same text or is added when there is none.
 type ChoiceUpdateRequest struct {
-	Text             string `json:"text" validate:"required"`
-	IsCorrect        bool   `json:"is_correct"`
-	LinkedQuestionId uint   `json:"linked_question_id"`
+	ID               uint              `json:"id"`
+	Text             string            `json:"text" validate:"required"`
+	TextTranslations map[string]string `json:"text_translations"`
+	IsCorrect        bool              `json:"is_correct"`
+	Anchored         bool              `json:"anchored"`
+	Points           float64           `json:"points"`
+	LinkedQuestionId uint              `json:"linked_question_id"`
+}
+
+// QuestionAddRequest adds a question to an existing survey at a 1 based Position,
+// a Position of 0 or after the last question appends it. The text is not required when the
+// question comes from the question bank, the service checks it.
+type QuestionAddRequest struct {
+	QuestionCreateRequest `validate:"-"`
+	SectionID             uint `json:"section_id"`
+	Position              int  `json:"position" validate:"min=0"`
+}
+
+// QuestionsReorderRequest lists every question of a survey in its new order.
+type QuestionsReorderRequest struct {
+	QuestionIDs []uint `json:"question_ids" validate:"required"`
 }
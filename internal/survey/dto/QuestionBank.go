@@ -0,0 +1,68 @@
+package dto
+
+import "time"
+
+type BankQuestion struct {
+	ID                uint           `json:"bank_question_id"`
+	OwnerID           uint           `json:"owner_id"`
+	Shared            bool           `json:"shared"`
+	Text              string         `json:"text"`
+	Type              QuestionType   `json:"type"`
+	Config            QuestionConfig `json:"config"`
+	HasMultipleChoice bool           `json:"has_multiple_choice"`
+	MediaUrl          string         `json:"media_url"`
+	Tags              []string       `json:"tags"`
+	Choices           []Choice       `json:"choices"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+}
+
+type BankQuestionCreateRequest struct {
+	Text              string                `json:"text" validate:"required"`
+	Type              QuestionType          `json:"type"`
+	Config            QuestionConfig        `json:"config"`
+	HasMultipleChoice bool                  `json:"has_multiple_choice"`
+	MediaUrl          string                `json:"media_url"`
+	Tags              []string              `json:"tags"`
+	Shared            bool                  `json:"shared"`
+	Choices           []ChoiceCreateRequest `json:"choices"`
+}
+
+// BankQuestionUpdateRequest replaces a bank question, surveys that already use it keep their copy.
+type BankQuestionUpdateRequest BankQuestionCreateRequest
+
+// BankQuestionsGetRequest searches the library, Scope is mine, shared or empty for both.
+type BankQuestionsGetRequest struct {
+	Page   int    `query:"page" validate:"numeric"`
+	Search string `query:"search"`
+	Tag    string `query:"tag"`
+	Scope  string `query:"scope"`
+}
+
+// BankQuestionsFilter is the repository query of a bank question search.
+type BankQuestionsFilter struct {
+	UserID     uint
+	OnlyOwn    bool
+	OnlyShared bool
+	Search     string
+	Tag        string
+	Limit      uint
+	Offset     uint
+}
+
+// BankQuestionUsage reports the answers to one copy of a bank question, the combined usage of a
+// report has survey id 0 and counts choices by their text.
+type BankQuestionUsage struct {
+	SurveyID    uint               `json:"survey_id"`
+	SurveyTitle string             `json:"survey_title"`
+	QuestionID  uint               `json:"question_id"`
+	Choices     *QuestionReport    `json:"choices,omitempty"`
+	Aggregate   *QuestionAggregate `json:"aggregate,omitempty"`
+}
+
+type BankQuestionReport struct {
+	BankQuestionID uint                `json:"bank_question_id"`
+	Text           string              `json:"text"`
+	Surveys        []BankQuestionUsage `json:"surveys"`
+	Combined       BankQuestionUsage   `json:"combined"`
+}
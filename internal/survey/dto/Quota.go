@@ -0,0 +1,27 @@
+package dto
+
+// Quota caps the participations of a segment of participants: the participants of a city, of an age
+// range or the ones that gave one of Values as answer to the screening question QuestionID.
+// Filled counts the places taken by running and committed participations, Completed the committed ones.
+type Quota struct {
+	ID         uint     `json:"quota_id"`
+	Name       string   `json:"name"`
+	Attribute  string   `json:"attribute"`
+	Values     []string `json:"values,omitempty"`
+	MinAge     *int     `json:"min_age,omitempty"`
+	MaxAge     *int     `json:"max_age,omitempty"`
+	QuestionID *uint    `json:"question_id,omitempty"`
+	Limit      int      `json:"limit"`
+	Filled     int      `json:"filled"`
+	Completed  int      `json:"completed"`
+}
+
+type QuotaRequest struct {
+	Name       string   `json:"name" validate:"required"`
+	Attribute  string   `json:"attribute" validate:"required,oneof=city age answer"`
+	Values     []string `json:"values"`
+	MinAge     *int     `json:"min_age" validate:"omitempty,min=0"`
+	MaxAge     *int     `json:"max_age" validate:"omitempty,min=0"`
+	QuestionID *uint    `json:"question_id"`
+	Limit      int      `json:"limit" validate:"min=1"`
+}
@@ -5,15 +5,24 @@ type CorrectAnswerPercentageToShow struct {
 	Percentage string `json:"percentage"`
 }
 
+// QuestionReport breaks the votes of a choice question down by choice, the breakdown of a
+// question of an anonymous survey answered by too few respondents is Suppressed.
 type QuestionReport struct {
 	QuestionID   uint           `json:"question_id"`
+	Respondents  int64          `json:"respondents"`
+	Selections   int64          `json:"selections"`
 	ChoiceReport []ChoiceReport `json:"choice_report"`
+	Unmapped     int64          `json:"unmapped,omitempty"`
+	Suppressed   bool           `json:"suppressed,omitempty"`
 }
 
+// ChoiceReport has the share of respondents that picked the choice in Percentage and the share
+// of all selections in SelectionPercentage, both are the same for single choice questions.
 type ChoiceReport struct {
-	ID         uint   `json:"id"`
-	Text       string `json:"text"`
-	Percentage string `json:"percentage"`
+	ID                  uint   `json:"id"`
+	Text                string `json:"text"`
+	Percentage          string `json:"percentage"`
+	SelectionPercentage string `json:"selection_percentage"`
 }
 
 type ReportResponse struct {
@@ -21,17 +30,116 @@ type ReportResponse struct {
 	CorrectAnswers                []CorrectAnswerPercentageToShow `json:"correct_answers"`
 	MultipleParticipationCount    []ParticipationReport           `json:"multiple_participation_count"`
 	SuddenlyFinishedParticipation string                          `json:"suddenly_finished_participation"`
+	GuestParticipations           int64                           `json:"guest_participations"`
 	ChoicesPercentage             []QuestionReport                `json:"choices_percentage"`
 	AverageResponseTime           string                          `json:"average_response_time"`
 	DispersionResponseByHour      []HourDispersionDTO             `json:"dispersion_response_by_hour"`
+	QuestionAggregates            []QuestionAggregate             `json:"question_aggregates"`
+	ScoreDistribution             *ScoreDistribution              `json:"score_distribution,omitempty"`
+	QuestionTimes                 []QuestionTime                  `json:"question_times"`
+}
+
+// QuestionTime is the average time participants spent on a question in seconds, TimedOut counts
+// the participations that ran out of time on it.
+type QuestionTime struct {
+	QuestionID     uint    `json:"question_id"`
+	Participations int64   `json:"participations"`
+	AverageSeconds float64 `json:"average_seconds"`
+	TimedOut       int64   `json:"timed_out"`
+}
+
+// ScoreDistribution summarizes the scores of the committed participations of a quiz, percentages
+// are of the highest score possible. Buckets count the participations by percentage in steps of 10,
+// the last bucket includes 100 and negative percentages fall into the first.
+type ScoreDistribution struct {
+	Participations    int           `json:"participations"`
+	Passed            int           `json:"passed"`
+	AverageScore      float64       `json:"average_score"`
+	AveragePercentage float64       `json:"average_percentage"`
+	MinPercentage     float64       `json:"min_percentage"`
+	MaxPercentage     float64       `json:"max_percentage"`
+	Buckets           []ScoreBucket `json:"buckets"`
+}
+
+type ScoreBucket struct {
+	From  int `json:"from"`
+	To    int `json:"to"`
+	Count int `json:"count"`
+}
+
+// QuestionAggregate summarizes the answers of a typed question, only the fields of its type are set:
+// rating and numeric have average, min and max, rating and likert have a distribution,
+// date has earliest and latest, ranking has the average rank of each item and matrix counts each row and column.
+// Suppressed aggregates of anonymous surveys only have their count, too few respondents answered them.
+type QuestionAggregate struct {
+	QuestionID   uint                      `json:"question_id"`
+	Type         QuestionType              `json:"type"`
+	Count        int                       `json:"count"`
+	Average      *float64                  `json:"average,omitempty"`
+	Min          *float64                  `json:"min,omitempty"`
+	Max          *float64                  `json:"max,omitempty"`
+	Earliest     string                    `json:"earliest,omitempty"`
+	Latest       string                    `json:"latest,omitempty"`
+	Distribution map[string]int            `json:"distribution,omitempty"`
+	AverageRank  map[string]float64        `json:"average_rank,omitempty"`
+	Matrix       map[string]map[string]int `json:"matrix,omitempty"`
+	Suppressed   bool                      `json:"suppressed,omitempty"`
+}
+
+// SectionReport has the reports of the questions of one section, Respondents counts the users
+// that answered at least one of them.
+type SectionReport struct {
+	SectionID          uint                `json:"section_id"`
+	Title              string              `json:"title"`
+	Questions          int                 `json:"questions"`
+	Respondents        int64               `json:"respondents"`
+	ChoicesPercentage  []QuestionReport    `json:"choices_percentage"`
+	QuestionAggregates []QuestionAggregate `json:"question_aggregates"`
 }
 
+// ParticipationReport is a user or a guest that took a survey Count times.
 type ParticipationReport struct {
-	UserID uint  `json:"user_id"`
-	Count  int64 `json:"count"`
+	UserID  uint  `json:"user_id,omitempty"`
+	GuestID uint  `json:"guest_id,omitempty"`
+	Count   int64 `json:"count"`
 }
 
 type HourDispersionDTO struct {
 	Hour  int `json:"hour"`
 	Count int `json:"count"`
 }
+
+type VersionReport struct {
+	Version           int              `json:"version"`
+	Participations    int64            `json:"participations"`
+	ChoicesPercentage []QuestionReport `json:"choices_percentage"`
+}
+
+// MergedReportRequest combines several versions into the layout of TargetVersion.
+// ChoiceMapping maps a choice id of an older version to a choice id of the target version,
+// choices that kept their id between versions are matched without a mapping.
+type MergedReportRequest struct {
+	Versions      []int         `json:"versions" validate:"required"`
+	TargetVersion int           `json:"target_version"`
+	ChoiceMapping map[uint]uint `json:"choice_mapping"`
+}
+
+type MergedReport struct {
+	TargetVersion     int              `json:"target_version"`
+	Versions          []int            `json:"versions"`
+	Participations    int64            `json:"participations"`
+	ChoicesPercentage []QuestionReport `json:"choices_percentage"`
+}
+
+type QuestionRespondentCount struct {
+	QuestionID  uint
+	Respondents int64
+}
+
+type ChoiceVoteCount struct {
+	QuestionID    uint
+	ChoiceID      uint
+	SurveyVersion int
+	Answer        string
+	Count         int64
+}
@@ -0,0 +1,47 @@
+package dto
+
+type Section struct {
+	ID       uint   `json:"section_id"`
+	Title    string `json:"title"`
+	Intro    string `json:"intro"`
+	MediaUrl string `json:"media_url"`
+	Order    int    `json:"order"`
+}
+
+type SectionCreateRequest struct {
+	Title    string `json:"title" validate:"required"`
+	Intro    string `json:"intro"`
+	MediaUrl string `json:"media_url"`
+}
+
+type SectionUpdateRequest struct {
+	Title    string `json:"title" validate:"required"`
+	Intro    string `json:"intro"`
+	MediaUrl string `json:"media_url"`
+	Order    int    `json:"order"`
+}
+
+// PageResponse delivers all questions of a section at once when a survey is answered page by page.
+type PageResponse struct {
+	ParticipationID uint        `json:"participation_id,omitempty"`
+	Section         *Section    `json:"section"`
+	Questions       []*Question `json:"questions"`
+	Message         string      `json:"message"`
+	Code            string      `json:"code,omitempty"`
+	Errors          []PageError `json:"errors,omitempty"`
+	Result          *QuizResult `json:"result,omitempty"`
+	TimeLeft        int         `json:"time_left,omitempty"`
+	StepToken       string      `json:"step_token,omitempty"`
+}
+
+type PageError struct {
+	QuestionID uint   `json:"question_id"`
+	Message    string `json:"message"`
+	Code       string `json:"code"`
+}
+
+// PageVoteRequest answers every question of the current page in one message.
+type PageVoteRequest struct {
+	Operation OperationType `json:"operation"`
+	Answers   []VoteRequest `json:"answers"`
+}
@@ -0,0 +1,29 @@
+package dto
+
+import "time"
+
+type ShareLink struct {
+	ID                      uint   `json:"share_link_id"`
+	SurveyID                uint   `json:"survey_id"`
+	Slug                    string `json:"slug"`
+	Enabled                 bool   `json:"enabled"`
+	GuestParticipationLimit int    `json:"guest_participation_limit"`
+}
+
+// ShareLinkRequest sets the share link of a survey. A slug is generated when Slug is empty and the
+// survey has none yet, guests can take part once when GuestParticipationLimit is zero.
+type ShareLinkRequest struct {
+	Slug                    string `json:"slug"`
+	Enabled                 bool   `json:"enabled"`
+	GuestParticipationLimit int    `json:"guest_participation_limit" validate:"min=0"`
+}
+
+// ShareLinkPreview is what the share link of a survey shows, GuestToken is the token of the guest
+// session the survey is taken with.
+type ShareLinkPreview struct {
+	SurveyID    uint      `json:"survey_id"`
+	SurveyTitle string    `json:"survey_title"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+	GuestToken  string    `json:"guest_token"`
+}
@@ -6,32 +6,95 @@ import (
 	"github.com/G9QBootcamp/qoli-survey/internal/user/dto"
 )
 
+// SurveyCreateRequest creates a survey with its questions, answers piped into texts with {{q3}}
+// refer to the 1 based position of the question in Questions.
 type SurveyCreateRequest struct {
 	Title              string                  `json:"title" validate:"required"`
+	TitleTranslations  map[string]string       `json:"title_translations"`
 	StartTime          time.Time               `json:"start_time" validate:"required"`
 	EndTime            time.Time               `json:"end_time" validate:"required"`
 	IsSequential       bool                    `json:"is_sequential"`
+	Randomization      *Randomization          `json:"randomization"`
+	Quiz               *QuizSettings           `json:"quiz"`
+	Anonymity          *AnonymitySettings      `json:"anonymity"`
 	AllowReturn        bool                    `json:"allow_return"`
 	ParticipationLimit int                     `json:"participation_limit" validate:"required"`
 	AnswerTimeLimit    int                     `json:"answer_time_limit" validate:"required"`
+	IsTemplate         bool                    `json:"is_template"`
+	Sections           []SectionCreateRequest  `json:"sections"`
 	Questions          []QuestionCreateRequest `json:"questions"`
 	OwnerID            uint
 }
 
 type SurveyUpdateRequest struct {
-	Title              string    `json:"title" validate:"required"`
-	StartTime          time.Time `json:"start_time" validate:"required"`
-	EndTime            time.Time `json:"end_time" validate:"required"`
-	IsSequential       bool      `json:"is_sequential"`
-	AllowReturn        bool      `json:"allow_return"`
-	ParticipationLimit int       `json:"participation_limit" validate:"required"`
-	AnswerTimeLimit    int       `json:"answer_time_limit" validate:"required"`
+	Title              string             `json:"title" validate:"required"`
+	TitleTranslations  map[string]string  `json:"title_translations"`
+	StartTime          time.Time          `json:"start_time" validate:"required"`
+	EndTime            time.Time          `json:"end_time" validate:"required"`
+	IsSequential       bool               `json:"is_sequential"`
+	Randomization      *Randomization     `json:"randomization"`
+	Quiz               *QuizSettings      `json:"quiz"`
+	Anonymity          *AnonymitySettings `json:"anonymity"`
+	AllowReturn        bool               `json:"allow_return"`
+	ParticipationLimit int                `json:"participation_limit" validate:"required"`
+	AnswerTimeLimit    int                `json:"answer_time_limit" validate:"required"`
+	IsTemplate         bool               `json:"is_template"`
+}
+
+// SurveyCloneRequest copies a survey into a new draft owned by the caller, an empty title
+// keeps the title of the original survey.
+// Randomization sets what is shuffled for each participant: the order of the sections, the order
+// of the questions inside their section and the order of the choices. Anchored choices and pinned
+// questions keep their position. Sections and questions can only be shuffled in non sequential
+// surveys, a survey created without randomization shuffles both unless it is sequential.
+type Randomization struct {
+	Sections  bool `json:"sections"`
+	Questions bool `json:"questions"`
+	Choices   bool `json:"choices"`
+}
+
+// QuizSettings makes a survey a quiz. Every committed participation is scored: a choice is worth
+// its points, which may be partial or negative, and a correct choice without points is worth 1.
+// The points a participant picked on a question are multiplied by the weight of the question.
+// A participation passes when its score is at least PassMark percent of the highest score
+// possible on the questions it was asked, ShowScore sends the score to the participant.
+type QuizSettings struct {
+	PassMark  float64 `json:"pass_mark"`
+	ShowScore bool    `json:"show_score"`
+}
+
+// AnonymitySettings makes a survey anonymous. Participations still count against the participation
+// limit, but answers are stored apart from them and from the participant, with nothing to join them
+// on. Reports hide the breakdowns of fewer than MinGroupSize respondents, 5 when it is zero.
+type AnonymitySettings struct {
+	MinGroupSize int `json:"min_group_size"`
+}
+
+// QuizResult is the score of a participation in a quiz.
+type QuizResult struct {
+	Score      float64 `json:"score"`
+	MaxScore   float64 `json:"max_score"`
+	Percentage float64 `json:"percentage"`
+	Passed     bool    `json:"passed"`
+}
+
+type SurveyCloneRequest struct {
+	Title      string    `json:"title"`
+	StartTime  time.Time `json:"start_time" validate:"required"`
+	EndTime    time.Time `json:"end_time" validate:"required"`
+	IsTemplate bool      `json:"is_template"`
 }
 
 type SurveysGetRequest struct {
-	Page   int    `query:"page" validate:"numeric"`
-	UserId int    `query:"page" validate:"numeric"`
-	Title  string `query:"title"`
+	Page     int    `query:"page" validate:"numeric"`
+	UserId   int    `query:"page" validate:"numeric"`
+	Title    string `query:"title"`
+	Status   string `query:"status"`
+	Template string `query:"template"`
+}
+
+type SurveyStatusUpdateRequest struct {
+	Status string `json:"status" validate:"required"`
 }
 
 type SurveyOptionCreateRequest struct {
@@ -43,22 +106,50 @@ type SurveyOptionResponse struct {
 	Name  string `json:"name"`
 	Value string `json:"value"`
 }
+type OptionType string
+
+const (
+	OptionTypeInt    OptionType = "int"
+	OptionTypeEnum   OptionType = "enum"
+	OptionTypeString OptionType = "string"
+	OptionTypeBool   OptionType = "bool"
+)
+
+// SurveyOptionSchema describes an option a survey can have, clients render option editors from it.
+type SurveyOptionSchema struct {
+	Name        string     `json:"name"`
+	Type        OptionType `json:"type"`
+	Description string     `json:"description"`
+	Allowed     []string   `json:"allowed,omitempty"`
+	Min         *int       `json:"min,omitempty"`
+	Default     string     `json:"default,omitempty"`
+	Deprecated  string     `json:"deprecated,omitempty"`
+}
+
 type SurveyOptionsGetRequest struct {
 	SurveyId uint   `json:"survey_id" validate:"numeric"`
-	Name     string `json:"name" validate:"required"`
+	Name     string `json:"name"`
 }
 
 type QuestionCreateRequest struct {
 	Text              string                `json:"text" validate:"required"`
+	TextTranslations  map[string]string     `json:"text_translations"`
+	Type              QuestionType          `json:"type"`
+	Config            QuestionConfig        `json:"config"`
 	HasMultipleChoice bool                  `json:"has_multiple_choice"`
 	MediaUrl          string                `json:"media_url"`
 	Choices           []ChoiceCreateRequest `json:"choices"`
 	Condition         Condition             `json:"condition"`
+	Section           string                `json:"section"`
+	BankQuestionID    uint                  `json:"bank_question_id"`
 }
 
 type ChoiceCreateRequest struct {
-	Text      string `json:"text" validate:"required"`
-	IsCorrect bool   `json:"is_correct"`
+	Text             string            `json:"text" validate:"required"`
+	TextTranslations map[string]string `json:"text_translations"`
+	IsCorrect        bool              `json:"is_correct"`
+	Anchored         bool              `json:"anchored"`
+	Points           float64           `json:"points"`
 }
 
 type Condition struct {
@@ -70,29 +161,129 @@ type SurveyResponse struct {
 	SurveyID           uint                   `json:"survey_id"`
 	UserId             uint                   `json:"user_id"`
 	Title              string                 `json:"title"`
+	TitleTranslations  map[string]string      `json:"title_translations,omitempty"`
+	Status             string                 `json:"status"`
 	StartTime          string                 `json:"start_time"`
 	EndTime            string                 `json:"end_time"`
 	IsSequential       bool                   `json:"is_sequential"`
+	Randomization      *Randomization         `json:"randomization,omitempty"`
+	Quiz               *QuizSettings          `json:"quiz,omitempty"`
+	Anonymity          *AnonymitySettings     `json:"anonymity,omitempty"`
+	Eligibility        *EligibilityRule       `json:"eligibility,omitempty"`
 	AllowReturn        bool                   `json:"allow_return"`
 	ParticipationLimit int                    `json:"participation_limit"`
 	AnswerTimeLimit    int                    `json:"answer_time_limit"`
+	CurrentVersion     int                    `json:"current_version"`
+	IsTemplate         bool                   `json:"is_template"`
 	Options            []SurveyOptionResponse `json:"options"`
 }
 
+// SurveyDefinition is the content frozen in a published survey version.
+type SurveyDefinition struct {
+	Title             string            `json:"title"`
+	TitleTranslations map[string]string `json:"title_translations,omitempty"`
+	Randomization     *Randomization    `json:"randomization,omitempty"`
+	Sections          []Section         `json:"sections,omitempty"`
+	Questions         QuestionList      `json:"questions"`
+	Rules             []BranchRule      `json:"rules,omitempty"`
+}
+
+type SurveyVersionResponse struct {
+	ID         uint             `json:"id"`
+	SurveyID   uint             `json:"survey_id"`
+	Version    int              `json:"version"`
+	CreatedAt  time.Time        `json:"created_at"`
+	Definition SurveyDefinition `json:"definition"`
+}
+
 type Choice struct {
-	ID               uint   `json:"choice_id"`
-	Text             string `json:"text"`
-	IsCorrect        bool   `json:"is_correct"`
-	LinkedQuestionID uint   `json:"linked_question_id"`
+	ID               uint              `json:"choice_id"`
+	Text             string            `json:"text"`
+	TextTranslations map[string]string `json:"text_translations,omitempty"`
+	IsCorrect        bool              `json:"is_correct"`
+	Anchored         bool              `json:"anchored,omitempty"`
+	Points           float64           `json:"points,omitempty"`
+	LinkedQuestionID uint              `json:"linked_question_id"`
 }
 
 type UserSurveyParticipationResponse struct {
-	ID          uint      `json:"id"`
-	UserId      uint      `json:"user_id"`
-	SurveyID    uint      `json:"survey_id"`
-	StartAt     time.Time `json:"start_at"`
-	EndAt       time.Time `json:"end_at"`
-	CommittedAt time.Time `json:"committed_at"`
+	ID            uint       `json:"id"`
+	UserId        uint       `json:"user_id"`
+	GuestID       *uint      `json:"guest_id,omitempty"`
+	SurveyID      uint       `json:"survey_id"`
+	SurveyVersion int        `json:"survey_version"`
+	Language      string     `json:"language"`
+	Seed          int64      `json:"seed"`
+	StartAt       time.Time  `json:"start_at"`
+	EndAt         time.Time  `json:"end_at"`
+	CommittedAt   time.Time  `json:"committed_at"`
+	Score         *float64   `json:"score,omitempty"`
+	MaxScore      *float64   `json:"max_score,omitempty"`
+	Passed        *bool      `json:"passed,omitempty"`
+	ScreenedOutAt *time.Time `json:"screened_out_at,omitempty"`
+}
+
+const (
+	ParticipationModeQuestion = "question"
+	ParticipationModePage     = "page"
+)
+
+// ParticipationProgress is where a live participation got to. A participation answered a question
+// at a time has the questions it was sent in Asked, the last one is the question it is on unless
+// Finished tells that every question was asked. One answered a page at a time has the pages it finished in Pages and the page it is on in Page.
+// Spent has the milliseconds spent on every question and TimeLeft the seconds left of the answer
+// time limit of the survey. OverRequests tells that it is answered over requests, where the time
+// between two requests counts as answer time.
+type ParticipationProgress struct {
+	Mode         string         `json:"mode"`
+	Asked        []uint         `json:"asked,omitempty"`
+	Pages        [][]uint       `json:"pages,omitempty"`
+	Page         []uint         `json:"page,omitempty"`
+	Spent        map[uint]int64 `json:"spent,omitempty"`
+	TimeLeft     int            `json:"time_left"`
+	Finished     bool           `json:"finished,omitempty"`
+	OverRequests bool           `json:"over_requests,omitempty"`
+}
+
+// ParticipationResume is an interrupted participation that is picked up again, Answers are the
+// answers it gave so far by question and SuspendedAt is when it was interrupted. An anonymous
+// participation also has the response id and the votes its step token carried.
+type ParticipationResume struct {
+	Participation UserSurveyParticipationResponse `json:"participation"`
+	Progress      ParticipationProgress           `json:"progress"`
+	Answers       map[uint][]string               `json:"answers"`
+	SuspendedAt   time.Time                       `json:"suspended_at"`
+	ResponseID    string                          `json:"response_id,omitempty"`
+	Votes         []PendingVote                   `json:"votes,omitempty"`
+}
+
+// ParticipationStep is where a participation answered over requests got to after a request. The
+// progress, response id and votes of an anonymous participation would tie its participant to the
+// response, so they are not stored but handed to the client in a signed step token.
+type ParticipationStep struct {
+	ParticipationID uint                  `json:"participation_id"`
+	Progress        ParticipationProgress `json:"progress"`
+	ResponseID      string                `json:"response_id,omitempty"`
+	Votes           []PendingVote         `json:"votes,omitempty"`
+	SuspendedAt     time.Time             `json:"suspended_at"`
+}
+
+// PendingVote is a vote of an anonymous response that is only stored when the response is committed.
+type PendingVote struct {
+	QuestionID uint   `json:"question_id"`
+	ChoiceID   uint   `json:"choice_id,omitempty"`
+	Answer     string `json:"answer"`
+	IsCorrect  bool   `json:"is_correct,omitempty"`
+}
+
+// ParticipationOrderResponse is the order a participant was shown the survey in, Sections lists
+// the section ids in their order and every question has its choices in the order they were shown.
+type ParticipationOrderResponse struct {
+	ParticipationID uint         `json:"participation_id"`
+	SurveyVersion   int          `json:"survey_version"`
+	Seed            int64        `json:"seed"`
+	Sections        []uint       `json:"sections,omitempty"`
+	Questions       QuestionList `json:"questions"`
 }
 
 type OperationType string
@@ -101,21 +292,37 @@ const CommitOperation OperationType = "commit"
 const BackOperation OperationType = "back"
 
 type VoteRequest struct {
-	Operation  OperationType `json:"operation"`
-	QuestionId uint          `json:"question_id" validate:"numeric"`
-	Answer     string        `json:"answer"`
+	Operation  OperationType     `json:"operation"`
+	QuestionId uint              `json:"question_id" validate:"numeric"`
+	Answer     string            `json:"answer"`
+	ChoiceIds  []uint            `json:"choice_ids"`
+	Ranking    []string          `json:"ranking"`
+	Matrix     map[string]string `json:"matrix"`
 }
+
+// VoteResponse carries the next question, Code is set when the last answer was rejected and Result
+// has the score of a finished quiz when the participant may see it. TimeLeft is the number of
+// seconds left to answer a question with a time limit. ParticipationID is set on the answers of the
+// REST protocol, which name the participation in their path.
 type VoteResponse struct {
-	Question *Question `json:"question"`
-	Message  string    `json:"message"`
+	ParticipationID uint        `json:"participation_id,omitempty"`
+	Question        *Question   `json:"question"`
+	Message         string      `json:"message"`
+	Code            string      `json:"code,omitempty"`
+	Result          *QuizResult `json:"result,omitempty"`
+	TimeLeft        int         `json:"time_left,omitempty"`
+	StepToken       string      `json:"step_token,omitempty"`
 }
 
 type GetVoteResponse struct {
-	ID         uint             `json:"id"`
-	VoterID    uint             `json:"voter_id"`
-	QuestionID uint             `json:"question_id"`
-	Answer     string           `json:"answer"`
-	IsCorrect  bool             `json:"is_correct"`
-	Voter      dto.UserResponse `json:"voter"`
-	CreatedAt  time.Time        `json:"created_at"`
+	ID            uint             `json:"id"`
+	VoterID       uint             `json:"voter_id"`
+	GuestID       *uint            `json:"guest_id,omitempty"`
+	QuestionID    uint             `json:"question_id"`
+	ChoiceID      uint             `json:"choice_id"`
+	SurveyVersion int              `json:"survey_version"`
+	Answer        string           `json:"answer"`
+	IsCorrect     bool             `json:"is_correct"`
+	Voter         dto.UserResponse `json:"voter"`
+	CreatedAt     time.Time        `json:"created_at"`
 }
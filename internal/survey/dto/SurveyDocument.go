@@ -0,0 +1,91 @@
+package dto
+
+import "time"
+
+// SurveyDocumentSchemaVersion is the version of the survey document layout written by export.
+const SurveyDocumentSchemaVersion = 1
+
+// SurveyDocument is the portable definition of a survey, it is exported and imported as json or yaml.
+//
+// Schema version 1:
+//   - questions are listed in the order they are asked and refer to their section by title
+//   - a question with a condition is only asked when the question with text condition.question_text
+//     was answered with the choice condition.answer
+//   - rules refer to questions by their 1 based position in questions, both in question,
+//     target_question and in the question_id of their conditions
+//   - answers piped into texts with {{q3}} and config.choices_from refer to positions too
+//   - the title, questions and choices may have translations by language code
+//
+// Ids of the database are never part of a document. The eligibility rule and the quotas are not
+// part of schema version 1 either: they refer to audiences, other surveys and screening questions
+// by id, so they are set again on the imported survey.
+type SurveyDocument struct {
+	SchemaVersion      int                `json:"schema_version"`
+	Title              string             `json:"title"`
+	TitleTranslations  map[string]string  `json:"title_translations,omitempty"`
+	StartTime          time.Time          `json:"start_time"`
+	EndTime            time.Time          `json:"end_time"`
+	IsSequential       bool               `json:"is_sequential"`
+	Randomization      *Randomization     `json:"randomization,omitempty"`
+	Quiz               *QuizSettings      `json:"quiz,omitempty"`
+	Anonymity          *AnonymitySettings `json:"anonymity,omitempty"`
+	AllowReturn        bool               `json:"allow_return"`
+	ParticipationLimit int                `json:"participation_limit"`
+	AnswerTimeLimit    int                `json:"answer_time_limit"`
+	Options            []DocumentOption   `json:"options,omitempty"`
+	Sections           []DocumentSection  `json:"sections,omitempty"`
+	Questions          []DocumentQuestion `json:"questions"`
+	Rules              []DocumentRule     `json:"rules,omitempty"`
+}
+
+type DocumentOption struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type DocumentSection struct {
+	Title    string `json:"title"`
+	Intro    string `json:"intro,omitempty"`
+	MediaUrl string `json:"media_url,omitempty"`
+}
+
+type DocumentQuestion struct {
+	Text         string             `json:"text"`
+	Translations map[string]string  `json:"translations,omitempty"`
+	Type         QuestionType       `json:"type"`
+	Config       *QuestionConfig    `json:"config,omitempty"`
+	MediaUrl     string             `json:"media_url,omitempty"`
+	Section      string             `json:"section,omitempty"`
+	Choices      []DocumentChoice   `json:"choices,omitempty"`
+	Condition    *DocumentCondition `json:"condition,omitempty"`
+}
+
+type DocumentChoice struct {
+	Text         string            `json:"text"`
+	Translations map[string]string `json:"translations,omitempty"`
+	IsCorrect    bool              `json:"is_correct,omitempty"`
+	Anchored     bool              `json:"anchored,omitempty"`
+	Points       float64           `json:"points,omitempty"`
+}
+
+type DocumentCondition struct {
+	QuestionText string `json:"question_text"`
+	Answer       string `json:"answer"`
+}
+
+type DocumentRule struct {
+	Question       int            `json:"question"`
+	Priority       int            `json:"priority,omitempty"`
+	Condition      RuleExpression `json:"condition"`
+	TargetQuestion int            `json:"target_question,omitempty"`
+	EndSurvey      bool           `json:"end_survey,omitempty"`
+}
+
+// SurveyImportResponse lists every problem of an imported document, Survey is only set when
+// the document was valid and not imported as a dry run.
+type SurveyImportResponse struct {
+	Valid    bool            `json:"valid"`
+	DryRun   bool            `json:"dry_run"`
+	Problems []string        `json:"problems"`
+	Survey   *SurveyResponse `json:"survey,omitempty"`
+}
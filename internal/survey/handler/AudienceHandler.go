@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/db"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/repository"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"github.com/labstack/echo/v4"
+)
+
+type AudienceHandler struct {
+	conf    *config.Config
+	db      db.DbService
+	service service.IAudienceService
+	logger  logging.Logger
+}
+
+func NewAudienceHandler(conf *config.Config, db db.DbService, logger logging.Logger) *AudienceHandler {
+	return &AudienceHandler{conf: conf, db: db, service: service.NewAudienceService(conf, repository.NewSurveyRepository(db, logger), logger), logger: logger}
+}
+
+func (h *AudienceHandler) GetAudiences(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	audiences, err := h.service.GetAudiences(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, audiences)
+}
+
+func (h *AudienceHandler) GetAudience(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	id, err := strconv.Atoi(c.Param("audience_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in get audience", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid audience id"})
+	}
+
+	audience, err := h.service.GetAudience(c.Request().Context(), userID, uint(id))
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, audience)
+}
+
+func (h *AudienceHandler) CreateAudience(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	req := dto.AudienceRequest{}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in create audience api", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "validation failed"})
+	}
+
+	audience, err := h.service.CreateAudience(c.Request().Context(), userID, req)
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, audience)
+}
+
+func (h *AudienceHandler) UpdateAudience(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	id, err := strconv.Atoi(c.Param("audience_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in update audience", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid audience id"})
+	}
+
+	req := dto.AudienceRequest{}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in update audience api", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "validation failed"})
+	}
+
+	audience, err := h.service.UpdateAudience(c.Request().Context(), userID, uint(id), req)
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, audience)
+}
+
+func (h *AudienceHandler) DeleteAudience(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	id, err := strconv.Atoi(c.Param("audience_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in delete audience", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid audience id"})
+	}
+
+	err = h.service.DeleteAudience(c.Request().Context(), userID, uint(id))
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, nil)
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/db"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/repository"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"github.com/labstack/echo/v4"
+)
+
+type EligibilityHandler struct {
+	conf    *config.Config
+	db      db.DbService
+	service service.IEligibilityService
+	logger  logging.Logger
+}
+
+func NewEligibilityHandler(conf *config.Config, db db.DbService, logger logging.Logger) *EligibilityHandler {
+	return &EligibilityHandler{conf: conf, db: db, service: service.NewEligibilityService(conf, repository.NewSurveyRepository(db, logger), logger), logger: logger}
+}
+
+func (h *EligibilityHandler) GetEligibility(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in get eligibility", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+
+	eligibility, err := h.service.GetEligibility(c.Request().Context(), uint(iSurveyId))
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, eligibility)
+}
+
+func (h *EligibilityHandler) UpdateEligibility(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in update eligibility", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+
+	req := dto.EligibilityRequest{}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+
+	eligibility, err := h.service.UpdateEligibility(c.Request().Context(), uint(iSurveyId), req)
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, eligibility)
+}
+
+// PreviewEligibility counts the users the rule of the request would let take the survey.
+func (h *EligibilityHandler) PreviewEligibility(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in preview eligibility", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+
+	req := dto.EligibilityRequest{}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+
+	eligibility, err := h.service.PreviewEligibility(c.Request().Context(), uint(iSurveyId), req)
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, eligibility)
+}
+
+// CheckEligibility tells the user whether they can take the survey and why not.
+func (h *EligibilityHandler) CheckEligibility(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in check eligibility", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+
+	check, err := h.service.CheckEligibility(c.Request().Context(), userID, uint(iSurveyId))
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, check)
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/db"
+	notification "github.com/G9QBootcamp/qoli-survey/internal/notification/service"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/repository"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"github.com/labstack/echo/v4"
+)
+
+type InvitationHandler struct {
+	conf    *config.Config
+	db      db.DbService
+	service service.IInvitationService
+	logger  logging.Logger
+}
+
+func NewInvitationHandler(conf *config.Config, db db.DbService, logger logging.Logger, notificationService notification.INotificationService) *InvitationHandler {
+	return &InvitationHandler{conf: conf, db: db,
+		service: service.NewInvitationService(conf, repository.NewSurveyRepository(db, logger), logger, notificationService, notification.NewMailer(conf, logger)),
+		logger:  logger,
+	}
+}
+
+func (h *InvitationHandler) GetInvitations(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in get invitations", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+
+	invitations, err := h.service.GetInvitations(c.Request().Context(), uint(iSurveyId))
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, invitations)
+}
+
+func (h *InvitationHandler) Invite(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in invite", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+
+	req := dto.InvitationRequest{}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in invite api", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "validation failed"})
+	}
+
+	response, err := h.service.Invite(c.Request().Context(), uint(iSurveyId), req)
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, response)
+}
+
+func (h *InvitationHandler) ResendInvitation(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in resend invitation", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+	iInvitationId, err := strconv.Atoi(c.Param("invitation_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in resend invitation", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid invitation id"})
+	}
+
+	invitation, err := h.service.ResendInvitation(c.Request().Context(), uint(iSurveyId), uint(iInvitationId))
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, invitation)
+}
+
+func (h *InvitationHandler) DeleteInvitation(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in delete invitation", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+	iInvitationId, err := strconv.Atoi(c.Param("invitation_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in delete invitation", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid invitation id"})
+	}
+
+	if err := h.service.DeleteInvitation(c.Request().Context(), uint(iSurveyId), uint(iInvitationId)); err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, nil)
+}
+
+// OpenInvitation is where the link of an invitation email leads, it needs no sign in.
+func (h *InvitationHandler) OpenInvitation(c echo.Context) error {
+	preview, err := h.service.OpenInvitation(c.Request().Context(), c.Param("token"))
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, preview)
+}
+
+func (h *InvitationHandler) AcceptInvitation(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	invitation, err := h.service.AcceptInvitation(c.Request().Context(), userID, c.Param("token"))
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, invitation)
+}
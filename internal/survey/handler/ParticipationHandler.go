@@ -0,0 +1,359 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/db"
+	notification "github.com/G9QBootcamp/qoli-survey/internal/notification/service"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/repository"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"github.com/labstack/echo/v4"
+)
+
+// stepTokenHeader carries the step token of an anonymous participation, every step returns the
+// token the next request must send.
+const stepTokenHeader = "X-Step-Token"
+
+// ParticipationHandler answers surveys over plain requests, for clients that can not keep a websocket
+// open. Every request picks up the participation, takes one step on the participation engine the
+// websocket uses and stores where it got to, so between requests a participation waits like one whose
+// connection was lost. The time between requests counts as answer time, a participation is ended
+// once the answer time of the survey runs out. An anonymous participation does not store where it
+// got to, the client holds it in the step token of the last step.
+type ParticipationHandler struct {
+	conf         *config.Config
+	db           db.DbService
+	service      service.ISurveyService
+	surveys      *SurveyHandler
+	participants participants
+	logger       logging.Logger
+}
+
+// NewParticipationHandler answers surveys for signed in users.
+func NewParticipationHandler(conf *config.Config, db db.DbService, logger logging.Logger, notificationService notification.INotificationService) *ParticipationHandler {
+	h := NewParticipationHandlerWithService(conf, logger, NewSurveyHandler(conf, db, logger, notificationService).service)
+	h.db = db
+	return h
+}
+
+// NewGuestParticipationHandler answers surveys for guests that opened their share link.
+func NewGuestParticipationHandler(conf *config.Config, db db.DbService, logger logging.Logger, notificationService notification.INotificationService) *ParticipationHandler {
+	shareLinks := service.NewShareLinkService(conf, repository.NewSurveyRepository(db, logger), logger)
+	h := NewGuestParticipationHandlerWithService(conf, logger, NewSurveyHandler(conf, db, logger, notificationService).service, shareLinks)
+	h.db = db
+	return h
+}
+
+// NewParticipationHandlerWithService answers surveys for signed in users on surveyService.
+func NewParticipationHandlerWithService(conf *config.Config, logger logging.Logger, surveyService service.ISurveyService) *ParticipationHandler {
+	surveys := &SurveyHandler{conf: conf, service: surveyService, logger: logger}
+	return &ParticipationHandler{conf: conf, service: surveyService, surveys: surveys, participants: &userParticipants{surveys: surveys}, logger: logger}
+}
+
+// NewGuestParticipationHandlerWithService answers surveys for guests on surveyService, shareLinks
+// finds their guest sessions.
+func NewGuestParticipationHandlerWithService(conf *config.Config, logger logging.Logger, surveyService service.ISurveyService, shareLinks service.IShareLinkService) *ParticipationHandler {
+	surveys := &SurveyHandler{conf: conf, service: surveyService, logger: logger}
+	return &ParticipationHandler{conf: conf, service: surveyService, surveys: surveys, participants: &guestParticipants{shareLinks: shareLinks, surveys: surveys}, logger: logger}
+}
+
+// participants find who takes a step of a participation, users sign in and guests come with the
+// guest session of a share link.
+type participants interface {
+	// respondent returns the survey and the respondent of a request, a nil respondent means they can
+	// not take a step and the reason was written then
+	respondent(c echo.Context) (uint, *models.Respondent, error)
+	// refused writes why a respondent can not start a participation of a survey, refused is false when they can
+	refused(c echo.Context, surveyId uint, respondent models.Respondent) (bool, error)
+}
+
+type userParticipants struct {
+	surveys *SurveyHandler
+}
+
+func (p *userParticipants) respondent(c echo.Context) (uint, *models.Respondent, error) {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return 0, nil, c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		p.surveys.logger.Warn(logging.Validation, logging.Api, "validation error in participation step", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return 0, nil, c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+	respondent := models.UserRespondent(userID)
+	return uint(iSurveyId), &respondent, nil
+}
+
+func (p *userParticipants) refused(c echo.Context, surveyId uint, respondent models.Respondent) (bool, error) {
+	return p.surveys.participationRefused(c, *respondent.UserID, surveyId)
+}
+
+type guestParticipants struct {
+	shareLinks service.IShareLinkService
+	surveys    *SurveyHandler
+}
+
+func (p *guestParticipants) respondent(c echo.Context) (uint, *models.Respondent, error) {
+	surveyId, guestId, err := p.shareLinks.GetGuest(c.Request().Context(), c.Param("slug"), guestToken(c))
+	if err != nil {
+		return 0, nil, c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	respondent := models.GuestRespondent(guestId)
+	return surveyId, &respondent, nil
+}
+
+func (p *guestParticipants) refused(c echo.Context, surveyId uint, respondent models.Respondent) (bool, error) {
+	return p.surveys.guestParticipationRefused(c, *respondent.GuestID, surveyId)
+}
+
+// participationStep is a participation picked up for one request.
+type participationStep struct {
+	engine   *service.ParticipationEngine
+	timeLeft time.Duration
+	// timedOut tells that the question the participant was on ran out of time since the last request
+	timedOut bool
+}
+
+// prompt is the message and code of an answer to a request that shows the question or page the
+// participant is on.
+func (s *participationStep) prompt() (string, string) {
+	switch {
+	case s.engine.Finished():
+		return "every question is answered, commit the participation", ""
+	case s.timedOut:
+		return "time is up, " + s.ask(), service.AnswerTimedOut
+	}
+	return s.ask(), ""
+}
+
+func (s *participationStep) ask() string {
+	if s.engine.PageMode() {
+		return "answer page:"
+	}
+	return "answer question:"
+}
+
+// state is where the participation got to after the request.
+func (s *participationStep) state() dto.ParticipationStep {
+	step := dto.ParticipationStep{ParticipationID: s.engine.ParticipationID(), Progress: s.engine.Progress(s.timeLeft)}
+	if respondent := s.engine.Respondent(); respondent.IsAnonymous() {
+		step.ResponseID, step.Votes = respondent.ResponseID, s.engine.Pending()
+	}
+	return step
+}
+
+// StartParticipation starts a participation and returns its first question, or its first page when
+// the mode query parameter is page.
+func (h *ParticipationHandler) StartParticipation(c echo.Context) error {
+	surveyId, respondent, err := h.participants.respondent(c)
+	if respondent == nil {
+		return err
+	}
+	survey, err := h.survey(c, surveyId)
+	if survey == nil {
+		return err
+	}
+
+	if refused, err := h.participants.refused(c, survey.SurveyID, *respondent); refused {
+		return err
+	}
+	// the answers of an anonymous survey are only kept under a response id the participation never learns
+	answering := *respondent
+	if survey.Anonymity != nil {
+		responseId, err := service.NewResponseID()
+		if err != nil {
+			h.logger.Error(logging.Internal, logging.Api, "error in creating a response id", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+			return c.JSON(http.StatusInternalServerError, err.Error())
+		}
+		answering = models.AnonymousRespondent(responseId)
+	}
+	participation, flow, err := h.surveys.participate(c, survey, *respondent)
+	if participation == nil {
+		return err
+	}
+
+	pageMode := c.QueryParam("mode") == "page"
+	engine := service.NewParticipationEngine(h.service, h.logger, *participation, answering, flow, pageMode, survey.AllowReturn, nil)
+	engine.Start()
+	step := &participationStep{engine: engine, timeLeft: time.Duration(survey.AnswerTimeLimit) * time.Second}
+	message, code := step.prompt()
+	return h.respond(c, step, http.StatusCreated, message, code, nil)
+}
+
+// NextQuestion returns the question or page the participant is on.
+func (h *ParticipationHandler) NextQuestion(c echo.Context) error {
+	step, err := h.load(c)
+	if step == nil {
+		return err
+	}
+	message, code := step.prompt()
+	return h.respond(c, step, http.StatusOK, message, code, nil)
+}
+
+// AnswerQuestion answers the question the participant is on and returns the next one. In page mode
+// it answers every question of the page, the page is only taken when all of its answers are valid.
+func (h *ParticipationHandler) AnswerQuestion(c echo.Context) error {
+	step, err := h.load(c)
+	if step == nil {
+		return err
+	}
+	if step.timedOut {
+		// the answer came after the time of its question ran out
+		message, code := step.prompt()
+		return h.respond(c, step, http.StatusConflict, message, code, nil)
+	}
+
+	var pageErrors []dto.PageError
+	if step.engine.PageMode() {
+		var req dto.PageVoteRequest
+		if err := c.Bind(&req); err != nil {
+			return h.respond(c, step, http.StatusBadRequest, "invalid request body", "", nil)
+		}
+		pageErrors, err = step.engine.AnswerPage(c.Request().Context(), req)
+	} else {
+		var req dto.VoteRequest
+		if err := c.Bind(&req); err != nil {
+			return h.respond(c, step, http.StatusBadRequest, "invalid request body", "", nil)
+		}
+		err = step.engine.Answer(c.Request().Context(), req)
+	}
+	if errors.Is(err, service.ErrQuotaFull) {
+		// the participation was ended, there is nothing to pick up
+		return c.JSON(errorStatus(err), map[string]interface{}{"error": err.Error(), "code": service.ParticipationScreenedOut, "participation_id": step.engine.ParticipationID()})
+	}
+	if len(pageErrors) > 0 {
+		return h.respond(c, step, http.StatusUnprocessableEntity, errPageRejected.Error(), stepInvalidPage, pageErrors)
+	}
+	if err != nil {
+		return h.respond(c, step, errorStatus(err), err.Error(), service.StepErrorCode(err), nil)
+	}
+	message, code := step.prompt()
+	return h.respond(c, step, http.StatusOK, message, code, nil)
+}
+
+// GoBack returns to the previous question or page when the survey allows it.
+func (h *ParticipationHandler) GoBack(c echo.Context) error {
+	step, err := h.load(c)
+	if step == nil {
+		return err
+	}
+
+	if err := step.engine.Back(); err != nil {
+		return h.respond(c, step, errorStatus(err), err.Error(), service.StepErrorCode(err), nil)
+	}
+	return h.respond(c, step, http.StatusOK, step.ask(), "", nil)
+}
+
+// CommitParticipation commits a participation that answered every question.
+func (h *ParticipationHandler) CommitParticipation(c echo.Context) error {
+	step, err := h.load(c)
+	if step == nil {
+		return err
+	}
+
+	result, err := step.engine.Commit(c.Request().Context())
+	if err != nil {
+		if !errors.Is(err, service.ErrNotFinished) {
+			h.logger.Error(logging.General, logging.Api, "error in committing survey participation", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		}
+		return h.respond(c, step, errorStatus(err), err.Error(), service.StepErrorCode(err), nil)
+	}
+	message := "survey answers committed successfully"
+	if step.engine.PageMode() {
+		return c.JSON(http.StatusOK, dto.PageResponse{ParticipationID: step.engine.ParticipationID(), Message: message, Result: result})
+	}
+	return c.JSON(http.StatusOK, dto.VoteResponse{ParticipationID: step.engine.ParticipationID(), Message: message, Result: result})
+}
+
+// survey returns the survey of a request, a nil survey means it was not found and the reason was written.
+func (h *ParticipationHandler) survey(c echo.Context, surveyId uint) (*dto.SurveyResponse, error) {
+	survey, err := h.service.GetSurvey(c.Request().Context(), surveyId)
+	if err != nil {
+		h.logger.Error(logging.General, logging.Api, "Failed to get survey", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return nil, c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	if survey == nil {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{"error": "survey not found"})
+	}
+	return survey, nil
+}
+
+// load picks up the participation of a request. A nil step means it can not go on, the reason
+// was written then. Questions whose time ran out since the last request are left unanswered.
+func (h *ParticipationHandler) load(c echo.Context) (*participationStep, error) {
+	surveyId, respondent, err := h.participants.respondent(c)
+	if respondent == nil {
+		return nil, err
+	}
+	iParticipationId, err := strconv.Atoi(c.Param("participation_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in participation step", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return nil, c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid participation id"})
+	}
+	survey, err := h.survey(c, surveyId)
+	if survey == nil {
+		return nil, err
+	}
+
+	resume, err := h.service.ContinueParticipation(c.Request().Context(), *respondent, survey.SurveyID, uint(iParticipationId), c.Request().Header.Get(stepTokenHeader))
+	if errors.Is(err, service.ErrTimeLimitReached) {
+		return nil, c.JSON(errorStatus(err), map[string]string{"error": err.Error(), "code": service.StepTimeLimitReached})
+	}
+	if err != nil {
+		return nil, c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	answering := *respondent
+	if resume.ResponseID != "" {
+		answering = models.AnonymousRespondent(resume.ResponseID)
+	}
+	flow, err := h.surveys.restoreParticipation(c, survey, answering, resume)
+	if flow == nil {
+		return nil, err
+	}
+
+	elapsed := service.SuspendedAnswerTime(resume.Progress, resume.SuspendedAt, time.Now())
+	step := &participationStep{timeLeft: time.Duration(resume.Progress.TimeLeft)*time.Second - elapsed}
+	if step.timeLeft <= 0 {
+		if err := h.service.EndParticipation(c.Request().Context(), resume.Participation.ID); err != nil {
+			h.logger.Error(logging.General, logging.Api, "error in ending user survey participation", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		}
+		return nil, c.JSON(errorStatus(service.ErrTimeLimitReached), map[string]string{"error": service.ErrTimeLimitReached.Error(), "code": service.StepTimeLimitReached})
+	}
+
+	pageMode := resume.Progress.Mode == dto.ParticipationModePage
+	step.engine = service.NewParticipationEngine(h.service, h.logger, resume.Participation, answering, flow, pageMode, survey.AllowReturn, nil)
+	step.engine.Restore(resume.Progress, elapsed)
+	step.engine.RestorePending(resume.Votes)
+	for step.engine.Expired() {
+		step.engine.Expire(c.Request().Context())
+		step.timedOut = true
+	}
+	return step, nil
+}
+
+// respond stores where a participation got to, so the next request picks it up there, and writes
+// the answer to the request with the question or page on screen. A participation that was committed
+// or ended is left as it is.
+func (h *ParticipationHandler) respond(c echo.Context, step *participationStep, status int, message string, code string, pageErrors []dto.PageError) error {
+	token, err := h.service.SuspendStep(c.Request().Context(), step.state())
+	if err != nil {
+		h.logger.Error(logging.General, logging.Api, "error in saving participation progress", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	if step.engine.PageMode() {
+		response := step.engine.PageResponse(message, pageErrors)
+		response.ParticipationID, response.Code, response.StepToken = step.engine.ParticipationID(), code, token
+		return c.JSON(status, response)
+	}
+	response := step.engine.Response(message, code)
+	response.ParticipationID, response.StepToken = step.engine.ParticipationID(), token
+	return c.JSON(status, response)
+}
@@ -0,0 +1,276 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// writeWait is the time a message may take to be written.
+	writeWait = 10 * time.Second
+	// pongWait is how long a connection may stay silent before it counts as lost, clients answer
+	// the pings sent every pingPeriod.
+	pongWait   = 60 * time.Second
+	pingPeriod = pongWait * 9 / 10
+)
+
+// The codes of the errors of the typed protocol that do not come from the participation engine.
+const (
+	stepInvalidMessage   = "invalid_message"
+	stepInvalidOperation = "invalid_operation"
+	stepInvalidPage      = "invalid_page"
+)
+
+var (
+	errInvalidMessage   = errors.New("invalid message")
+	errInvalidOperation = errors.New("invalid operation")
+	errPageRejected     = errors.New("fix the answers of this page")
+)
+
+// protocolVersions are the versions of the typed participation protocol by their subprotocol.
+var protocolVersions = map[string]int{dto.ProtocolV1: 1}
+
+// participationUpgrader negotiates the typed participation protocol with the clients that ask for it.
+var participationUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+	Subprotocols: []string{dto.ProtocolV1},
+}
+
+// participationSession is a participation walked through over a websocket. It writes what happens
+// on the engine in the protocol the client negotiated, version 0 being the untyped messages.
+type participationSession struct {
+	conn    *websocket.Conn
+	engine  *service.ParticipationEngine
+	version int
+	logger  logging.Logger
+	// mu keeps the writes of the session and of the answer time limit apart
+	mu sync.Mutex
+}
+
+// newParticipationSession starts the read deadline of a connection, every pong the client sends
+// extends it.
+func newParticipationSession(conn *websocket.Conn, engine *service.ParticipationEngine, logger logging.Logger) *participationSession {
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	return &participationSession{conn: conn, engine: engine, version: protocolVersions[conn.Subprotocol()], logger: logger}
+}
+
+// heartbeat pings the client until done is closed. A client that stops answering runs into the
+// read deadline and its participation waits to be resumed.
+func (s *participationSession) heartbeat(done <-chan struct{}) {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				s.logger.Info(logging.General, logging.Api, "websocket ping failed", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+				return
+			}
+		}
+	}
+}
+
+func (s *participationSession) write(message interface{}) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	if err := s.conn.WriteJSON(message); err != nil {
+		s.logger.Error(logging.General, logging.Api, "error in writing to websocket connection", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return false
+	}
+	return true
+}
+
+func (s *participationSession) message(messageType dto.MessageType) dto.ProtocolMessage {
+	return dto.ProtocolMessage{Version: s.version, Type: messageType, ParticipationID: s.engine.ParticipationID()}
+}
+
+// timer is the time left for the questions on screen and for the participation, whose answer
+// time limit is the deadline of c.
+func (s *participationSession) timer(c context.Context) *dto.TimerStatus {
+	total := max(0, timeLeft(c)).Round(time.Second)
+	return &dto.TimerStatus{QuestionSeconds: s.engine.Timer().RemainingSeconds(), TotalSeconds: int(total / time.Second)}
+}
+
+// show sends the question or page on screen, resumed tells that it is the first one of a resumed
+// session and timedOut that the one before ran out of time.
+func (s *participationSession) show(c context.Context, resumed bool, timedOut bool) bool {
+	if s.version == 0 {
+		message := "answer question:"
+		if s.engine.PageMode() {
+			message = "answer page:"
+		}
+		code := ""
+		switch {
+		case resumed:
+			message = "participation resumed, " + message
+		case timedOut:
+			message = "time is up, " + message
+			code = service.AnswerTimedOut
+		}
+		if s.engine.PageMode() {
+			return s.write(s.engine.PageResponse(message, nil))
+		}
+		return s.write(s.engine.Response(message, code))
+	}
+
+	m := s.message(dto.MessageQuestion)
+	if s.engine.PageMode() {
+		page := s.engine.PageResponse("", nil)
+		m.Section, m.Questions = page.Section, page.Questions
+	} else {
+		m.Question = s.engine.Response("", "").Question
+	}
+	status := s.engine.Status()
+	m.Resumed, m.Progress, m.Timer = resumed, &status, s.timer(c)
+	return s.write(m)
+}
+
+// expired tells that questions ran out of time, the question shown next follows.
+func (s *participationSession) expired(c context.Context, questions []*dto.Question) bool {
+	if s.version == 0 {
+		return true
+	}
+	m := s.message(dto.MessageTimer)
+	m.Timer = s.timer(c)
+	m.Timer.Event, m.Timer.QuestionIDs = dto.TimerQuestionExpired, dto.QuestionList(questions).GetIds()
+	return s.write(m)
+}
+
+// accepted tells that a message moved the participation on.
+func (s *participationSession) accepted() bool {
+	if s.version == 0 {
+		return true
+	}
+	m := s.message(dto.MessageProgress)
+	status := s.engine.Status()
+	m.Progress = &status
+	return s.write(m)
+}
+
+// reject tells that a message was rejected, pageErrors are the rejected answers of a page.
+func (s *participationSession) reject(err error, pageErrors []dto.PageError) bool {
+	if s.version == 0 {
+		if s.engine.PageMode() {
+			return s.write(s.engine.PageResponse(err.Error(), pageErrors))
+		}
+		return s.write(s.engine.Response(err.Error(), answerCode(err)))
+	}
+	m := s.message(dto.MessageError)
+	m.Error = &dto.ProtocolError{Code: stepErrorCode(err), Message: err.Error(), Errors: pageErrors}
+	return s.write(m)
+}
+
+// failed tells that the participation could not go on, the untyped protocol says nothing.
+func (s *participationSession) failed(err error) {
+	if s.version > 0 {
+		s.reject(err, nil)
+	}
+}
+
+func (s *participationSession) screenedOut() bool {
+	if s.version > 0 {
+		m := s.message(dto.MessageCompleted)
+		m.Outcome = dto.OutcomeScreenedOut
+		return s.write(m)
+	}
+	if s.engine.PageMode() {
+		return s.write(dto.PageResponse{Message: service.ErrQuotaFull.Error(), Code: service.ParticipationScreenedOut})
+	}
+	return s.write(dto.VoteResponse{Message: service.ErrQuotaFull.Error(), Code: service.ParticipationScreenedOut})
+}
+
+func (s *participationSession) committed(result *dto.QuizResult) bool {
+	if s.version > 0 {
+		m := s.message(dto.MessageCompleted)
+		m.Outcome, m.Result = dto.OutcomeCommitted, result
+		return s.write(m)
+	}
+	if s.engine.PageMode() {
+		return s.write(dto.PageResponse{Message: "survey answers committed successfully", Result: result})
+	}
+	return s.write(dto.VoteResponse{Message: "survey answers committed successfully", Result: result})
+}
+
+func (s *participationSession) timeLimitReached() bool {
+	if s.version > 0 {
+		m := s.message(dto.MessageCompleted)
+		m.Outcome = dto.OutcomeTimeLimitReached
+		return s.write(m)
+	}
+	return s.write(dto.VoteResponse{Question: nil, Message: service.ErrTimeLimitReached.Error()})
+}
+
+// apply takes a message of the participant on the engine. A page whose answers were rejected returns
+// errPageRejected with the errors of its answers.
+func (s *participationSession) apply(c context.Context, message []byte) ([]dto.PageError, error) {
+	var operation dto.OperationType
+	answer := dto.VoteRequest{}
+	page := dto.PageVoteRequest{}
+	var err error
+	if s.engine.PageMode() {
+		err = json.Unmarshal(message, &page)
+		operation = page.Operation
+	} else {
+		err = json.Unmarshal(message, &answer)
+		operation = answer.Operation
+	}
+	if err != nil {
+		s.logger.Error(logging.General, logging.Api, "invalid websocket participation message", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		if s.version > 0 {
+			return nil, errInvalidMessage
+		}
+	}
+
+	switch operation {
+	case dto.BackOperation:
+		return nil, s.engine.Back()
+	case dto.CommitOperation:
+		if !s.engine.PageMode() {
+			return nil, s.engine.Answer(c, answer)
+		}
+		pageErrors, err := s.engine.AnswerPage(c, page)
+		if len(pageErrors) > 0 {
+			return pageErrors, errPageRejected
+		}
+		return nil, err
+	}
+	return nil, errInvalidOperation
+}
+
+// stepErrorCode is the code of a rejected message in the typed protocol.
+func stepErrorCode(err error) string {
+	switch {
+	case errors.Is(err, errInvalidMessage):
+		return stepInvalidMessage
+	case errors.Is(err, errInvalidOperation):
+		return stepInvalidOperation
+	case errors.Is(err, errPageRejected):
+		return stepInvalidPage
+	}
+	return service.StepErrorCode(err)
+}
+
+// answerCode is the code of a rejected message in the untyped protocol, only rejected answers have one.
+func answerCode(err error) string {
+	if !errors.Is(err, service.ErrInvalidAnswer) {
+		return ""
+	}
+	return service.AnswerErrorCode(err)
+}
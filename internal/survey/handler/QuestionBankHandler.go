@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/db"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/repository"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"github.com/labstack/echo/v4"
+)
+
+type QuestionBankHandler struct {
+	conf    *config.Config
+	db      db.DbService
+	service service.IQuestionBankService
+	logger  logging.Logger
+}
+
+func NewQuestionBankHandler(conf *config.Config, db db.DbService, logger logging.Logger) *QuestionBankHandler {
+	return &QuestionBankHandler{conf: conf, db: db, logger: logger,
+		service: service.NewQuestionBankService(conf, repository.NewSurveyRepository(db, logger), repository.NewReportRepository(db, logger), logger),
+	}
+}
+
+func (h *QuestionBankHandler) GetBankQuestions(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	req := dto.BankQuestionsGetRequest{}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in get bank questions api", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "validation failed"})
+	}
+
+	questions, err := h.service.GetBankQuestions(c.Request().Context(), userID, req)
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, questions)
+}
+
+func (h *QuestionBankHandler) GetBankQuestion(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	id, err := strconv.Atoi(c.Param("bank_question_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in get bank question", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid bank question id"})
+	}
+
+	question, err := h.service.GetBankQuestion(c.Request().Context(), userID, uint(id))
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	if question == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "bank question not found"})
+	}
+	return c.JSON(http.StatusOK, question)
+}
+
+func (h *QuestionBankHandler) CreateBankQuestion(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	req := dto.BankQuestionCreateRequest{}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in create bank question api", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "validation failed"})
+	}
+
+	question, err := h.service.CreateBankQuestion(c.Request().Context(), userID, req)
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, question)
+}
+
+func (h *QuestionBankHandler) UpdateBankQuestion(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	id, err := strconv.Atoi(c.Param("bank_question_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in update bank question", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid bank question id"})
+	}
+
+	req := dto.BankQuestionUpdateRequest{}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in update bank question api", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "validation failed"})
+	}
+
+	question, err := h.service.UpdateBankQuestion(c.Request().Context(), userID, uint(id), req)
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, question)
+}
+
+func (h *QuestionBankHandler) DeleteBankQuestion(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	id, err := strconv.Atoi(c.Param("bank_question_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in delete bank question", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid bank question id"})
+	}
+
+	err = h.service.DeleteBankQuestion(c.Request().Context(), userID, uint(id))
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, nil)
+}
+
+func (h *QuestionBankHandler) GetBankQuestionReport(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	id, err := strconv.Atoi(c.Param("bank_question_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in bank question report", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid bank question id"})
+	}
+
+	report, err := h.service.GetBankQuestionReport(c.Request().Context(), userID, uint(id), c.Get("role") == "SuperAdmin")
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, report)
+}
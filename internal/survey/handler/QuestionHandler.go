@@ -42,7 +42,7 @@ func (h *QuestionHandler) GetQuestion(c echo.Context) error {
 
 	q, err := h.service.GetQuestion(c.Request().Context(), uint(iquestion_id))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
 	}
 	if q == nil {
 		return c.JSON(http.StatusNotFound, nil)
@@ -67,7 +67,7 @@ func (h *QuestionHandler) DeleteQuestion(c echo.Context) error {
 
 	err = h.service.DeleteQuestion(c.Request().Context(), uint(iQuestion_id))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, nil)
 }
@@ -101,7 +101,7 @@ func (h *QuestionHandler) GetQuestions(c echo.Context) error {
 	response, err := h.service.GetQuestions(c.Request().Context(), req)
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, response)
 
@@ -145,8 +145,74 @@ func (h *QuestionHandler) UpdateQuestion(c echo.Context) error {
 
 	question, err := h.service.UpdateQuestion(c.Request().Context(), uint(iQuestion_id), req)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
 	}
 
 	return c.JSON(http.StatusCreated, question)
 }
+
+func (h *QuestionHandler) AddQuestion(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	surveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in add question", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+
+	var req dto.QuestionAddRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in add question api", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "validation failed"})
+	}
+
+	seen := make(map[string]bool)
+	for _, choice := range req.Choices {
+		if seen[strings.ToLower(choice.Text)] {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "validation failed: the request has same choices for a question"})
+		}
+		seen[strings.ToLower(choice.Text)] = true
+	}
+
+	question, err := h.service.AddQuestion(c.Request().Context(), userID, uint(surveyId), req)
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, question)
+}
+
+func (h *QuestionHandler) ReorderQuestions(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	surveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in reorder questions", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+
+	var req dto.QuestionsReorderRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in reorder questions api", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "validation failed"})
+	}
+
+	questions, err := h.service.ReorderQuestions(c.Request().Context(), uint(surveyId), req)
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, questions)
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/db"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/repository"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"github.com/labstack/echo/v4"
+)
+
+type QuotaHandler struct {
+	conf    *config.Config
+	db      db.DbService
+	service service.IQuotaService
+	logger  logging.Logger
+}
+
+func NewQuotaHandler(conf *config.Config, db db.DbService, logger logging.Logger) *QuotaHandler {
+	return &QuotaHandler{conf: conf, db: db, service: service.NewQuotaService(conf, repository.NewSurveyRepository(db, logger), logger), logger: logger}
+}
+
+func (h *QuotaHandler) GetQuotas(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in get quotas", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+
+	quotas, err := h.service.GetQuotas(c.Request().Context(), uint(iSurveyId))
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, quotas)
+}
+
+func (h *QuotaHandler) CreateQuota(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in create quota", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+
+	req := dto.QuotaRequest{}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in create quota api", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "validation failed"})
+	}
+
+	quota, err := h.service.CreateQuota(c.Request().Context(), uint(iSurveyId), req)
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, quota)
+}
+
+func (h *QuotaHandler) UpdateQuota(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in update quota", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+	iQuotaId, err := strconv.Atoi(c.Param("quota_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in update quota", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid quota id"})
+	}
+
+	req := dto.QuotaRequest{}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in update quota api", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "validation failed"})
+	}
+
+	quota, err := h.service.UpdateQuota(c.Request().Context(), uint(iSurveyId), uint(iQuotaId), req)
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	if quota == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "quota not found"})
+	}
+	return c.JSON(http.StatusOK, quota)
+}
+
+func (h *QuotaHandler) DeleteQuota(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in delete quota", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+	iQuotaId, err := strconv.Atoi(c.Param("quota_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in delete quota", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid quota id"})
+	}
+
+	err = h.service.DeleteQuota(c.Request().Context(), uint(iSurveyId), uint(iQuotaId))
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, nil)
+}
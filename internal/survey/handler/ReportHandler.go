@@ -5,6 +5,7 @@ import (
 	"encoding/csv"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -86,9 +87,85 @@ func (h *ReportHandler) GetSurveyReport(c echo.Context) error {
 	}
 	reportResponse.DispersionResponseByHour = dispersionResponseByHour
 
+	questionAggregates, err := h.service.GetQuestionAggregates(c.Request().Context(), uint(surveyID))
+	if err != nil {
+		h.logger.Error(logging.General, logging.Api, "Error getting question aggregates", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get question aggregates"})
+	}
+	reportResponse.QuestionAggregates = questionAggregates
+
+	scoreDistribution, err := h.service.GetScoreDistribution(c.Request().Context(), uint(surveyID))
+	if err != nil {
+		h.logger.Error(logging.General, logging.Api, "Error getting score distribution", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get score distribution"})
+	}
+	reportResponse.ScoreDistribution = scoreDistribution
+
+	questionTimes, err := h.service.GetQuestionTimes(c.Request().Context(), uint(surveyID))
+	if err != nil {
+		h.logger.Error(logging.General, logging.Api, "Error getting question times", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get question times"})
+	}
+	reportResponse.QuestionTimes = questionTimes
+
 	return c.JSON(http.StatusOK, reportResponse)
 }
 
+func (h *ReportHandler) GetVersionReport(c echo.Context) error {
+	surveyID, err := strconv.ParseUint(c.Param("survey_id"), 10, 0)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request parameters"})
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request parameters"})
+	}
+
+	report, err := h.service.GetVersionReport(c.Request().Context(), uint(surveyID), version)
+	if err != nil {
+		h.logger.Error(logging.General, logging.Api, "Error getting survey version report", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+func (h *ReportHandler) GetSectionReports(c echo.Context) error {
+	surveyID, err := strconv.ParseUint(c.Param("survey_id"), 10, 0)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request parameters"})
+	}
+
+	reports, err := h.service.GetSectionReports(c.Request().Context(), uint(surveyID))
+	if err != nil {
+		h.logger.Error(logging.General, logging.Api, "Error getting survey section reports", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, reports)
+}
+
+func (h *ReportHandler) GetMergedReport(c echo.Context) error {
+	surveyID, err := strconv.ParseUint(c.Param("survey_id"), 10, 0)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request parameters"})
+	}
+
+	var req dto.MergedReportRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in merged report api", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "validation failed"})
+	}
+
+	report, err := h.service.GetMergedReport(c.Request().Context(), uint(surveyID), req)
+	if err != nil {
+		h.logger.Error(logging.General, logging.Api, "Error getting merged survey report", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
 func (h *ReportHandler) GenerateAllSurveysReport(c echo.Context) error {
 	userID, ok := c.Get("userID").(uint)
 	if !ok || userID == 0 {
@@ -119,9 +196,10 @@ func (h *ReportHandler) GenerateAllSurveysReport(c echo.Context) error {
 		"Correct Answers (%)",
 		"Multiple Participation Count",
 		"Suddenly Finished Participation (%)",
-		"Choices Percentage",
+		"Choices Percentage (respondents, selections)",
 		"Average Response Time (minutes)",
 		"Dispersion Response by Hour",
+		"Question Aggregates",
 	}
 	if err := csvWriter.Write(headers); err != nil {
 		h.logger.Error(logging.General, logging.Api, "Error writing CSV headers", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
@@ -149,6 +227,7 @@ func (h *ReportHandler) GenerateAllSurveysReport(c echo.Context) error {
 			choicesPercentage,
 			report.AverageResponseTime,
 			dispersionByHour,
+			formatQuestionAggregates(report.QuestionAggregates),
 		}
 
 		if err := csvWriter.Write(row); err != nil {
@@ -179,7 +258,7 @@ func formatChoicesPercentage(questionChoices []dto.QuestionReport) string {
 	var results []string
 	for _, qc := range questionChoices {
 		for _, c := range qc.ChoiceReport {
-			results = append(results, fmt.Sprintf("%d => %s: %s", qc.QuestionID, c.Text, c.Percentage))
+			results = append(results, fmt.Sprintf("%d => %s: %s of respondents, %s of selections", qc.QuestionID, c.Text, c.Percentage, c.SelectionPercentage))
 		}
 	}
 	return strings.Join(results, "; ")
@@ -200,3 +279,32 @@ func formatParticipationReport(participations []dto.ParticipationReport) string
 	}
 	return strings.Join(results, "; ")
 }
+
+func formatQuestionAggregates(aggregates []dto.QuestionAggregate) string {
+	var results []string
+	for _, a := range aggregates {
+		switch {
+		case a.Average != nil:
+			results = append(results, fmt.Sprintf("%d (%s): avg %.2f, min %g, max %g", a.QuestionID, a.Type, *a.Average, *a.Min, *a.Max))
+		case a.Earliest != "":
+			results = append(results, fmt.Sprintf("%d (%s): %s .. %s", a.QuestionID, a.Type, a.Earliest, a.Latest))
+		case a.AverageRank != nil:
+			items := make([]string, 0, len(a.AverageRank))
+			for item, rank := range a.AverageRank {
+				items = append(items, fmt.Sprintf("%s %.2f", item, rank))
+			}
+			sort.Strings(items)
+			results = append(results, fmt.Sprintf("%d (%s): %s", a.QuestionID, a.Type, strings.Join(items, ", ")))
+		case a.Distribution != nil:
+			items := make([]string, 0, len(a.Distribution))
+			for answer, count := range a.Distribution {
+				items = append(items, fmt.Sprintf("%s %d", answer, count))
+			}
+			sort.Strings(items)
+			results = append(results, fmt.Sprintf("%d (%s): %s", a.QuestionID, a.Type, strings.Join(items, ", ")))
+		default:
+			results = append(results, fmt.Sprintf("%d (%s): %d answers", a.QuestionID, a.Type, a.Count))
+		}
+	}
+	return strings.Join(results, "; ")
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/db"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/repository"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"github.com/labstack/echo/v4"
+)
+
+type SectionHandler struct {
+	conf    *config.Config
+	db      db.DbService
+	service service.ISectionService
+	logger  logging.Logger
+}
+
+func NewSectionHandler(conf *config.Config, db db.DbService, logger logging.Logger) *SectionHandler {
+	return &SectionHandler{conf: conf, db: db, service: service.NewSectionService(conf, repository.NewSurveyRepository(db, logger), logger), logger: logger}
+}
+
+func (h *SectionHandler) GetSections(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in get sections", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+
+	sections, err := h.service.GetSections(c.Request().Context(), uint(iSurveyId))
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, sections)
+}
+
+func (h *SectionHandler) CreateSection(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in create section", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+
+	req := dto.SectionCreateRequest{}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in create section api", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "validation failed"})
+	}
+
+	section, err := h.service.CreateSection(c.Request().Context(), uint(iSurveyId), req)
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, section)
+}
+
+func (h *SectionHandler) UpdateSection(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in update section", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+	iSectionId, err := strconv.Atoi(c.Param("section_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in update section", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid section id"})
+	}
+
+	req := dto.SectionUpdateRequest{}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in update section api", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "validation failed"})
+	}
+
+	section, err := h.service.UpdateSection(c.Request().Context(), uint(iSurveyId), uint(iSectionId), req)
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	if section == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "section not found"})
+	}
+	return c.JSON(http.StatusOK, section)
+}
+
+func (h *SectionHandler) DeleteSection(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in delete section", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+	iSectionId, err := strconv.Atoi(c.Param("section_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in delete section", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid section id"})
+	}
+
+	err = h.service.DeleteSection(c.Request().Context(), uint(iSurveyId), uint(iSectionId))
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, nil)
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/db"
+	notification "github.com/G9QBootcamp/qoli-survey/internal/notification/service"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/repository"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"github.com/labstack/echo/v4"
+)
+
+// guestTokenCookie keeps the guest session of a browser, the token can also be sent in the
+// guest_token query parameter by clients without cookies.
+const guestTokenCookie = "guest_token"
+
+type ShareLinkHandler struct {
+	conf          *config.Config
+	db            db.DbService
+	service       service.IShareLinkService
+	surveyService service.ISurveyService
+	surveys       *SurveyHandler
+	logger        logging.Logger
+}
+
+func NewShareLinkHandler(conf *config.Config, db db.DbService, logger logging.Logger, notificationService notification.INotificationService) *ShareLinkHandler {
+	repo := repository.NewSurveyRepository(db, logger)
+	surveys := NewSurveyHandler(conf, db, logger, notificationService)
+	return &ShareLinkHandler{conf: conf, db: db,
+		service:       service.NewShareLinkService(conf, repo, logger),
+		surveyService: surveys.service,
+		surveys:       surveys,
+		logger:        logger,
+	}
+}
+
+func (h *ShareLinkHandler) GetShareLink(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in get share link", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+
+	link, err := h.service.GetShareLink(c.Request().Context(), uint(iSurveyId))
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, link)
+}
+
+func (h *ShareLinkHandler) UpdateShareLink(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in update share link", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+
+	req := dto.ShareLinkRequest{}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in update share link api", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "validation failed"})
+	}
+
+	link, err := h.service.UpdateShareLink(c.Request().Context(), uint(iSurveyId), req)
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, link)
+}
+
+// OpenShareLink is where the share link of a survey leads, it needs no sign in. The guest session
+// of the browser is kept in a cookie.
+func (h *ShareLinkHandler) OpenShareLink(c echo.Context) error {
+	preview, err := h.service.OpenShareLink(c.Request().Context(), c.Param("slug"), guestToken(c))
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	c.SetCookie(&http.Cookie{
+		Name:     guestTokenCookie,
+		Value:    preview.GuestToken,
+		Path:     "/s/",
+		Expires:  time.Now().AddDate(1, 0, 0),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return c.JSON(http.StatusOK, preview)
+}
+
+// StartGuestSurvey lets a guest answer a survey through its share link over the same websocket
+// flow users answer in.
+func (h *ShareLinkHandler) StartGuestSurvey(c echo.Context) error {
+	surveyId, guestId, err := h.service.GetGuest(c.Request().Context(), c.Param("slug"), guestToken(c))
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+
+	survey, err := h.surveyService.GetSurvey(c.Request().Context(), surveyId)
+	if err != nil {
+		h.logger.Error(logging.General, logging.Api, "Failed to get survey", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	if survey == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "survey not found"})
+	}
+
+	if refused, err := h.surveys.guestParticipationRefused(c, guestId, surveyId); refused {
+		return err
+	}
+
+	return h.surveys.answerSurvey(c, survey, models.GuestRespondent(guestId))
+}
+
+// ResumeGuestSurvey reconnects a guest to a participation of theirs that lost its connection.
+func (h *ShareLinkHandler) ResumeGuestSurvey(c echo.Context) error {
+	surveyId, guestId, err := h.service.GetGuest(c.Request().Context(), c.Param("slug"), guestToken(c))
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	participationId, err := strconv.Atoi(c.Param("participation_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in resume guest survey", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid participation id"})
+	}
+
+	survey, err := h.surveyService.GetSurvey(c.Request().Context(), surveyId)
+	if err != nil {
+		h.logger.Error(logging.General, logging.Api, "Failed to get survey", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	if survey == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "survey not found"})
+	}
+
+	return h.surveys.resumeSurvey(c, survey, models.GuestRespondent(guestId), uint(participationId))
+}
+
+// guestToken returns the token of the guest session of a request.
+func guestToken(c echo.Context) string {
+	if token := c.QueryParam("guest_token"); token != "" {
+		return token
+	}
+	cookie, err := c.Cookie(guestTokenCookie)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
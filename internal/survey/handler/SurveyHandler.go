@@ -3,6 +3,9 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -17,6 +20,7 @@ import (
 	notification "github.com/G9QBootcamp/qoli-survey/internal/notification/service"
 	"github.com/G9QBootcamp/qoli-survey/internal/survey/repository"
 	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/G9QBootcamp/qoli-survey/internal/util"
 
 	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
 	"github.com/labstack/echo/v4"
@@ -71,12 +75,122 @@ func (h *SurveyHandler) CreateSurvey(c echo.Context) error {
 	req.OwnerID = userID
 	survey, err := h.service.CreateSurvey(c.Request().Context(), req)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
 	}
 
 	return c.JSON(http.StatusCreated, survey)
 }
 
+func (h *SurveyHandler) CloneSurvey(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in clone survey", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+
+	var req dto.SurveyCloneRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if err := c.Validate(&req); err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in clone survey api", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "validation failed"})
+	}
+
+	survey, err := h.service.CloneSurvey(c.Request().Context(), userID, uint(iSurveyId), req)
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, survey)
+}
+
+// ExportSurvey downloads the survey document as json, or as yaml with format=yaml.
+func (h *SurveyHandler) ExportSurvey(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in export survey", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "yaml" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "format must be json or yaml"})
+	}
+
+	doc, err := h.service.ExportSurvey(c.Request().Context(), uint(iSurveyId))
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	contentType := echo.MIMEApplicationJSON
+	if err == nil && format == "yaml" {
+		data, err = util.JSONToYAML(data)
+		contentType = "application/x-yaml"
+	}
+	if err != nil {
+		h.logger.Error(logging.General, logging.Api, "error in encoding survey document", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to encode survey"})
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=survey_%d.%s", iSurveyId, format))
+	return c.Blob(http.StatusOK, contentType, data)
+}
+
+// ImportSurvey creates a survey from a json or yaml document, yaml is read when format=yaml or the
+// content type mentions yaml. With dry_run=true the document is only validated.
+func (h *SurveyHandler) ImportSurvey(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	dryRun := false
+	if v := c.QueryParam("dry_run"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "dry_run must be true or false"})
+		}
+	}
+
+	data, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if c.QueryParam("format") == "yaml" || strings.Contains(c.Request().Header.Get(echo.HeaderContentType), "yaml") {
+		if data, err = util.YAMLToJSON(data); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid yaml document: " + err.Error()})
+		}
+	}
+	doc := dto.SurveyDocument{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey document: " + err.Error()})
+	}
+
+	response, err := h.service.ImportSurvey(c.Request().Context(), userID, doc, dryRun)
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	switch {
+	case !response.Valid:
+		return c.JSON(http.StatusUnprocessableEntity, response)
+	case dryRun:
+		return c.JSON(http.StatusOK, response)
+	}
+	return c.JSON(http.StatusCreated, response)
+}
+
 func (h *SurveyHandler) CreateSurveyOption(c echo.Context) error {
 	var req dto.SurveyOptionCreateRequest
 
@@ -104,12 +218,17 @@ func (h *SurveyHandler) CreateSurveyOption(c echo.Context) error {
 
 	survey, err := h.service.CreateOption(c.Request().Context(), userID, uint(iSurveyId), req)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
 	}
 
 	return c.JSON(http.StatusCreated, survey)
 }
 
+// GetSurveyOptionSchemas lists the options a survey can have with their types and allowed values.
+func (h *SurveyHandler) GetSurveyOptionSchemas(c echo.Context) error {
+	return c.JSON(http.StatusOK, service.SurveyOptionSchemas())
+}
+
 func (h *SurveyHandler) GetSurveyOptions(c echo.Context) error {
 
 	userID, ok := c.Get("userID").(uint)
@@ -125,10 +244,10 @@ func (h *SurveyHandler) GetSurveyOptions(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
 	}
 
-	response, err := h.service.GetOptions(c.Request().Context(), dto.SurveyOptionsGetRequest{SurveyId: uint(iSurveyId)})
+	response, err := h.service.GetOptions(c.Request().Context(), dto.SurveyOptionsGetRequest{SurveyId: uint(iSurveyId), Name: c.QueryParam("name")})
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, response)
 
@@ -161,7 +280,7 @@ func (h *SurveyHandler) UpdateSurveyOption(c echo.Context) error {
 
 	survey, err := h.service.UpdateOption(c.Request().Context(), uint(iOptionId), req)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
 	}
 
 	return c.JSON(http.StatusCreated, survey)
@@ -185,7 +304,7 @@ func (h *SurveyHandler) DeleteSurveyOption(c echo.Context) error {
 	err = h.service.DeleteOption(c.Request().Context(), uint(iOptionId))
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, nil)
 
@@ -218,7 +337,7 @@ func (h *SurveyHandler) GetSurveys(c echo.Context) error {
 	response, err := h.service.GetSurveys(c.Request().Context(), req)
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, response)
 
@@ -242,7 +361,7 @@ func (h *SurveyHandler) GetSurvey(c echo.Context) error {
 
 	survey, err := h.service.GetSurvey(c.Request().Context(), uint(iSurveyId))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
 	}
 	if survey == nil {
 		return c.JSON(http.StatusNotFound, nil)
@@ -267,7 +386,7 @@ func (h *SurveyHandler) DeleteSurvey(c echo.Context) error {
 
 	err = h.service.DeleteSurvey(c.Request().Context(), uint(iSurveyId))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, nil)
 }
@@ -322,23 +441,14 @@ func (h *SurveyHandler) DeleteVote(c echo.Context) error {
 
 	}
 
-	for _, v := range survey.Options {
-
-		if v.Name == "vote_deletion_limit_hours" {
-			hours, err := strconv.Atoi(v.Value)
-			if err != nil {
-				continue
-			}
-
-			if vote.CreatedAt.Add(time.Hour * time.Duration(hours)).Before(time.Now()) {
-				return c.JSON(http.StatusForbidden, map[string]string{"error": "you are not allowed because of vote_deletion_limit_hours"})
-			}
+	if hours, ok := service.OptionInt(survey.Options, service.OptionVoteDeletionLimitHours); ok {
+		if vote.CreatedAt.Add(time.Hour * time.Duration(hours)).Before(time.Now()) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "you are not allowed because of vote_deletion_limit_hours"})
 		}
-
 	}
 	err = h.service.DeleteVote(c.Request().Context(), uint(ivote_id))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, nil)
 }
@@ -369,11 +479,175 @@ func (h *SurveyHandler) UpdateSurvey(c echo.Context) error {
 
 	survey, err := h.service.UpdateSurvey(c.Request().Context(), uint(iSurveyId), req)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
 	}
 
 	return c.JSON(http.StatusOK, survey)
 }
+func (h *SurveyHandler) ChangeSurveyStatus(c echo.Context) error {
+	survey_id := c.Param("survey_id")
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(survey_id)
+
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in change survey status", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+
+	req := dto.SurveyStatusUpdateRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in change survey status api", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "validation failed"})
+	}
+
+	survey, err := h.service.ChangeSurveyStatus(c.Request().Context(), uint(iSurveyId), req)
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, survey)
+}
+
+func (h *SurveyHandler) GetSurveyVersions(c echo.Context) error {
+	survey_id := c.Param("survey_id")
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(survey_id)
+
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in get survey versions", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+
+	versions, err := h.service.GetSurveyVersions(c.Request().Context(), uint(iSurveyId))
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, versions)
+}
+
+func (h *SurveyHandler) GetBranchRules(c echo.Context) error {
+	survey_id := c.Param("survey_id")
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(survey_id)
+
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in get branch rules", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+
+	rules, err := h.service.GetBranchRules(c.Request().Context(), uint(iSurveyId))
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, rules)
+}
+
+func (h *SurveyHandler) UpdateBranchRules(c echo.Context) error {
+	survey_id := c.Param("survey_id")
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(survey_id)
+
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in update branch rules", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+
+	req := dto.BranchRulesUpdateRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in update branch rules api", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "validation failed"})
+	}
+
+	rules, err := h.service.UpdateBranchRules(c.Request().Context(), uint(iSurveyId), req)
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, rules)
+}
+
+func (h *SurveyHandler) GetSurveyVersion(c echo.Context) error {
+	survey_id := c.Param("survey_id")
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(survey_id)
+
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in get survey version", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+
+	iVersion, err := strconv.Atoi(c.Param("version"))
+
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in get survey version", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid version"})
+	}
+
+	version, err := h.service.GetSurveyVersion(c.Request().Context(), uint(iSurveyId), iVersion)
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	if version == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "version not found"})
+	}
+	return c.JSON(http.StatusOK, version)
+}
+
+// GetParticipationOrder replays the order a participant was shown the questions and choices in.
+func (h *SurveyHandler) GetParticipationOrder(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
+
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in get participation order", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+
+	iParticipationId, err := strconv.Atoi(c.Param("participation_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in get participation order", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid participation id"})
+	}
+
+	order, err := h.service.GetParticipationOrder(c.Request().Context(), uint(iSurveyId), uint(iParticipationId))
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, order)
+}
+
 func (h *SurveyHandler) StartSurvey(c echo.Context) error {
 
 	survey_id := c.Param("survey_id")
@@ -399,225 +673,384 @@ func (h *SurveyHandler) StartSurvey(c echo.Context) error {
 
 	}
 
-	can, canError := h.service.CanUserParticipateToSurvey(c.Request().Context(), userID, uint(iSurveyId))
-	if canError != nil || !can {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": canError.Error()})
+	if refused, err := h.participationRefused(c, userID, uint(iSurveyId)); refused {
+		return err
 	}
 
-	questionsAnswerMap, err := h.service.GetSurveyQuestionsInOrder(c.Request().Context(), survey.SurveyID)
+	return h.answerSurvey(c, survey, models.UserRespondent(userID))
+}
+
+// ResumeSurvey reconnects a participant to a participation of theirs that lost its connection.
+func (h *SurveyHandler) ResumeSurvey(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok || userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "userID not found"})
+	}
 
+	iSurveyId, err := strconv.Atoi(c.Param("survey_id"))
 	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in resume survey", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid survey id"})
+	}
+	iParticipationId, err := strconv.Atoi(c.Param("participation_id"))
+	if err != nil {
+		h.logger.Warn(logging.Validation, logging.Api, "validation error in resume survey", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error(), logging.UserId: userID})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid participation id"})
+	}
+
+	survey, err := h.service.GetSurvey(c.Request().Context(), uint(iSurveyId))
+	if err != nil {
+		h.logger.Error(logging.General, logging.Api, "Failed to get survey", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
 		return c.JSON(http.StatusInternalServerError, err.Error())
 	}
-	if len(questionsAnswerMap) <= 0 {
-		return c.JSON(http.StatusNoContent, map[string]string{"error": "there are no question for this survey"})
+	if survey == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "survey not found"})
+	}
+
+	return h.resumeSurvey(c, survey, models.UserRespondent(userID), uint(iParticipationId))
+}
+
+// participationRefused writes why a user can not start a participation of a survey, refused is false
+// when they can.
+func (h *SurveyHandler) participationRefused(c echo.Context, userID uint, surveyId uint) (refused bool, err error) {
+	can, canError := h.service.CanUserParticipateToSurvey(c.Request().Context(), userID, surveyId)
+	if errors.Is(canError, service.ErrQuotaFull) {
+		return true, c.JSON(errorStatus(canError), map[string]string{"error": canError.Error(), "code": service.ParticipationScreenedOut})
+	}
+	var suspended *service.SuspendedParticipationError
+	if errors.As(canError, &suspended) {
+		return true, c.JSON(errorStatus(canError), map[string]interface{}{"error": canError.Error(), "code": service.ParticipationSuspended, "participation_id": suspended.ParticipationID})
+	}
+	if errors.Is(canError, service.ErrNotEligible) {
+		return true, c.JSON(errorStatus(canError), map[string]string{"error": canError.Error()})
 	}
+	if canError != nil || !can {
+		return true, c.JSON(http.StatusBadRequest, map[string]string{"error": canError.Error()})
+	}
+	return false, nil
+}
 
-	timeLimit := survey.AnswerTimeLimit
+// guestParticipationRefused writes why a guest can not start a participation of a survey, refused is
+// false when they can.
+func (h *SurveyHandler) guestParticipationRefused(c echo.Context, guestId uint, surveyId uint) (refused bool, err error) {
+	can, canError := h.service.CanGuestParticipateToSurvey(c.Request().Context(), guestId, surveyId)
+	var suspended *service.SuspendedParticipationError
+	if errors.As(canError, &suspended) {
+		return true, c.JSON(errorStatus(canError), map[string]interface{}{"error": canError.Error(), "code": service.ParticipationSuspended, "participation_id": suspended.ParticipationID})
+	}
+	if canError != nil || !can {
+		status := errorStatus(canError)
+		if status == http.StatusInternalServerError {
+			status = http.StatusBadRequest
+		}
+		return true, c.JSON(status, map[string]string{"error": canError.Error()})
+	}
+	return false, nil
+}
+
+// participate starts a participation of a user or a guest and builds the flow it walks through. A nil
+// participation means it could not start, the reason was written then.
+func (h *SurveyHandler) participate(c echo.Context, survey *dto.SurveyResponse, respondent models.Respondent) (*dto.UserSurveyParticipationResponse, *service.BranchEngine, error) {
+	// the seed is stored with the participation, so the order it is shown in can be replayed
+	seed := util.NewSeed()
+	flow, err := h.service.GetSurveyFlow(c.Request().Context(), survey.SurveyID, seed)
 
-	participation, err := h.service.Participate(c.Request().Context(), userID, survey.SurveyID)
+	if err != nil {
+		return nil, nil, c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	if flow.Len() <= 0 {
+		return nil, nil, c.JSON(http.StatusNoContent, map[string]string{"error": "there are no question for this survey"})
+	}
+
+	// the lang query parameter is the preference of the participant, Accept-Language comes next
+	language := service.NegotiateLanguage(c.QueryParam("lang"), c.Request().Header.Get("Accept-Language"), service.SurveyLanguages(survey.TitleTranslations))
+	participation, err := h.service.Participate(c.Request().Context(), respondent, survey.SurveyID, language, seed)
+	if errors.Is(err, service.ErrQuotaFull) {
+		return nil, nil, c.JSON(errorStatus(err), map[string]string{"error": err.Error(), "code": service.ParticipationScreenedOut})
+	}
 	if err != nil {
 		h.logger.Error(logging.General, logging.Api, "error in create user participation", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return nil, nil, c.JSON(http.StatusInternalServerError, err.Error())
 	}
+	return participation, flow, nil
+}
 
-	conn, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+// answerSurvey starts a participation of a user or a guest and walks them through the survey over a websocket.
+func (h *SurveyHandler) answerSurvey(c echo.Context, survey *dto.SurveyResponse, respondent models.Respondent) error {
+	// from here on the answers of an anonymous survey are only kept under a response id the
+	// participation never learns
+	answering := respondent
+	if survey.Anonymity != nil {
+		responseId, err := service.NewResponseID()
+		if err != nil {
+			h.logger.Error(logging.Internal, logging.Api, "error in creating a response id", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+			return c.JSON(http.StatusInternalServerError, err.Error())
+		}
+		answering = models.AnonymousRespondent(responseId)
+	}
+	participation, flow, err := h.participate(c, survey, respondent)
+	if participation == nil {
+		return err
+	}
+
+	pageMode := c.QueryParam("mode") == "page"
+	engine := service.NewParticipationEngine(h.service, h.logger, *participation, answering, flow, pageMode, survey.AllowReturn, nil)
+	engine.Start()
+	return h.runParticipation(c, engine, false, time.Duration(survey.AnswerTimeLimit)*time.Second)
+}
+
+// restoreParticipation builds the flow a claimed participation was shown, with the answers it gave
+// so far, respondent is the one its answers are stored under. A nil flow means it can not go on,
+// the reason was written then.
+func (h *SurveyHandler) restoreParticipation(c echo.Context, survey *dto.SurveyResponse, respondent models.Respondent, resume *dto.ParticipationResume) (*service.BranchEngine, error) {
+	participationId := resume.Participation.ID
+	// the seed of the participation shows the survey in the order it was shown before
+	flow, err := h.service.GetSurveyFlow(c.Request().Context(), survey.SurveyID, resume.Participation.Seed)
+	if err != nil {
+		h.leaveParticipation(c.Request().Context(), participationId, respondent, resume.Progress)
+		return nil, c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	if !service.ProgressFits(flow, resume.Progress) {
+		if err := h.service.EndParticipation(c.Request().Context(), participationId); err != nil {
+			h.logger.Error(logging.General, logging.Api, "error in ending user survey participation", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		}
+		err = fmt.Errorf("%w: its questions changed", service.ErrNotResumable)
+		return nil, c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	for questionId, answers := range resume.Answers {
+		flow.SetAnswer(questionId, answers)
+	}
+	return flow, nil
+}
+
+// resumeSurvey picks up a participation that lost its connection where the participant left off,
+// with the answers, question times and answer time they had left. One that waited between requests
+// used up answer time meanwhile.
+func (h *SurveyHandler) resumeSurvey(c echo.Context, survey *dto.SurveyResponse, respondent models.Respondent, participationId uint) error {
+	resume, err := h.service.ResumeParticipation(c.Request().Context(), respondent, survey.SurveyID, participationId)
+	if err != nil {
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
+	}
+	flow, err := h.restoreParticipation(c, survey, respondent, resume)
+	if flow == nil {
+		return err
+	}
+
+	elapsed := service.SuspendedAnswerTime(resume.Progress, resume.SuspendedAt, time.Now())
+	pageMode := resume.Progress.Mode == dto.ParticipationModePage
+	engine := service.NewParticipationEngine(h.service, h.logger, resume.Participation, respondent, flow, pageMode, survey.AllowReturn, nil)
+	engine.Restore(resume.Progress, elapsed)
+	return h.runParticipation(c, engine, true, time.Duration(resume.Progress.TimeLeft)*time.Second-elapsed)
+}
+
+// runParticipation walks a participant through a participation over a websocket, resumed tells that
+// it picks up where an interrupted session left off and timeLimit is the time the participant has to answer.
+// Clients that negotiate a subprotocol of dto.ProtocolV1 get the typed participation protocol.
+func (h *SurveyHandler) runParticipation(c echo.Context, engine *service.ParticipationEngine, resumed bool, timeLimit time.Duration) error {
+	conn, err := participationUpgrader.Upgrade(c.Response(), c.Request(), nil)
 	if err != nil {
 		h.logger.Error(logging.General, logging.Api, "Failed to upgrade connection", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		if resumed {
+			// the participation waits to be resumed again
+			h.leaveParticipation(c.Request().Context(), engine.ParticipationID(), engine.Respondent(), engine.Progress(timeLimit))
+		}
 		return err
 	}
 	defer conn.Close()
+	session := newParticipationSession(conn, engine, h.logger)
 
 	// Channel to signal disconnection
 	disconnectSignal := make(chan struct{})
 
-	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Duration(timeLimit)*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request().Context(), timeLimit)
 	defer cancel()
 
-	go h.startTimer(ctx, conn, participation.ID, disconnectSignal)
-	h.readAnswers(ctx, conn, participation.ID, userID, questionsAnswerMap, survey.AllowReturn, disconnectSignal)
+	go session.heartbeat(disconnectSignal)
+	go h.startTimer(ctx, session, disconnectSignal)
+	h.readAnswers(ctx, session, resumed, disconnectSignal)
 
 	return nil
 }
 
-func (h *SurveyHandler) startTimer(contextWithTimeout context.Context, conn *websocket.Conn, participationId uint, disconnectSignal chan struct{}) error {
-
-	defer h.service.EndParticipation(contextWithTimeout, participationId)
+// startTimer ends a participation when its answer time runs out. A session that is over before
+// was committed or left to be resumed, see leaveParticipation.
+func (h *SurveyHandler) startTimer(contextWithTimeout context.Context, session *participationSession, disconnectSignal chan struct{}) error {
 
 	select {
 	case <-disconnectSignal:
-		err := h.service.EndParticipation(context.Background(), participationId)
-		if err != nil {
-			h.logger.Error(logging.General, logging.Api, "error in ending user survey participation", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
-			return err
-		}
 		return nil
 	case <-contextWithTimeout.Done():
+		if !errors.Is(contextWithTimeout.Err(), context.DeadlineExceeded) {
+			return nil
+		}
 
-		err := h.service.EndParticipation(context.Background(), participationId)
+		err := h.service.EndParticipation(context.Background(), session.engine.ParticipationID())
 		if err != nil {
 			h.logger.Error(logging.General, logging.Api, "error in ending user survey participation", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
 			return err
 		}
-		err = conn.WriteJSON(dto.VoteResponse{Question: nil, Message: "time limit reached"})
-		if err != nil {
-			h.logger.Error(logging.General, logging.Api, "error in writing to websocket connection", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
-		}
-		return conn.Close()
+		session.timeLimitReached()
+		return session.conn.Close()
 	}
 
 }
 
-func (h *SurveyHandler) readAnswers(c context.Context, conn *websocket.Conn, participationId uint, userId uint, questionsAnswerMap dto.QuestionsAnswerMap, allowReturn bool, disconnectSignal chan struct{}) {
+// readAnswers delivers the survey a question at a time, or a section at a time in page mode where
+// every question of a page is answered in one message and the page is only taken when all of its
+// answers are valid. Questions are shown in the language of the participation while answers are
+// checked and stored against the untranslated question. Questions with a time limit are left
+// unanswered when their time runs out and the next ones are sent. A resumed participation starts
+// again on the question or page it was on.
+func (h *SurveyHandler) readAnswers(c context.Context, session *participationSession, resumed bool, disconnectSignal chan struct{}) {
 	defer close(disconnectSignal)
 
-	sentQuestions := []*dto.Question{}
-	i := 0
-	items := questionsAnswerMap[i]
-	q := items[dto.NoAnswer]
-	err := conn.WriteJSON(dto.VoteResponse{Question: q, Message: "answer question:"})
-	if err != nil {
-		h.logger.Error(logging.General, logging.Api, "error in writing to websocket connection", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
-		return
-	}
-	sentQuestions = append(sentQuestions, q)
-
-	for {
-		_, message, err := conn.ReadMessage()
-
-		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				h.logger.Error(logging.General, logging.Api, "Unexpected close websocket connection error", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
-			} else {
-				h.logger.Info(logging.General, logging.Api, "Websocket Connection closed", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
-			}
-			return
+	engine := session.engine
+	finished := false
+	defer func() {
+		if !finished {
+			h.leaveParticipation(c, engine.ParticipationID(), engine.Respondent(), engine.Progress(timeLeft(c)))
 		}
-		req := dto.VoteRequest{}
-		err = json.Unmarshal(message, &req)
-		if err != nil {
-			h.logger.Error(logging.General, logging.Api, "Unexpected close websocket connection error", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}()
+	if !engine.Finished() {
+		if !session.show(c, resumed, false) {
+			return
 		}
+		h.saveProgress(c, engine)
+	}
 
-		if req.Operation != dto.CommitOperation && req.Operation != dto.BackOperation {
-			err := conn.WriteJSON(dto.VoteResponse{Question: q, Message: "invalid operation"})
-			if err != nil {
-				h.logger.Error(logging.General, logging.Api, "error in writing to websocket connection", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	messages := h.readMessages(session.conn, disconnectSignal)
+	for !engine.Finished() {
+		var message []byte
+		expired, stop := expiry(engine.Timer())
+		select {
+		case m, ok := <-messages:
+			stop()
+			if !ok {
 				return
 			}
-		}
-
-		if req.Operation == dto.BackOperation && allowReturn {
-			if i == 0 {
-				err := conn.WriteJSON(dto.VoteResponse{Question: q, Message: "this is first question"})
-				if err != nil {
-					h.logger.Error(logging.General, logging.Api, "error in writing to websocket connection", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+			message = m
+		case <-expired:
+			if !session.expired(c, engine.Expire(c)) {
+				return
+			}
+			if !engine.Finished() {
+				if !session.show(c, false, true) {
 					return
 				}
-				continue
-			}
-
-			i--
-			sentQuestions = sentQuestions[:len(sentQuestions)-1]
-			q = sentQuestions[len(sentQuestions)-1]
-
-			err := conn.WriteJSON(dto.VoteResponse{Question: q, Message: "answer question:"})
-			if err != nil {
-				h.logger.Error(logging.General, logging.Api, "error in writing to websocket connection", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
-				return
+				h.saveProgress(c, engine)
 			}
 			continue
 		}
 
-		if req.Operation == dto.BackOperation && !allowReturn {
-			err := conn.WriteJSON(dto.VoteResponse{Question: q, Message: "you are not allowed to return in this survey"})
-			if err != nil {
-				h.logger.Error(logging.General, logging.Api, "error in writing to websocket connection", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		pageErrors, err := session.apply(c, message)
+		if errors.Is(err, service.ErrQuotaFull) {
+			finished = true
+			session.screenedOut()
+			return
+		}
+		if err != nil {
+			if !session.reject(err, pageErrors) {
 				return
 			}
 			continue
 		}
-
-		if req.QuestionId != q.ID {
-			err := conn.WriteJSON(dto.VoteResponse{Question: q, Message: "invalid question id"})
-			if err != nil {
-				h.logger.Error(logging.General, logging.Api, "error in writing to websocket connection", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
-
-			}
-
-			continue
+		if !session.accepted() {
+			return
 		}
 
-		validChoice := false
-		isCorrectAnswer := false
-		if len(q.Choices) > 0 && q.HasMultipleChoice {
-			for _, v := range q.Choices {
-				if v.Text == req.Answer && v.IsCorrect {
-					isCorrectAnswer = true
-				}
-				if v.Text == req.Answer {
-					validChoice = true
-				}
-			}
-
-			if !validChoice {
-				err := conn.WriteJSON(dto.VoteResponse{Question: q, Message: "enter valid choice"})
-				if err != nil {
-					h.logger.Error(logging.General, logging.Api, "error in writing to websocket connection", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		if engine.Finished() {
+			break
+		}
+		if !session.show(c, false, false) {
+			return
+		}
+		h.saveProgress(c, engine)
+	}
 
-				}
-				continue
-			}
+	result, err := engine.Commit(c)
+	if err != nil {
+		h.logger.Error(logging.General, logging.Api, "error in committing survey participation", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		session.failed(err)
+		return
+	}
+	finished = true
+	session.committed(result)
+}
 
-		}
+// saveProgress stores where a participation got to, so it can be resumed on another connection. The
+// path through an anonymous survey tells its answers, so it is not stored.
+func (h *SurveyHandler) saveProgress(c context.Context, engine *service.ParticipationEngine) {
+	if engine.Respondent().IsAnonymous() {
+		return
+	}
+	if err := h.service.SaveProgress(c, engine.ParticipationID(), engine.Progress(timeLeft(c))); err != nil {
+		h.logger.Error(logging.General, logging.Api, "error in saving participation progress", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+}
 
-		h.service.CommitVote(c, models.Vote{VoterID: userId, QuestionID: q.ID, Answer: req.Answer, IsCorrect: isCorrectAnswer})
-		i++
+// leaveParticipation handles a participant that left a session before finishing it. A participation
+// of an anonymous survey ends without its answers, which are only stored on commit, others wait to be
+// resumed. One that ran out of time is ended by startTimer.
+func (h *SurveyHandler) leaveParticipation(c context.Context, participationId uint, respondent models.Respondent, progress dto.ParticipationProgress) {
+	if errors.Is(c.Err(), context.DeadlineExceeded) {
+		return
+	}
+	var err error
+	if respondent.IsAnonymous() {
+		err = h.service.EndParticipation(context.Background(), participationId)
+	} else {
+		err = h.service.SuspendParticipation(context.Background(), participationId, progress)
+	}
+	if err != nil {
+		h.logger.Error(logging.General, logging.Api, "error in leaving survey participation", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+}
 
-		if len(questionsAnswerMap) <= i {
-			break
-		}
+// timeLeft returns the answer time left in a session.
+func timeLeft(c context.Context) time.Duration {
+	deadline, ok := c.Deadline()
+	if !ok {
+		return 0
+	}
+	return time.Until(deadline)
+}
 
+// readMessages reads the messages of a websocket connection until it is closed or done is closed.
+func (h *SurveyHandler) readMessages(conn *websocket.Conn, done <-chan struct{}) <-chan []byte {
+	messages := make(chan []byte)
+	go func() {
+		defer close(messages)
 		for {
-			if len(questionsAnswerMap) <= i {
-				break
-			}
-			items := questionsAnswerMap[i]
-			var e bool
-			q, e = items[dto.Answer(req.Answer)]
-			if e {
-				break
-			} else {
-				q, e = items[dto.NoAnswer]
-				if e {
-					break
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+					h.logger.Error(logging.General, logging.Api, "Unexpected close websocket connection error", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
 				} else {
-					i++
+					h.logger.Info(logging.General, logging.Api, "Websocket Connection closed", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
 				}
+				return
+			}
+			// any message shows the client is still there, like the pongs of the heartbeat
+			conn.SetReadDeadline(time.Now().Add(pongWait))
+			select {
+			case messages <- message:
+			case <-done:
+				return
 			}
-
-		}
-		if q == nil {
-			break
-		}
-		err = conn.WriteJSON(dto.VoteResponse{Question: q, Message: "answer question:"})
-		if err != nil {
-			h.logger.Error(logging.General, logging.Api, "error in writing to websocket connection", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
-			return
 		}
-		sentQuestions = append(sentQuestions, q)
+	}()
+	return messages
+}
 
+// expiry returns a channel that fires when the time of the shown questions runs out, the channel
+// never fires when they have no limit. stop releases the timer.
+func expiry(timer *service.AnswerTimer) (expired <-chan time.Time, stop func()) {
+	remaining, ok := timer.Remaining()
+	if !ok {
+		return nil, func() {}
 	}
-	err = h.service.CommitParticipation(c, participationId)
-	if err != nil {
-		h.logger.Error(logging.General, logging.Api, "error in committing survey participation", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
-		return
-	}
-	err = conn.WriteJSON(dto.VoteResponse{Message: "survey answers committed successfully"})
-	if err != nil {
-		h.logger.Error(logging.General, logging.Api, "error in writing to websocket connection", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
-		return
-	}
+	t := time.NewTimer(remaining)
+	return t.C, func() { t.Stop() }
 }
 
 func (h *SurveyHandler) GetUserVotes(c echo.Context) error {
@@ -637,7 +1070,7 @@ func (h *SurveyHandler) GetUserVotes(c echo.Context) error {
 
 	votes, err := h.service.GetVotes(uint(surveyID), viewerID, uint(respondentID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{"votes": votes})
@@ -653,7 +1086,7 @@ func (h *SurveyHandler) GetVisibleVoteUsers(c echo.Context) error {
 
 	users, err := h.service.GetVisibleVoteUsers(uint(surveyID), userID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
 	}
 
 	return c.JSON(http.StatusOK, users)
@@ -685,16 +1118,13 @@ func (h *SurveyHandler) SurveyVotes(c echo.Context) error {
 
 	role, _ := c.Get("role").(string)
 
-	for _, v := range survey.Options {
-
-		if v.Name == "votes_visibility" && v.Value == "invisible" {
-			return c.JSON(http.StatusForbidden, map[string]string{"message": "results of this survey is invisible"})
-		}
-
-		if v.Name == "votes_visibility" && v.Value == "admin" && role != "SuperAdmin" && survey.UserId != userID {
+	switch service.OptionValue(survey.Options, service.OptionVotesVisibility) {
+	case service.VotesVisibilityInvisible:
+		return c.JSON(http.StatusForbidden, map[string]string{"message": "results of this survey is invisible"})
+	case service.VotesVisibilityAdmin:
+		if role != "SuperAdmin" && survey.UserId != userID {
 			return c.JSON(http.StatusForbidden, map[string]string{"message": "results of this survey is visible for admin and owner os survey"})
 		}
-
 	}
 
 	conn, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
@@ -744,7 +1174,7 @@ func (h *SurveyHandler) UploadMedia(c echo.Context) error {
 
 	filePath, err := h.service.UploadMedia(fileHeader)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(errorStatus(err), map[string]string{"error": err.Error()})
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"file_path": filePath})
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+)
+
+// errorStatus maps the errors returned by survey services to http status codes.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrSurveyNotFound),
+		errors.Is(err, service.ErrBankQuestionNotFound),
+		errors.Is(err, service.ErrParticipationNotFound),
+		errors.Is(err, service.ErrAudienceNotFound),
+		errors.Is(err, service.ErrInvitationNotFound),
+		errors.Is(err, service.ErrShareLinkNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrGuestSessionNotFound):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrBankQuestionNotOwned),
+		errors.Is(err, service.ErrQuotaFull),
+		errors.Is(err, service.ErrNotEligible),
+		errors.Is(err, service.ErrNotInvited),
+		errors.Is(err, service.ErrSurveyAnonymous),
+		errors.Is(err, service.ErrReturnNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrInvalidSurveyStatus),
+		errors.Is(err, service.ErrInvalidQuestionConfig),
+		errors.Is(err, service.ErrInvalidAnswer),
+		errors.Is(err, service.ErrInvalidBranchRule),
+		errors.Is(err, service.ErrInvalidSection),
+		errors.Is(err, service.ErrInvalidChoice),
+		errors.Is(err, service.ErrInvalidBankQuery),
+		errors.Is(err, service.ErrInvalidSurveyFilter),
+		errors.Is(err, service.ErrInvalidQuestionOrder),
+		errors.Is(err, service.ErrInvalidPiping),
+		errors.Is(err, service.ErrInvalidRandomization),
+		errors.Is(err, service.ErrInvalidQuiz),
+		errors.Is(err, service.ErrInvalidQuota),
+		errors.Is(err, service.ErrInvalidOption),
+		errors.Is(err, service.ErrInvalidEligibility),
+		errors.Is(err, service.ErrInvalidAudience),
+		errors.Is(err, service.ErrInvalidInvitation),
+		errors.Is(err, service.ErrInvalidShareLink),
+		errors.Is(err, service.ErrInvalidAnonymity),
+		errors.Is(err, service.ErrInvalidStepToken),
+		errors.Is(err, service.ErrWrongQuestion):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, service.ErrInvalidStatusTransition),
+		errors.Is(err, service.ErrSurveyNotEditable),
+		errors.Is(err, service.ErrSurveyNotOpen),
+		errors.Is(err, service.ErrSurveyReadOnly),
+		errors.Is(err, service.ErrSurveyIsTemplate),
+		errors.Is(err, service.ErrInvitationUsed),
+		errors.Is(err, service.ErrInvitationExpired),
+		errors.Is(err, service.ErrShareLinkTaken),
+		errors.Is(err, service.ErrParticipationSuspended),
+		errors.Is(err, service.ErrNotResumable),
+		errors.Is(err, service.ErrFirstQuestion),
+		errors.Is(err, service.ErrFirstPage),
+		errors.Is(err, service.ErrNothingToAnswer),
+		errors.Is(err, service.ErrNotFinished),
+		errors.Is(err, service.ErrTimeLimitReached):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
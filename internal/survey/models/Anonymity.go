@@ -0,0 +1,29 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// AnonymitySettings makes a survey anonymous, it is stored as json.
+type AnonymitySettings struct {
+	MinGroupSize int `json:"min_group_size"`
+}
+
+func (a AnonymitySettings) Value() (driver.Value, error) {
+	b, err := json.Marshal(a)
+	return string(b), err
+}
+
+func (a *AnonymitySettings) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	}
+	return errors.New("unsupported anonymity settings value")
+}
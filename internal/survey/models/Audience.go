@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/user/models"
+)
+
+// Audience is a saved list of users an owner targets surveys at through eligibility rules.
+type Audience struct {
+	ID        uint             `gorm:"primarykey" json:"audience_id"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	OwnerID   uint             `gorm:"not null;index" json:"owner_id"`
+	Name      string           `gorm:"not null" json:"name"`
+	Members   []AudienceMember `gorm:"foreignKey:AudienceID;constraint:OnDelete:CASCADE;" json:"members"`
+	Owner     models.User      `gorm:"foreignKey:OwnerID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+}
+
+type AudienceMember struct {
+	ID         uint        `gorm:"primarykey" json:"id"`
+	AudienceID uint        `gorm:"not null;uniqueIndex:idx_audience_member" json:"audience_id"`
+	UserID     uint        `gorm:"not null;uniqueIndex:idx_audience_member;index" json:"user_id"`
+	User       models.User `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Tags are the labels of a bank question, they are stored as a json array so they can be searched with @>.
+type Tags []string
+
+func (t Tags) Value() (driver.Value, error) {
+	if t == nil {
+		t = Tags{}
+	}
+	b, err := json.Marshal(t)
+	return string(b), err
+}
+
+func (t *Tags) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	}
+	return errors.New("unsupported tags value")
+}
+
+// BankQuestion is a reusable question of a user's library, surveys copy it into their own questions.
+// Shared bank questions can be used by every user but only changed by their owner.
+type BankQuestion struct {
+	ID                uint           `gorm:"primarykey" json:"bank_question_id"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	OwnerID           uint           `gorm:"not null;index" json:"owner_id"`
+	Shared            bool           `gorm:"default:false" json:"shared"`
+	Text              string         `gorm:"not null" json:"text"`
+	Type              string         `gorm:"not null;default:text" json:"type"`
+	Config            QuestionConfig `gorm:"type:jsonb" json:"config"`
+	HasMultipleChoice bool           `gorm:"default:false" json:"has_multiple_choice"`
+	MediaUrl          string         `json:"media_url"`
+	Tags              Tags           `gorm:"type:jsonb" json:"tags"`
+	Choices           []BankChoice   `gorm:"foreignKey:BankQuestionID;constraint:OnDelete:CASCADE;" json:"choices"`
+}
+
+type BankChoice struct {
+	ID             uint    `gorm:"primarykey" json:"choice_id"`
+	BankQuestionID uint    `gorm:"not null;index" json:"-"`
+	Text           string  `gorm:"not null" json:"text"`
+	IsCorrect      bool    `json:"is_correct"`
+	Anchored       bool    `gorm:"default:false" json:"anchored"`
+	Points         float64 `gorm:"default:0" json:"points"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// RuleExpression is the condition of a branch rule, it is stored as json.
+type RuleExpression struct {
+	And        []RuleExpression `json:"and,omitempty"`
+	Or         []RuleExpression `json:"or,omitempty"`
+	Not        *RuleExpression  `json:"not,omitempty"`
+	QuestionID uint             `json:"question_id,omitempty"`
+	Operator   string           `json:"operator,omitempty"`
+	Operand    string           `json:"value,omitempty"`
+}
+
+func (e RuleExpression) Value() (driver.Value, error) {
+	b, err := json.Marshal(e)
+	return string(b), err
+}
+
+func (e *RuleExpression) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, e)
+	case string:
+		return json.Unmarshal([]byte(v), e)
+	}
+	return errors.New("unsupported rule expression value")
+}
+
+// RemapQuestions returns a copy of the expression that refers to the questions in ids instead,
+// it is used when a survey is cloned.
+func (e RuleExpression) RemapQuestions(ids map[uint]uint) RuleExpression {
+	remapped := RuleExpression{Operator: e.Operator, Operand: e.Operand, QuestionID: ids[e.QuestionID]}
+	for _, child := range e.And {
+		remapped.And = append(remapped.And, child.RemapQuestions(ids))
+	}
+	for _, child := range e.Or {
+		remapped.Or = append(remapped.Or, child.RemapQuestions(ids))
+	}
+	if e.Not != nil {
+		not := e.Not.RemapQuestions(ids)
+		remapped.Not = &not
+	}
+	return remapped
+}
+
+// BranchRule is evaluated after its question is answered, the first matching rule of a question
+// decides where the survey continues.
+type BranchRule struct {
+	ID               uint           `gorm:"primarykey" json:"id"`
+	SurveyID         uint           `gorm:"not null;index" json:"survey_id"`
+	QuestionID       uint           `gorm:"not null;index" json:"question_id"`
+	Priority         int            `json:"priority"`
+	Condition        RuleExpression `gorm:"type:jsonb" json:"condition"`
+	TargetQuestionID uint           `json:"target_question_id"`
+	EndSurvey        bool           `json:"end_survey"`
+	Survey           Survey         `gorm:"foreignKey:SurveyID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+}
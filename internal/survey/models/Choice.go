@@ -1,10 +1,13 @@
 package models
 
 type Choice struct {
-	ID               uint     `json:"choice_id"`
-	QuestionID       uint     `gorm:"not null"`
-	Text             string   `gorm:"not null" json:"text"`
-	IsCorrect        bool     `json:"is_correct"`
-	LinkedQuestionID uint     `json:"linked_question_id"`
-	Question         Question `gorm:"foreignKey:QuestionID;references:ID;constraint:OnDelete:CASCADE;"`
+	ID               uint         `json:"choice_id"`
+	QuestionID       uint         `gorm:"not null"`
+	Text             string       `gorm:"not null" json:"text"`
+	TextTranslations Translations `gorm:"type:jsonb" json:"text_translations"`
+	IsCorrect        bool         `json:"is_correct"`
+	Anchored         bool         `gorm:"default:false" json:"anchored"`
+	Points           float64      `gorm:"default:0" json:"points"`
+	LinkedQuestionID uint         `json:"linked_question_id"`
+	Question         Question     `gorm:"foreignKey:QuestionID;references:ID;constraint:OnDelete:CASCADE;"`
 }
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+const (
+	EligibilityCity          = "city"
+	EligibilityAge           = "age"
+	EligibilityEmailDomain   = "email_domain"
+	EligibilityAccountAge    = "account_age"
+	EligibilityEmailVerified = "email_verified"
+	EligibilityAudience      = "audience"
+	EligibilityParticipated  = "participated"
+)
+
+// EligibilityRule decides who may take a survey, it is either a group that combines its rules with
+// And or Or, or a condition of Type on the participant. It is stored as json.
+type EligibilityRule struct {
+	And            []EligibilityRule `json:"and,omitempty"`
+	Or             []EligibilityRule `json:"or,omitempty"`
+	Type           string            `json:"type,omitempty"`
+	Values         []string          `json:"values,omitempty"`
+	MinAge         *int              `json:"min_age,omitempty"`
+	MaxAge         *int              `json:"max_age,omitempty"`
+	MinAccountDays int               `json:"min_account_days,omitempty"`
+	AudienceID     uint              `json:"audience_id,omitempty"`
+	SurveyID       uint              `json:"survey_id,omitempty"`
+}
+
+func (e EligibilityRule) Value() (driver.Value, error) {
+	b, err := json.Marshal(e)
+	return string(b), err
+}
+
+func (e *EligibilityRule) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, e)
+	case string:
+		return json.Unmarshal([]byte(v), e)
+	}
+	return errors.New("unsupported eligibility rule value")
+}
@@ -0,0 +1,80 @@
+package models
+
+import "time"
+
+// ShareLink is the public link of a survey, guests without an account take the survey through it.
+// Every guest session can take part GuestParticipationLimit times.
+type ShareLink struct {
+	ID                      uint      `gorm:"primarykey" json:"share_link_id"`
+	CreatedAt               time.Time `json:"created_at"`
+	UpdatedAt               time.Time `json:"updated_at"`
+	SurveyID                uint      `gorm:"not null;uniqueIndex" json:"survey_id"`
+	Slug                    string    `gorm:"not null;uniqueIndex" json:"slug"`
+	Enabled                 bool      `gorm:"not null;default:false" json:"enabled"`
+	GuestParticipationLimit int       `gorm:"not null;default:1" json:"guest_participation_limit"`
+	Survey                  Survey    `gorm:"foreignKey:SurveyID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+}
+
+// GuestSession is a browser that takes surveys without an account, its token is signed over the id and Nonce.
+type GuestSession struct {
+	ID         uint      `gorm:"primarykey" json:"guest_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Nonce      string    `gorm:"not null" json:"-"`
+}
+
+// Respondent is who answers a survey: a user, or a guest that answers through the share link of
+// the survey. One of the ids is set. The answers to anonymous surveys are only kept under a
+// random ResponseID that is never stored next to the participation.
+type Respondent struct {
+	UserID     *uint
+	GuestID    *uint
+	ResponseID string
+}
+
+func UserRespondent(userId uint) Respondent {
+	return Respondent{UserID: &userId}
+}
+
+func GuestRespondent(guestId uint) Respondent {
+	return Respondent{GuestID: &guestId}
+}
+
+func AnonymousRespondent(responseId string) Respondent {
+	return Respondent{ResponseID: responseId}
+}
+
+func (r Respondent) IsGuest() bool {
+	return r.GuestID != nil
+}
+
+func (r Respondent) IsAnonymous() bool {
+	return r.ResponseID != ""
+}
+
+// Is tells whether two respondents are the same user or the same guest.
+func (r Respondent) Is(other Respondent) bool {
+	switch {
+	case r.UserID != nil && other.UserID != nil:
+		return *r.UserID == *other.UserID
+	case r.GuestID != nil && other.GuestID != nil:
+		return *r.GuestID == *other.GuestID
+	}
+	return false
+}
+
+// Condition returns the condition that matches the rows of the respondent, userColumn is the
+// column the table keeps the user in. The guest is kept in guest_id and the anonymous response
+// in response_id.
+func (r Respondent) Condition(userColumn string) (string, interface{}) {
+	if r.ResponseID != "" {
+		return "response_id = ?", r.ResponseID
+	}
+	if r.GuestID != nil {
+		return "guest_id = ?", *r.GuestID
+	}
+	if r.UserID != nil {
+		return userColumn + " = ?", *r.UserID
+	}
+	return userColumn + " = ?", 0
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/user/models"
+)
+
+// InvitationStatus is how far an invitee got, from the invitation email to the committed participation.
+type InvitationStatus string
+
+const (
+	InvitationPending   InvitationStatus = "pending"
+	InvitationSent      InvitationStatus = "sent"
+	InvitationOpened    InvitationStatus = "opened"
+	InvitationAccepted  InvitationStatus = "accepted"
+	InvitationStarted   InvitationStatus = "started"
+	InvitationCompleted InvitationStatus = "completed"
+	InvitationBounced   InvitationStatus = "bounced"
+)
+
+// Invitation invites an email address to take a survey. Its token is signed over the id and Nonce,
+// it is spent once a user accepts the invitation.
+type Invitation struct {
+	ID          uint             `gorm:"primarykey" json:"invitation_id"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+	SurveyID    uint             `gorm:"not null;uniqueIndex:idx_invitation" json:"survey_id"`
+	Email       string           `gorm:"not null;uniqueIndex:idx_invitation" json:"email"`
+	UserID      *uint            `gorm:"index" json:"user_id"`
+	Nonce       string           `gorm:"not null" json:"-"`
+	Status      InvitationStatus `gorm:"not null" json:"status"`
+	Error       string           `json:"error"`
+	ExpiresAt   *time.Time       `json:"expires_at"`
+	SentAt      *time.Time       `json:"sent_at"`
+	OpenedAt    *time.Time       `json:"opened_at"`
+	AcceptedAt  *time.Time       `json:"accepted_at"`
+	StartedAt   *time.Time       `json:"started_at"`
+	CompletedAt *time.Time       `json:"completed_at"`
+	BouncedAt   *time.Time       `json:"bounced_at"`
+	Survey      Survey           `gorm:"foreignKey:SurveyID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+	User        *models.User     `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+}
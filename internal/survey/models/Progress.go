@@ -0,0 +1,37 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// ParticipationProgress is where a live participation got to, it is stored as json with the
+// participation so the participant can resume it on another connection or pick it up on the next request.
+type ParticipationProgress struct {
+	Mode         string         `json:"mode"`
+	Asked        []uint         `json:"asked,omitempty"`
+	Pages        [][]uint       `json:"pages,omitempty"`
+	Page         []uint         `json:"page,omitempty"`
+	Spent        map[uint]int64 `json:"spent,omitempty"`
+	TimeLeft     int            `json:"time_left"`
+	Finished     bool           `json:"finished,omitempty"`
+	OverRequests bool           `json:"over_requests,omitempty"`
+}
+
+func (p ParticipationProgress) Value() (driver.Value, error) {
+	b, err := json.Marshal(p)
+	return string(b), err
+}
+
+func (p *ParticipationProgress) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	}
+	return errors.New("unsupported participation progress value")
+}
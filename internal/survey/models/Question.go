@@ -1,12 +1,83 @@
 package models
 
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// QuestionConfig holds the settings of typed questions, it is stored as json.
+type QuestionConfig struct {
+	Min     *float64 `json:"min,omitempty"`
+	Max     *float64 `json:"max,omitempty"`
+	Integer bool     `json:"integer,omitempty"`
+	Labels  []string `json:"labels,omitempty"`
+	MinDate string   `json:"min_date,omitempty"`
+	MaxDate string   `json:"max_date,omitempty"`
+	Items   []string `json:"items,omitempty"`
+	Rows    []string `json:"rows,omitempty"`
+	Columns []string `json:"columns,omitempty"`
+
+	MultiSelect   bool `json:"multi_select,omitempty"`
+	MinSelections int  `json:"min_selections,omitempty"`
+	MaxSelections int  `json:"max_selections,omitempty"`
+	ChoicesFrom   uint `json:"choices_from,omitempty"`
+
+	Pinned           bool  `json:"pinned,omitempty"`
+	RandomizeChoices *bool `json:"randomize_choices,omitempty"`
+
+	Weight    *float64 `json:"weight,omitempty"`
+	TimeLimit int      `json:"time_limit,omitempty"`
+
+	Validation *AnswerValidation `json:"validation,omitempty"`
+}
+
+// AnswerValidation holds the rules answers to a question must follow.
+type AnswerValidation struct {
+	Optional  bool     `json:"optional,omitempty"`
+	MinLength int      `json:"min_length,omitempty"`
+	MaxLength int      `json:"max_length,omitempty"`
+	Pattern   string   `json:"pattern,omitempty"`
+	Min       *float64 `json:"min,omitempty"`
+	Max       *float64 `json:"max,omitempty"`
+	Format    string   `json:"format,omitempty"`
+	Message   string   `json:"message,omitempty"`
+
+	MessageTranslations map[string]string `json:"message_translations,omitempty"`
+}
+
+func (c QuestionConfig) Value() (driver.Value, error) {
+	b, err := json.Marshal(c)
+	return string(b), err
+}
+
+func (c *QuestionConfig) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	}
+	return errors.New("unsupported question config value")
+}
+
 type Question struct {
-	ID                uint   `json:"question_id"`
-	SurveyID          uint   `gorm:"not null"`
-	Text              string `gorm:"not null" json:"text"`
-	HasMultipleChoice bool   `gorm:"default:false" json:"has_multiple_choice"`
-	MediaUrl          string `json:"media_url"`
+	ID                uint           `json:"question_id"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
+	SurveyID          uint           `gorm:"not null"`
+	Text              string         `gorm:"not null" json:"text"`
+	TextTranslations  Translations   `gorm:"type:jsonb" json:"text_translations"`
+	Type              string         `gorm:"not null;default:text" json:"type"`
+	Config            QuestionConfig `gorm:"type:jsonb" json:"config"`
+	HasMultipleChoice bool           `gorm:"default:false" json:"has_multiple_choice"`
+	MediaUrl          string         `json:"media_url"`
 	Order             int
+	SectionID         uint `gorm:"index" json:"section_id"`
+	BankQuestionID    uint `gorm:"index" json:"bank_question_id"`
 	LinkedQuestionID  uint
 	Survey            Survey   `gorm:"foreignKey:SurveyID;references:ID;constraint:OnDelete:CASCADE;"`
 	Choices           []Choice `gorm:"foreignKey:QuestionID;constraint:OnDelete:CASCADE;" json:"choices"`
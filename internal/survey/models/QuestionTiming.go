@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// QuestionTiming is the time a participant spent on a question during a participation,
+// TimedOut is set when the time limit of the question ran out before it was answered.
+type QuestionTiming struct {
+	ID              uint `gorm:"primarykey" json:"id"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	ParticipationID uint                    `gorm:"not null;uniqueIndex:idx_question_timing" json:"participation_id"`
+	QuestionID      uint                    `gorm:"not null;uniqueIndex:idx_question_timing;index" json:"question_id"`
+	Milliseconds    int64                   `gorm:"not null" json:"milliseconds"`
+	TimedOut        bool                    `gorm:"default:false" json:"timed_out"`
+	Participation   UserSurveyParticipation `gorm:"foreignKey:ParticipationID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+	Question        Question                `gorm:"foreignKey:QuestionID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+}
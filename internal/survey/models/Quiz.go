@@ -0,0 +1,30 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// QuizSettings makes a survey a quiz, it is stored as json.
+type QuizSettings struct {
+	PassMark  float64 `json:"pass_mark"`
+	ShowScore bool    `json:"show_score"`
+}
+
+func (q QuizSettings) Value() (driver.Value, error) {
+	b, err := json.Marshal(q)
+	return string(b), err
+}
+
+func (q *QuizSettings) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, q)
+	case string:
+		return json.Unmarshal([]byte(v), q)
+	}
+	return errors.New("unsupported quiz settings value")
+}
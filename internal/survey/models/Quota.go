@@ -0,0 +1,69 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// QuotaAttribute is the participant attribute a quota segments participants on.
+type QuotaAttribute string
+
+const (
+	QuotaCity   QuotaAttribute = "city"
+	QuotaAge    QuotaAttribute = "age"
+	QuotaAnswer QuotaAttribute = "answer"
+)
+
+// QuotaValues are the cities or answers a quota matches, they are stored as a json array.
+type QuotaValues []string
+
+func (v QuotaValues) Value() (driver.Value, error) {
+	if v == nil {
+		v = QuotaValues{}
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+func (v *QuotaValues) Scan(value interface{}) error {
+	switch s := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(s, v)
+	case string:
+		return json.Unmarshal([]byte(s), v)
+	}
+	return errors.New("unsupported quota values value")
+}
+
+// SurveyQuota caps the participations of a segment of participants. Filled counts the participations
+// holding a place in the quota, running ones included, Completed counts the committed ones.
+type SurveyQuota struct {
+	ID                 uint           `gorm:"primarykey" json:"quota_id"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	SurveyID           uint           `gorm:"not null;index" json:"survey_id"`
+	Name               string         `gorm:"not null" json:"name"`
+	Attribute          QuotaAttribute `gorm:"not null" json:"attribute"`
+	Values             QuotaValues    `gorm:"type:jsonb" json:"values"`
+	MinAge             *int           `json:"min_age"`
+	MaxAge             *int           `json:"max_age"`
+	QuestionID         *uint          `json:"question_id"`
+	ParticipationLimit int            `gorm:"not null" json:"limit"`
+	Filled             int            `gorm:"not null;default:0" json:"filled"`
+	Completed          int            `gorm:"not null;default:0" json:"completed"`
+	Survey             Survey         `gorm:"foreignKey:SurveyID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+}
+
+// QuotaPlace is the place a participation holds in a quota.
+type QuotaPlace struct {
+	ID              uint                    `gorm:"primarykey" json:"id"`
+	CreatedAt       time.Time               `json:"created_at"`
+	ParticipationID uint                    `gorm:"not null;uniqueIndex:idx_quota_place" json:"participation_id"`
+	QuotaID         uint                    `gorm:"not null;uniqueIndex:idx_quota_place;index" json:"quota_id"`
+	Participation   UserSurveyParticipation `gorm:"foreignKey:ParticipationID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+	Quota           SurveyQuota             `gorm:"foreignKey:QuotaID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// Randomization holds what a survey shuffles for each participant, it is stored as json.
+type Randomization struct {
+	Sections  bool `json:"sections"`
+	Questions bool `json:"questions"`
+	Choices   bool `json:"choices"`
+}
+
+func (r Randomization) Value() (driver.Value, error) {
+	b, err := json.Marshal(r)
+	return string(b), err
+}
+
+func (r *Randomization) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, r)
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	}
+	return errors.New("unsupported randomization value")
+}
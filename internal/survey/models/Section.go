@@ -0,0 +1,12 @@
+package models
+
+// Section groups questions of a survey into a page with its own intro.
+type Section struct {
+	ID       uint   `gorm:"primarykey" json:"section_id"`
+	SurveyID uint   `gorm:"not null;index" json:"survey_id"`
+	Title    string `gorm:"not null" json:"title"`
+	Intro    string `json:"intro"`
+	MediaUrl string `json:"media_url"`
+	Order    int    `json:"order"`
+	Survey   Survey `gorm:"foreignKey:SurveyID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+}
@@ -7,6 +7,41 @@ import (
 	"gorm.io/gorm"
 )
 
+type SurveyStatus string
+
+const (
+	SurveyStatusDraft     SurveyStatus = "draft"
+	SurveyStatusScheduled SurveyStatus = "scheduled"
+	SurveyStatusOpen      SurveyStatus = "open"
+	SurveyStatusClosed    SurveyStatus = "closed"
+	SurveyStatusArchived  SurveyStatus = "archived"
+)
+
+// surveyStatusTransitions lists the statuses each status may move to.
+var surveyStatusTransitions = map[SurveyStatus][]SurveyStatus{
+	SurveyStatusDraft:     {SurveyStatusScheduled},
+	SurveyStatusScheduled: {SurveyStatusDraft, SurveyStatusOpen},
+	SurveyStatusOpen:      {SurveyStatusClosed},
+	SurveyStatusClosed:    {SurveyStatusArchived},
+}
+
+func (s SurveyStatus) IsValid() bool {
+	switch s {
+	case SurveyStatusDraft, SurveyStatusScheduled, SurveyStatusOpen, SurveyStatusClosed, SurveyStatusArchived:
+		return true
+	}
+	return false
+}
+
+func (s SurveyStatus) CanTransitionTo(next SurveyStatus) bool {
+	for _, v := range surveyStatusTransitions[s] {
+		if v == next {
+			return true
+		}
+	}
+	return false
+}
+
 type Survey struct {
 	ID                 uint `gorm:"primarykey" json:"survey_id"`
 	CreatedAt          time.Time
@@ -14,9 +49,17 @@ type Survey struct {
 	DeletedAt          gorm.DeletedAt          `gorm:"index"`
 	OwnerID            uint                    `gorm:"not null" json:"user_id"`
 	Title              string                  `gorm:"not null" json:"title"`
+	TitleTranslations  Translations            `gorm:"type:jsonb" json:"title_translations"`
+	Status             SurveyStatus            `gorm:"not null;default:draft;index" json:"status"`
+	CurrentVersion     int                     `gorm:"not null;default:0" json:"current_version"`
+	IsTemplate         bool                    `gorm:"default:false;index" json:"is_template"`
 	StartTime          time.Time               `gorm:"not null" json:"start_time"`
 	EndTime            time.Time               `gorm:"not null" json:"end_time"`
 	IsSequential       bool                    `gorm:"default:false" json:"is_sequential"`
+	Randomization      *Randomization          `gorm:"type:jsonb" json:"randomization"`
+	Quiz               *QuizSettings           `gorm:"type:jsonb" json:"quiz"`
+	Anonymity          *AnonymitySettings      `gorm:"type:jsonb" json:"anonymity"`
+	Eligibility        *EligibilityRule        `gorm:"type:jsonb" json:"eligibility"`
 	AllowReturn        bool                    `gorm:"default:false" json:"allow_return"`
 	ParticipationLimit int                     `gorm:"default:1" json:"participation_limit"`
 	AnswerTimeLimit    int                     `gorm:"not null" json:"answer_time_limit"`
@@ -25,4 +68,33 @@ type Survey struct {
 	UserSurveyRoles    []models.UserSurveyRole `gorm:"foreignKey:SurveyID;constraint:OnDelete:CASCADE;"`
 	VoteVisibilities   []models.VoteVisibility `gorm:"foreignKey:SurveyID;constraint:OnDelete:CASCADE;"`
 	Options            []SurveyOption          `gorm:"foreignKey:SurveyId;constraint:OnDelete:CASCADE;"`
+	Versions           []SurveyVersion         `gorm:"foreignKey:SurveyID;constraint:OnDelete:CASCADE;" json:"-"`
+}
+
+// CurrentStatus returns the status the survey should have at the given time,
+// applying the transitions driven by StartTime and EndTime.
+func (s *Survey) CurrentStatus(now time.Time) SurveyStatus {
+	status := s.Status
+	if status == SurveyStatusScheduled && !now.Before(s.StartTime) {
+		status = SurveyStatusOpen
+	}
+	if status == SurveyStatusOpen && !now.Before(s.EndTime) {
+		status = SurveyStatusClosed
+	}
+	return status
+}
+
+// RandomizationSettings returns what the survey shuffles, surveys created before randomization
+// was configurable shuffle their sections and questions unless they are sequential.
+func (s *Survey) RandomizationSettings() Randomization {
+	if s.Randomization != nil {
+		return *s.Randomization
+	}
+	return Randomization{Sections: !s.IsSequential, Questions: !s.IsSequential}
+}
+
+// IsEditable reports whether the survey settings and questions can still be changed.
+func (s *Survey) IsEditable() bool {
+	status := s.CurrentStatus(time.Now())
+	return status == SurveyStatusDraft || status == SurveyStatusScheduled
 }
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+// SurveyVersion is an immutable snapshot of a published survey definition.
+type SurveyVersion struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	SurveyID   uint      `gorm:"not null;uniqueIndex:idx_survey_versions_survey_version" json:"survey_id"`
+	Version    int       `gorm:"not null;uniqueIndex:idx_survey_versions_survey_version" json:"version"`
+	Definition string    `gorm:"type:jsonb;not null" json:"definition"`
+	Survey     Survey    `gorm:"foreignKey:SurveyID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// Translations maps a language code such as fa or en to a translated text, the untranslated
+// text stays in its own column.
+type Translations map[string]string
+
+func (t Translations) Value() (driver.Value, error) {
+	if t == nil {
+		t = Translations{}
+	}
+	b, err := json.Marshal(t)
+	return string(b), err
+}
+
+func (t *Translations) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	}
+	return errors.New("unsupported translations value")
+}
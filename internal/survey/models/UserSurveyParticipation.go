@@ -8,15 +8,33 @@ import (
 )
 
 type UserSurveyParticipation struct {
-	ID          uint `gorm:"primarykey" json:"id"`
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	DeletedAt   gorm.DeletedAt `gorm:"index"`
-	UserId      uint           `gorm:"not null" json:"user_id"`
-	SurveyID    uint           `gorm:"not null" json:"survey_id"`
-	StartAt     time.Time      `gorm:"not null" json:"start_at"`
-	EndAt       *time.Time     `gorm:"default:null" json:"end_at"`
-	CommittedAt *time.Time     `gorm:"default:null" json:"committed_at"`
-	User        models.User    `gorm:"foreignKey:UserId;references:ID;"`
-	Survey      Survey         `gorm:"foreignKey:SurveyID;references:ID;"`
+	ID            uint `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+	UserId        *uint          `gorm:"index" json:"user_id"`
+	GuestID       *uint          `gorm:"index" json:"guest_id"`
+	SurveyID      uint           `gorm:"not null" json:"survey_id"`
+	SurveyVersion int            `gorm:"not null;default:0" json:"survey_version"`
+	Language      string         `json:"language"`
+	Seed          int64          `gorm:"not null;default:0" json:"seed"`
+	StartAt       time.Time      `gorm:"not null" json:"start_at"`
+	EndAt         *time.Time     `gorm:"default:null" json:"end_at"`
+	CommittedAt   *time.Time     `gorm:"default:null" json:"committed_at"`
+	Score         *float64       `gorm:"default:null" json:"score"`
+	MaxScore      *float64       `gorm:"default:null" json:"max_score"`
+	Passed        *bool          `gorm:"default:null" json:"passed"`
+	ScreenedOutAt *time.Time     `gorm:"default:null" json:"screened_out_at"`
+	// DisconnectedAt is when the participant lost the connection, the participation can be resumed
+	// from Progress for a while after it.
+	DisconnectedAt *time.Time             `gorm:"default:null" json:"disconnected_at"`
+	Progress       *ParticipationProgress `gorm:"type:jsonb" json:"progress"`
+	User           models.User            `gorm:"foreignKey:UserId;references:ID;"`
+	Guest          *GuestSession          `gorm:"foreignKey:GuestID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+	Survey         Survey                 `gorm:"foreignKey:SurveyID;references:ID;"`
+}
+
+// Respondent returns who took part, a user or a guest.
+func (p *UserSurveyParticipation) Respondent() Respondent {
+	return Respondent{UserID: p.UserId, GuestID: p.GuestID}
 }
@@ -8,14 +8,19 @@ import (
 )
 
 type Vote struct {
-	ID         uint      `gorm:"primarykey" json:"id"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time
-	DeletedAt  gorm.DeletedAt `gorm:"index"`
-	VoterID    uint           `gorm:"not null" json:"voter_id"`
-	QuestionID uint           `gorm:"not null" json:"question_id"`
-	Answer     string         `gorm:"not null" json:"answer"`
-	IsCorrect  bool           `json:"is_correct"`
-	Voter      models.User    `gorm:"foreignKey:VoterID;references:ID;constraint:OnDelete:CASCADE;" json:"voter"`
-	Question   Question       `gorm:"foreignKey:QuestionID;references:ID;constraint:OnDelete:CASCADE;" json:"question"`
+	ID            uint      `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+	VoterID       *uint          `gorm:"index" json:"voter_id"`
+	GuestID       *uint          `gorm:"index" json:"guest_id"`
+	ResponseID    string         `gorm:"index" json:"response_id,omitempty"`
+	QuestionID    uint           `gorm:"not null" json:"question_id"`
+	ChoiceID      uint           `gorm:"index" json:"choice_id"`
+	SurveyVersion int            `gorm:"not null;default:0;index" json:"survey_version"`
+	Answer        string         `gorm:"not null" json:"answer"`
+	IsCorrect     bool           `json:"is_correct"`
+	Voter         models.User    `gorm:"foreignKey:VoterID;references:ID;constraint:OnDelete:CASCADE;" json:"voter"`
+	Guest         *GuestSession  `gorm:"foreignKey:GuestID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+	Question      Question       `gorm:"foreignKey:QuestionID;references:ID;constraint:OnDelete:CASCADE;" json:"question"`
 }
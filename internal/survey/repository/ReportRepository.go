@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
 	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
 	userModels "github.com/G9QBootcamp/qoli-survey/internal/user/models"
 	"gorm.io/gorm"
@@ -22,14 +23,32 @@ type IReportRepository interface {
 	GetCorrectChoiceByQuestionID(ctx context.Context, qid uint) (*models.Choice, error)
 	GetChoicesByQuestionID(ctx context.Context, qid uint) ([]models.Choice, error)
 	GetGivenAnswerCountByQuestionID(ctx context.Context, qid uint, answer string) (int64, error)
+	GetChoiceVoteCount(ctx context.Context, choice models.Choice) (int64, error)
 	GetParticipationCount(ctx context.Context, surveyId uint, userId uint) (int64, error)
+	GetGuestParticipationCount(ctx context.Context, surveyId uint) (int64, error)
+	GetMultipleGuestParticipations(ctx context.Context, surveyId uint) ([]dto.ParticipationReport, error)
 	GetTotalParticipants(ctx context.Context, surveyId uint) ([]userModels.User, error)
 	GetAverageResponseTime(ctx context.Context, surveyId uint) (float64, error)
 	GetResponseDispersionByHour(ctx context.Context, surveyId uint) (map[int]int, error)
 	GetAllSurveys(ctx context.Context) ([]models.Survey, error)
 	GetAccessibleSurveys(ctx context.Context, userID uint, permission string) ([]models.Survey, error)
+	GetSurvey(ctx context.Context, surveyId uint) (*models.Survey, error)
+	GetSurveyVersion(ctx context.Context, surveyId uint, version int) (*models.SurveyVersion, error)
+	GetParticipationCountByVersions(ctx context.Context, surveyId uint, versions []int) (int64, error)
+	GetChoiceVoteCounts(ctx context.Context, questionIds []uint, versions []int) ([]dto.ChoiceVoteCount, error)
+	GetAnswersByQuestionID(ctx context.Context, qid uint) ([]string, error)
+	GetQuestionRespondentsCount(ctx context.Context, qid uint) (int64, error)
+	GetQuestionRespondentCounts(ctx context.Context, questionIds []uint, versions []int) ([]dto.QuestionRespondentCount, error)
+	GetSectionsBySurveyID(ctx context.Context, sid uint) ([]models.Section, error)
+	GetRespondentsCount(ctx context.Context, questionIds []uint) (int64, error)
+	GetQuestionsByBankQuestionID(ctx context.Context, bankQuestionId uint) ([]models.Question, error)
+	GetScoredParticipations(ctx context.Context, surveyId uint) ([]models.UserSurveyParticipation, error)
+	GetQuestionTimes(ctx context.Context, surveyId uint) ([]dto.QuestionTime, error)
 }
 
+// respondentKey tells the respondents of votes apart, users, guests and anonymous responses have separate ids.
+const respondentKey = "CONCAT(voter_id, '-', guest_id, '-', response_id)"
+
 type ReportRepository struct {
 	db     db.DbService
 	logger logging.Logger
@@ -173,6 +192,21 @@ func (r *ReportRepository) GetGivenAnswerCountByQuestionID(ctx context.Context,
 	return count, nil
 }
 
+// GetChoiceVoteCount counts the votes on a choice by its id, whatever language it was shown in.
+// Votes stored before choice ids were kept on votes are matched by their answer text.
+func (r *ReportRepository) GetChoiceVoteCount(ctx context.Context, choice models.Choice) (int64, error) {
+	var count int64
+	err := r.db.GetDb().WithContext(ctx).Table("votes").
+		Where("deleted_at IS NULL AND (choice_id = ? OR (choice_id = 0 AND question_id = ? AND answer = ?))", choice.ID, choice.QuestionID, choice.Text).
+		Count(&count).Error
+
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "GetChoiceVoteCount error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return 0, err
+	}
+	return count, nil
+}
+
 func (r *ReportRepository) GetParticipationCount(ctx context.Context, surveyId uint, userId uint) (int64, error) {
 	var count int64
 	err := r.db.GetDb().WithContext(ctx).Table("user_survey_participations").
@@ -187,6 +221,38 @@ func (r *ReportRepository) GetParticipationCount(ctx context.Context, surveyId u
 	return count, nil
 }
 
+// GetGuestParticipationCount counts the participations of guests that took a survey through its share link.
+func (r *ReportRepository) GetGuestParticipationCount(ctx context.Context, surveyId uint) (int64, error) {
+	var count int64
+	err := r.db.GetDb().WithContext(ctx).Table("user_survey_participations").
+		Where("survey_id = ? AND guest_id IS NOT NULL", surveyId).
+		Count(&count).
+		Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		r.logger.Error(logging.Database, logging.Select, "GetGuestParticipationCount error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetMultipleGuestParticipations returns the guests that took a survey more than once.
+func (r *ReportRepository) GetMultipleGuestParticipations(ctx context.Context, surveyId uint) ([]dto.ParticipationReport, error) {
+	var reports []dto.ParticipationReport
+	err := r.db.GetDb().WithContext(ctx).Table("user_survey_participations").
+		Select("guest_id, COUNT(*) as count").
+		Where("survey_id = ? AND guest_id IS NOT NULL", surveyId).
+		Group("guest_id").
+		Having("COUNT(*) > 1").
+		Scan(&reports).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		r.logger.Error(logging.Database, logging.Select, "GetMultipleGuestParticipations error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return nil, err
+	}
+	return reports, nil
+}
+
 func (r *ReportRepository) GetTotalParticipants(ctx context.Context, surveyId uint) ([]userModels.User, error) {
 	var users []userModels.User
 
@@ -278,3 +344,167 @@ func (r *ReportRepository) GetAccessibleSurveys(ctx context.Context, userID uint
 	}
 	return surveys, nil
 }
+
+func (r *ReportRepository) GetSurvey(ctx context.Context, surveyId uint) (*models.Survey, error) {
+	var survey models.Survey
+	err := r.db.GetDb().WithContext(ctx).First(&survey, surveyId).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "GetSurvey error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return nil, err
+	}
+	return &survey, nil
+}
+
+func (r *ReportRepository) GetSurveyVersion(ctx context.Context, surveyId uint, version int) (*models.SurveyVersion, error) {
+	var v models.SurveyVersion
+	err := r.db.GetDb().WithContext(ctx).Where("survey_id = ? AND version = ?", surveyId, version).First(&v).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "GetSurveyVersion error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *ReportRepository) GetParticipationCountByVersions(ctx context.Context, surveyId uint, versions []int) (int64, error) {
+	var count int64
+	err := r.db.GetDb().WithContext(ctx).Table("user_survey_participations").
+		Where("survey_id = ? AND survey_version IN ? AND deleted_at IS NULL", surveyId, versions).
+		Count(&count).
+		Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		r.logger.Error(logging.Database, logging.Select, "GetParticipationCountByVersions error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *ReportRepository) GetChoiceVoteCounts(ctx context.Context, questionIds []uint, versions []int) ([]dto.ChoiceVoteCount, error) {
+	var counts []dto.ChoiceVoteCount
+	err := r.db.GetDb().WithContext(ctx).Table("votes").
+		Select("question_id, choice_id, survey_version, answer, COUNT(*) as count").
+		Where("question_id IN ? AND survey_version IN ? AND deleted_at IS NULL", questionIds, versions).
+		Group("question_id, choice_id, survey_version, answer").
+		Scan(&counts).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		r.logger.Error(logging.Database, logging.Select, "GetChoiceVoteCounts error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (r *ReportRepository) GetAnswersByQuestionID(ctx context.Context, qid uint) ([]string, error) {
+	var answers []string
+	err := r.db.GetDb().WithContext(ctx).Table("votes").Where("question_id = ? AND deleted_at IS NULL", qid).Pluck("answer", &answers).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		r.logger.Error(logging.Database, logging.Select, "GetAnswersByQuestionID error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return nil, err
+	}
+	return answers, nil
+}
+
+func (r *ReportRepository) GetQuestionRespondentsCount(ctx context.Context, qid uint) (int64, error) {
+	var count int64
+	err := r.db.GetDb().WithContext(ctx).Table("votes").Select("COUNT(DISTINCT "+respondentKey+")").Where("question_id = ? AND deleted_at IS NULL", qid).Scan(&count).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		r.logger.Error(logging.Database, logging.Select, "GetQuestionRespondentsCount error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *ReportRepository) GetQuestionRespondentCounts(ctx context.Context, questionIds []uint, versions []int) ([]dto.QuestionRespondentCount, error) {
+	var counts []dto.QuestionRespondentCount
+	err := r.db.GetDb().WithContext(ctx).Table("votes").
+		Select("question_id, COUNT(DISTINCT "+respondentKey+") as respondents").
+		Where("question_id IN ? AND survey_version IN ? AND deleted_at IS NULL", questionIds, versions).
+		Group("question_id").
+		Scan(&counts).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		r.logger.Error(logging.Database, logging.Select, "GetQuestionRespondentCounts error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (r *ReportRepository) GetSectionsBySurveyID(ctx context.Context, sid uint) ([]models.Section, error) {
+	var sections []models.Section
+	err := r.db.GetDb().WithContext(ctx).Where("survey_id = ?", sid).Order("\"order\", id").Find(&sections).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		r.logger.Error(logging.Database, logging.Select, "GetSectionsBySurveyID error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return nil, err
+	}
+	return sections, nil
+}
+
+// GetRespondentsCount counts the users and guests that answered at least one of the questions.
+func (r *ReportRepository) GetRespondentsCount(ctx context.Context, questionIds []uint) (int64, error) {
+	var count int64
+	if len(questionIds) == 0 {
+		return 0, nil
+	}
+	err := r.db.GetDb().WithContext(ctx).Table("votes").Select("COUNT(DISTINCT "+respondentKey+")").Where("question_id IN ? AND deleted_at IS NULL", questionIds).Scan(&count).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		r.logger.Error(logging.Database, logging.Select, "GetRespondentsCount error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *ReportRepository) GetQuestionsByBankQuestionID(ctx context.Context, bankQuestionId uint) ([]models.Question, error) {
+	var questions []models.Question
+	err := r.db.GetDb().WithContext(ctx).Preload("Survey").Preload("Choices").
+		Where("bank_question_id = ?", bankQuestionId).Order("survey_id, id").Find(&questions).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		r.logger.Error(logging.Database, logging.Select, "GetQuestionsByBankQuestionID error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return nil, err
+	}
+	return questions, nil
+}
+
+// GetScoredParticipations returns the committed participations of a quiz that have a score.
+func (r *ReportRepository) GetScoredParticipations(ctx context.Context, surveyId uint) ([]models.UserSurveyParticipation, error) {
+	var participations []models.UserSurveyParticipation
+	err := r.db.GetDb().WithContext(ctx).
+		Where("survey_id = ? AND committed_at IS NOT NULL AND score IS NOT NULL", surveyId).
+		Order("id").Find(&participations).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		r.logger.Error(logging.Database, logging.Select, "GetScoredParticipations error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return nil, err
+	}
+	return participations, nil
+}
+
+// GetQuestionTimes averages the time spent on each question of a survey that was timed.
+func (r *ReportRepository) GetQuestionTimes(ctx context.Context, surveyId uint) ([]dto.QuestionTime, error) {
+	var times []dto.QuestionTime
+	err := r.db.GetDb().WithContext(ctx).Table("question_timings").
+		Select("question_timings.question_id, COUNT(*) as participations, AVG(question_timings.milliseconds) / 1000.0 as average_seconds, SUM(CASE WHEN question_timings.timed_out THEN 1 ELSE 0 END) as timed_out").
+		Joins("inner join questions on questions.id = question_timings.question_id").
+		Where("questions.survey_id = ? AND questions.deleted_at IS NULL", surveyId).
+		Group("question_timings.question_id").
+		Order("question_timings.question_id").
+		Scan(&times).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		r.logger.Error(logging.Database, logging.Select, "GetQuestionTimes error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return nil, err
+	}
+	return times, nil
+}
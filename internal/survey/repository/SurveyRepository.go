@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -12,37 +13,55 @@ import (
 	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
 	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
 	userModels "github.com/G9QBootcamp/qoli-survey/internal/user/models"
+	"github.com/G9QBootcamp/qoli-survey/internal/util"
 	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
 	"gorm.io/gorm"
 )
 
 type ISurveyRepository interface {
 	CreateSurvey(ctx context.Context, survey *models.Survey) error
+	CloneSurvey(ctx context.Context, sourceId uint, clone *models.Survey) (map[uint]uint, error)
 	GetSurveyByID(ctx context.Context, surveyId uint) (*models.Survey, error)
 	CreateQuestion(ctx context.Context, question *models.Question) error
+	InsertQuestion(ctx context.Context, question *models.Question, position int) error
+	ReorderQuestions(ctx context.Context, surveyId uint, ids []uint) error
+	UnlinkQuestion(ctx context.Context, questionId uint) error
 	CreateChoice(ctx context.Context, choice *models.Choice) error
 	UpdateChoice(ctx context.Context, choice *models.Choice) error
 	GetChoiceByTextAndQuestion(ctx context.Context, text string, questionID uint) (*models.Choice, error)
 	GetUserParticipationList(ctx context.Context, userId uint, surveyId uint) ([]models.UserSurveyParticipation, error)
+	GetGuestParticipationList(ctx context.Context, guestId uint, surveyId uint) ([]models.UserSurveyParticipation, error)
 	CreateUserParticipation(ctx context.Context, participation *models.UserSurveyParticipation) (*models.UserSurveyParticipation, error)
 	UpdateUserParticipation(ctx context.Context, participation *models.UserSurveyParticipation) error
+	TruncateParticipationTimes(ctx context.Context, participationId uint) error
+	GetSuspendedParticipations(ctx context.Context) ([]models.UserSurveyParticipation, error)
+	EndSuspendedParticipation(ctx context.Context, participationId uint, disconnectedAt time.Time, at time.Time) (bool, error)
 	GetUserParticipation(ctx context.Context, participationId uint) (*models.UserSurveyParticipation, error)
 	GetLastUserParticipation(ctx context.Context, userId uint, surveyId uint) (*models.UserSurveyParticipation, error)
+	SaveParticipationProgress(ctx context.Context, participationId uint, progress *models.ParticipationProgress) error
+	SuspendParticipation(ctx context.Context, participationId uint, progress *models.ParticipationProgress, at time.Time) error
+	ClaimParticipation(ctx context.Context, participationId uint, disconnectedAt time.Time) (bool, error)
 	CreateVote(ctx context.Context, v *models.Vote) (*models.Vote, error)
+	CreateVotes(ctx context.Context, votes []models.Vote) error
 	UpdateVote(ctx context.Context, v *models.Vote) (*models.Vote, error)
-	GetUserSurveyVote(ctx context.Context, user_id uint, question_id uint) (*models.Vote, error)
+	GetRespondentVote(ctx context.Context, respondent models.Respondent, questionId uint) (*models.Vote, error)
+	GetRespondentVotes(ctx context.Context, surveyId uint, respondent models.Respondent) ([]models.Vote, error)
 	UpdateQuestion(c context.Context, m *models.Question) (*models.Question, error)
 	DeleteQuestion(c context.Context, id uint) error
 	DeleteSurvey(c context.Context, id uint) error
+	PurgeSurvey(c context.Context, id uint) error
 	GetQuestionByID(ctx context.Context, id uint) (*models.Question, error)
 	DeleteQuestionChoices(ctx context.Context, questionId uint) error
 	GetQuestions(ctx context.Context, req *dto.RepositoryRequest) ([]*models.Question, error)
-	GetSurveys(ctx context.Context, req *dto.RepositoryRequest) (questions []*models.Survey, err error)
+	GetSurveys(ctx context.Context, req *dto.RepositoryRequest, status models.SurveyStatus, now time.Time) (questions []*models.Survey, err error)
+	GetSurveysDueForStatusChange(ctx context.Context, now time.Time) ([]*models.Survey, error)
 	CheckVoteVisibility(surveyID, viewerID, respondentID uint) (bool, error)
 	GetVotes(surveyID, respondentID uint) ([]models.Vote, error)
 	GetVisibleVoteUsers(surveyID, viewerID uint) ([]map[string]interface{}, error)
 	//GetResponses(ctx context.Context, userID uint, surveyID uint, privacyLevel string) ([]models.Choice, error)
 	DeleteVote(c context.Context, id uint) error
+	ReplaceQuestionVotes(ctx context.Context, respondent models.Respondent, questionId uint, votes []models.Vote) error
+	SaveQuestionTiming(ctx context.Context, timing *models.QuestionTiming) error
 	GetVoteByID(ctx context.Context, id uint) (*models.Vote, error)
 	UpdateSurvey(ctx context.Context, survey *models.Survey) error
 	GetSurveyVotes(ctx context.Context, id uint) ([]*models.Vote, error)
@@ -53,6 +72,68 @@ type ISurveyRepository interface {
 	GetOptionByID(ctx context.Context, id uint) (*models.SurveyOption, error)
 	GetOptions(ctx context.Context, req *dto.RepositoryRequest) (options []*models.SurveyOption, err error)
 
+	CreateSurveyVersion(ctx context.Context, version *models.SurveyVersion) (*models.SurveyVersion, error)
+	GetSurveyVersions(ctx context.Context, surveyId uint) ([]*models.SurveyVersion, error)
+	GetSurveyVersion(ctx context.Context, surveyId uint, version int) (*models.SurveyVersion, error)
+	GetLatestSurveyVersion(ctx context.Context, surveyId uint) (*models.SurveyVersion, error)
+	SetSurveyCurrentVersion(ctx context.Context, surveyId uint, version int) error
+	DeleteChoice(ctx context.Context, id uint) error
+
+	GetBranchRules(ctx context.Context, surveyId uint) ([]*models.BranchRule, error)
+	ReplaceBranchRules(ctx context.Context, surveyId uint, rules []*models.BranchRule) error
+
+	CreateSection(ctx context.Context, section *models.Section) error
+	UpdateSection(ctx context.Context, section *models.Section) error
+	DeleteSection(ctx context.Context, id uint) error
+	GetSectionByID(ctx context.Context, id uint) (*models.Section, error)
+	GetSections(ctx context.Context, surveyId uint) ([]*models.Section, error)
+
+	GetParticipant(ctx context.Context, userId uint) (*userModels.User, error)
+	GetQuotas(ctx context.Context, surveyId uint) ([]*models.SurveyQuota, error)
+	GetQuotaByID(ctx context.Context, id uint) (*models.SurveyQuota, error)
+	CreateQuota(ctx context.Context, quota *models.SurveyQuota) error
+	UpdateQuota(ctx context.Context, quota *models.SurveyQuota) error
+	DeleteQuota(ctx context.Context, id uint) error
+	CreateQuotaParticipation(ctx context.Context, participation *models.UserSurveyParticipation, quotaIds []uint) (*models.SurveyQuota, error)
+	ReserveQuotas(ctx context.Context, participationId uint, quotaIds []uint) (*models.SurveyQuota, error)
+	ReleaseQuotas(ctx context.Context, participationId uint, quotaIds []uint) error
+	GetQuotaPlaces(ctx context.Context, participationId uint) ([]uint, error)
+	CompleteQuotas(ctx context.Context, participationId uint) ([]*models.SurveyQuota, error)
+
+	GetAudienceByID(ctx context.Context, id uint) (*models.Audience, error)
+	GetAudiences(ctx context.Context, ownerId uint) ([]*models.Audience, error)
+	CreateAudience(ctx context.Context, audience *models.Audience) error
+	UpdateAudience(ctx context.Context, audience *models.Audience) error
+	DeleteAudience(ctx context.Context, id uint) error
+	GetUserAudiences(ctx context.Context, userId uint, audienceIds []uint) ([]uint, error)
+	GetCommittedSurveys(ctx context.Context, userId uint, surveyIds []uint) ([]uint, error)
+	GetExistingUserIds(ctx context.Context, ids []uint) ([]uint, error)
+	CountEligibleUsers(ctx context.Context, condition string, args []interface{}) (int64, error)
+
+	GetInvitees(ctx context.Context, userIds []uint, emails []string) ([]*userModels.User, error)
+	GetInvitations(ctx context.Context, surveyId uint) ([]*models.Invitation, error)
+	GetInvitationByID(ctx context.Context, id uint) (*models.Invitation, error)
+	GetUserInvitation(ctx context.Context, surveyId uint, userId uint) (*models.Invitation, error)
+	CreateInvitation(ctx context.Context, invitation *models.Invitation) error
+	UpdateInvitation(ctx context.Context, invitation *models.Invitation) error
+	OpenInvitation(ctx context.Context, id uint, openedAt time.Time) (bool, error)
+	DeleteInvitation(ctx context.Context, id uint) error
+	AcceptInvitation(ctx context.Context, invitation *models.Invitation, userId uint, voteUntil time.Time) (bool, error)
+	TrackInvitation(ctx context.Context, surveyId uint, userId uint, status models.InvitationStatus) error
+
+	GetShareLink(ctx context.Context, surveyId uint) (*models.ShareLink, error)
+	GetShareLinkBySlug(ctx context.Context, slug string) (*models.ShareLink, error)
+	SaveShareLink(ctx context.Context, link *models.ShareLink) error
+	CreateGuestSession(ctx context.Context, guest *models.GuestSession) error
+	GetGuestSession(ctx context.Context, id uint) (*models.GuestSession, error)
+	TouchGuestSession(ctx context.Context, id uint) error
+
+	CreateBankQuestion(ctx context.Context, question *models.BankQuestion) error
+	UpdateBankQuestion(ctx context.Context, question *models.BankQuestion) error
+	DeleteBankQuestion(ctx context.Context, id uint) error
+	GetBankQuestionByID(ctx context.Context, id uint) (*models.BankQuestion, error)
+	GetBankQuestions(ctx context.Context, req *dto.BankQuestionsFilter) ([]*models.BankQuestion, error)
+
 	SaveFile(fileName string, fileData []byte) (string, error)
 }
 
@@ -100,11 +181,101 @@ func (r *SurveyRepository) CreateQuestion(ctx context.Context, question *models.
 	return err
 }
 
+// InsertQuestion creates a question with its choices at a 1 based position of its survey,
+// a position of 0 or after the last question appends it. The order of all questions is rewritten.
+func (r *SurveyRepository) InsertQuestion(ctx context.Context, question *models.Question, position int) error {
+	err := r.db.GetDb().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ids := []uint{}
+		if err := tx.Model(&models.Question{}).Where("survey_id = ?", question.SurveyID).Order("\"order\" asc, id asc").Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(question).Error; err != nil {
+			return err
+		}
+		ids, question.Order = util.InsertAt(ids, question.ID, position)
+		return updateQuestionOrder(tx, question.SurveyID, ids)
+	})
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Insert, "insert question error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+// ReorderQuestions sets the order of the questions of a survey to their position in ids.
+func (r *SurveyRepository) ReorderQuestions(ctx context.Context, surveyId uint, ids []uint) error {
+	err := r.db.GetDb().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return updateQuestionOrder(tx, surveyId, ids)
+	})
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Update, "reorder questions error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+func updateQuestionOrder(tx *gorm.DB, surveyId uint, ids []uint) error {
+	for i, id := range ids {
+		if err := tx.Model(&models.Question{}).Where("id = ? AND survey_id = ?", id, surveyId).Update("order", i+1).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnlinkQuestion removes the links of choices and questions to a deleted question.
+func (r *SurveyRepository) UnlinkQuestion(ctx context.Context, questionId uint) error {
+	err := r.db.GetDb().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Choice{}).Where("linked_question_id = ?", questionId).Update("linked_question_id", 0).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Question{}).Where("linked_question_id = ?", questionId).Update("linked_question_id", 0).Error
+	})
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Update, "unlink question error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
 func (r *SurveyRepository) GetQuestions(ctx context.Context, req *dto.RepositoryRequest) (questions []*models.Question, err error) {
 	return GetRecords[*models.Question](r.db.GetDb(), req)
 }
-func (r *SurveyRepository) GetSurveys(ctx context.Context, req *dto.RepositoryRequest) (questions []*models.Survey, err error) {
-	return GetRecords[*models.Survey](r.db.GetDb(), req)
+
+// GetSurveys returns the surveys req selects, a status keeps the surveys that have it at now even
+// when the scheduler did not store their transition yet.
+func (r *SurveyRepository) GetSurveys(ctx context.Context, req *dto.RepositoryRequest, status models.SurveyStatus, now time.Time) (questions []*models.Survey, err error) {
+	db := r.db.GetDb().WithContext(ctx)
+	if status != "" {
+		query, args := surveyStatusCondition(status, now)
+		db = db.Where(query, args...)
+	}
+	return GetRecords[*models.Survey](db, req)
+}
+
+// surveyStatusCondition selects the surveys whose current status at now is status, the stored
+// status of a survey lags behind its start and end times until the scheduler runs.
+func surveyStatusCondition(status models.SurveyStatus, now time.Time) (string, []interface{}) {
+	switch status {
+	case models.SurveyStatusScheduled:
+		return "status = ? AND start_time > ?", []interface{}{status, now}
+	case models.SurveyStatusOpen:
+		return "((status = ? AND end_time > ?) OR (status = ? AND start_time <= ? AND end_time > ?))",
+			[]interface{}{models.SurveyStatusOpen, now, models.SurveyStatusScheduled, now, now}
+	case models.SurveyStatusClosed:
+		return "(status = ? OR (status = ? AND end_time <= ?) OR (status = ? AND start_time <= ? AND end_time <= ?))",
+			[]interface{}{models.SurveyStatusClosed, models.SurveyStatusOpen, now, models.SurveyStatusScheduled, now, now}
+	}
+	return "status = ?", []interface{}{status}
+}
+
+func (r *SurveyRepository) GetSurveysDueForStatusChange(ctx context.Context, now time.Time) ([]*models.Survey, error) {
+	var surveys []*models.Survey
+	err := r.db.GetDb().WithContext(ctx).
+		Where("(status = ? AND start_time <= ?) OR (status IN ? AND end_time <= ?)",
+			models.SurveyStatusScheduled, now, []models.SurveyStatus{models.SurveyStatusScheduled, models.SurveyStatusOpen}, now).
+		Find(&surveys).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "get surveys due for status change error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return surveys, err
 }
 
 func (r *SurveyRepository) CreateChoice(ctx context.Context, choice *models.Choice) error {
@@ -147,6 +318,16 @@ func (r *SurveyRepository) CreateVote(ctx context.Context, v *models.Vote) (*mod
 	}
 	return v, err
 }
+
+// CreateVotes inserts votes in one statement, in the order they are given.
+func (r *SurveyRepository) CreateVotes(ctx context.Context, votes []models.Vote) error {
+	err := r.db.GetDb().WithContext(ctx).Create(&votes).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Insert, "create votes error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
 func (r *SurveyRepository) UpdateVote(ctx context.Context, v *models.Vote) (*models.Vote, error) {
 	err := r.db.GetDb().WithContext(ctx).Save(v).Error
 	if err != nil {
@@ -154,19 +335,37 @@ func (r *SurveyRepository) UpdateVote(ctx context.Context, v *models.Vote) (*mod
 	}
 	return v, err
 }
-func (r *SurveyRepository) GetUserSurveyVote(ctx context.Context, user_id uint, question_id uint) (*models.Vote, error) {
+
+// GetRespondentVote returns the vote of a user or a guest on a question, nil when there is none.
+func (r *SurveyRepository) GetRespondentVote(ctx context.Context, respondent models.Respondent, questionId uint) (*models.Vote, error) {
 	var vote models.Vote
-	err := r.db.GetDb().WithContext(ctx).Where("voter_id = ? AND question_id = ?", user_id, question_id).First(&vote).Error
+	condition, id := respondent.Condition("voter_id")
+	err := r.db.GetDb().WithContext(ctx).Where(condition+" AND question_id = ?", id, questionId).First(&vote).Error
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
-		r.logger.Error(logging.Database, logging.Select, "get vote by user id and question id error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		r.logger.Error(logging.Database, logging.Select, "get vote by respondent and question id error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
 	}
 	return &vote, err
 }
 
+// GetRespondentVotes returns the votes of a user or a guest on the questions of a survey.
+func (r *SurveyRepository) GetRespondentVotes(ctx context.Context, surveyId uint, respondent models.Respondent) ([]models.Vote, error) {
+	var votes []models.Vote
+	condition, id := respondent.Condition("voter_id")
+	err := r.db.GetDb().WithContext(ctx).
+		Joins("inner join questions on questions.id = votes.question_id").
+		Where("questions.survey_id = ? AND "+condition, surveyId, id).
+		Find(&votes).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "get respondent votes error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return nil, err
+	}
+	return votes, nil
+}
+
 func (r *SurveyRepository) GetUserParticipationList(ctx context.Context, userId uint, surveyId uint) ([]models.UserSurveyParticipation, error) {
 	var userParticipationList []models.UserSurveyParticipation
 	err := r.db.GetDb().WithContext(ctx).Where("user_id = ? AND survey_id = ?", userId, surveyId).Find(&userParticipationList).Error
@@ -180,6 +379,16 @@ func (r *SurveyRepository) GetUserParticipationList(ctx context.Context, userId
 	return userParticipationList, nil
 }
 
+func (r *SurveyRepository) GetGuestParticipationList(ctx context.Context, guestId uint, surveyId uint) ([]models.UserSurveyParticipation, error) {
+	var guestParticipationList []models.UserSurveyParticipation
+	err := r.db.GetDb().WithContext(ctx).Where("guest_id = ? AND survey_id = ?", guestId, surveyId).Find(&guestParticipationList).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "Get guest participation list  error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return nil, err
+	}
+	return guestParticipationList, nil
+}
+
 func (r *SurveyRepository) CreateUserParticipation(ctx context.Context, participation *models.UserSurveyParticipation) (*models.UserSurveyParticipation, error) {
 	err := r.db.GetDb().WithContext(ctx).Create(&participation).Error
 	if err != nil {
@@ -196,6 +405,25 @@ func (r *SurveyRepository) UpdateUserParticipation(ctx context.Context, particip
 	return err
 }
 
+// TruncateParticipationTimes keeps the times of a participation to the day it happened on and forgets
+// when it was disconnected and the answer time it had left. The columns are written directly so updated_at is truncated too.
+func (r *SurveyRepository) TruncateParticipationTimes(ctx context.Context, participationId uint) error {
+	err := r.db.GetDb().WithContext(ctx).Model(&models.UserSurveyParticipation{}).Where("id = ?", participationId).UpdateColumns(map[string]interface{}{
+		"created_at":      gorm.Expr("date_trunc('day', created_at)"),
+		"updated_at":      gorm.Expr("date_trunc('day', updated_at)"),
+		"start_at":        gorm.Expr("date_trunc('day', start_at)"),
+		"end_at":          gorm.Expr("date_trunc('day', end_at)"),
+		"committed_at":    gorm.Expr("date_trunc('day', committed_at)"),
+		"screened_out_at": gorm.Expr("date_trunc('day', screened_out_at)"),
+		"disconnected_at": nil,
+		"progress":        nil,
+	}).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Update, "truncate participation times error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
 func (r *SurveyRepository) GetUserParticipation(ctx context.Context, participationId uint) (*models.UserSurveyParticipation, error) {
 	var p models.UserSurveyParticipation
 
@@ -218,6 +446,67 @@ func (r *SurveyRepository) GetLastUserParticipation(ctx context.Context, userId
 	return userParticipation, nil
 }
 
+// liveParticipation is the condition of participations that were neither committed nor ended.
+const liveParticipation = "id = ? AND committed_at IS NULL AND end_at IS NULL"
+
+// SaveParticipationProgress stores where a live participation got to.
+func (r *SurveyRepository) SaveParticipationProgress(ctx context.Context, participationId uint, progress *models.ParticipationProgress) error {
+	err := r.db.GetDb().WithContext(ctx).Model(&models.UserSurveyParticipation{}).
+		Where(liveParticipation, participationId).
+		Update("progress", progress).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Update, "save participation progress error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+// SuspendParticipation stores the progress of a live participation whose participant lost the connection at.
+func (r *SurveyRepository) SuspendParticipation(ctx context.Context, participationId uint, progress *models.ParticipationProgress, at time.Time) error {
+	err := r.db.GetDb().WithContext(ctx).Model(&models.UserSurveyParticipation{}).
+		Where(liveParticipation, participationId).
+		Updates(map[string]interface{}{"progress": progress, "disconnected_at": at}).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Update, "suspend participation error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+// ClaimParticipation marks a suspended participation connected again. Only one connection can claim
+// a participation that was disconnected at disconnectedAt, the others get false.
+func (r *SurveyRepository) ClaimParticipation(ctx context.Context, participationId uint, disconnectedAt time.Time) (bool, error) {
+	result := r.db.GetDb().WithContext(ctx).Model(&models.UserSurveyParticipation{}).
+		Where(liveParticipation+" AND disconnected_at = ?", participationId, disconnectedAt).
+		Update("disconnected_at", nil)
+	if result.Error != nil {
+		r.logger.Error(logging.Database, logging.Update, "claim participation error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: result.Error.Error()})
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// GetSuspendedParticipations returns the live participations whose participant lost the connection.
+func (r *SurveyRepository) GetSuspendedParticipations(ctx context.Context) ([]models.UserSurveyParticipation, error) {
+	var participations []models.UserSurveyParticipation
+	err := r.db.GetDb().WithContext(ctx).Where("committed_at IS NULL AND end_at IS NULL AND disconnected_at IS NOT NULL").Find(&participations).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "get suspended participations error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return participations, err
+}
+
+// EndSuspendedParticipation ends a participation that is still suspended since disconnectedAt, it
+// reports false when the participation was resumed or ended meanwhile.
+func (r *SurveyRepository) EndSuspendedParticipation(ctx context.Context, participationId uint, disconnectedAt time.Time, at time.Time) (bool, error) {
+	result := r.db.GetDb().WithContext(ctx).Model(&models.UserSurveyParticipation{}).
+		Where(liveParticipation+" AND disconnected_at = ?", participationId, disconnectedAt).
+		Update("end_at", at)
+	if result.Error != nil {
+		r.logger.Error(logging.Database, logging.Update, "end suspended participation error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: result.Error.Error()})
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
 func (q *SurveyRepository) UpdateQuestion(c context.Context, m *models.Question) (*models.Question, error) {
 	err := q.db.GetDb().WithContext(c).Save(m).Error
 	if err != nil {
@@ -234,11 +523,59 @@ func (q *SurveyRepository) DeleteSurvey(c context.Context, id uint) error {
 	return q.db.GetDb().WithContext(c).Where("ID = ?", id).Delete(&models.Survey{}).Error
 
 }
+
+// PurgeSurvey deletes a survey for good, its sections, questions, choices, options and rules go with it.
+func (q *SurveyRepository) PurgeSurvey(c context.Context, id uint) error {
+	return q.db.GetDb().WithContext(c).Unscoped().Where("ID = ?", id).Delete(&models.Survey{}).Error
+}
+
 func (q *SurveyRepository) DeleteVote(c context.Context, id uint) error {
 	return q.db.GetDb().WithContext(c).Where("ID = ?", id).Delete(&models.Vote{}).Error
 
 }
 
+func (r *SurveyRepository) ReplaceQuestionVotes(ctx context.Context, respondent models.Respondent, questionId uint, votes []models.Vote) error {
+	condition, id := respondent.Condition("voter_id")
+	err := r.db.GetDb().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		deletion := tx
+		if respondent.IsAnonymous() {
+			// a soft deleted anonymous vote would keep the time it was replaced at
+			deletion = tx.Unscoped()
+		}
+		if err := deletion.Where(condition+" AND question_id = ?", id, questionId).Delete(&models.Vote{}).Error; err != nil {
+			return err
+		}
+		if len(votes) == 0 {
+			return nil
+		}
+		return tx.Create(&votes).Error
+	})
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Insert, "replace question votes error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+// SaveQuestionTiming stores the time spent on a question, replacing the time stored before
+// for the same participation and question.
+func (r *SurveyRepository) SaveQuestionTiming(ctx context.Context, timing *models.QuestionTiming) error {
+	err := r.db.GetDb().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing models.QuestionTiming
+		err := tx.Where("participation_id = ? AND question_id = ?", timing.ParticipationID, timing.QuestionID).First(&existing).Error
+		if err == nil {
+			timing.ID = existing.ID
+			timing.CreatedAt = existing.CreatedAt
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		return tx.Save(timing).Error
+	})
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Insert, "save question timing error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
 func (r *SurveyRepository) GetQuestionByID(ctx context.Context, id uint) (*models.Question, error) {
 	var question models.Question
 
@@ -253,6 +590,802 @@ func (r *SurveyRepository) DeleteQuestionChoices(c context.Context, questionId u
 	return r.db.GetDb().WithContext(c).Where("question_id = ?", questionId).Delete(&models.Choice{}).Error
 }
 
+func (r *SurveyRepository) DeleteChoice(ctx context.Context, id uint) error {
+	return r.db.GetDb().WithContext(ctx).Where("ID = ?", id).Delete(&models.Choice{}).Error
+}
+
+func (r *SurveyRepository) CreateSurveyVersion(ctx context.Context, version *models.SurveyVersion) (*models.SurveyVersion, error) {
+	err := r.db.GetDb().WithContext(ctx).Create(&version).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Insert, "create survey version error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return version, err
+}
+
+func (r *SurveyRepository) GetSurveyVersions(ctx context.Context, surveyId uint) ([]*models.SurveyVersion, error) {
+	var versions []*models.SurveyVersion
+	err := r.db.GetDb().WithContext(ctx).Where("survey_id = ?", surveyId).Order("version asc").Find(&versions).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "get survey versions error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return versions, err
+}
+
+func (r *SurveyRepository) GetBranchRules(ctx context.Context, surveyId uint) ([]*models.BranchRule, error) {
+	var rules []*models.BranchRule
+	err := r.db.GetDb().WithContext(ctx).Where("survey_id = ?", surveyId).Order("question_id asc, priority asc, id asc").Find(&rules).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "get branch rules error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return rules, err
+}
+
+func (r *SurveyRepository) ReplaceBranchRules(ctx context.Context, surveyId uint, rules []*models.BranchRule) error {
+	err := r.db.GetDb().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("survey_id = ?", surveyId).Delete(&models.BranchRule{}).Error; err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		return tx.Create(&rules).Error
+	})
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Insert, "replace branch rules error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+func (r *SurveyRepository) CreateSection(ctx context.Context, section *models.Section) error {
+	err := r.db.GetDb().WithContext(ctx).Create(section).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Insert, "create section error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+func (r *SurveyRepository) UpdateSection(ctx context.Context, section *models.Section) error {
+	err := r.db.GetDb().WithContext(ctx).Save(section).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Update, "update section error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+// DeleteSection removes a section, its questions stay in the survey without a section.
+func (r *SurveyRepository) DeleteSection(ctx context.Context, id uint) error {
+	err := r.db.GetDb().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Question{}).Where("section_id = ?", id).Update("section_id", 0).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Section{}, id).Error
+	})
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Delete, "delete section error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+func (r *SurveyRepository) GetSectionByID(ctx context.Context, id uint) (*models.Section, error) {
+	var section models.Section
+	err := r.db.GetDb().WithContext(ctx).First(&section, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &section, err
+}
+
+func (r *SurveyRepository) GetSections(ctx context.Context, surveyId uint) ([]*models.Section, error) {
+	var sections []*models.Section
+	err := r.db.GetDb().WithContext(ctx).Where("survey_id = ?", surveyId).Order("\"order\" asc, id asc").Find(&sections).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "get sections error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return sections, err
+}
+
+func (r *SurveyRepository) GetParticipant(ctx context.Context, userId uint) (*userModels.User, error) {
+	var user userModels.User
+	err := r.db.GetDb().WithContext(ctx).First(&user, userId).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &user, err
+}
+
+func (r *SurveyRepository) GetQuotas(ctx context.Context, surveyId uint) ([]*models.SurveyQuota, error) {
+	var quotas []*models.SurveyQuota
+	err := r.db.GetDb().WithContext(ctx).Where("survey_id = ?", surveyId).Order("id asc").Find(&quotas).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "get quotas error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return quotas, err
+}
+
+func (r *SurveyRepository) GetQuotaByID(ctx context.Context, id uint) (*models.SurveyQuota, error) {
+	var quota models.SurveyQuota
+	err := r.db.GetDb().WithContext(ctx).First(&quota, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &quota, err
+}
+
+func (r *SurveyRepository) CreateQuota(ctx context.Context, quota *models.SurveyQuota) error {
+	err := r.db.GetDb().WithContext(ctx).Create(quota).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Insert, "create quota error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+// UpdateQuota stores the definition of a quota, its counters are only changed by the participations.
+func (r *SurveyRepository) UpdateQuota(ctx context.Context, quota *models.SurveyQuota) error {
+	err := r.db.GetDb().WithContext(ctx).Model(quota).
+		Select("name", "attribute", "values", "min_age", "max_age", "question_id", "participation_limit").
+		Updates(quota).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Update, "update quota error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+func (r *SurveyRepository) DeleteQuota(ctx context.Context, id uint) error {
+	err := r.db.GetDb().WithContext(ctx).Delete(&models.SurveyQuota{}, id).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Delete, "delete quota error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+// errQuotaFull rolls back a reservation that found a full quota.
+var errQuotaFull = errors.New("quota is full")
+
+// CreateQuotaParticipation creates a participation holding a place in every quota of quotaIds. When one of
+// them is full nothing is stored and the full quota is returned.
+func (r *SurveyRepository) CreateQuotaParticipation(ctx context.Context, participation *models.UserSurveyParticipation, quotaIds []uint) (*models.SurveyQuota, error) {
+	var full *models.SurveyQuota
+	err := r.db.GetDb().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(participation).Error; err != nil {
+			return err
+		}
+		var err error
+		full, err = reserveQuotas(tx, participation.ID, quotaIds)
+		return err
+	})
+	if errors.Is(err, errQuotaFull) {
+		return full, nil
+	}
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Insert, "create quota participation error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return nil, err
+}
+
+// ReserveQuotas takes a place for a participation in every quota of quotaIds it does not hold one in yet.
+// When one of them is full no place is taken and the full quota is returned.
+func (r *SurveyRepository) ReserveQuotas(ctx context.Context, participationId uint, quotaIds []uint) (*models.SurveyQuota, error) {
+	var full *models.SurveyQuota
+	err := r.db.GetDb().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		full, err = reserveQuotas(tx, participationId, quotaIds)
+		return err
+	})
+	if errors.Is(err, errQuotaFull) {
+		return full, nil
+	}
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Update, "reserve quotas error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return nil, err
+}
+
+// reserveQuotas counts a place up only while the quota is not full, the conditional update keeps
+// concurrent participations from taking more places than the limit.
+func reserveQuotas(tx *gorm.DB, participationId uint, quotaIds []uint) (*models.SurveyQuota, error) {
+	for _, id := range quotaIds {
+		var held int64
+		if err := tx.Model(&models.QuotaPlace{}).Where("participation_id = ? AND quota_id = ?", participationId, id).Count(&held).Error; err != nil {
+			return nil, err
+		}
+		if held > 0 {
+			continue
+		}
+		result := tx.Model(&models.SurveyQuota{}).Where("id = ? AND filled < participation_limit", id).UpdateColumn("filled", gorm.Expr("filled + 1"))
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			var quota models.SurveyQuota
+			err := tx.First(&quota, id).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// the quota was deleted meanwhile
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			return &quota, errQuotaFull
+		}
+		if err := tx.Create(&models.QuotaPlace{ParticipationID: participationId, QuotaID: id}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// ReleaseQuotas gives back the places a participation holds in the quotas of quotaIds, nil releases all of them.
+func (r *SurveyRepository) ReleaseQuotas(ctx context.Context, participationId uint, quotaIds []uint) error {
+	err := r.db.GetDb().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("participation_id = ?", participationId)
+		if quotaIds != nil {
+			query = query.Where("quota_id IN ?", quotaIds)
+		}
+		var places []*models.QuotaPlace
+		if err := query.Find(&places).Error; err != nil {
+			return err
+		}
+		for _, place := range places {
+			result := tx.Delete(&models.QuotaPlace{}, place.ID)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			err := tx.Model(&models.SurveyQuota{}).Where("id = ? AND filled > 0", place.QuotaID).UpdateColumn("filled", gorm.Expr("filled - 1")).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Update, "release quotas error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+// GetQuotaPlaces returns the ids of the quotas a participation holds a place in.
+func (r *SurveyRepository) GetQuotaPlaces(ctx context.Context, participationId uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.GetDb().WithContext(ctx).Model(&models.QuotaPlace{}).Where("participation_id = ?", participationId).Pluck("quota_id", &ids).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "get quota places error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return ids, err
+}
+
+// CompleteQuotas counts a committed participation in the quotas it holds a place in and returns
+// the quotas it completed.
+func (r *SurveyRepository) CompleteQuotas(ctx context.Context, participationId uint) ([]*models.SurveyQuota, error) {
+	completed := []*models.SurveyQuota{}
+	err := r.db.GetDb().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var places []*models.QuotaPlace
+		if err := tx.Where("participation_id = ?", participationId).Find(&places).Error; err != nil {
+			return err
+		}
+		for _, place := range places {
+			err := tx.Model(&models.SurveyQuota{}).Where("id = ?", place.QuotaID).UpdateColumn("completed", gorm.Expr("completed + 1")).Error
+			if err != nil {
+				return err
+			}
+			// the updated row stays locked until the commit, so only one participation sees the quota complete
+			var quota models.SurveyQuota
+			if err := tx.First(&quota, place.QuotaID).Error; err != nil {
+				return err
+			}
+			if quota.Completed == quota.ParticipationLimit {
+				completed = append(completed, &quota)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Update, "complete quotas error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return completed, err
+}
+
+func (r *SurveyRepository) GetAudienceByID(ctx context.Context, id uint) (*models.Audience, error) {
+	var audience models.Audience
+	err := r.db.GetDb().WithContext(ctx).Preload("Members").First(&audience, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &audience, err
+}
+
+func (r *SurveyRepository) GetAudiences(ctx context.Context, ownerId uint) ([]*models.Audience, error) {
+	var audiences []*models.Audience
+	err := r.db.GetDb().WithContext(ctx).Preload("Members").Where("owner_id = ?", ownerId).Order("id asc").Find(&audiences).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "get audiences error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return audiences, err
+}
+
+func (r *SurveyRepository) CreateAudience(ctx context.Context, audience *models.Audience) error {
+	err := r.db.GetDb().WithContext(ctx).Create(audience).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Insert, "create audience error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+// UpdateAudience stores the name of an audience and replaces its members.
+func (r *SurveyRepository) UpdateAudience(ctx context.Context, audience *models.Audience) error {
+	err := r.db.GetDb().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("audience_id = ?", audience.ID).Delete(&models.AudienceMember{}).Error; err != nil {
+			return err
+		}
+		for i := range audience.Members {
+			audience.Members[i].ID = 0
+			audience.Members[i].AudienceID = audience.ID
+		}
+		if len(audience.Members) > 0 {
+			if err := tx.Create(&audience.Members).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(audience).Update("name", audience.Name).Error
+	})
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Update, "update audience error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+func (r *SurveyRepository) DeleteAudience(ctx context.Context, id uint) error {
+	err := r.db.GetDb().WithContext(ctx).Delete(&models.Audience{}, id).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Delete, "delete audience error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+// GetUserAudiences returns the audiences of audienceIds a user is a member of.
+func (r *SurveyRepository) GetUserAudiences(ctx context.Context, userId uint, audienceIds []uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.GetDb().WithContext(ctx).Model(&models.AudienceMember{}).
+		Where("user_id = ? AND audience_id IN ?", userId, audienceIds).
+		Pluck("audience_id", &ids).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "get user audiences error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return ids, err
+}
+
+// GetCommittedSurveys returns the surveys of surveyIds a user committed a participation in.
+func (r *SurveyRepository) GetCommittedSurveys(ctx context.Context, userId uint, surveyIds []uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.GetDb().WithContext(ctx).Model(&models.UserSurveyParticipation{}).
+		Where("user_id = ? AND survey_id IN ? AND committed_at IS NOT NULL", userId, surveyIds).
+		Distinct().Pluck("survey_id", &ids).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "get committed surveys error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return ids, err
+}
+
+// GetExistingUserIds returns the ids of ids that belong to users.
+func (r *SurveyRepository) GetExistingUserIds(ctx context.Context, ids []uint) ([]uint, error) {
+	var existing []uint
+	err := r.db.GetDb().WithContext(ctx).Model(&userModels.User{}).Where("id IN ?", ids).Pluck("id", &existing).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "get existing user ids error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return existing, err
+}
+
+// CountEligibleUsers counts the users that meet condition, an empty condition counts every user.
+func (r *SurveyRepository) CountEligibleUsers(ctx context.Context, condition string, args []interface{}) (int64, error) {
+	var count int64
+	query := r.db.GetDb().WithContext(ctx).Model(&userModels.User{})
+	if condition != "" {
+		query = query.Where(condition, args...)
+	}
+	err := query.Count(&count).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "count eligible users error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return count, err
+}
+
+// GetInvitees returns the users of userIds and the users whose email is one of emails.
+func (r *SurveyRepository) GetInvitees(ctx context.Context, userIds []uint, emails []string) ([]*userModels.User, error) {
+	var users []*userModels.User
+	err := r.db.GetDb().WithContext(ctx).Where("id IN ? OR LOWER(email) IN ?", userIds, emails).Find(&users).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "get invitees error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return users, err
+}
+
+func (r *SurveyRepository) GetInvitations(ctx context.Context, surveyId uint) ([]*models.Invitation, error) {
+	var invitations []*models.Invitation
+	err := r.db.GetDb().WithContext(ctx).Where("survey_id = ?", surveyId).Order("id asc").Find(&invitations).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "get invitations error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return invitations, err
+}
+
+func (r *SurveyRepository) GetInvitationByID(ctx context.Context, id uint) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := r.db.GetDb().WithContext(ctx).First(&invitation, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &invitation, err
+}
+
+// GetUserInvitation returns the invitation to a survey a user accepted, nil when there is none.
+func (r *SurveyRepository) GetUserInvitation(ctx context.Context, surveyId uint, userId uint) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := r.db.GetDb().WithContext(ctx).Where("survey_id = ? AND user_id = ? AND accepted_at IS NOT NULL", surveyId, userId).First(&invitation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &invitation, err
+}
+
+func (r *SurveyRepository) CreateInvitation(ctx context.Context, invitation *models.Invitation) error {
+	err := r.db.GetDb().WithContext(ctx).Create(invitation).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Insert, "create invitation error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+func (r *SurveyRepository) UpdateInvitation(ctx context.Context, invitation *models.Invitation) error {
+	err := r.db.GetDb().WithContext(ctx).Save(invitation).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Update, "update invitation error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+// OpenInvitation marks an invitation opened at openedAt, an invitation that got further keeps its
+// status. It returns false when the invitation was opened before.
+func (r *SurveyRepository) OpenInvitation(ctx context.Context, id uint, openedAt time.Time) (bool, error) {
+	// only the open columns are written, so an invitation accepted meanwhile stays accepted
+	result := r.db.GetDb().WithContext(ctx).Model(&models.Invitation{}).
+		Where("id = ? AND opened_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"opened_at": openedAt,
+			"status": gorm.Expr("CASE WHEN status IN ? THEN ? ELSE status END",
+				[]models.InvitationStatus{models.InvitationPending, models.InvitationSent, models.InvitationBounced}, models.InvitationOpened),
+		})
+	if result.Error != nil {
+		r.logger.Error(logging.Database, logging.Update, "open invitation error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: result.Error.Error()})
+	}
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *SurveyRepository) DeleteInvitation(ctx context.Context, id uint) error {
+	err := r.db.GetDb().WithContext(ctx).Delete(&models.Invitation{}, id).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Delete, "delete invitation error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+// AcceptInvitation spends the token of an invitation and grants the user the vote permission on
+// the survey until voteUntil, users that already have it keep their role. It returns false when
+// the token was spent before.
+func (r *SurveyRepository) AcceptInvitation(ctx context.Context, invitation *models.Invitation, userId uint, voteUntil time.Time) (bool, error) {
+	accepted := false
+	now := time.Now()
+	err := r.db.GetDb().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// the nonce check makes a token spent by a concurrent request fail here
+		result := tx.Model(&models.Invitation{}).
+			Where("id = ? AND nonce = ? AND accepted_at IS NULL", invitation.ID, invitation.Nonce).
+			Updates(map[string]interface{}{"user_id": userId, "accepted_at": now, "status": models.InvitationAccepted})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		accepted = true
+
+		var granted int64
+		err := tx.Model(&userModels.UserSurveyRole{}).
+			Joins("JOIN role_permissions ON role_permissions.role_id = user_survey_roles.role_id").
+			Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+			Where("user_survey_roles.user_id = ? AND user_survey_roles.survey_id = ? AND user_survey_roles.expires_at > ? AND permissions.action = ?", userId, invitation.SurveyID, now, "vote").
+			Count(&granted).Error
+		if err != nil || granted > 0 {
+			return err
+		}
+
+		var permission userModels.Permission
+		if err := tx.Where("action = ?", "vote").First(&permission).Error; err != nil {
+			return err
+		}
+		role := userModels.Role{Name: "invitee", Permissions: []userModels.Permission{permission}}
+		if err := tx.Create(&role).Error; err != nil {
+			return err
+		}
+		return tx.Create(&userModels.UserSurveyRole{UserID: userId, SurveyID: invitation.SurveyID, RoleID: role.ID, ExpiresAt: voteUntil}).Error
+	})
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Update, "accept invitation error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return false, err
+	}
+	if accepted {
+		invitation.UserID = &userId
+		invitation.AcceptedAt = &now
+		invitation.Status = models.InvitationAccepted
+	}
+	return accepted, nil
+}
+
+// TrackInvitation moves the invitation a user accepted to the started or completed status.
+func (r *SurveyRepository) TrackInvitation(ctx context.Context, surveyId uint, userId uint, status models.InvitationStatus) error {
+	column := "started_at"
+	if status == models.InvitationCompleted {
+		column = "completed_at"
+	}
+	err := r.db.GetDb().WithContext(ctx).Model(&models.Invitation{}).
+		Where("survey_id = ? AND user_id = ? AND accepted_at IS NOT NULL AND status <> ?", surveyId, userId, models.InvitationCompleted).
+		Updates(map[string]interface{}{"status": status, column: time.Now()}).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Update, "track invitation error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+func (r *SurveyRepository) GetShareLink(ctx context.Context, surveyId uint) (*models.ShareLink, error) {
+	var link models.ShareLink
+	err := r.db.GetDb().WithContext(ctx).Where("survey_id = ?", surveyId).First(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &link, err
+}
+
+func (r *SurveyRepository) GetShareLinkBySlug(ctx context.Context, slug string) (*models.ShareLink, error) {
+	var link models.ShareLink
+	err := r.db.GetDb().WithContext(ctx).Where("slug = ?", slug).First(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &link, err
+}
+
+func (r *SurveyRepository) SaveShareLink(ctx context.Context, link *models.ShareLink) error {
+	err := r.db.GetDb().WithContext(ctx).Save(link).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Update, "save share link error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+func (r *SurveyRepository) CreateGuestSession(ctx context.Context, guest *models.GuestSession) error {
+	err := r.db.GetDb().WithContext(ctx).Create(guest).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Insert, "create guest session error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+func (r *SurveyRepository) GetGuestSession(ctx context.Context, id uint) (*models.GuestSession, error) {
+	var guest models.GuestSession
+	err := r.db.GetDb().WithContext(ctx).First(&guest, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &guest, err
+}
+
+// TouchGuestSession records that a guest session was used now.
+func (r *SurveyRepository) TouchGuestSession(ctx context.Context, id uint) error {
+	err := r.db.GetDb().WithContext(ctx).Model(&models.GuestSession{}).Where("id = ?", id).Update("last_seen_at", time.Now()).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Update, "touch guest session error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+// CloneSurvey stores clone with a copy of the sections, questions, choices, options and branch rules
+// of the source survey, every reference between them is remapped to the new ids. It returns the ids
+// of the copied questions by the ids of their source questions, the answers piped into their texts
+// and choices still refer to the source questions.
+func (r *SurveyRepository) CloneSurvey(ctx context.Context, sourceId uint, clone *models.Survey) (map[uint]uint, error) {
+	questionIds := map[uint]uint{}
+	err := r.db.GetDb().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(clone).Error; err != nil {
+			return err
+		}
+
+		var sections []models.Section
+		if err := tx.Where("survey_id = ?", sourceId).Find(&sections).Error; err != nil {
+			return err
+		}
+		sectionIds := map[uint]uint{}
+		for _, section := range sections {
+			oldId := section.ID
+			section.ID = 0
+			section.SurveyID = clone.ID
+			if err := tx.Create(&section).Error; err != nil {
+				return err
+			}
+			sectionIds[oldId] = section.ID
+		}
+
+		var questions []models.Question
+		if err := tx.Preload("Choices").Where("survey_id = ?", sourceId).Order("id").Find(&questions).Error; err != nil {
+			return err
+		}
+		copies := []*models.Question{}
+		for _, question := range questions {
+			oldId := question.ID
+			question.ID = 0
+			question.SurveyID = clone.ID
+			question.SectionID = sectionIds[question.SectionID]
+			for i := range question.Choices {
+				question.Choices[i].ID = 0
+				question.Choices[i].QuestionID = 0
+			}
+			cloned := question
+			if err := tx.Create(&cloned).Error; err != nil {
+				return err
+			}
+			questionIds[oldId] = cloned.ID
+			copies = append(copies, &cloned)
+		}
+		for _, question := range copies {
+			if question.LinkedQuestionID != 0 {
+				if err := tx.Model(question).Update("linked_question_id", questionIds[question.LinkedQuestionID]).Error; err != nil {
+					return err
+				}
+			}
+			for _, choice := range question.Choices {
+				if choice.LinkedQuestionID != 0 {
+					if err := tx.Model(&choice).Update("linked_question_id", questionIds[choice.LinkedQuestionID]).Error; err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		var options []models.SurveyOption
+		if err := tx.Where("survey_id = ?", sourceId).Find(&options).Error; err != nil {
+			return err
+		}
+		for _, option := range options {
+			cloned := models.SurveyOption{UserId: clone.OwnerID, SurveyId: clone.ID, Name: option.Name, Value: option.Value}
+			if err := tx.Create(&cloned).Error; err != nil {
+				return err
+			}
+		}
+
+		var rules []models.BranchRule
+		if err := tx.Where("survey_id = ?", sourceId).Find(&rules).Error; err != nil {
+			return err
+		}
+		for _, rule := range rules {
+			cloned := models.BranchRule{
+				SurveyID:         clone.ID,
+				QuestionID:       questionIds[rule.QuestionID],
+				Priority:         rule.Priority,
+				Condition:        rule.Condition.RemapQuestions(questionIds),
+				TargetQuestionID: questionIds[rule.TargetQuestionID],
+				EndSurvey:        rule.EndSurvey,
+			}
+			if err := tx.Create(&cloned).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Insert, "clone survey error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return questionIds, err
+}
+
+func (r *SurveyRepository) CreateBankQuestion(ctx context.Context, question *models.BankQuestion) error {
+	err := r.db.GetDb().WithContext(ctx).Create(question).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Insert, "create bank question error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+// UpdateBankQuestion saves a bank question and replaces its choices.
+func (r *SurveyRepository) UpdateBankQuestion(ctx context.Context, question *models.BankQuestion) error {
+	err := r.db.GetDb().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("bank_question_id = ?", question.ID).Delete(&models.BankChoice{}).Error; err != nil {
+			return err
+		}
+		for i := range question.Choices {
+			question.Choices[i].ID = 0
+		}
+		return tx.Save(question).Error
+	})
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Update, "update bank question error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+func (r *SurveyRepository) DeleteBankQuestion(ctx context.Context, id uint) error {
+	err := r.db.GetDb().WithContext(ctx).Delete(&models.BankQuestion{}, id).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Delete, "delete bank question error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
+func (r *SurveyRepository) GetBankQuestionByID(ctx context.Context, id uint) (*models.BankQuestion, error) {
+	var question models.BankQuestion
+	err := r.db.GetDb().WithContext(ctx).Preload("Choices").First(&question, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "get bank question error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return nil, err
+	}
+	return &question, nil
+}
+
+// GetBankQuestions returns the bank questions the user can see, their own and shared ones.
+func (r *SurveyRepository) GetBankQuestions(ctx context.Context, req *dto.BankQuestionsFilter) ([]*models.BankQuestion, error) {
+	query := r.db.GetDb().WithContext(ctx).Preload("Choices")
+	switch {
+	case req.OnlyOwn:
+		query = query.Where("owner_id = ?", req.UserID)
+	case req.OnlyShared:
+		query = query.Where("shared = ? AND owner_id <> ?", true, req.UserID)
+	default:
+		query = query.Where("owner_id = ? OR shared = ?", req.UserID, true)
+	}
+	if req.Search != "" {
+		query = query.Where("text ILIKE ?", "%"+req.Search+"%")
+	}
+	if req.Tag != "" {
+		tag, _ := json.Marshal([]string{req.Tag})
+		query = query.Where("tags @> ?::jsonb", string(tag))
+	}
+
+	var questions []*models.BankQuestion
+	err := query.Order("created_at desc").Limit(int(req.Limit)).Offset(int(req.Offset)).Find(&questions).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "get bank questions error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return questions, err
+}
+
+func (r *SurveyRepository) GetSurveyVersion(ctx context.Context, surveyId uint, version int) (*models.SurveyVersion, error) {
+	var v models.SurveyVersion
+	err := r.db.GetDb().WithContext(ctx).Where("survey_id = ? AND version = ?", surveyId, version).First(&v).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &v, err
+}
+
+func (r *SurveyRepository) GetLatestSurveyVersion(ctx context.Context, surveyId uint) (*models.SurveyVersion, error) {
+	var v models.SurveyVersion
+	err := r.db.GetDb().WithContext(ctx).Where("survey_id = ?", surveyId).Order("version desc").First(&v).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &v, err
+}
+
+func (r *SurveyRepository) SetSurveyCurrentVersion(ctx context.Context, surveyId uint, version int) error {
+	err := r.db.GetDb().WithContext(ctx).Model(&models.Survey{}).Where("id = ?", surveyId).Update("current_version", version).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Update, "set survey current version error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return err
+}
+
 func (r *SurveyRepository) CheckVoteVisibility(surveyID, viewerID, respondentID uint) (bool, error) {
 	var visibility userModels.VoteVisibility
 	err := r.db.GetDb().Where("survey_id = ? AND viewer_id = ? AND respondent_id = ?", surveyID, viewerID, respondentID).
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"golang.org/x/net/context"
+)
+
+// DefaultMinGroupSize is the smallest group of respondents reports break anonymous surveys down to.
+const DefaultMinGroupSize = 5
+
+// ValidateAnonymity checks the anonymity settings of a survey. Anonymous surveys can not be
+// quizzes, a score stored with the participation would tell what the participant answered.
+func ValidateAnonymity(anonymity *dto.AnonymitySettings, quiz *dto.QuizSettings) error {
+	if anonymity == nil {
+		return nil
+	}
+	if anonymity.MinGroupSize < 0 {
+		return fmt.Errorf("%w: the minimum group size can not be negative", ErrInvalidAnonymity)
+	}
+	if quiz != nil {
+		return fmt.Errorf("%w: anonymous surveys can not be quizzes", ErrInvalidAnonymity)
+	}
+	return nil
+}
+
+// MinGroupSize returns the smallest group of respondents a report of a survey may show, 0 when
+// the survey is not anonymous and every group is shown.
+func MinGroupSize(anonymity *dto.AnonymitySettings) int64 {
+	if anonymity == nil {
+		return 0
+	}
+	if anonymity.MinGroupSize == 0 {
+		return DefaultMinGroupSize
+	}
+	return int64(anonymity.MinGroupSize)
+}
+
+// SuppressQuestionReports hides the choice breakdowns of questions answered by fewer respondents
+// than minGroupSize, so nobody can be picked out of them.
+func SuppressQuestionReports(reports []dto.QuestionReport, minGroupSize int64) []dto.QuestionReport {
+	for i := range reports {
+		if reports[i].Respondents >= minGroupSize {
+			continue
+		}
+		reports[i].Selections = 0
+		reports[i].Unmapped = 0
+		reports[i].ChoiceReport = []dto.ChoiceReport{}
+		reports[i].Suppressed = true
+	}
+	return reports
+}
+
+// SuppressAggregates hides the aggregates of questions answered by fewer respondents than minGroupSize.
+func SuppressAggregates(aggregates []dto.QuestionAggregate, minGroupSize int64) []dto.QuestionAggregate {
+	for i, aggregate := range aggregates {
+		if int64(aggregate.Count) >= minGroupSize {
+			continue
+		}
+		aggregates[i] = dto.QuestionAggregate{QuestionID: aggregate.QuestionID, Type: aggregate.Type, Count: aggregate.Count, Suppressed: true}
+	}
+	return aggregates
+}
+
+// anonymousVotes prepares the votes of an anonymous response to be stored, their times are
+// kept to the day so they can not be matched with the times of the participation.
+func anonymousVotes(votes []models.Vote) []models.Vote {
+	now := time.Now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	for i := range votes {
+		votes[i].VoterID = nil
+		votes[i].GuestID = nil
+		votes[i].CreatedAt = day
+		votes[i].UpdatedAt = day
+	}
+	return votes
+}
+
+// concealParticipation keeps the times of an ended participation of an anonymous survey to the day,
+// exact times could be matched with when its answers were stored.
+func (s *SurveyService) concealParticipation(c context.Context, survey *models.Survey, participationId uint) error {
+	if survey == nil || survey.Anonymity == nil {
+		return nil
+	}
+	return s.repo.TruncateParticipationTimes(c, participationId)
+}
+
+// NewResponseID returns the id the answers of one anonymous response are stored under, it is
+// kept nowhere else.
+func NewResponseID() (string, error) {
+	return newTokenNonce()
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+)
+
+// AnswerTimer keeps the time a participant spends on the questions of a live session. The questions
+// shown together share the time they are shown, so a page splits it evenly between its questions.
+// Time spent on a question is kept when the participant goes back to it.
+type AnswerTimer struct {
+	now   func() time.Time
+	spent map[uint]time.Duration
+	shown []*dto.Question
+	since time.Time
+}
+
+func NewAnswerTimer(now func() time.Time) *AnswerTimer {
+	if now == nil {
+		now = time.Now
+	}
+	return &AnswerTimer{now: now, spent: map[uint]time.Duration{}}
+}
+
+// Show starts timing the questions shown together, the questions shown before stop being timed.
+func (t *AnswerTimer) Show(questions ...*dto.Question) {
+	t.pause()
+	t.shown = questions
+	t.since = t.now()
+}
+
+func (t *AnswerTimer) pause() {
+	if len(t.shown) == 0 {
+		return
+	}
+	share := t.now().Sub(t.since) / time.Duration(len(t.shown))
+	for _, q := range t.shown {
+		t.spent[q.ID] += share
+	}
+	t.shown = nil
+}
+
+// Limit returns the time the shown questions may take, the sum of their time limits.
+// Zero means they have no limit.
+func (t *AnswerTimer) Limit() time.Duration {
+	limit := time.Duration(0)
+	for _, q := range t.shown {
+		limit += time.Duration(q.Config.TimeLimit) * time.Second
+	}
+	return limit
+}
+
+// Remaining returns the time left for the shown questions, ok is false when they have no limit.
+func (t *AnswerTimer) Remaining() (remaining time.Duration, ok bool) {
+	limit := t.Limit()
+	if limit == 0 {
+		return 0, false
+	}
+	used := t.now().Sub(t.since)
+	for _, q := range t.shown {
+		used += t.spent[q.ID]
+	}
+	return max(0, limit-used), true
+}
+
+// RemainingSeconds returns the time left for the shown questions rounded up to seconds,
+// zero when they have no limit.
+func (t *AnswerTimer) RemainingSeconds() int {
+	remaining, ok := t.Remaining()
+	if !ok {
+		return 0
+	}
+	return int((remaining + time.Second - 1) / time.Second)
+}
+
+// Spent returns the time spent on a question so far.
+func (t *AnswerTimer) Spent(questionId uint) time.Duration {
+	spent := t.spent[questionId]
+	for _, q := range t.shown {
+		if q.ID == questionId {
+			spent += t.now().Sub(t.since) / time.Duration(len(t.shown))
+		}
+	}
+	return spent
+}
+
+// SpentTimes returns the time spent on every question so far.
+func (t *AnswerTimer) SpentTimes() map[uint]time.Duration {
+	spent := map[uint]time.Duration{}
+	for id := range t.spent {
+		spent[id] = t.Spent(id)
+	}
+	for _, q := range t.shown {
+		spent[q.ID] = t.Spent(q.ID)
+	}
+	return spent
+}
+
+// Restore carries over the time spent on questions in an earlier session of the participation.
+func (t *AnswerTimer) Restore(spent map[uint]time.Duration) {
+	for id, d := range spent {
+		t.spent[id] = d
+	}
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/pkg/validation"
+)
+
+const (
+	AnswerRequired        = "required"
+	AnswerTooShort        = "too_short"
+	AnswerTooLong         = "too_long"
+	AnswerPatternMismatch = "pattern_mismatch"
+	AnswerNotNumber       = "not_a_number"
+	AnswerOutOfRange      = "out_of_range"
+	AnswerInvalidFormat   = "invalid_format"
+	AnswerInvalid         = "invalid_answer"
+	AnswerTimedOut        = "timed_out"
+)
+
+// AnswerError is an answer rejected by the validation rules of its question, Code lets
+// clients tell the rules apart whatever the message is.
+type AnswerError struct {
+	Code    string
+	Message string
+}
+
+func (e *AnswerError) Error() string {
+	return ErrInvalidAnswer.Error() + ": " + e.Message
+}
+
+func (e *AnswerError) Unwrap() error {
+	return ErrInvalidAnswer
+}
+
+// AnswerErrorCode returns the code of a rejected answer, answers that do not fit the type
+// of their question have the invalid_answer code.
+func AnswerErrorCode(err error) string {
+	var answerErr *AnswerError
+	if errors.As(err, &answerErr) {
+		return answerErr.Code
+	}
+	return AnswerInvalid
+}
+
+// SkipsAnswer reports whether a vote leaves its question unanswered, which is only accepted
+// for optional questions.
+func SkipsAnswer(q *dto.Question, req dto.VoteRequest) (bool, error) {
+	empty := strings.TrimSpace(req.Answer) == "" && len(req.ChoiceIds) == 0 && len(req.Ranking) == 0 && len(req.Matrix) == 0
+	if !empty {
+		return false, nil
+	}
+	rules := q.Config.Validation
+	if rules != nil && rules.Optional {
+		return true, nil
+	}
+	return false, answerError(rules, AnswerRequired, "an answer is required")
+}
+
+// checkAnswerRules checks a text answer against the validation rules of its question.
+func checkAnswerRules(rules *dto.AnswerValidation, answer string) error {
+	if rules == nil {
+		return nil
+	}
+	length := utf8.RuneCountInString(answer)
+	if rules.MinLength > 0 && length < rules.MinLength {
+		return answerError(rules, AnswerTooShort, fmt.Sprintf("answer must be at least %d characters", rules.MinLength))
+	}
+	if rules.MaxLength > 0 && length > rules.MaxLength {
+		return answerError(rules, AnswerTooLong, fmt.Sprintf("answer must be at most %d characters", rules.MaxLength))
+	}
+	if rules.Pattern != "" {
+		// patterns are compiled when the question is saved
+		if matched, _ := regexp.MatchString(rules.Pattern, answer); !matched {
+			return answerError(rules, AnswerPatternMismatch, "answer does not match the expected pattern")
+		}
+	}
+	if rules.Min != nil || rules.Max != nil {
+		n, err := strconv.ParseFloat(strings.TrimSpace(answer), 64)
+		if err != nil {
+			return answerError(rules, AnswerNotNumber, "answer must be a number")
+		}
+		if (rules.Min != nil && n < *rules.Min) || (rules.Max != nil && n > *rules.Max) {
+			return answerError(rules, AnswerOutOfRange, "number is out of range")
+		}
+	}
+	if rules.Format != "" && !validation.ValidateFormat(strings.TrimSpace(answer), string(rules.Format)) {
+		return answerError(rules, AnswerInvalidFormat, fmt.Sprintf("answer is not a valid %s", rules.Format))
+	}
+	return nil
+}
+
+func answerError(rules *dto.AnswerValidation, code string, message string) error {
+	if rules != nil && rules.Message != "" {
+		message = rules.Message
+	}
+	return &AnswerError{Code: code, Message: message}
+}
+
+// validateAnswerRules checks the validation rules of a question when it is saved.
+func validateAnswerRules(questionType dto.QuestionType, rules *dto.AnswerValidation) error {
+	if rules == nil {
+		return nil
+	}
+	textRules := rules.MinLength != 0 || rules.MaxLength != 0 || rules.Pattern != "" || rules.Min != nil || rules.Max != nil || rules.Format != ""
+	if textRules && questionType != dto.TextQuestion {
+		return fmt.Errorf("%w: length, pattern, range and format rules only apply to text questions", ErrInvalidQuestionConfig)
+	}
+	if rules.MinLength < 0 || rules.MaxLength < 0 || (rules.MaxLength > 0 && rules.MinLength > rules.MaxLength) {
+		return fmt.Errorf("%w: invalid length limits", ErrInvalidQuestionConfig)
+	}
+	if rules.Min != nil && rules.Max != nil && *rules.Min > *rules.Max {
+		return fmt.Errorf("%w: validation min is greater than max", ErrInvalidQuestionConfig)
+	}
+	if rules.Pattern != "" {
+		if _, err := regexp.Compile(rules.Pattern); err != nil {
+			return fmt.Errorf("%w: invalid pattern: %s", ErrInvalidQuestionConfig, err.Error())
+		}
+	}
+	switch rules.Format {
+	case "", dto.EmailFormat, dto.PhoneFormat, dto.NationalIDFormat:
+	default:
+		return fmt.Errorf("%w: unknown answer format %s", ErrInvalidQuestionConfig, rules.Format)
+	}
+	return nil
+}
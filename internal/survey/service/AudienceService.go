@@ -0,0 +1,134 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/repository"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"golang.org/x/net/context"
+)
+
+type IAudienceService interface {
+	GetAudiences(c context.Context, userId uint) ([]*dto.Audience, error)
+	GetAudience(c context.Context, userId uint, id uint) (*dto.Audience, error)
+	CreateAudience(c context.Context, userId uint, req dto.AudienceRequest) (*dto.Audience, error)
+	UpdateAudience(c context.Context, userId uint, id uint, req dto.AudienceRequest) (*dto.Audience, error)
+	DeleteAudience(c context.Context, userId uint, id uint) error
+}
+
+type AudienceService struct {
+	conf   *config.Config
+	repo   repository.ISurveyRepository
+	logger logging.Logger
+}
+
+func NewAudienceService(conf *config.Config, repo repository.ISurveyRepository, logger logging.Logger) *AudienceService {
+	return &AudienceService{conf: conf, repo: repo, logger: logger}
+}
+
+func (s *AudienceService) GetAudiences(c context.Context, userId uint) ([]*dto.Audience, error) {
+	audiences, err := s.repo.GetAudiences(c, userId)
+	if err != nil {
+		return []*dto.Audience{}, err
+	}
+	response := []*dto.Audience{}
+	for _, audience := range audiences {
+		response = append(response, audienceResponse(audience))
+	}
+	return response, nil
+}
+
+// GetAudience returns an audience of the user, audiences are private to their owner.
+func (s *AudienceService) GetAudience(c context.Context, userId uint, id uint) (*dto.Audience, error) {
+	audience, err := s.ownedAudience(c, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	return audienceResponse(audience), nil
+}
+
+func (s *AudienceService) CreateAudience(c context.Context, userId uint, req dto.AudienceRequest) (*dto.Audience, error) {
+	audience := models.Audience{OwnerID: userId, Name: req.Name}
+	if err := s.fill(c, &audience, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateAudience(c, &audience); err != nil {
+		return nil, err
+	}
+	return audienceResponse(&audience), nil
+}
+
+// UpdateAudience renames an audience and replaces its members, surveys that target it see the new members.
+func (s *AudienceService) UpdateAudience(c context.Context, userId uint, id uint, req dto.AudienceRequest) (*dto.Audience, error) {
+	audience, err := s.ownedAudience(c, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fill(c, audience, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateAudience(c, audience); err != nil {
+		return nil, err
+	}
+	return audienceResponse(audience), nil
+}
+
+func (s *AudienceService) DeleteAudience(c context.Context, userId uint, id uint) error {
+	if _, err := s.ownedAudience(c, userId, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteAudience(c, id)
+}
+
+func (s *AudienceService) ownedAudience(c context.Context, userId uint, id uint) (*models.Audience, error) {
+	audience, err := s.repo.GetAudienceByID(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if audience == nil || audience.OwnerID != userId {
+		return nil, ErrAudienceNotFound
+	}
+	return audience, nil
+}
+
+// fill copies a request into audience, every member must be a user.
+func (s *AudienceService) fill(c context.Context, audience *models.Audience, req dto.AudienceRequest) error {
+	ids := map[uint]bool{}
+	for _, id := range req.UserIDs {
+		ids[id] = true
+	}
+	unique := []uint{}
+	for id := range ids {
+		unique = append(unique, id)
+	}
+	sort.Slice(unique, func(i, j int) bool { return unique[i] < unique[j] })
+
+	if len(unique) > 0 {
+		existing, err := s.repo.GetExistingUserIds(c, unique)
+		if err != nil {
+			return err
+		}
+		if len(existing) != len(unique) {
+			return fmt.Errorf("%w: %d of the users do not exist", ErrInvalidAudience, len(unique)-len(existing))
+		}
+	}
+
+	audience.Name = req.Name
+	audience.Members = []models.AudienceMember{}
+	for _, id := range unique {
+		audience.Members = append(audience.Members, models.AudienceMember{AudienceID: audience.ID, UserID: id})
+	}
+	return nil
+}
+
+func audienceResponse(audience *models.Audience) *dto.Audience {
+	response := &dto.Audience{ID: audience.ID, Name: audience.Name, OwnerID: audience.OwnerID, UserIDs: []uint{}}
+	for _, member := range audience.Members {
+		response.UserIDs = append(response.UserIDs, member.UserID)
+	}
+	return response
+}
@@ -0,0 +1,497 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+)
+
+// BranchEngine decides which question a participant gets next, based on the branch rules
+// of the survey and the answers given so far in the participation.
+type BranchEngine struct {
+	questions  []*dto.Question
+	sections   map[uint]*dto.Section
+	index      map[uint]int
+	rules      map[uint][]dto.BranchRule
+	conditions map[uint]dto.RuleExpression
+	answers    map[uint][]string
+}
+
+// NewBranchEngine builds an engine over questions in the order they are asked.
+// Questions linked from a choice are asked right after the question of that choice and only
+// when that choice was picked.
+func NewBranchEngine(questions []*dto.Question, sections []*dto.Section, rules []dto.BranchRule) *BranchEngine {
+	e := &BranchEngine{
+		sections:   map[uint]*dto.Section{},
+		index:      map[uint]int{},
+		rules:      map[uint][]dto.BranchRule{},
+		conditions: map[uint]dto.RuleExpression{},
+		answers:    map[uint][]string{},
+	}
+
+	byId := map[uint]*dto.Question{}
+	for _, q := range questions {
+		byId[q.ID] = q
+	}
+
+	linked := map[uint][]*dto.Question{}
+	for _, q := range questions {
+		for _, choice := range q.Choices {
+			target, ok := byId[choice.LinkedQuestionID]
+			if !ok || target.ID == q.ID {
+				continue
+			}
+			condition, exists := e.conditions[target.ID]
+			if !exists {
+				linked[q.ID] = append(linked[q.ID], target)
+			}
+			condition.Or = append(condition.Or, dto.RuleExpression{QuestionID: q.ID, Operator: dto.OperatorEqual, Value: choice.Text})
+			e.conditions[target.ID] = condition
+		}
+	}
+
+	var place func(q *dto.Question)
+	place = func(q *dto.Question) {
+		if _, placed := e.index[q.ID]; placed {
+			return
+		}
+		e.index[q.ID] = len(e.questions)
+		e.questions = append(e.questions, q)
+		for _, target := range linked[q.ID] {
+			place(target)
+		}
+	}
+	for _, q := range questions {
+		if _, isLinked := e.conditions[q.ID]; !isLinked {
+			place(q)
+		}
+	}
+	for _, q := range questions {
+		place(q)
+	}
+
+	for _, section := range sections {
+		e.sections[section.ID] = section
+	}
+	for _, rule := range rules {
+		e.rules[rule.QuestionID] = append(e.rules[rule.QuestionID], rule)
+	}
+	for _, list := range e.rules {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].Priority < list[j].Priority })
+	}
+	return e
+}
+
+func (e *BranchEngine) Len() int {
+	return len(e.questions)
+}
+
+// Questions returns the questions of ids in their order, ids that are not in the survey are left out.
+func (e *BranchEngine) Questions(ids []uint) []*dto.Question {
+	questions := []*dto.Question{}
+	for _, id := range ids {
+		if i, ok := e.index[id]; ok {
+			questions = append(questions, e.questions[i])
+		}
+	}
+	return questions
+}
+
+func (e *BranchEngine) SetAnswer(questionId uint, values []string) {
+	e.answers[questionId] = values
+}
+
+func (e *BranchEngine) ClearAnswer(questionId uint) {
+	delete(e.answers, questionId)
+}
+
+// Next returns the question that follows current, or nil when the survey is finished.
+// A nil current returns the first question. Questions in asked are never returned again.
+func (e *BranchEngine) Next(current *dto.Question, asked []*dto.Question) *dto.Question {
+	skip := map[uint]bool{}
+	for _, q := range asked {
+		skip[q.ID] = true
+	}
+
+	position := 0
+	if current != nil {
+		position = e.index[current.ID] + 1
+		if rule := e.matchingRule(current.ID); rule != nil {
+			if rule.EndSurvey {
+				return nil
+			}
+			if i, ok := e.index[rule.TargetQuestionID]; ok {
+				position = i
+			}
+		}
+	}
+
+	for ; position < len(e.questions); position++ {
+		q := e.questions[position]
+		if skip[q.ID] {
+			continue
+		}
+		if condition, ok := e.conditions[q.ID]; ok && !EvaluateExpression(condition, e.answers) {
+			continue
+		}
+		if q.Config.ChoicesFrom != 0 && len(e.Resolve(q).Choices) == 0 {
+			// nothing was picked on the question the choices come from
+			continue
+		}
+		return q
+	}
+	return nil
+}
+
+// Section returns the section of a question, nil for questions outside any section.
+func (e *BranchEngine) Section(q *dto.Question) *dto.Section {
+	return e.sections[q.SectionID]
+}
+
+// Page returns first and the questions that follow it in the same section. Questions that
+// depend on answers given on the same page, by a choice or by piping, are left for later pages.
+func (e *BranchEngine) Page(first *dto.Question, asked []*dto.Question) []*dto.Question {
+	page := []*dto.Question{first}
+	seen := append(append([]*dto.Question{}, asked...), first)
+	for {
+		next := e.Next(page[len(page)-1], seen)
+		if next == nil || next.SectionID != first.SectionID || pipesFrom(next, page) {
+			return page
+		}
+		page = append(page, next)
+		seen = append(seen, next)
+	}
+}
+
+// NextAfterPage returns the first question after an answered page, the first question of the page
+// with a matching rule decides the jump, otherwise the page falls through from its last question.
+func (e *BranchEngine) NextAfterPage(page []*dto.Question, asked []*dto.Question) *dto.Question {
+	for _, q := range page {
+		if e.matchingRule(q.ID) != nil {
+			return e.Next(q, asked)
+		}
+	}
+	return e.Next(page[len(page)-1], asked)
+}
+
+// Resolve returns q with the choices a participant can pick, questions that take their choices
+// from an earlier question only keep the choices picked there.
+func (e *BranchEngine) Resolve(q *dto.Question) *dto.Question {
+	if q == nil || q.Config.ChoicesFrom == 0 {
+		return q
+	}
+	picked := map[string]bool{}
+	for _, v := range e.answers[q.Config.ChoicesFrom] {
+		picked[v] = true
+	}
+	resolved := *q
+	resolved.Choices = []dto.Choice{}
+	for _, choice := range q.Choices {
+		if picked[choice.Text] {
+			resolved.Choices = append(resolved.Choices, choice)
+		}
+	}
+	return &resolved
+}
+
+// Render returns q as a participant sees it, resolved, in language and with the answers given
+// so far piped into its texts. References to unanswered questions become empty and the correct
+// choices and their points are left out.
+func (e *BranchEngine) Render(q *dto.Question, language string) *dto.Question {
+	if q == nil {
+		return nil
+	}
+	localized := LocalizeQuestion(e.Resolve(q), language)
+	value := func(id uint) (string, bool) {
+		return e.pipedAnswer(id, language), true
+	}
+	rendered := *localized
+	rendered.Text = pipeText(localized.Text, value)
+	rendered.Choices = []dto.Choice{}
+	for _, choice := range localized.Choices {
+		choice.Text = pipeText(choice.Text, value)
+		choice.IsCorrect = false
+		choice.Points = 0
+		rendered.Choices = append(rendered.Choices, choice)
+	}
+	return &rendered
+}
+
+// RenderPage renders every question of a page.
+func (e *BranchEngine) RenderPage(page []*dto.Question, language string) []*dto.Question {
+	rendered := []*dto.Question{}
+	for _, q := range page {
+		rendered = append(rendered, e.Render(q, language))
+	}
+	return rendered
+}
+
+// pipedAnswer returns the answer to a question as it is piped, picked choices are shown in language.
+func (e *BranchEngine) pipedAnswer(questionId uint, language string) string {
+	values := append([]string{}, e.answers[questionId]...)
+	if i, ok := e.index[questionId]; ok {
+		source := e.questions[i]
+		for j, v := range values {
+			for _, choice := range source.Choices {
+				if choice.Text == v {
+					values[j] = translate(choice.Text, choice.TextTranslations, language)
+				}
+			}
+		}
+	}
+	return joinAnswers(values)
+}
+
+func pipesFrom(q *dto.Question, page []*dto.Question) bool {
+	for _, id := range PipingReferences(q) {
+		for _, v := range page {
+			if v.ID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (e *BranchEngine) matchingRule(questionId uint) *dto.BranchRule {
+	for _, rule := range e.rules[questionId] {
+		if EvaluateExpression(rule.Condition, e.answers) {
+			return &rule
+		}
+	}
+	return nil
+}
+
+// EvaluateExpression evaluates a rule expression against answers by question id.
+// Multi select questions have several answers, a comparison matches when any of them matches,
+// neq only matches answered questions. Ordering operators compare numbers when both sides are
+// numeric and text otherwise, so dates in YYYY-MM-DD compare correctly.
+func EvaluateExpression(expression dto.RuleExpression, answers map[uint][]string) bool {
+	switch {
+	case len(expression.And) > 0:
+		for _, e := range expression.And {
+			if !EvaluateExpression(e, answers) {
+				return false
+			}
+		}
+		return true
+	case len(expression.Or) > 0:
+		for _, e := range expression.Or {
+			if EvaluateExpression(e, answers) {
+				return true
+			}
+		}
+		return false
+	case expression.Not != nil:
+		return !EvaluateExpression(*expression.Not, answers)
+	case expression.IsEmpty():
+		return true
+	}
+
+	values := answers[expression.QuestionID]
+	switch expression.Operator {
+	case dto.OperatorAnswered:
+		return len(values) > 0
+	case dto.OperatorNotAnswered:
+		return len(values) == 0
+	case dto.OperatorNotEqual:
+		if len(values) == 0 {
+			return false
+		}
+		for _, v := range values {
+			if v == expression.Value {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, v := range values {
+		if matchValue(expression.Operator, v, expression.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchValue(operator dto.RuleOperator, answer string, value string) bool {
+	switch operator {
+	case dto.OperatorEqual:
+		return answer == value
+	case dto.OperatorContains:
+		return strings.Contains(strings.ToLower(answer), strings.ToLower(value))
+	}
+
+	compared := strings.Compare(answer, value)
+	a, errA := strconv.ParseFloat(answer, 64)
+	b, errB := strconv.ParseFloat(value, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case a < b:
+			compared = -1
+		case a > b:
+			compared = 1
+		default:
+			compared = 0
+		}
+	}
+
+	switch operator {
+	case dto.OperatorGreater:
+		return compared > 0
+	case dto.OperatorGreaterEqual:
+		return compared >= 0
+	case dto.OperatorLess:
+		return compared < 0
+	case dto.OperatorLessEqual:
+		return compared <= 0
+	}
+	return false
+}
+
+// ValidateBranchRules returns every problem of rules over questions in their survey order.
+// Targets and referenced questions must belong to the survey and rules must not form a cycle,
+// for sequential surveys falling through to the next question counts as an edge too.
+func ValidateBranchRules(questions []*dto.Question, rules []dto.BranchRule, sequential bool) []string {
+	problems := []string{}
+	exists := map[uint]bool{}
+	for _, q := range questions {
+		exists[q.ID] = true
+	}
+
+	edges := map[uint][]uint{}
+	for i, rule := range rules {
+		name := fmt.Sprintf("rule %d", i+1)
+		if !exists[rule.QuestionID] {
+			problems = append(problems, fmt.Sprintf("%s: question %d does not exist in this survey", name, rule.QuestionID))
+		}
+		switch {
+		case rule.EndSurvey && rule.TargetQuestionID != 0:
+			problems = append(problems, fmt.Sprintf("%s: set either target_question_id or end_survey", name))
+		case !rule.EndSurvey && rule.TargetQuestionID == 0:
+			problems = append(problems, fmt.Sprintf("%s: target_question_id or end_survey is required", name))
+		case !rule.EndSurvey && !exists[rule.TargetQuestionID]:
+			problems = append(problems, fmt.Sprintf("%s: target question %d does not exist in this survey", name, rule.TargetQuestionID))
+		case !rule.EndSurvey:
+			edges[rule.QuestionID] = append(edges[rule.QuestionID], rule.TargetQuestionID)
+		}
+		problems = append(problems, validateExpression(name, rule.Condition, exists, true)...)
+	}
+
+	if sequential {
+		for i := 0; i+1 < len(questions); i++ {
+			edges[questions[i].ID] = append(edges[questions[i].ID], questions[i+1].ID)
+		}
+	}
+	if cycle := findCycle(questions, edges); len(cycle) > 0 {
+		path := []string{}
+		for _, id := range cycle {
+			path = append(path, strconv.Itoa(int(id)))
+		}
+		problems = append(problems, "rules form a cycle through questions "+strings.Join(path, " -> "))
+	}
+	return problems
+}
+
+func validateExpression(name string, e dto.RuleExpression, exists map[uint]bool, root bool) []string {
+	kinds := 0
+	for _, set := range []bool{len(e.And) > 0, len(e.Or) > 0, e.Not != nil, e.QuestionID != 0 || e.Operator != ""} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds > 1 {
+		return []string{fmt.Sprintf("%s: a condition can only be one of and, or, not or a comparison", name)}
+	}
+
+	problems := []string{}
+	switch {
+	case len(e.And) > 0:
+		for _, child := range e.And {
+			problems = append(problems, validateExpression(name, child, exists, false)...)
+		}
+	case len(e.Or) > 0:
+		for _, child := range e.Or {
+			problems = append(problems, validateExpression(name, child, exists, false)...)
+		}
+	case e.Not != nil:
+		problems = append(problems, validateExpression(name, *e.Not, exists, false)...)
+	case e.IsEmpty():
+		if !root {
+			problems = append(problems, fmt.Sprintf("%s: empty condition", name))
+		}
+	default:
+		if !exists[e.QuestionID] {
+			problems = append(problems, fmt.Sprintf("%s: condition refers to question %d which does not exist in this survey", name, e.QuestionID))
+		}
+		switch e.Operator {
+		case dto.OperatorAnswered, dto.OperatorNotAnswered:
+		case dto.OperatorEqual, dto.OperatorNotEqual, dto.OperatorGreater, dto.OperatorGreaterEqual,
+			dto.OperatorLess, dto.OperatorLessEqual, dto.OperatorContains:
+			if e.Value == "" {
+				problems = append(problems, fmt.Sprintf("%s: operator %s needs a value", name, e.Operator))
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("%s: unknown operator %s", name, e.Operator))
+		}
+	}
+	return problems
+}
+
+func findCycle(questions []*dto.Question, edges map[uint][]uint) []uint {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[uint]int{}
+	stack := []uint{}
+
+	var visit func(id uint) []uint
+	visit = func(id uint) []uint {
+		state[id] = visiting
+		stack = append(stack, id)
+		for _, next := range edges[id] {
+			switch state[next] {
+			case visiting:
+				for i, v := range stack {
+					if v == next {
+						return append(append([]uint{}, stack[i:]...), next)
+					}
+				}
+			case unvisited:
+				if cycle := visit(next); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = done
+		return nil
+	}
+
+	for _, q := range questions {
+		if state[q.ID] == unvisited {
+			if cycle := visit(q.ID); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// expressionReferences reports whether a rule expression depends on the answer to questionId.
+func expressionReferences(e dto.RuleExpression, questionId uint) bool {
+	if e.QuestionID == questionId {
+		return true
+	}
+	for _, child := range append(append([]dto.RuleExpression{}, e.And...), e.Or...) {
+		if expressionReferences(child, questionId) {
+			return true
+		}
+	}
+	return e.Not != nil && expressionReferences(*e.Not, questionId)
+}
@@ -0,0 +1,480 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/repository"
+	"github.com/G9QBootcamp/qoli-survey/internal/util"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"golang.org/x/net/context"
+)
+
+type IEligibilityService interface {
+	GetEligibility(c context.Context, surveyId uint) (*dto.EligibilityResponse, error)
+	UpdateEligibility(c context.Context, surveyId uint, req dto.EligibilityRequest) (*dto.EligibilityResponse, error)
+	PreviewEligibility(c context.Context, surveyId uint, req dto.EligibilityRequest) (*dto.EligibilityResponse, error)
+	CheckEligibility(c context.Context, userId uint, surveyId uint) (*dto.EligibilityCheckResponse, error)
+}
+
+type EligibilityService struct {
+	conf   *config.Config
+	repo   repository.ISurveyRepository
+	logger logging.Logger
+}
+
+func NewEligibilityService(conf *config.Config, repo repository.ISurveyRepository, logger logging.Logger) *EligibilityService {
+	return &EligibilityService{conf: conf, repo: repo, logger: logger}
+}
+
+// GetEligibility returns the rule that decides who may take a survey and how many users it lets in.
+func (s *EligibilityService) GetEligibility(c context.Context, surveyId uint) (*dto.EligibilityResponse, error) {
+	survey, err := s.getSurvey(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	rule, err := s.surveyEligibility(survey)
+	if err != nil {
+		return nil, err
+	}
+	return s.preview(c, rule)
+}
+
+// UpdateEligibility replaces the eligibility rule of a survey, the city and minimum_age options
+// it replaces are removed.
+func (s *EligibilityService) UpdateEligibility(c context.Context, surveyId uint, req dto.EligibilityRequest) (*dto.EligibilityResponse, error) {
+	survey, err := s.getSurvey(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	if survey.Status == models.SurveyStatusArchived {
+		return nil, ErrSurveyReadOnly
+	}
+	if err := s.validate(c, survey, req.Rule); err != nil {
+		return nil, err
+	}
+
+	survey.Eligibility = nil
+	if req.Rule != nil {
+		rule := models.EligibilityRule{}
+		if err := util.ConvertTypes(s.logger, req.Rule, &rule); err != nil {
+			return nil, err
+		}
+		survey.Eligibility = &rule
+	}
+	options := survey.Options
+	survey.Options = nil
+	if err := s.repo.UpdateSurvey(c, survey); err != nil {
+		return nil, err
+	}
+	for _, option := range options {
+		if option.Name != OptionCity && option.Name != OptionMinimumAge {
+			continue
+		}
+		if err := s.repo.DeleteOption(c, option.ID); err != nil {
+			return nil, err
+		}
+	}
+	return s.preview(c, req.Rule)
+}
+
+// PreviewEligibility counts the users a rule lets take a survey without storing it, the rule
+// of the survey is counted when the request has none.
+func (s *EligibilityService) PreviewEligibility(c context.Context, surveyId uint, req dto.EligibilityRequest) (*dto.EligibilityResponse, error) {
+	survey, err := s.getSurvey(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	if req.Rule == nil {
+		rule, err := s.surveyEligibility(survey)
+		if err != nil {
+			return nil, err
+		}
+		return s.preview(c, rule)
+	}
+	if err := s.validate(c, survey, req.Rule); err != nil {
+		return nil, err
+	}
+	return s.preview(c, req.Rule)
+}
+
+// CheckEligibility tells a user whether the eligibility rule of a survey lets them take it, and why not.
+func (s *EligibilityService) CheckEligibility(c context.Context, userId uint, surveyId uint) (*dto.EligibilityCheckResponse, error) {
+	survey, err := s.getSurvey(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	rule, err := s.surveyEligibility(survey)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return &dto.EligibilityCheckResponse{Eligible: true}, nil
+	}
+
+	user, err := s.repo.GetParticipant(c, userId)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return &dto.EligibilityCheckResponse{Reasons: []string{"user not found"}}, nil
+	}
+	facts := EligibilityFacts{City: user.City, Email: user.Email, DateOfBirth: user.DateOfBirth, RegisteredAt: user.CreatedAt, EmailVerified: user.EmailVerified}
+	audiences, surveys := EligibilityReferences(*rule)
+	if facts.Audiences, err = s.memberships(c, s.repo.GetUserAudiences, userId, audiences); err != nil {
+		return nil, err
+	}
+	if facts.Participated, err = s.memberships(c, s.repo.GetCommittedSurveys, userId, surveys); err != nil {
+		return nil, err
+	}
+
+	eligible, reasons := EvaluateEligibility(*rule, facts, time.Now())
+	return &dto.EligibilityCheckResponse{Eligible: eligible, Reasons: reasons}, nil
+}
+
+func (s *EligibilityService) memberships(c context.Context, get func(context.Context, uint, []uint) ([]uint, error), userId uint, ids []uint) (map[uint]bool, error) {
+	found := map[uint]bool{}
+	if len(ids) == 0 {
+		return found, nil
+	}
+	matched, err := get(c, userId, ids)
+	for _, id := range matched {
+		found[id] = true
+	}
+	return found, err
+}
+
+func (s *EligibilityService) preview(c context.Context, rule *dto.EligibilityRule) (*dto.EligibilityResponse, error) {
+	condition, args := "", []interface{}{}
+	if rule != nil {
+		condition, args = EligibilityCondition(*rule, time.Now())
+	}
+	count, err := s.repo.CountEligibleUsers(c, condition, args)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.EligibilityResponse{Rule: rule, EligibleUsers: count}, nil
+}
+
+// validate checks a rule and that the audiences it names belong to the owner of the survey.
+func (s *EligibilityService) validate(c context.Context, survey *models.Survey, rule *dto.EligibilityRule) error {
+	if rule == nil {
+		return nil
+	}
+	if err := ValidateEligibility(*rule); err != nil {
+		return err
+	}
+	audiences, surveys := EligibilityReferences(*rule)
+	for _, id := range audiences {
+		audience, err := s.repo.GetAudienceByID(c, id)
+		if err != nil {
+			return err
+		}
+		if audience == nil || audience.OwnerID != survey.OwnerID {
+			return fmt.Errorf("%w: audience %d not found", ErrInvalidEligibility, id)
+		}
+	}
+	for _, id := range surveys {
+		other, err := s.repo.GetSurveyByID(c, id)
+		if err != nil {
+			return err
+		}
+		if other == nil || other.ID == survey.ID {
+			return fmt.Errorf("%w: survey %d can not be required", ErrInvalidEligibility, id)
+		}
+	}
+	return nil
+}
+
+func (s *EligibilityService) getSurvey(c context.Context, surveyId uint) (*models.Survey, error) {
+	survey, err := s.repo.GetSurveyByID(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	if survey == nil {
+		return nil, ErrSurveyNotFound
+	}
+	return survey, nil
+}
+
+// surveyEligibility returns the eligibility rule of a survey, surveys without one are checked
+// against the city and minimum_age options they used before, nil lets everyone in.
+func (s *EligibilityService) surveyEligibility(survey *models.Survey) (*dto.EligibilityRule, error) {
+	if survey.Eligibility != nil {
+		rule := dto.EligibilityRule{}
+		return &rule, util.ConvertTypes(s.logger, survey.Eligibility, &rule)
+	}
+	return OptionsEligibility(optionResponses(survey.Options)), nil
+}
+
+// EligibilityFacts are what eligibility rules know about a participant. Audiences and Participated
+// hold the audiences and surveys the rule names that the participant is a member of or took part in.
+type EligibilityFacts struct {
+	City          string
+	Email         string
+	DateOfBirth   time.Time
+	RegisteredAt  time.Time
+	EmailVerified bool
+	Audiences     map[uint]bool
+	Participated  map[uint]bool
+}
+
+// OptionsEligibility turns the city and minimum_age options into the rule they stand for, nil when
+// there are none.
+func OptionsEligibility(options []dto.SurveyOptionResponse) *dto.EligibilityRule {
+	rules := []dto.EligibilityRule{}
+	for _, option := range options {
+		switch option.Name {
+		case OptionCity:
+			rules = append(rules, dto.EligibilityRule{Type: models.EligibilityCity, Values: []string{option.Value}})
+		case OptionMinimumAge:
+			age, err := strconv.Atoi(option.Value)
+			if err != nil {
+				continue
+			}
+			rules = append(rules, dto.EligibilityRule{Type: models.EligibilityAge, MinAge: &age})
+		}
+	}
+	switch len(rules) {
+	case 0:
+		return nil
+	case 1:
+		return &rules[0]
+	}
+	return &dto.EligibilityRule{And: rules}
+}
+
+// ValidateEligibility checks that every group of a rule combines its rules one way and that every
+// condition has what its type needs.
+func ValidateEligibility(rule dto.EligibilityRule) error {
+	if len(rule.And) > 0 && len(rule.Or) > 0 {
+		return fmt.Errorf("%w: a rule combines its rules with either and or or", ErrInvalidEligibility)
+	}
+	if children := ruleChildren(rule); len(children) > 0 {
+		if rule.Type != "" {
+			return fmt.Errorf("%w: a group of rules has no type", ErrInvalidEligibility)
+		}
+		for _, child := range children {
+			if err := ValidateEligibility(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	switch rule.Type {
+	case models.EligibilityCity, models.EligibilityEmailDomain:
+		if len(rule.Values) == 0 {
+			return fmt.Errorf("%w: a %s rule needs values", ErrInvalidEligibility, rule.Type)
+		}
+	case models.EligibilityAge:
+		if rule.MinAge == nil && rule.MaxAge == nil {
+			return fmt.Errorf("%w: an age rule needs a minimum or maximum age", ErrInvalidEligibility)
+		}
+		if (rule.MinAge != nil && *rule.MinAge < 0) || (rule.MaxAge != nil && *rule.MaxAge < 0) {
+			return fmt.Errorf("%w: ages can not be negative", ErrInvalidEligibility)
+		}
+		if rule.MinAge != nil && rule.MaxAge != nil && *rule.MinAge > *rule.MaxAge {
+			return fmt.Errorf("%w: minimum age is above maximum age", ErrInvalidEligibility)
+		}
+	case models.EligibilityAccountAge:
+		if rule.MinAccountDays < 1 {
+			return fmt.Errorf("%w: an account_age rule needs min_account_days", ErrInvalidEligibility)
+		}
+	case models.EligibilityEmailVerified:
+	case models.EligibilityAudience:
+		if rule.AudienceID == 0 {
+			return fmt.Errorf("%w: an audience rule needs audience_id", ErrInvalidEligibility)
+		}
+	case models.EligibilityParticipated:
+		if rule.SurveyID == 0 {
+			return fmt.Errorf("%w: a participated rule needs survey_id", ErrInvalidEligibility)
+		}
+	case "":
+		return fmt.Errorf("%w: a rule needs a type or rules to combine", ErrInvalidEligibility)
+	default:
+		return fmt.Errorf("%w: unknown rule type %q", ErrInvalidEligibility, rule.Type)
+	}
+	return nil
+}
+
+// EligibilityReferences returns the audiences and surveys a rule names.
+func EligibilityReferences(rule dto.EligibilityRule) (audiences []uint, surveys []uint) {
+	audiences, surveys = []uint{}, []uint{}
+	for _, child := range ruleChildren(rule) {
+		a, s := EligibilityReferences(child)
+		audiences = append(audiences, a...)
+		surveys = append(surveys, s...)
+	}
+	switch rule.Type {
+	case models.EligibilityAudience:
+		audiences = append(audiences, rule.AudienceID)
+	case models.EligibilityParticipated:
+		surveys = append(surveys, rule.SurveyID)
+	}
+	return audiences, surveys
+}
+
+// EvaluateEligibility reports whether a participant satisfies a rule, and otherwise the reasons
+// the participant can be told. An or group that fails gives its alternatives as one reason.
+func EvaluateEligibility(rule dto.EligibilityRule, facts EligibilityFacts, now time.Time) (bool, []string) {
+	switch {
+	case len(rule.And) > 0:
+		reasons := []string{}
+		for _, child := range rule.And {
+			if ok, r := EvaluateEligibility(child, facts, now); !ok {
+				reasons = append(reasons, r...)
+			}
+		}
+		return len(reasons) == 0, reasons
+	case len(rule.Or) > 0:
+		alternatives := []string{}
+		for _, child := range rule.Or {
+			ok, r := EvaluateEligibility(child, facts, now)
+			if ok {
+				return true, nil
+			}
+			alternatives = append(alternatives, strings.Join(r, " and "))
+		}
+		if len(alternatives) == 1 {
+			return false, alternatives
+		}
+		return false, []string{"one of these is needed: " + strings.Join(alternatives, ", or ")}
+	}
+
+	switch rule.Type {
+	case models.EligibilityCity:
+		if facts.City == "" {
+			return false, []string{"add your city to your profile, the survey is for " + strings.Join(rule.Values, ", ")}
+		}
+		if !containsQuotaValue(rule.Values, facts.City) {
+			return false, []string{"your city must be one of " + strings.Join(rule.Values, ", ")}
+		}
+	case models.EligibilityAge:
+		age, ok := ParticipantAge(facts.DateOfBirth, now)
+		if !ok {
+			return false, []string{"add your date of birth to your profile, the survey is for ages " + ageRange(rule)}
+		}
+		if (rule.MinAge != nil && age < *rule.MinAge) || (rule.MaxAge != nil && age > *rule.MaxAge) {
+			return false, []string{"your age must be " + ageRange(rule)}
+		}
+	case models.EligibilityEmailDomain:
+		domain := emailDomain(facts.Email)
+		found := false
+		for _, v := range rule.Values {
+			if domain != "" && domain == normalizeDomain(v) {
+				found = true
+			}
+		}
+		if !found {
+			return false, []string{"your email must be at " + strings.Join(rule.Values, ", ")}
+		}
+	case models.EligibilityAccountAge:
+		if now.Sub(facts.RegisteredAt) < time.Duration(rule.MinAccountDays)*24*time.Hour {
+			return false, []string{fmt.Sprintf("your account must be at least %d days old", rule.MinAccountDays)}
+		}
+	case models.EligibilityEmailVerified:
+		if !facts.EmailVerified {
+			return false, []string{"your email must be verified"}
+		}
+	case models.EligibilityAudience:
+		if !facts.Audiences[rule.AudienceID] {
+			return false, []string{"the survey is only for an invited audience"}
+		}
+	case models.EligibilityParticipated:
+		if !facts.Participated[rule.SurveyID] {
+			return false, []string{fmt.Sprintf("you must complete survey %d first", rule.SurveyID)}
+		}
+	}
+	return true, nil
+}
+
+// EligibilityCondition translates a rule into a condition on the users table with its arguments,
+// it selects the users EvaluateEligibility lets in.
+func EligibilityCondition(rule dto.EligibilityRule, now time.Time) (string, []interface{}) {
+	if len(rule.And) > 0 || len(rule.Or) > 0 {
+		children, join := rule.And, " AND "
+		if len(rule.Or) > 0 {
+			children, join = rule.Or, " OR "
+		}
+		parts := []string{}
+		args := []interface{}{}
+		for _, child := range children {
+			part, a := EligibilityCondition(child, now)
+			parts = append(parts, part)
+			args = append(args, a...)
+		}
+		return "(" + strings.Join(parts, join) + ")", args
+	}
+
+	switch rule.Type {
+	case models.EligibilityCity:
+		cities := []string{}
+		for _, v := range rule.Values {
+			cities = append(cities, strings.ToLower(strings.TrimSpace(v)))
+		}
+		return "LOWER(TRIM(users.city)) IN ?", []interface{}{cities}
+	case models.EligibilityAge:
+		// a participant is min years old once the birth date is min years before now
+		parts := []string{"users.date_of_birth > ?"}
+		args := []interface{}{time.Time{}}
+		if rule.MinAge != nil {
+			parts = append(parts, "users.date_of_birth <= ?")
+			args = append(args, now.AddDate(-*rule.MinAge, 0, 0))
+		}
+		if rule.MaxAge != nil {
+			parts = append(parts, "users.date_of_birth > ?")
+			args = append(args, now.AddDate(-*rule.MaxAge-1, 0, 0))
+		}
+		return "(" + strings.Join(parts, " AND ") + ")", args
+	case models.EligibilityEmailDomain:
+		domains := []string{}
+		for _, v := range rule.Values {
+			domains = append(domains, normalizeDomain(v))
+		}
+		return "LOWER(SPLIT_PART(users.email, '@', 2)) IN ?", []interface{}{domains}
+	case models.EligibilityAccountAge:
+		return "users.created_at <= ?", []interface{}{now.Add(-time.Duration(rule.MinAccountDays) * 24 * time.Hour)}
+	case models.EligibilityEmailVerified:
+		return "users.email_verified = ?", []interface{}{true}
+	case models.EligibilityAudience:
+		return "users.id IN (SELECT user_id FROM audience_members WHERE audience_id = ?)", []interface{}{rule.AudienceID}
+	case models.EligibilityParticipated:
+		return "users.id IN (SELECT user_id FROM user_survey_participations WHERE survey_id = ? AND committed_at IS NOT NULL AND deleted_at IS NULL)", []interface{}{rule.SurveyID}
+	}
+	return "TRUE", []interface{}{}
+}
+
+func ruleChildren(rule dto.EligibilityRule) []dto.EligibilityRule {
+	children := append([]dto.EligibilityRule{}, rule.And...)
+	return append(children, rule.Or...)
+}
+
+func ageRange(rule dto.EligibilityRule) string {
+	switch {
+	case rule.MinAge != nil && rule.MaxAge != nil:
+		return fmt.Sprintf("between %d and %d", *rule.MinAge, *rule.MaxAge)
+	case rule.MinAge != nil:
+		return fmt.Sprintf("at least %d", *rule.MinAge)
+	case rule.MaxAge != nil:
+		return fmt.Sprintf("at most %d", *rule.MaxAge)
+	}
+	return "any"
+}
+
+func emailDomain(email string) string {
+	parts := strings.Split(email, "@")
+	if len(parts) < 2 {
+		return ""
+	}
+	return strings.ToLower(parts[1])
+}
+
+func normalizeDomain(domain string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@"))
+}
@@ -0,0 +1,372 @@
+package service
+
+import (
+	"crypto/hmac"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	notification "github.com/G9QBootcamp/qoli-survey/internal/notification/service"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/repository"
+	"github.com/G9QBootcamp/qoli-survey/internal/util"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"golang.org/x/net/context"
+)
+
+type IInvitationService interface {
+	GetInvitations(c context.Context, surveyId uint) (*dto.InvitationsResponse, error)
+	Invite(c context.Context, surveyId uint, req dto.InvitationRequest) (*dto.InvitationResponse, error)
+	ResendInvitation(c context.Context, surveyId uint, id uint) (*dto.Invitation, error)
+	DeleteInvitation(c context.Context, surveyId uint, id uint) error
+	OpenInvitation(c context.Context, token string) (*dto.InvitationPreview, error)
+	AcceptInvitation(c context.Context, userId uint, token string) (*dto.Invitation, error)
+}
+
+type InvitationService struct {
+	conf                *config.Config
+	repo                repository.ISurveyRepository
+	logger              logging.Logger
+	notificationService notification.INotificationService
+	mailer              notification.Mailer
+}
+
+func NewInvitationService(conf *config.Config, repo repository.ISurveyRepository, logger logging.Logger, notificationService notification.INotificationService, mailer notification.Mailer) *InvitationService {
+	return &InvitationService{conf: conf, repo: repo, logger: logger, notificationService: notificationService, mailer: mailer}
+}
+
+func (s *InvitationService) GetInvitations(c context.Context, surveyId uint) (*dto.InvitationsResponse, error) {
+	invitations, err := s.repo.GetInvitations(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	response := &dto.InvitationsResponse{Invitations: []*dto.Invitation{}, Statuses: map[string]int{}}
+	if err := util.ConvertTypes(s.logger, invitations, &response.Invitations); err != nil {
+		return nil, err
+	}
+	for _, invitation := range invitations {
+		response.Statuses[string(invitation.Status)]++
+	}
+	return response, nil
+}
+
+// Invite invites users and email addresses to a survey, the ones invited before are skipped.
+// Mails that can not be sent leave their invitation bounced, it can be sent again.
+func (s *InvitationService) Invite(c context.Context, surveyId uint, req dto.InvitationRequest) (*dto.InvitationResponse, error) {
+	survey, err := s.invitingSurvey(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	emails, err := InvitationEmails(req.Emails)
+	if err != nil {
+		return nil, err
+	}
+	if len(emails) == 0 && len(req.UserIDs) == 0 {
+		return nil, fmt.Errorf("%w: no emails or users to invite", ErrInvalidInvitation)
+	}
+
+	users, err := s.repo.GetInvitees(c, req.UserIDs, emails)
+	if err != nil {
+		return nil, err
+	}
+	usersById := map[uint]uint{}
+	usersByEmail := map[string]uint{}
+	emailsById := map[uint]string{}
+	for _, user := range users {
+		usersById[user.ID] = user.ID
+		usersByEmail[strings.ToLower(user.Email)] = user.ID
+		emailsById[user.ID] = strings.ToLower(user.Email)
+	}
+	recipients := []string{}
+	for _, id := range req.UserIDs {
+		if _, ok := usersById[id]; !ok {
+			return nil, fmt.Errorf("%w: user %d does not exist", ErrInvalidInvitation, id)
+		}
+		recipients = append(recipients, emailsById[id])
+	}
+	recipients = append(recipients, emails...)
+
+	invitations, err := s.repo.GetInvitations(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	invited := map[string]bool{}
+	for _, invitation := range invitations {
+		invited[invitation.Email] = true
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInHours > 0 {
+		expiry := time.Now().Add(time.Duration(req.ExpiresInHours) * time.Hour)
+		expiresAt = &expiry
+	}
+
+	response := &dto.InvitationResponse{Invitations: []*dto.Invitation{}}
+	for _, email := range recipients {
+		if invited[email] {
+			response.Skipped = append(response.Skipped, email)
+			continue
+		}
+		invited[email] = true
+
+		nonce, err := newTokenNonce()
+		if err != nil {
+			return nil, err
+		}
+		invitation := &models.Invitation{SurveyID: surveyId, Email: email, Nonce: nonce, Status: models.InvitationPending, ExpiresAt: expiresAt}
+		if id, ok := usersByEmail[email]; ok {
+			invitation.UserID = &id
+		}
+		if err := s.repo.CreateInvitation(c, invitation); err != nil {
+			return nil, err
+		}
+		if err := s.send(c, survey, invitation, req.Message); err != nil {
+			return nil, err
+		}
+		sent := dto.Invitation{}
+		if err := util.ConvertTypes(s.logger, invitation, &sent); err != nil {
+			return nil, err
+		}
+		response.Invitations = append(response.Invitations, &sent)
+	}
+	return response, nil
+}
+
+// ResendInvitation sends an invitation that was not accepted again with a new token, the old one stops working.
+func (s *InvitationService) ResendInvitation(c context.Context, surveyId uint, id uint) (*dto.Invitation, error) {
+	survey, err := s.invitingSurvey(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	invitation, err := s.repo.GetInvitationByID(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil || invitation.SurveyID != surveyId {
+		return nil, ErrInvitationNotFound
+	}
+	if invitation.AcceptedAt != nil {
+		return nil, ErrInvitationUsed
+	}
+	if invitation.ExpiresAt != nil && !time.Now().Before(*invitation.ExpiresAt) {
+		return nil, ErrInvitationExpired
+	}
+
+	invitation.Nonce, err = newTokenNonce()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.send(c, survey, invitation, ""); err != nil {
+		return nil, err
+	}
+	response := dto.Invitation{}
+	return &response, util.ConvertTypes(s.logger, invitation, &response)
+}
+
+// DeleteInvitation withdraws an invitation, the vote permission of an invitee that accepted it stays.
+func (s *InvitationService) DeleteInvitation(c context.Context, surveyId uint, id uint) error {
+	invitation, err := s.repo.GetInvitationByID(c, id)
+	if err != nil {
+		return err
+	}
+	if invitation == nil || invitation.SurveyID != surveyId {
+		return ErrInvitationNotFound
+	}
+	return s.repo.DeleteInvitation(c, id)
+}
+
+// OpenInvitation shows the survey of an invitation to whoever follows its link and marks it opened.
+func (s *InvitationService) OpenInvitation(c context.Context, token string) (*dto.InvitationPreview, error) {
+	invitation, err := s.tokenInvitation(c, token)
+	if err != nil {
+		return nil, err
+	}
+	survey, err := s.repo.GetSurveyByID(c, invitation.SurveyID)
+	if err != nil {
+		return nil, err
+	}
+	if survey == nil {
+		return nil, ErrInvitationNotFound
+	}
+
+	if invitation.OpenedAt == nil {
+		now := time.Now()
+		opened, err := s.repo.OpenInvitation(c, invitation.ID, now)
+		if err != nil {
+			return nil, err
+		}
+		if opened && (invitation.Status == models.InvitationPending || invitation.Status == models.InvitationSent || invitation.Status == models.InvitationBounced) {
+			invitation.Status = models.InvitationOpened
+		}
+	}
+
+	return &dto.InvitationPreview{
+		SurveyID:    survey.ID,
+		SurveyTitle: survey.Title,
+		Email:       invitation.Email,
+		Status:      string(invitation.Status),
+		StartTime:   survey.StartTime,
+		EndTime:     survey.EndTime,
+	}, nil
+}
+
+// AcceptInvitation spends the token of an invitation and lets the user vote on its survey until the
+// survey ends. Only the invited user, or the user with the invited email address, can accept it.
+func (s *InvitationService) AcceptInvitation(c context.Context, userId uint, token string) (*dto.Invitation, error) {
+	invitation, err := s.tokenInvitation(c, token)
+	if err != nil {
+		return nil, err
+	}
+	if invitation.AcceptedAt != nil {
+		return nil, ErrInvitationUsed
+	}
+	if invitation.ExpiresAt != nil && !time.Now().Before(*invitation.ExpiresAt) {
+		return nil, ErrInvitationExpired
+	}
+
+	user, err := s.repo.GetParticipant(c, userId)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("%w: user not found", ErrNotInvited)
+	}
+	if invitation.UserID != nil && *invitation.UserID != userId || invitation.UserID == nil && !strings.EqualFold(user.Email, invitation.Email) {
+		return nil, fmt.Errorf("%w: the invitation is for another user", ErrNotInvited)
+	}
+
+	survey, err := s.repo.GetSurveyByID(c, invitation.SurveyID)
+	if err != nil {
+		return nil, err
+	}
+	if survey == nil {
+		return nil, ErrInvitationNotFound
+	}
+	accepted, err := s.repo.AcceptInvitation(c, invitation, userId, survey.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	if !accepted {
+		return nil, ErrInvitationUsed
+	}
+	response := dto.Invitation{}
+	return &response, util.ConvertTypes(s.logger, invitation, &response)
+}
+
+func (s *InvitationService) invitingSurvey(c context.Context, surveyId uint) (*models.Survey, error) {
+	survey, err := s.repo.GetSurveyByID(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	if survey == nil {
+		return nil, ErrSurveyNotFound
+	}
+	if survey.IsTemplate {
+		return nil, ErrSurveyIsTemplate
+	}
+	if status := survey.CurrentStatus(time.Now()); status == models.SurveyStatusClosed || status == models.SurveyStatusArchived {
+		return nil, fmt.Errorf("%w: survey is %s", ErrSurveyNotOpen, status)
+	}
+	return survey, nil
+}
+
+// tokenInvitation returns the invitation a token was signed for, tokens of resent invitations are rejected.
+func (s *InvitationService) tokenInvitation(c context.Context, token string) (*models.Invitation, error) {
+	id, nonce, err := ParseInvitationToken(s.conf.JWT.SecretKey, token)
+	if err != nil {
+		return nil, err
+	}
+	invitation, err := s.repo.GetInvitationByID(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil || !hmac.Equal([]byte(invitation.Nonce), []byte(nonce)) {
+		return nil, ErrInvitationNotFound
+	}
+	return invitation, nil
+}
+
+// send mails an invitation and records whether it was sent or bounced, invitees with an account
+// are also notified in the app.
+func (s *InvitationService) send(c context.Context, survey *models.Survey, invitation *models.Invitation, message string) error {
+	token := SignInvitationToken(s.conf.JWT.SecretKey, invitation.ID, invitation.Nonce)
+	subject := fmt.Sprintf("You are invited to the survey %s", survey.Title)
+
+	now := time.Now()
+	err := s.mailer.Send(c, invitation.Email, subject, InvitationEmail(survey.Title, message, s.conf.Email.InvitationURL+token))
+	if err != nil {
+		s.logger.Error(logging.Internal, logging.Api, "error in sending invitation email", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		invitation.Status = models.InvitationBounced
+		invitation.BouncedAt = &now
+		invitation.Error = err.Error()
+	} else {
+		invitation.Status = models.InvitationSent
+		invitation.SentAt = &now
+		invitation.Error = ""
+	}
+	if err := s.repo.UpdateInvitation(c, invitation); err != nil {
+		return err
+	}
+
+	if invitation.UserID != nil {
+		_, err := s.notificationService.Notify(c, *invitation.UserID, fmt.Sprintf("you are invited to the survey with name: %s, accept the invitation with the token %s", survey.Title, token))
+		if err != nil {
+			s.logger.Error(logging.Internal, logging.FailedToSendNotify, "error in sending notify in invitation service", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		}
+	}
+	return nil
+}
+
+// InvitationEmails trims, lowercases and dedupes emails, it fails on the first one that is not an email address.
+func InvitationEmails(emails []string) ([]string, error) {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, email := range emails {
+		email = strings.ToLower(strings.TrimSpace(email))
+		at := strings.Index(email, "@")
+		if at < 1 || at != strings.LastIndex(email, "@") || at == len(email)-1 || strings.ContainsAny(email, " \r\n") {
+			return nil, fmt.Errorf("%w: %q is not an email address", ErrInvalidInvitation, email)
+		}
+		if !seen[email] {
+			seen[email] = true
+			normalized = append(normalized, email)
+		}
+	}
+	return normalized, nil
+}
+
+// InvitationEmail is the body of an invitation email.
+func InvitationEmail(title string, message string, link string) string {
+	body := fmt.Sprintf("Hello,\n\nYou are invited to take the survey %s.", title)
+	if message = strings.TrimSpace(message); message != "" {
+		body += "\n\n" + message
+	}
+	return body + "\n\nFollow this link to take part, it can only be used once:\n" + link
+}
+
+// SignInvitationToken returns the token of an invitation, it is signed with secret so the id and
+// nonce in it can not be forged.
+func SignInvitationToken(secret string, id uint, nonce string) string {
+	payload := fmt.Sprintf("%d.%s", id, nonce)
+	return payload + "." + tokenSignature(secret, payload)
+}
+
+// ParseInvitationToken returns the invitation id and nonce of a token signed with secret.
+func ParseInvitationToken(secret string, token string) (uint, string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, "", ErrInvitationNotFound
+	}
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(tokenSignature(secret, payload))) {
+		return 0, "", ErrInvitationNotFound
+	}
+	id, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", ErrInvitationNotFound
+	}
+	return uint(id), parts[1], nil
+}
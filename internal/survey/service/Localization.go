@@ -0,0 +1,137 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+)
+
+// SurveyLanguages returns the languages a survey is offered in besides its untranslated texts,
+// a language is offered once the survey title is translated to it.
+func SurveyLanguages(titleTranslations map[string]string) []string {
+	languages := []string{}
+	for language := range titleTranslations {
+		languages = append(languages, strings.ToLower(language))
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// NegotiateLanguage picks the language a participant sees. The preference of the participant
+// comes first, then the languages of an Accept-Language header by their quality. Regional
+// variants fall back to their base language, so fa-IR matches fa. An empty result means the
+// untranslated texts.
+func NegotiateLanguage(preference string, acceptLanguage string, available []string) string {
+	offered := map[string]bool{}
+	for _, language := range available {
+		offered[strings.ToLower(language)] = true
+	}
+	match := func(tag string) string {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if offered[tag] {
+			return tag
+		}
+		if base, _, found := strings.Cut(tag, "-"); found && offered[base] {
+			return base
+		}
+		return ""
+	}
+
+	if language := match(preference); language != "" {
+		return language
+	}
+	for _, tag := range acceptedLanguages(acceptLanguage) {
+		if language := match(tag); language != "" {
+			return language
+		}
+	}
+	return ""
+}
+
+// acceptedLanguages returns the tags of an Accept-Language header from the most to the least preferred.
+func acceptedLanguages(header string) []string {
+	type accepted struct {
+		tag     string
+		quality float64
+	}
+	list := []accepted{}
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if v, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = v
+			}
+		}
+		if quality > 0 {
+			list = append(list, accepted{tag: tag, quality: quality})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].quality > list[j].quality })
+
+	tags := []string{}
+	for _, v := range list {
+		tags = append(tags, v.tag)
+	}
+	return tags
+}
+
+// LocalizeQuestion returns a copy of q with its text, choices and validation message in language,
+// texts without a translation stay untranslated. Choice ids do not change, so votes on a localized
+// question are the same whatever the language.
+func LocalizeQuestion(q *dto.Question, language string) *dto.Question {
+	if q == nil || language == "" {
+		return q
+	}
+	localized := *q
+	localized.Text = translate(q.Text, q.TextTranslations, language)
+	localized.TextTranslations = nil
+	localized.Choices = []dto.Choice{}
+	for _, choice := range q.Choices {
+		choice.Text = translate(choice.Text, choice.TextTranslations, language)
+		choice.TextTranslations = nil
+		localized.Choices = append(localized.Choices, choice)
+	}
+	if rules := q.Config.Validation; rules != nil {
+		localizedRules := *rules
+		localizedRules.Message = translate(rules.Message, rules.MessageTranslations, language)
+		localizedRules.MessageTranslations = nil
+		localized.Config.Validation = &localizedRules
+	}
+	return &localized
+}
+
+// LocalizeAnswerError translates the custom message of a rejected answer.
+func LocalizeAnswerError(err error, q *dto.Question, language string) error {
+	var answerErr *AnswerError
+	rules := q.Config.Validation
+	if language == "" || rules == nil || rules.Message == "" || !errors.As(err, &answerErr) {
+		return err
+	}
+	return &AnswerError{Code: answerErr.Code, Message: translate(rules.Message, rules.MessageTranslations, language)}
+}
+
+func translate(text string, translations map[string]string, language string) string {
+	for key, value := range translations {
+		if strings.EqualFold(key, language) && value != "" {
+			return value
+		}
+	}
+	return text
+}
+
+// isTranslationOf reports whether answer is one of the translations of a text.
+func isTranslationOf(answer string, translations map[string]string) bool {
+	for _, value := range translations {
+		if value != "" && value == answer {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,482 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"golang.org/x/net/context"
+)
+
+// The codes of the errors of a participation step, rejected answers have the codes of AnswerErrorCode.
+const (
+	StepWrongQuestion    = "invalid_question_id"
+	StepFirstQuestion    = "first_question"
+	StepFirstPage        = "first_page"
+	StepReturnNotAllowed = "return_not_allowed"
+	StepNothingToAnswer  = "nothing_to_answer"
+	StepNotFinished      = "not_finished"
+	StepTimeLimitReached = "time_limit_reached"
+	StepInternalError    = "internal_error"
+)
+
+// ParticipationStore keeps the answers and question times of a participation, ISurveyService is one.
+type ParticipationStore interface {
+	CommitVote(c context.Context, vote models.Vote) error
+	CommitVotes(c context.Context, respondent models.Respondent, questionId uint, votes []models.Vote) error
+	CommitResponse(c context.Context, votes []models.Vote) error
+	CheckAnswerQuotas(c context.Context, participationId uint, questionId uint, answers []string) error
+	RecordQuestionTime(c context.Context, participationId uint, questionId uint, spent time.Duration, timedOut bool) error
+	CommitParticipation(c context.Context, participationId uint, asked []uint) (*dto.QuizResult, error)
+}
+
+// ParticipationEngine walks a participant through a participation, a question or a page at a time.
+// It holds the rules every way of answering a survey shares: branching, question time limits,
+// going back and answer validation, while the transport only carries its messages.
+type ParticipationEngine struct {
+	store         ParticipationStore
+	logger        logging.Logger
+	participation dto.UserSurveyParticipationResponse
+	respondent    models.Respondent
+	flow          *BranchEngine
+	timer         *AnswerTimer
+	pageMode      bool
+	allowReturn   bool
+	// pages are the questions or pages that were answered or ran out of time, page is the one on
+	// screen and is empty once every question was asked
+	pages [][]*dto.Question
+	page  []*dto.Question
+	// pending are the votes of an anonymous response by question, they are only stored on commit
+	// and a response that is never committed leaves no votes
+	pending map[uint][]models.Vote
+}
+
+func NewParticipationEngine(store ParticipationStore, logger logging.Logger, participation dto.UserSurveyParticipationResponse, respondent models.Respondent, flow *BranchEngine, pageMode bool, allowReturn bool, now func() time.Time) *ParticipationEngine {
+	return &ParticipationEngine{
+		store:         store,
+		logger:        logger,
+		participation: participation,
+		respondent:    respondent,
+		flow:          flow,
+		timer:         NewAnswerTimer(now),
+		pageMode:      pageMode,
+		allowReturn:   allowReturn,
+		pages:         [][]*dto.Question{},
+		pending:       map[uint][]models.Vote{},
+	}
+}
+
+// Start shows the first question or page of the survey.
+func (e *ParticipationEngine) Start() {
+	e.show(e.flow.Next(nil, nil))
+}
+
+// Restore picks up a participation where progress left off, elapsed is the time that passed on the
+// question or page on screen since the progress was stored. The answers given so far must be set on
+// the flow and the progress must fit it, see ProgressFits.
+func (e *ParticipationEngine) Restore(progress dto.ParticipationProgress, elapsed time.Duration) {
+	if e.pageMode {
+		for _, ids := range progress.Pages {
+			e.pages = append(e.pages, e.flow.Questions(ids))
+		}
+		e.page = e.flow.Questions(progress.Page)
+	} else {
+		asked := e.flow.Questions(progress.Asked)
+		if !progress.Finished {
+			e.page = asked[len(asked)-1:]
+			asked = asked[:len(asked)-1]
+		}
+		for _, q := range asked {
+			e.pages = append(e.pages, []*dto.Question{q})
+		}
+	}
+	if progress.Finished {
+		e.page = nil
+	}
+
+	spent := ProgressSpent(progress)
+	for _, q := range e.page {
+		spent[q.ID] += elapsed / time.Duration(len(e.page))
+	}
+	e.timer.Restore(spent)
+	if len(e.page) > 0 {
+		e.timer.Show(e.page...)
+	}
+}
+
+// Pending returns the votes of an anonymous response to the questions asked so far, they are stored
+// when the response is committed.
+func (e *ParticipationEngine) Pending() []dto.PendingVote {
+	pending := []dto.PendingVote{}
+	for _, q := range e.Asked() {
+		for _, vote := range e.pending[q.ID] {
+			pending = append(pending, dto.PendingVote{QuestionID: vote.QuestionID, ChoiceID: vote.ChoiceID, Answer: vote.Answer, IsCorrect: vote.IsCorrect})
+		}
+	}
+	return pending
+}
+
+// RestorePending picks up the votes of an anonymous response that Pending returned before, their
+// answers must be set on the flow too.
+func (e *ParticipationEngine) RestorePending(votes []dto.PendingVote) {
+	for _, v := range votes {
+		vote := models.Vote{VoterID: e.respondent.UserID, GuestID: e.respondent.GuestID, ResponseID: e.respondent.ResponseID, QuestionID: v.QuestionID, ChoiceID: v.ChoiceID, SurveyVersion: e.participation.SurveyVersion, Answer: v.Answer, IsCorrect: v.IsCorrect}
+		e.pending[v.QuestionID] = append(e.pending[v.QuestionID], vote)
+	}
+}
+
+func (e *ParticipationEngine) ParticipationID() uint {
+	return e.participation.ID
+}
+
+func (e *ParticipationEngine) Respondent() models.Respondent {
+	return e.respondent
+}
+
+// PageMode tells whether the survey is answered a page at a time.
+func (e *ParticipationEngine) PageMode() bool {
+	return e.pageMode
+}
+
+// Timer times the questions on screen.
+func (e *ParticipationEngine) Timer() *AnswerTimer {
+	return e.timer
+}
+
+// Finished tells whether every question was asked, the participation can be committed then.
+func (e *ParticipationEngine) Finished() bool {
+	return len(e.page) == 0
+}
+
+// Expired tells whether the time of the questions on screen ran out.
+func (e *ParticipationEngine) Expired() bool {
+	remaining, ok := e.timer.Remaining()
+	return ok && !e.Finished() && remaining <= 0
+}
+
+// Asked returns the questions that were answered or ran out of time, in the order they were asked.
+func (e *ParticipationEngine) Asked() []*dto.Question {
+	asked := []*dto.Question{}
+	for _, page := range e.pages {
+		asked = append(asked, page...)
+	}
+	return asked
+}
+
+// Progress is where the participation got to, timeLeft is the answer time the participant has left.
+func (e *ParticipationEngine) Progress(timeLeft time.Duration) dto.ParticipationProgress {
+	if e.pageMode {
+		return PageProgress(e.pages, e.page, e.timer, timeLeft)
+	}
+	progress := QuestionProgress(append(e.Asked(), e.page...), e.timer, timeLeft)
+	progress.Finished = e.Finished()
+	return progress
+}
+
+// Response is a message of question mode, with the question on screen as the participant sees it.
+func (e *ParticipationEngine) Response(message string, code string) dto.VoteResponse {
+	var q *dto.Question
+	if !e.Finished() {
+		q = e.flow.Render(e.page[0], e.participation.Language)
+	}
+	return dto.VoteResponse{Question: q, Message: message, Code: code, TimeLeft: e.timer.RemainingSeconds()}
+}
+
+// PageResponse is a message of page mode, with the page on screen as the participant sees it.
+func (e *ParticipationEngine) PageResponse(message string, errors []dto.PageError) dto.PageResponse {
+	if e.Finished() {
+		return dto.PageResponse{Message: message, Errors: errors}
+	}
+	return dto.PageResponse{Section: e.flow.Section(e.page[0]), Questions: e.flow.RenderPage(e.page, e.participation.Language), Message: message, Errors: errors, TimeLeft: e.timer.RemainingSeconds()}
+}
+
+// Status tells how far the participant got, the questions still to come are counted along the
+// path of the answers given so far.
+func (e *ParticipationEngine) Status() dto.ProgressStatus {
+	asked := e.Asked()
+	status := dto.ProgressStatus{Answered: len(asked), EstimatedTotal: len(asked)}
+	if e.Finished() {
+		return status
+	}
+	status.Current = len(asked) + 1
+	seen := append(asked, e.page...)
+	next := e.flow.NextAfterPage(e.page, seen)
+	for next != nil {
+		seen = append(seen, next)
+		next = e.flow.Next(next, seen)
+	}
+	status.EstimatedTotal = len(seen)
+	return status
+}
+
+// Expire leaves the questions on screen unanswered because their time ran out and moves on, an
+// answer given before going back is dropped. It returns the questions that ran out of time.
+func (e *ParticipationEngine) Expire(c context.Context) []*dto.Question {
+	expired := e.page
+	if e.Finished() {
+		return expired
+	}
+	for _, q := range expired {
+		// the question is left unanswered either way, saveAnswer logs an earlier answer it could not drop
+		e.saveAnswer(c, q, nil, nil)
+	}
+	e.recordTimes(c, true, expired...)
+	e.advance()
+	return expired
+}
+
+// Back returns to the previous question or page. In question mode the answer of the question left
+// is dropped, in page mode the answers of the page returned to are.
+func (e *ParticipationEngine) Back() error {
+	if !e.allowReturn {
+		return ErrReturnNotAllowed
+	}
+	if len(e.pages) == 0 {
+		if e.pageMode {
+			return ErrFirstPage
+		}
+		return ErrFirstQuestion
+	}
+	if !e.pageMode && !e.Finished() {
+		e.flow.ClearAnswer(e.page[0].ID)
+	}
+	e.page, e.pages = e.pages[len(e.pages)-1], e.pages[:len(e.pages)-1]
+	if e.pageMode {
+		for _, q := range e.page {
+			e.flow.ClearAnswer(q.ID)
+		}
+	}
+	e.timer.Show(e.page...)
+	return nil
+}
+
+// Answer takes the answer to the question on screen in question mode and moves on to the next
+// question. A rejected answer wraps ErrInvalidAnswer and is in the language of the participation,
+// ErrQuotaFull tells that the answer screened the participant out and ended the participation.
+func (e *ParticipationEngine) Answer(c context.Context, req dto.VoteRequest) error {
+	if e.Finished() {
+		return ErrNothingToAnswer
+	}
+	q := e.page[0]
+	if req.QuestionId != q.ID {
+		return ErrWrongQuestion
+	}
+	votes, answers, err := e.checkAnswer(q, req)
+	if err != nil {
+		return LocalizeAnswerError(err, q, e.participation.Language)
+	}
+	if err := e.saveAnswer(c, q, votes, answers); err != nil {
+		return err
+	}
+	e.recordTimes(c, false, q)
+	if e.screenedOut(c, q, answers) {
+		return ErrQuotaFull
+	}
+	e.advance()
+	return nil
+}
+
+// AnswerPage takes the answers to the page on screen in page mode and moves on to the next page.
+// A page is only taken when all of its answers are valid, otherwise the errors by question are
+// returned. ErrQuotaFull tells that an answer screened the participant out.
+func (e *ParticipationEngine) AnswerPage(c context.Context, req dto.PageVoteRequest) ([]dto.PageError, error) {
+	if e.Finished() {
+		return nil, ErrNothingToAnswer
+	}
+	byQuestion := map[uint]dto.VoteRequest{}
+	pageErrors := []dto.PageError{}
+	for _, answer := range req.Answers {
+		byQuestion[answer.QuestionId] = answer
+	}
+	onPage := map[uint]bool{}
+	for _, q := range e.page {
+		onPage[q.ID] = true
+	}
+	for id := range byQuestion {
+		if !onPage[id] {
+			pageErrors = append(pageErrors, dto.PageError{QuestionID: id, Message: "question is not on this page", Code: AnswerInvalid})
+		}
+	}
+
+	votes := map[uint][]models.Vote{}
+	answers := map[uint][]string{}
+	for _, q := range e.page {
+		// questions left out of the message are unanswered, which only optional questions allow
+		var err error
+		votes[q.ID], answers[q.ID], err = e.checkAnswer(q, byQuestion[q.ID])
+		if err != nil {
+			err = LocalizeAnswerError(err, q, e.participation.Language)
+			pageErrors = append(pageErrors, dto.PageError{QuestionID: q.ID, Message: err.Error(), Code: AnswerErrorCode(err)})
+		}
+	}
+	if len(pageErrors) > 0 {
+		return pageErrors, nil
+	}
+
+	for _, q := range e.page {
+		if err := e.saveAnswer(c, q, votes[q.ID], answers[q.ID]); err != nil {
+			return nil, err
+		}
+	}
+	e.recordTimes(c, false, e.page...)
+	for _, q := range e.page {
+		if e.screenedOut(c, q, answers[q.ID]) {
+			return nil, ErrQuotaFull
+		}
+	}
+	e.advance()
+	return nil, nil
+}
+
+// Commit commits a participation that asked every question.
+func (e *ParticipationEngine) Commit(c context.Context) (*dto.QuizResult, error) {
+	if !e.Finished() {
+		return nil, ErrNotFinished
+	}
+	if e.respondent.IsAnonymous() {
+		votes := []models.Vote{}
+		for _, q := range e.Asked() {
+			votes = append(votes, e.pending[q.ID]...)
+		}
+		if err := e.store.CommitResponse(c, votes); err != nil {
+			return nil, err
+		}
+	}
+	return e.store.CommitParticipation(c, e.participation.ID, dto.QuestionList(e.Asked()).GetIds())
+}
+
+func (e *ParticipationEngine) show(first *dto.Question) {
+	if first == nil {
+		e.page = nil
+		return
+	}
+	if e.pageMode {
+		e.page = e.flow.Page(first, e.Asked())
+	} else {
+		e.page = []*dto.Question{first}
+	}
+	e.timer.Show(e.page...)
+}
+
+// advance moves past the questions on screen, a page jumps by the first of its questions with a
+// matching branch rule.
+func (e *ParticipationEngine) advance() {
+	done := e.page
+	e.pages = append(e.pages, done)
+	if e.pageMode {
+		e.show(e.flow.NextAfterPage(done, e.Asked()))
+		return
+	}
+	e.show(e.flow.Next(done[0], e.Asked()))
+}
+
+// checkAnswer validates a vote on q and returns the votes to store and the answers the branch rules see.
+// A skipped optional question has no votes.
+func (e *ParticipationEngine) checkAnswer(q *dto.Question, req dto.VoteRequest) ([]models.Vote, []string, error) {
+	q = e.flow.Resolve(q)
+	respondent := e.respondent
+	surveyVersion := e.participation.SurveyVersion
+	skip, err := SkipsAnswer(q, req)
+	if err != nil || skip {
+		return []models.Vote{}, []string{}, err
+	}
+	if len(q.Choices) > 0 && q.HasMultipleChoice {
+		selected, err := SelectChoices(q, req)
+		if err != nil {
+			return nil, nil, err
+		}
+		votes := []models.Vote{}
+		answers := []string{}
+		for _, v := range selected {
+			votes = append(votes, models.Vote{VoterID: respondent.UserID, GuestID: respondent.GuestID, ResponseID: respondent.ResponseID, QuestionID: q.ID, ChoiceID: v.ID, SurveyVersion: surveyVersion, Answer: v.Text, IsCorrect: v.IsCorrect})
+			answers = append(answers, v.Text)
+		}
+		return votes, answers, nil
+	}
+
+	answer, err := NormalizeAnswer(q, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	return []models.Vote{{VoterID: respondent.UserID, GuestID: respondent.GuestID, ResponseID: respondent.ResponseID, QuestionID: q.ID, SurveyVersion: surveyVersion, Answer: answer}}, []string{answer}, nil
+}
+
+// saveAnswer stores the votes on q and lets the branch rules see its answers, an answer that could
+// not be stored is not taken and returns ErrAnswerNotSaved.
+func (e *ParticipationEngine) saveAnswer(c context.Context, q *dto.Question, votes []models.Vote, answers []string) error {
+	var err error
+	switch {
+	case e.respondent.IsAnonymous():
+		// votes stored one by one could be matched with the steps of the participation
+		e.pending[q.ID] = votes
+	case len(votes) == 0 || len(q.Choices) > 0 && q.HasMultipleChoice:
+		// a skipped question drops the answer it may have had before going back
+		err = e.store.CommitVotes(c, e.respondent, q.ID, votes)
+	default:
+		err = e.store.CommitVote(c, votes[0])
+	}
+	if err != nil {
+		e.logger.Error(logging.Internal, logging.Api, "error in saving answer", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return ErrAnswerNotSaved
+	}
+	if len(votes) == 0 {
+		e.flow.ClearAnswer(q.ID)
+		return nil
+	}
+	e.flow.SetAnswer(q.ID, answers)
+	return nil
+}
+
+// recordTimes stores the time spent on questions that were answered or ran out of time. The path
+// through an anonymous survey tells its answers, so no times are stored for it.
+func (e *ParticipationEngine) recordTimes(c context.Context, timedOut bool, questions ...*dto.Question) {
+	if e.respondent.IsAnonymous() {
+		return
+	}
+	for _, q := range questions {
+		err := e.store.RecordQuestionTime(c, e.participation.ID, q.ID, e.timer.Spent(q.ID), timedOut)
+		if err != nil {
+			e.logger.Error(logging.Internal, logging.Api, "error in recording question time", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		}
+	}
+}
+
+// screenedOut checks the quotas of a screening question against its answers, it reports whether
+// the quota of the participant is full and the participation was ended.
+func (e *ParticipationEngine) screenedOut(c context.Context, q *dto.Question, answers []string) bool {
+	err := e.store.CheckAnswerQuotas(c, e.participation.ID, q.ID, answers)
+	if errors.Is(err, ErrQuotaFull) {
+		return true
+	}
+	if err != nil {
+		e.logger.Error(logging.Internal, logging.Api, "error in checking answer quotas", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return false
+}
+
+// StepErrorCode returns the code of an error of a participation step.
+func StepErrorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidAnswer):
+		return AnswerErrorCode(err)
+	case errors.Is(err, ErrWrongQuestion):
+		return StepWrongQuestion
+	case errors.Is(err, ErrFirstQuestion):
+		return StepFirstQuestion
+	case errors.Is(err, ErrFirstPage):
+		return StepFirstPage
+	case errors.Is(err, ErrReturnNotAllowed):
+		return StepReturnNotAllowed
+	case errors.Is(err, ErrNothingToAnswer):
+		return StepNothingToAnswer
+	case errors.Is(err, ErrNotFinished):
+		return StepNotFinished
+	case errors.Is(err, ErrTimeLimitReached):
+		return StepTimeLimitReached
+	case errors.Is(err, ErrQuotaFull):
+		return ParticipationScreenedOut
+	}
+	return StepInternalError
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+)
+
+// pipingReference matches {{q3}}, the answer to question 3 piped into a text.
+var pipingReference = regexp.MustCompile(`\{\{\s*q(\d+)\s*\}\}`)
+
+// PipingReferences returns the questions whose answers q pipes into its texts or choices, in any
+// of its languages, sorted by id.
+func PipingReferences(q *dto.Question) []uint {
+	seen := map[uint]bool{}
+	collect := func(text string) {
+		for _, match := range pipingReference.FindAllStringSubmatch(text, -1) {
+			if id, err := strconv.ParseUint(match[1], 10, 64); err == nil {
+				seen[uint(id)] = true
+			}
+		}
+	}
+	texts := []string{q.Text}
+	for _, text := range q.TextTranslations {
+		texts = append(texts, text)
+	}
+	for _, choice := range q.Choices {
+		texts = append(texts, choice.Text)
+		for _, text := range choice.TextTranslations {
+			texts = append(texts, text)
+		}
+	}
+	for _, text := range texts {
+		collect(text)
+	}
+	if q.Config.ChoicesFrom != 0 {
+		seen[q.Config.ChoicesFrom] = true
+	}
+
+	ids := []uint{}
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// ValidatePiping returns every problem of the piping of questions in the order they are asked.
+// A question can only pipe answers of questions asked before it, which is only known for
+// sequential surveys, and only take its choices from an earlier multiple choice question.
+func ValidatePiping(questions []*dto.Question, sequential bool) []string {
+	problems := []string{}
+	position := map[uint]int{}
+	for i, q := range questions {
+		position[q.ID] = i
+	}
+
+	for i, q := range questions {
+		references := PipingReferences(q)
+		if len(references) == 0 {
+			continue
+		}
+		name := fmt.Sprintf("question %d", q.ID)
+		if q.ID == 0 {
+			name = "the new question"
+		}
+		if !sequential {
+			problems = append(problems, fmt.Sprintf("%s: answers can only be piped in sequential surveys", name))
+			continue
+		}
+		for _, id := range references {
+			at, exists := position[id]
+			switch {
+			case !exists:
+				problems = append(problems, fmt.Sprintf("%s: refers to question %d which does not exist in this survey", name, id))
+			case at >= i:
+				problems = append(problems, fmt.Sprintf("%s: refers to question %d which is not asked before it", name, id))
+			}
+		}
+		if from := q.Config.ChoicesFrom; from != 0 {
+			if at, exists := position[from]; exists && at < i && questions[at].QuestionType() != dto.MultipleChoiceQuestion {
+				problems = append(problems, fmt.Sprintf("%s: choices can only come from a multiple choice question", name))
+			}
+		}
+	}
+	return problems
+}
+
+// RemapPiping rewrites the question references of a text, references missing from ids are kept.
+func RemapPiping(text string, ids map[uint]uint) string {
+	return pipeText(text, func(id uint) (string, bool) {
+		mapped, ok := ids[id]
+		return "{{q" + strconv.Itoa(int(mapped)) + "}}", ok
+	})
+}
+
+// pipeText replaces every reference of text that value resolves.
+func pipeText(text string, value func(id uint) (string, bool)) string {
+	return pipingReference.ReplaceAllStringFunc(text, func(reference string) string {
+		match := pipingReference.FindStringSubmatch(reference)
+		id, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return reference
+		}
+		if v, ok := value(uint(id)); ok {
+			return v
+		}
+		return reference
+	})
+}
+
+func remapTranslations(translations map[string]string, ids map[uint]uint) map[string]string {
+	if translations == nil {
+		return nil
+	}
+	remapped := map[string]string{}
+	for language, text := range translations {
+		remapped[language] = RemapPiping(text, ids)
+	}
+	return remapped
+}
+
+// joinAnswers formats the answers of a question for piping.
+func joinAnswers(values []string) string {
+	return strings.Join(values, ", ")
+}
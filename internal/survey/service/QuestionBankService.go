@@ -0,0 +1,174 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/repository"
+	"github.com/G9QBootcamp/qoli-survey/internal/util"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"golang.org/x/net/context"
+)
+
+type IQuestionBankService interface {
+	GetBankQuestions(c context.Context, userId uint, req dto.BankQuestionsGetRequest) ([]*dto.BankQuestion, error)
+	GetBankQuestion(c context.Context, userId uint, id uint) (*dto.BankQuestion, error)
+	CreateBankQuestion(c context.Context, userId uint, req dto.BankQuestionCreateRequest) (*dto.BankQuestion, error)
+	UpdateBankQuestion(c context.Context, userId uint, id uint, req dto.BankQuestionUpdateRequest) (*dto.BankQuestion, error)
+	DeleteBankQuestion(c context.Context, userId uint, id uint) error
+	GetBankQuestionReport(c context.Context, userId uint, id uint, allSurveys bool) (*dto.BankQuestionReport, error)
+}
+
+type QuestionBankService struct {
+	conf          *config.Config
+	repo          repository.ISurveyRepository
+	logger        logging.Logger
+	reportService IReportService
+}
+
+func NewQuestionBankService(conf *config.Config, repo repository.ISurveyRepository, reportRepo repository.IReportRepository, logger logging.Logger) *QuestionBankService {
+	return &QuestionBankService{conf: conf, repo: repo, logger: logger, reportService: NewReportService(conf, reportRepo, logger)}
+}
+
+func (s *QuestionBankService) GetBankQuestions(c context.Context, userId uint, req dto.BankQuestionsGetRequest) ([]*dto.BankQuestion, error) {
+	limit := 10
+	offset := 0
+	if req.Page > 0 {
+		offset = limit * (req.Page - 1)
+	}
+
+	filter := dto.BankQuestionsFilter{UserID: userId, Search: req.Search, Tag: strings.ToLower(strings.TrimSpace(req.Tag)), Limit: uint(limit), Offset: uint(offset)}
+	switch req.Scope {
+	case "":
+	case "mine":
+		filter.OnlyOwn = true
+	case "shared":
+		filter.OnlyShared = true
+	default:
+		return []*dto.BankQuestion{}, fmt.Errorf("%w: unknown scope %s", ErrInvalidBankQuery, req.Scope)
+	}
+
+	questions, err := s.repo.GetBankQuestions(c, &filter)
+	if err != nil {
+		return []*dto.BankQuestion{}, err
+	}
+	response := []*dto.BankQuestion{}
+	return response, util.ConvertTypes(s.logger, questions, &response)
+}
+
+// GetBankQuestion returns a bank question the user owns or that is shared, nil otherwise.
+func (s *QuestionBankService) GetBankQuestion(c context.Context, userId uint, id uint) (*dto.BankQuestion, error) {
+	question, err := s.repo.GetBankQuestionByID(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if question == nil || (question.OwnerID != userId && !question.Shared) {
+		return nil, nil
+	}
+	response := dto.BankQuestion{}
+	return &response, util.ConvertTypes(s.logger, question, &response)
+}
+
+func (s *QuestionBankService) CreateBankQuestion(c context.Context, userId uint, req dto.BankQuestionCreateRequest) (*dto.BankQuestion, error) {
+	question := models.BankQuestion{OwnerID: userId}
+	if err := s.fill(&question, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateBankQuestion(c, &question); err != nil {
+		return nil, err
+	}
+	response := dto.BankQuestion{}
+	return &response, util.ConvertTypes(s.logger, question, &response)
+}
+
+// UpdateBankQuestion replaces the content of a bank question, surveys keep the copy they were created with.
+func (s *QuestionBankService) UpdateBankQuestion(c context.Context, userId uint, id uint, req dto.BankQuestionUpdateRequest) (*dto.BankQuestion, error) {
+	question, err := s.ownedBankQuestion(c, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fill(question, dto.BankQuestionCreateRequest(req)); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateBankQuestion(c, question); err != nil {
+		return nil, err
+	}
+	response := dto.BankQuestion{}
+	return &response, util.ConvertTypes(s.logger, question, &response)
+}
+
+func (s *QuestionBankService) DeleteBankQuestion(c context.Context, userId uint, id uint) error {
+	if _, err := s.ownedBankQuestion(c, userId, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteBankQuestion(c, id)
+}
+
+func (s *QuestionBankService) GetBankQuestionReport(c context.Context, userId uint, id uint, allSurveys bool) (*dto.BankQuestionReport, error) {
+	question, err := s.GetBankQuestion(c, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	if question == nil {
+		return nil, ErrBankQuestionNotFound
+	}
+	return s.reportService.GetBankQuestionReport(c, question, userId, allSurveys)
+}
+
+func (s *QuestionBankService) ownedBankQuestion(c context.Context, userId uint, id uint) (*models.BankQuestion, error) {
+	question, err := s.repo.GetBankQuestionByID(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if question == nil || (question.OwnerID != userId && !question.Shared) {
+		return nil, ErrBankQuestionNotFound
+	}
+	if question.OwnerID != userId {
+		return nil, ErrBankQuestionNotOwned
+	}
+	return question, nil
+}
+
+// fill validates a bank question request like a survey question and copies it into question.
+func (s *QuestionBankService) fill(question *models.BankQuestion, req dto.BankQuestionCreateRequest) error {
+	questionType, config, err := ResolveQuestionType(req.Type, req.HasMultipleChoice, req.Config)
+	if err != nil {
+		return err
+	}
+	if questionType != dto.MultipleChoiceQuestion && len(req.Choices) > 0 {
+		return fmt.Errorf("%w: only multiple_choice questions can have choices", ErrInvalidQuestionConfig)
+	}
+
+	question.Text = req.Text
+	question.Type = string(questionType)
+	question.HasMultipleChoice = questionType == dto.MultipleChoiceQuestion
+	question.MediaUrl = req.MediaUrl
+	question.Shared = req.Shared
+	if err := util.ConvertTypes(s.logger, config, &question.Config); err != nil {
+		return err
+	}
+
+	question.Tags = models.Tags{}
+	seenTags := map[string]bool{}
+	for _, tag := range req.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seenTags[tag] {
+			seenTags[tag] = true
+			question.Tags = append(question.Tags, tag)
+		}
+	}
+
+	question.Choices = []models.BankChoice{}
+	seenChoices := map[string]bool{}
+	for _, choice := range req.Choices {
+		if seenChoices[strings.ToLower(choice.Text)] {
+			return fmt.Errorf("%w: choice %s is repeated", ErrInvalidQuestionConfig, choice.Text)
+		}
+		seenChoices[strings.ToLower(choice.Text)] = true
+		question.Choices = append(question.Choices, models.BankChoice{Text: choice.Text, IsCorrect: choice.IsCorrect, Anchored: choice.Anchored, Points: choice.Points})
+	}
+	return nil
+}
@@ -1,7 +1,11 @@
 package service
 
 import (
+	"fmt"
+	"reflect"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/G9QBootcamp/qoli-survey/internal/config"
 	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
@@ -17,16 +21,19 @@ type IQuestionService interface {
 	GetQuestions(c context.Context, req dto.GetQuestionsRequest) (response []*dto.Question, err error)
 	UpdateQuestion(c context.Context, id uint, req dto.QuestionUpdateRequest) (question *dto.Question, err error)
 	DeleteQuestion(c context.Context, id uint) error
+	AddQuestion(c context.Context, userId uint, surveyId uint, req dto.QuestionAddRequest) (*dto.Question, error)
+	ReorderQuestions(c context.Context, surveyId uint, req dto.QuestionsReorderRequest) (dto.QuestionList, error)
 }
 
 type QuestionService struct {
-	conf   *config.Config
-	repo   repository.ISurveyRepository
-	logger logging.Logger
+	conf           *config.Config
+	repo           repository.ISurveyRepository
+	logger         logging.Logger
+	versionService IVersionService
 }
 
 func NewQuestionService(conf *config.Config, repo repository.ISurveyRepository, logger logging.Logger) *QuestionService {
-	return &QuestionService{conf: conf, repo: repo, logger: logger}
+	return &QuestionService{conf: conf, repo: repo, logger: logger, versionService: NewVersionService(conf, repo, logger)}
 }
 
 func (q *QuestionService) GetQuestions(c context.Context, req dto.GetQuestionsRequest) (response []*dto.Question, err error) {
@@ -34,13 +41,192 @@ func (q *QuestionService) GetQuestions(c context.Context, req dto.GetQuestionsRe
 	Filters := []*dto.RepositoryFilter{}
 	Filter := dto.RepositoryFilter{Field: "survey_id", Operator: "=", Value: strconv.Itoa(int(req.SurveyId))}
 	Filters = append(Filters, &Filter)
-	questions, err := q.repo.GetQuestions(c, &dto.RepositoryRequest{Filters: Filters, With: "Choices"})
+	questions, err := q.repo.GetQuestions(c, &dto.RepositoryRequest{
+		Filters: Filters,
+		Sorts:   []*dto.RepositorySort{{Field: "\"order\"", SortType: "asc"}, {Field: "id", SortType: "asc"}},
+		With:    "Choices",
+	})
 	if err != nil {
 		return []*dto.Question{}, err
 	}
 
 	return response, util.ConvertTypes(q.logger, questions, &response)
 }
+
+// AddQuestion adds a question to a survey that can still be edited, at the requested position.
+// Like questions of a new survey it can come from the question bank and be linked to a choice
+// of an earlier question with a condition.
+func (q *QuestionService) AddQuestion(c context.Context, userId uint, surveyId uint, req dto.QuestionAddRequest) (*dto.Question, error) {
+	survey, err := q.editableSurvey(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyBankQuestion(c, q.repo, q.logger, userId, &req.QuestionCreateRequest); err != nil {
+		return nil, err
+	}
+	if req.Text == "" {
+		return nil, fmt.Errorf("%w: text is required", ErrInvalidQuestionConfig)
+	}
+	questionType, config, err := ResolveQuestionType(req.Type, req.HasMultipleChoice, req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	sectionId := req.SectionID
+	if sectionId == 0 && req.Section != "" {
+		sections, err := q.repo.GetSections(c, surveyId)
+		if err != nil {
+			return nil, err
+		}
+		for _, section := range sections {
+			if section.Title == req.Section {
+				sectionId = section.ID
+			}
+		}
+		if sectionId == 0 {
+			return nil, fmt.Errorf("%w: section '%s' not found", ErrInvalidSection, req.Section)
+		}
+	} else if sectionId != 0 {
+		section, err := q.repo.GetSectionByID(c, sectionId)
+		if err != nil {
+			return nil, err
+		}
+		if section == nil || section.SurveyID != surveyId {
+			return nil, ErrInvalidSection
+		}
+	}
+
+	// the question of the condition must already exist with the answer as one of its choices
+	var parentId uint
+	if req.Condition.QuestionText != "" && req.Condition.Answer != "" {
+		questions, err := q.getQuestions(c, surveyId)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range questions {
+			for _, choice := range v.Choices {
+				if v.Text == req.Condition.QuestionText && choice.Text == req.Condition.Answer {
+					parentId = v.ID
+				}
+			}
+		}
+		if parentId == 0 {
+			return nil, fmt.Errorf("%w: condition answer '%s' of question '%s' not found", ErrInvalidQuestionConfig, req.Condition.Answer, req.Condition.QuestionText)
+		}
+	}
+
+	question := models.Question{
+		SurveyID:          surveyId,
+		Text:              req.Text,
+		TextTranslations:  req.TextTranslations,
+		Type:              string(questionType),
+		HasMultipleChoice: questionType == dto.MultipleChoiceQuestion,
+		MediaUrl:          req.MediaUrl,
+		SectionID:         sectionId,
+		BankQuestionID:    req.BankQuestionID,
+	}
+	if err := util.ConvertTypes(q.logger, config, &question.Config); err != nil {
+		return nil, err
+	}
+	if question.HasMultipleChoice {
+		for _, choiceReq := range req.Choices {
+			question.Choices = append(question.Choices, models.Choice{Text: choiceReq.Text, TextTranslations: choiceReq.TextTranslations, IsCorrect: choiceReq.IsCorrect, Anchored: choiceReq.Anchored, Points: choiceReq.Points})
+		}
+	}
+	added := dto.Question{}
+	if err := util.ConvertTypes(q.logger, question, &added); err != nil {
+		return nil, err
+	}
+	if err := q.validatePiping(c, survey, func(list dto.QuestionList) dto.QuestionList {
+		inserted, _ := util.InsertAt(list, &added, req.Position)
+		return inserted
+	}); err != nil {
+		return nil, err
+	}
+	if err := q.repo.InsertQuestion(c, &question, req.Position); err != nil {
+		return nil, err
+	}
+
+	if parentId != 0 {
+		choice, err := q.repo.GetChoiceByTextAndQuestion(c, req.Condition.Answer, parentId)
+		if err != nil {
+			return nil, err
+		}
+		choice.LinkedQuestionID = question.ID
+		if err := q.repo.UpdateChoice(c, choice); err != nil {
+			return nil, err
+		}
+	}
+
+	if survey.Status != models.SurveyStatusDraft {
+		if _, err := q.versionService.PublishVersion(c, survey.ID); err != nil {
+			return nil, err
+		}
+	}
+	return q.GetQuestion(c, question.ID)
+}
+
+// ReorderQuestions stores a new order for all questions of a survey at once. The order is
+// rejected when the branch rules of the survey would no longer be valid with it, questions
+// linked from a choice keep following their choice whatever their position is.
+func (q *QuestionService) ReorderQuestions(c context.Context, surveyId uint, req dto.QuestionsReorderRequest) (dto.QuestionList, error) {
+	survey, err := q.editableSurvey(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	questions, err := q.getQuestions(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+
+	byId := questions.ToMap()
+	reordered := dto.QuestionList{}
+	for _, id := range req.QuestionIDs {
+		question, ok := byId[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: question %d is missing or listed twice", ErrInvalidQuestionOrder, id)
+		}
+		reordered = append(reordered, question)
+		delete(byId, id)
+	}
+	if len(byId) > 0 {
+		return nil, fmt.Errorf("%w: %d questions are not listed", ErrInvalidQuestionOrder, len(byId))
+	}
+
+	sections, err := q.repo.GetSections(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	sectionList := []*dto.Section{}
+	if err := util.ConvertTypes(q.logger, sections, &sectionList); err != nil {
+		return nil, err
+	}
+	rules, err := q.repo.GetBranchRules(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	ruleList := []dto.BranchRule{}
+	if err := util.ConvertTypes(q.logger, rules, &ruleList); err != nil {
+		return nil, err
+	}
+	reordered = orderBySection(reordered, sectionList)
+	if problems := ValidateBranchRules(reordered, ruleList, survey.IsSequential); len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidBranchRule, strings.Join(problems, "; "))
+	}
+	if problems := ValidatePiping(reordered, survey.IsSequential); len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPiping, strings.Join(problems, "; "))
+	}
+
+	if err := q.repo.ReorderQuestions(c, surveyId, req.QuestionIDs); err != nil {
+		return nil, err
+	}
+	if survey.Status != models.SurveyStatusDraft {
+		if _, err := q.versionService.PublishVersion(c, survey.ID); err != nil {
+			return nil, err
+		}
+	}
+	return reordered, nil
+}
 func (q *QuestionService) UpdateQuestion(c context.Context, id uint, req dto.QuestionUpdateRequest) (question *dto.Question, err error) {
 	mq, err := q.repo.GetQuestionByID(c, id)
 	if err != nil {
@@ -49,44 +235,244 @@ func (q *QuestionService) UpdateQuestion(c context.Context, id uint, req dto.Que
 	if mq == nil {
 		return nil, nil
 	}
+
+	survey, err := q.repo.GetSurveyByID(c, mq.SurveyID)
+	if err != nil {
+		return nil, err
+	}
+	if survey == nil {
+		return nil, ErrSurveyNotFound
+	}
+	current := dto.Question{}
+	if err := util.ConvertTypes(q.logger, mq, &current); err != nil {
+		return nil, err
+	}
+	if req.Type == "" && req.HasMultipleChoice == current.HasMultipleChoice {
+		// requests without a type keep the current type and config
+		req.Type = current.QuestionType()
+		if isEmptyConfig(req.Config) {
+			req.Config = current.Config
+		}
+	}
+	questionType, config, err := ResolveQuestionType(req.Type, req.HasMultipleChoice, req.Config)
+	if err != nil {
+		return nil, err
+	}
+	req.HasMultipleChoice = questionType == dto.MultipleChoiceQuestion
+
+	sectionId := mq.SectionID
+	if req.SectionID != nil {
+		sectionId = *req.SectionID
+	}
+	if sectionId != mq.SectionID && sectionId != 0 {
+		section, err := q.repo.GetSectionByID(c, sectionId)
+		if err != nil {
+			return nil, err
+		}
+		if section == nil || section.SurveyID != mq.SurveyID {
+			return nil, ErrInvalidSection
+		}
+	}
+
+	if !survey.IsEditable() {
+		// open surveys only accept wording fixes, never structural changes
+		structural := req.HasMultipleChoice != mq.HasMultipleChoice || len(req.Choices) > 0 || sectionId != mq.SectionID ||
+			questionType != current.QuestionType() || !reflect.DeepEqual(config, current.Config)
+		if survey.CurrentStatus(time.Now()) != models.SurveyStatusOpen || structural {
+			return nil, ErrSurveyNotEditable
+		}
+	}
+
+	var choices []models.Choice
+	if len(req.Choices) > 0 && req.HasMultipleChoice {
+		choices, err = matchChoices(mq.Choices, req.Choices)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	updated := current
+	updated.Text = req.Text
+	updated.TextTranslations = req.TextTranslations
+	updated.Type = questionType
+	updated.Config = config
+	updated.HasMultipleChoice = req.HasMultipleChoice
+	updated.SectionID = sectionId
+	if len(req.Choices) > 0 {
+		updated.Choices = []dto.Choice{}
+		for i, v := range req.Choices {
+			choice := dto.Choice{Text: v.Text, TextTranslations: v.TextTranslations}
+			if i < len(choices) {
+				choice.ID = choices[i].ID
+			}
+			updated.Choices = append(updated.Choices, choice)
+		}
+	}
+	if err := q.validatePiping(c, survey, func(list dto.QuestionList) dto.QuestionList {
+		for i, v := range list {
+			if v.ID == id {
+				list[i] = &updated
+			}
+		}
+		return list
+	}); err != nil {
+		return nil, err
+	}
+
 	mq.Text = req.Text
+	mq.TextTranslations = req.TextTranslations
+	mq.Type = string(questionType)
 	mq.HasMultipleChoice = req.HasMultipleChoice
 	mq.MediaUrl = req.MediaUrl
+	mq.SectionID = sectionId
+	mq.Config = models.QuestionConfig{}
+	if err := util.ConvertTypes(q.logger, config, &mq.Config); err != nil {
+		return nil, err
+	}
 
 	_, err = q.repo.UpdateQuestion(c, mq)
 	if err != nil {
 		return nil, err
 	}
 
-	choices := req.Choices
-	if len(choices) > 0 && req.HasMultipleChoice {
-		err := q.repo.DeleteQuestionChoices(c, id)
-		if err != nil {
-			return nil, err
-		}
-		for _, v := range choices {
-			ch := models.Choice{}
+	if len(choices) > 0 {
+		// choices that existed keep their id, so votes already given to them stay linked
+		kept := map[uint]bool{}
+		for i, v := range req.Choices {
+			ch := choices[i]
+			found := ch.ID != 0
+			ch.QuestionID = id
+			ch.Text = v.Text
 			ch.IsCorrect = v.IsCorrect
+			ch.Anchored = v.Anchored
+			ch.Points = v.Points
+			ch.TextTranslations = v.TextTranslations
 			ch.LinkedQuestionID = v.LinkedQuestionId
-			ch.Text = v.Text
-			ch.QuestionID = id
-			err := q.repo.CreateChoice(c, &ch)
+
+			if found {
+				err = q.repo.UpdateChoice(c, &ch)
+			} else {
+				err = q.repo.CreateChoice(c, &ch)
+			}
 			if err != nil {
 				return nil, err
 			}
+			kept[ch.ID] = true
 		}
+		for _, v := range mq.Choices {
+			if kept[v.ID] {
+				continue
+			}
+			if err := q.repo.DeleteChoice(c, v.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
 
+	if survey.Status != models.SurveyStatusDraft {
+		if _, err := q.versionService.PublishVersion(c, survey.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	mq, err = q.repo.GetQuestionByID(c, id)
+	if err != nil {
+		return nil, err
 	}
 	return question, util.ConvertTypes(q.logger, mq, &question)
 
 }
+
+// matchChoices returns the stored choice each requested choice updates, or a new choice when it
+// adds one. A requested choice is matched on its id, one without an id on the text of a choice no
+// other requested choice was matched to.
+func matchChoices(existing []models.Choice, requested []dto.ChoiceUpdateRequest) ([]models.Choice, error) {
+	matched := make([]models.Choice, len(requested))
+	taken := map[uint]bool{}
+	for i, v := range requested {
+		if v.ID == 0 {
+			continue
+		}
+		found := false
+		for _, ch := range existing {
+			if ch.ID == v.ID && !taken[ch.ID] {
+				matched[i], found = ch, true
+				taken[ch.ID] = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: %d", ErrInvalidChoice, v.ID)
+		}
+	}
+	for i, v := range requested {
+		if v.ID != 0 {
+			continue
+		}
+		for _, ch := range existing {
+			if ch.Text == v.Text && !taken[ch.ID] {
+				matched[i] = ch
+				taken[ch.ID] = true
+				break
+			}
+		}
+	}
+	return matched, nil
+}
+
 func (q *QuestionService) DeleteQuestion(c context.Context, id uint) error {
+	mq, err := q.repo.GetQuestionByID(c, id)
+	if err != nil {
+		return err
+	}
+	if mq == nil {
+		return nil
+	}
 
-	err := q.repo.DeleteQuestion(c, id)
+	survey, err := q.repo.GetSurveyByID(c, mq.SurveyID)
 	if err != nil {
 		return err
 	}
-	return q.repo.DeleteQuestionChoices(c, id)
+	if survey != nil && !survey.IsEditable() {
+		return ErrSurveyNotEditable
+	}
+	if survey != nil {
+		// questions that pipe the answer of this question could not be shown anymore
+		err = q.validatePiping(c, survey, func(list dto.QuestionList) dto.QuestionList {
+			kept := dto.QuestionList{}
+			for _, v := range list {
+				if v.ID != id {
+					kept = append(kept, v)
+				}
+			}
+			return kept
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = q.repo.DeleteQuestion(c, id)
+	if err != nil {
+		return err
+	}
+	err = q.repo.DeleteQuestionChoices(c, id)
+	if err != nil {
+		return err
+	}
+	err = q.repo.UnlinkQuestion(c, id)
+	if err != nil {
+		return err
+	}
+	err = q.removeBranchRulesOf(c, mq.SurveyID, id)
+	if err != nil {
+		return err
+	}
+
+	if survey != nil && survey.Status != models.SurveyStatusDraft {
+		_, err = q.versionService.PublishVersion(c, survey.ID)
+	}
+	return err
 
 }
 func (q *QuestionService) GetQuestion(c context.Context, id uint) (response *dto.Question, err error) {
@@ -101,3 +487,71 @@ func (q *QuestionService) GetQuestion(c context.Context, id uint) (response *dto
 	}
 	return response, util.ConvertTypes(q.logger, qu, &response)
 }
+
+func (q *QuestionService) editableSurvey(c context.Context, surveyId uint) (*models.Survey, error) {
+	survey, err := q.repo.GetSurveyByID(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	if survey == nil {
+		return nil, ErrSurveyNotFound
+	}
+	if !survey.IsEditable() {
+		return nil, ErrSurveyNotEditable
+	}
+	return survey, nil
+}
+
+// validatePiping checks the piping of a survey after change is applied to its questions,
+// the questions are passed to change in their stored order.
+func (q *QuestionService) validatePiping(c context.Context, survey *models.Survey, change func(list dto.QuestionList) dto.QuestionList) error {
+	list, err := q.getQuestions(c, survey.ID)
+	if err != nil {
+		return err
+	}
+	sections, err := q.repo.GetSections(c, survey.ID)
+	if err != nil {
+		return err
+	}
+	sectionList := []*dto.Section{}
+	if err := util.ConvertTypes(q.logger, sections, &sectionList); err != nil {
+		return err
+	}
+	if problems := ValidatePiping(orderBySection(change(list), sectionList), survey.IsSequential); len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidPiping, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (q *QuestionService) getQuestions(c context.Context, surveyId uint) (dto.QuestionList, error) {
+	list, err := q.GetQuestions(c, dto.GetQuestionsRequest{SurveyId: surveyId})
+	return dto.QuestionList(list), err
+}
+
+func isEmptyConfig(config dto.QuestionConfig) bool {
+	return reflect.DeepEqual(config, dto.QuestionConfig{})
+}
+
+// removeBranchRulesOf drops the branch rules that start at, jump to or depend on a deleted question.
+func (q *QuestionService) removeBranchRulesOf(c context.Context, surveyId uint, questionId uint) error {
+	rules, err := q.repo.GetBranchRules(c, surveyId)
+	if err != nil {
+		return err
+	}
+
+	kept := []*models.BranchRule{}
+	for _, rule := range rules {
+		condition := dto.RuleExpression{}
+		if err := util.ConvertTypes(q.logger, rule.Condition, &condition); err != nil {
+			return err
+		}
+		if rule.QuestionID == questionId || rule.TargetQuestionID == questionId || expressionReferences(condition, questionId) {
+			continue
+		}
+		kept = append(kept, rule)
+	}
+	if len(kept) == len(rules) {
+		return nil
+	}
+	return q.repo.ReplaceBranchRules(c, surveyId, kept)
+}
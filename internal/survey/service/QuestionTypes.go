@@ -0,0 +1,260 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+)
+
+const dateLayout = "2006-01-02"
+
+var defaultLikertLabels = []string{"Strongly disagree", "Disagree", "Neutral", "Agree", "Strongly agree"}
+
+// ResolveQuestionType validates the type and config of a question and fills config defaults.
+// An empty type falls back to the old behaviour of has_multiple_choice.
+func ResolveQuestionType(questionType dto.QuestionType, hasMultipleChoice bool, config dto.QuestionConfig) (dto.QuestionType, dto.QuestionConfig, error) {
+	if questionType == "" {
+		questionType = (&dto.Question{HasMultipleChoice: hasMultipleChoice}).QuestionType()
+	}
+	if hasMultipleChoice && questionType != dto.MultipleChoiceQuestion {
+		return "", config, fmt.Errorf("%w: only multiple_choice questions can have choices", ErrInvalidQuestionConfig)
+	}
+
+	if questionType != dto.MultipleChoiceQuestion && (config.MultiSelect || config.MinSelections != 0 || config.MaxSelections != 0) {
+		return "", config, fmt.Errorf("%w: only multiple_choice questions accept selections", ErrInvalidQuestionConfig)
+	}
+	if questionType != dto.MultipleChoiceQuestion && config.ChoicesFrom != 0 {
+		return "", config, fmt.Errorf("%w: only multiple_choice questions can take their choices from another question", ErrInvalidQuestionConfig)
+	}
+	if config.TimeLimit < 0 {
+		return "", config, fmt.Errorf("%w: time limit can not be negative", ErrInvalidQuestionConfig)
+	}
+	if config.Weight != nil && *config.Weight < 0 {
+		return "", config, fmt.Errorf("%w: weight can not be negative", ErrInvalidQuestionConfig)
+	}
+	if questionType != dto.MultipleChoiceQuestion && config.RandomizeChoices != nil {
+		return "", config, fmt.Errorf("%w: only multiple_choice questions have choices to shuffle", ErrInvalidQuestionConfig)
+	}
+	if err := validateAnswerRules(questionType, config.Validation); err != nil {
+		return "", config, err
+	}
+
+	switch questionType {
+	case dto.TextQuestion:
+	case dto.MultipleChoiceQuestion:
+		if !config.MultiSelect && (config.MinSelections != 0 || config.MaxSelections != 0) {
+			return "", config, fmt.Errorf("%w: selection limits need multi_select", ErrInvalidQuestionConfig)
+		}
+		if config.MinSelections < 0 || config.MaxSelections < 0 || (config.MaxSelections > 0 && config.MinSelections > config.MaxSelections) {
+			return "", config, fmt.Errorf("%w: invalid selection limits", ErrInvalidQuestionConfig)
+		}
+	case dto.RatingQuestion:
+		if config.Min == nil {
+			min := 1.0
+			config.Min = &min
+		}
+		if config.Max == nil || *config.Max <= *config.Min {
+			return "", config, fmt.Errorf("%w: rating needs a max greater than min", ErrInvalidQuestionConfig)
+		}
+		if *config.Min != math.Trunc(*config.Min) || *config.Max != math.Trunc(*config.Max) {
+			return "", config, fmt.Errorf("%w: rating bounds must be whole numbers", ErrInvalidQuestionConfig)
+		}
+	case dto.LikertQuestion:
+		if len(config.Labels) == 0 {
+			config.Labels = defaultLikertLabels
+		}
+		if len(config.Labels) < 2 || hasDuplicates(config.Labels) {
+			return "", config, fmt.Errorf("%w: likert needs at least two distinct labels", ErrInvalidQuestionConfig)
+		}
+	case dto.NumericQuestion:
+		if config.Min != nil && config.Max != nil && *config.Min > *config.Max {
+			return "", config, fmt.Errorf("%w: numeric min is greater than max", ErrInvalidQuestionConfig)
+		}
+	case dto.DateQuestion:
+		min, max, err := dateBounds(config)
+		if err != nil {
+			return "", config, fmt.Errorf("%w: %s", ErrInvalidQuestionConfig, err.Error())
+		}
+		if !min.IsZero() && !max.IsZero() && min.After(max) {
+			return "", config, fmt.Errorf("%w: min_date is after max_date", ErrInvalidQuestionConfig)
+		}
+	case dto.RankingQuestion:
+		if len(config.Items) < 2 || hasDuplicates(config.Items) {
+			return "", config, fmt.Errorf("%w: ranking needs at least two distinct items", ErrInvalidQuestionConfig)
+		}
+	case dto.MatrixQuestion:
+		if len(config.Rows) == 0 || hasDuplicates(config.Rows) {
+			return "", config, fmt.Errorf("%w: matrix needs distinct rows", ErrInvalidQuestionConfig)
+		}
+		if len(config.Columns) < 2 || hasDuplicates(config.Columns) {
+			return "", config, fmt.Errorf("%w: matrix needs at least two distinct columns", ErrInvalidQuestionConfig)
+		}
+	default:
+		return "", config, fmt.Errorf("%w: unknown question type %s", ErrInvalidQuestionConfig, questionType)
+	}
+	return questionType, config, nil
+}
+
+// NormalizeAnswer checks a vote against the type of its question and returns the answer as it is stored.
+// Ranking and matrix answers are stored as json, multiple choice answers are checked by the caller.
+func NormalizeAnswer(q *dto.Question, req dto.VoteRequest) (string, error) {
+	answer := strings.TrimSpace(req.Answer)
+	config := q.Config
+
+	switch q.QuestionType() {
+	case dto.RatingQuestion:
+		n, err := strconv.Atoi(answer)
+		if err != nil {
+			return "", fmt.Errorf("%w: rating must be a whole number", ErrInvalidAnswer)
+		}
+		if (config.Min != nil && float64(n) < *config.Min) || (config.Max != nil && float64(n) > *config.Max) {
+			return "", fmt.Errorf("%w: rating is out of range", ErrInvalidAnswer)
+		}
+		return strconv.Itoa(n), nil
+	case dto.LikertQuestion:
+		labels := config.Labels
+		if len(labels) == 0 {
+			labels = defaultLikertLabels
+		}
+		for _, l := range labels {
+			if strings.EqualFold(l, answer) {
+				return l, nil
+			}
+		}
+		return "", fmt.Errorf("%w: answer is not one of the likert labels", ErrInvalidAnswer)
+	case dto.NumericQuestion:
+		n, err := strconv.ParseFloat(answer, 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return "", fmt.Errorf("%w: answer must be a number", ErrInvalidAnswer)
+		}
+		if config.Integer && n != math.Trunc(n) {
+			return "", fmt.Errorf("%w: answer must be a whole number", ErrInvalidAnswer)
+		}
+		if (config.Min != nil && n < *config.Min) || (config.Max != nil && n > *config.Max) {
+			return "", fmt.Errorf("%w: number is out of range", ErrInvalidAnswer)
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	case dto.DateQuestion:
+		d, err := time.Parse(dateLayout, answer)
+		if err != nil {
+			return "", fmt.Errorf("%w: date must be in YYYY-MM-DD format", ErrInvalidAnswer)
+		}
+		min, max, _ := dateBounds(config)
+		if (!min.IsZero() && d.Before(min)) || (!max.IsZero() && d.After(max)) {
+			return "", fmt.Errorf("%w: date is out of range", ErrInvalidAnswer)
+		}
+		return d.Format(dateLayout), nil
+	case dto.RankingQuestion:
+		if len(req.Ranking) != len(config.Items) || hasDuplicates(req.Ranking) || !containsAll(config.Items, req.Ranking) {
+			return "", fmt.Errorf("%w: ranking must order every item exactly once", ErrInvalidAnswer)
+		}
+		b, err := json.Marshal(req.Ranking)
+		return string(b), err
+	case dto.MatrixQuestion:
+		if len(req.Matrix) != len(config.Rows) {
+			return "", fmt.Errorf("%w: every matrix row must be answered", ErrInvalidAnswer)
+		}
+		for _, row := range config.Rows {
+			column, ok := req.Matrix[row]
+			if !ok || !containsAll(config.Columns, []string{column}) {
+				return "", fmt.Errorf("%w: invalid answer for row %s", ErrInvalidAnswer, row)
+			}
+		}
+		b, err := json.Marshal(req.Matrix)
+		return string(b), err
+	}
+	return req.Answer, checkAnswerRules(config.Validation, req.Answer)
+}
+
+// SelectChoices returns the choices picked by a vote on a multiple choice question.
+// Choices are picked by choice_ids, or by the answer text in any language for single choice questions.
+func SelectChoices(q *dto.Question, req dto.VoteRequest) ([]dto.Choice, error) {
+	ids := req.ChoiceIds
+	if len(ids) == 0 {
+		for _, v := range q.Choices {
+			if v.Text == req.Answer || isTranslationOf(req.Answer, v.TextTranslations) {
+				ids = []uint{v.ID}
+				break
+			}
+		}
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("%w: enter valid choice", ErrInvalidAnswer)
+		}
+	}
+
+	if !q.IsMultiSelect() && len(ids) > 1 {
+		return nil, fmt.Errorf("%w: only one choice can be selected", ErrInvalidAnswer)
+	}
+
+	choices := map[uint]dto.Choice{}
+	for _, v := range q.Choices {
+		choices[v.ID] = v
+	}
+	selected := []dto.Choice{}
+	seen := map[uint]bool{}
+	for _, id := range ids {
+		choice, ok := choices[id]
+		if !ok || seen[id] {
+			return nil, fmt.Errorf("%w: enter valid choice", ErrInvalidAnswer)
+		}
+		seen[id] = true
+		selected = append(selected, choice)
+	}
+
+	if q.IsMultiSelect() {
+		min := q.Config.MinSelections
+		if min == 0 {
+			min = 1
+		}
+		if len(selected) < min {
+			return nil, fmt.Errorf("%w: select at least %d choices", ErrInvalidAnswer, min)
+		}
+		if q.Config.MaxSelections > 0 && len(selected) > q.Config.MaxSelections {
+			return nil, fmt.Errorf("%w: select at most %d choices", ErrInvalidAnswer, q.Config.MaxSelections)
+		}
+	}
+	return selected, nil
+}
+
+func dateBounds(config dto.QuestionConfig) (min time.Time, max time.Time, err error) {
+	if config.MinDate != "" {
+		if min, err = time.Parse(dateLayout, config.MinDate); err != nil {
+			return min, max, fmt.Errorf("min_date must be in YYYY-MM-DD format")
+		}
+	}
+	if config.MaxDate != "" {
+		if max, err = time.Parse(dateLayout, config.MaxDate); err != nil {
+			return min, max, fmt.Errorf("max_date must be in YYYY-MM-DD format")
+		}
+	}
+	return min, max, nil
+}
+
+func hasDuplicates(values []string) bool {
+	seen := map[string]bool{}
+	for _, v := range values {
+		if seen[v] {
+			return true
+		}
+		seen[v] = true
+	}
+	return false
+}
+
+func containsAll(set []string, values []string) bool {
+	known := map[string]bool{}
+	for _, v := range set {
+		known[v] = true
+	}
+	for _, v := range values {
+		if !known[v] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+)
+
+// ValidateQuiz checks the quiz settings of a survey, the pass mark is a percentage.
+func ValidateQuiz(quiz *dto.QuizSettings) error {
+	if quiz != nil && (quiz.PassMark < 0 || quiz.PassMark > 100) {
+		return fmt.Errorf("%w: the pass mark must be between 0 and 100", ErrInvalidQuiz)
+	}
+	return nil
+}
+
+// ChoicePoints returns what picking a choice is worth, a correct choice without points is worth 1.
+func ChoicePoints(choice dto.Choice) float64 {
+	if choice.Points == 0 && choice.IsCorrect {
+		return 1
+	}
+	return choice.Points
+}
+
+func questionWeight(q *dto.Question) float64 {
+	if q.Config.Weight == nil {
+		return 1
+	}
+	return *q.Config.Weight
+}
+
+// maxQuestionScore returns the highest score a question can give and whether it is scored at all,
+// only multiple choice questions with choices worth points are.
+func maxQuestionScore(q *dto.Question) (float64, bool) {
+	if q.QuestionType() != dto.MultipleChoiceQuestion {
+		return 0, false
+	}
+	points := []float64{}
+	scored := false
+	for _, choice := range q.Choices {
+		p := ChoicePoints(choice)
+		scored = scored || p != 0
+		if p > 0 {
+			points = append(points, p)
+		}
+	}
+	if !scored {
+		return 0, false
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(points)))
+	picks := 1
+	if q.IsMultiSelect() {
+		picks = len(points)
+		if q.Config.MaxSelections > 0 && q.Config.MaxSelections < picks {
+			picks = q.Config.MaxSelections
+		}
+	}
+	best := 0.0
+	for _, p := range points[:min(picks, len(points))] {
+		best += p
+	}
+	return best * questionWeight(q), true
+}
+
+// ScoreQuiz scores the choices picked on the questions a participant was asked, picked maps a
+// question id to the ids of its picked choices. The highest score possible only counts the
+// questions that were asked, so questions skipped by branching do not lower the percentage.
+func ScoreQuiz(asked []*dto.Question, picked map[uint][]uint, quiz dto.QuizSettings) dto.QuizResult {
+	result := dto.QuizResult{}
+	for _, q := range asked {
+		best, scored := maxQuestionScore(q)
+		if !scored {
+			continue
+		}
+		result.MaxScore += best
+
+		points := map[uint]float64{}
+		for _, choice := range q.Choices {
+			points[choice.ID] = ChoicePoints(choice)
+		}
+		for _, id := range picked[q.ID] {
+			result.Score += points[id] * questionWeight(q)
+		}
+	}
+	if result.MaxScore > 0 {
+		result.Percentage = math.Round(10000*result.Score/result.MaxScore) / 100
+	}
+	result.Passed = result.Percentage >= quiz.PassMark
+	return result
+}
+
+// DistributeScores summarizes the results of the participations of a quiz.
+func DistributeScores(results []dto.QuizResult) dto.ScoreDistribution {
+	distribution := dto.ScoreDistribution{Participations: len(results), Buckets: []dto.ScoreBucket{}}
+	for from := 0; from < 100; from += 10 {
+		distribution.Buckets = append(distribution.Buckets, dto.ScoreBucket{From: from, To: from + 10})
+	}
+	if len(results) == 0 {
+		return distribution
+	}
+
+	distribution.MinPercentage = results[0].Percentage
+	distribution.MaxPercentage = results[0].Percentage
+	totalScore, totalPercentage := 0.0, 0.0
+	for _, result := range results {
+		if result.Passed {
+			distribution.Passed++
+		}
+		totalScore += result.Score
+		totalPercentage += result.Percentage
+		distribution.MinPercentage = math.Min(distribution.MinPercentage, result.Percentage)
+		distribution.MaxPercentage = math.Max(distribution.MaxPercentage, result.Percentage)
+
+		bucket := int(result.Percentage) / 10
+		bucket = max(0, min(bucket, len(distribution.Buckets)-1))
+		distribution.Buckets[bucket].Count++
+	}
+	distribution.AverageScore = math.Round(100*totalScore/float64(len(results))) / 100
+	distribution.AveragePercentage = math.Round(100*totalPercentage/float64(len(results))) / 100
+	return distribution
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/repository"
+	"github.com/G9QBootcamp/qoli-survey/internal/util"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"golang.org/x/net/context"
+)
+
+// ParticipationScreenedOut is the code of the message that ends a participation whose quota is full.
+const ParticipationScreenedOut = "screened_out"
+
+type IQuotaService interface {
+	GetQuotas(c context.Context, surveyId uint) ([]*dto.Quota, error)
+	CreateQuota(c context.Context, surveyId uint, req dto.QuotaRequest) (*dto.Quota, error)
+	UpdateQuota(c context.Context, surveyId uint, id uint, req dto.QuotaRequest) (*dto.Quota, error)
+	DeleteQuota(c context.Context, surveyId uint, id uint) error
+}
+
+type QuotaService struct {
+	conf   *config.Config
+	repo   repository.ISurveyRepository
+	logger logging.Logger
+}
+
+func NewQuotaService(conf *config.Config, repo repository.ISurveyRepository, logger logging.Logger) *QuotaService {
+	return &QuotaService{conf: conf, repo: repo, logger: logger}
+}
+
+func (s *QuotaService) GetQuotas(c context.Context, surveyId uint) ([]*dto.Quota, error) {
+	quotas, err := s.repo.GetQuotas(c, surveyId)
+	if err != nil {
+		return []*dto.Quota{}, err
+	}
+	response := []*dto.Quota{}
+	return response, util.ConvertTypes(s.logger, quotas, &response)
+}
+
+// CreateQuota adds a quota to a survey, quotas can be added while the survey is open.
+func (s *QuotaService) CreateQuota(c context.Context, surveyId uint, req dto.QuotaRequest) (*dto.Quota, error) {
+	if err := s.validate(c, surveyId, req); err != nil {
+		return nil, err
+	}
+	quota := models.SurveyQuota{SurveyID: surveyId}
+	applyQuotaRequest(&quota, req)
+	if err := s.repo.CreateQuota(c, &quota); err != nil {
+		return nil, err
+	}
+	response := dto.Quota{}
+	return &response, util.ConvertTypes(s.logger, quota, &response)
+}
+
+// UpdateQuota changes the definition of a quota, the places already taken are kept.
+func (s *QuotaService) UpdateQuota(c context.Context, surveyId uint, id uint, req dto.QuotaRequest) (*dto.Quota, error) {
+	quota, err := s.repo.GetQuotaByID(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if quota == nil || quota.SurveyID != surveyId {
+		return nil, nil
+	}
+	if err := s.validate(c, surveyId, req); err != nil {
+		return nil, err
+	}
+	applyQuotaRequest(quota, req)
+	if err := s.repo.UpdateQuota(c, quota); err != nil {
+		return nil, err
+	}
+	response := dto.Quota{}
+	return &response, util.ConvertTypes(s.logger, quota, &response)
+}
+
+func (s *QuotaService) DeleteQuota(c context.Context, surveyId uint, id uint) error {
+	quota, err := s.repo.GetQuotaByID(c, id)
+	if err != nil {
+		return err
+	}
+	if quota == nil || quota.SurveyID != surveyId {
+		return nil
+	}
+	if _, err := s.writableSurvey(c, surveyId); err != nil {
+		return err
+	}
+	return s.repo.DeleteQuota(c, id)
+}
+
+func (s *QuotaService) validate(c context.Context, surveyId uint, req dto.QuotaRequest) error {
+	survey, err := s.writableSurvey(c, surveyId)
+	if err != nil {
+		return err
+	}
+	if survey.Anonymity != nil && models.QuotaAttribute(req.Attribute) == models.QuotaAnswer {
+		// placing a participation by its answers would link the answers to the participant
+		return fmt.Errorf("%w: anonymous surveys can not have answer quotas", ErrInvalidQuota)
+	}
+	filter := dto.RepositoryFilter{Field: "survey_id", Operator: "=", Value: strconv.Itoa(int(surveyId))}
+	questions, err := s.repo.GetQuestions(c, &dto.RepositoryRequest{Filters: []*dto.RepositoryFilter{&filter}, With: "Choices"})
+	if err != nil {
+		return err
+	}
+	list := dto.QuestionList{}
+	if err := util.ConvertTypes(s.logger, questions, &list); err != nil {
+		return err
+	}
+	return ValidateQuota(req, list)
+}
+
+// writableSurvey returns the survey quotas are changed on, archived surveys keep theirs.
+func (s *QuotaService) writableSurvey(c context.Context, surveyId uint) (*models.Survey, error) {
+	survey, err := s.repo.GetSurveyByID(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	if survey == nil {
+		return nil, ErrSurveyNotFound
+	}
+	if survey.Status == models.SurveyStatusArchived {
+		return nil, ErrSurveyReadOnly
+	}
+	return survey, nil
+}
+
+func applyQuotaRequest(quota *models.SurveyQuota, req dto.QuotaRequest) {
+	quota.Name = req.Name
+	quota.Attribute = models.QuotaAttribute(req.Attribute)
+	quota.Values = models.QuotaValues(req.Values)
+	quota.MinAge = req.MinAge
+	quota.MaxAge = req.MaxAge
+	quota.QuestionID = req.QuestionID
+	quota.ParticipationLimit = req.Limit
+}
+
+// ValidateQuota checks that a quota names what it segments on: cities, an age range or the
+// answers of a screening question of the survey. Answers of choice questions are choice texts.
+func ValidateQuota(req dto.QuotaRequest, questions dto.QuestionList) error {
+	if req.Limit < 1 {
+		return fmt.Errorf("%w: limit must be at least 1", ErrInvalidQuota)
+	}
+	switch models.QuotaAttribute(req.Attribute) {
+	case models.QuotaCity:
+		if len(req.Values) == 0 {
+			return fmt.Errorf("%w: a city quota needs the cities it counts", ErrInvalidQuota)
+		}
+		if req.MinAge != nil || req.MaxAge != nil || req.QuestionID != nil {
+			return fmt.Errorf("%w: a city quota only has values", ErrInvalidQuota)
+		}
+	case models.QuotaAge:
+		if req.MinAge == nil && req.MaxAge == nil {
+			return fmt.Errorf("%w: an age quota needs a minimum or maximum age", ErrInvalidQuota)
+		}
+		if (req.MinAge != nil && *req.MinAge < 0) || (req.MaxAge != nil && *req.MaxAge < 0) {
+			return fmt.Errorf("%w: ages can not be negative", ErrInvalidQuota)
+		}
+		if req.MinAge != nil && req.MaxAge != nil && *req.MinAge > *req.MaxAge {
+			return fmt.Errorf("%w: minimum age is above maximum age", ErrInvalidQuota)
+		}
+		if len(req.Values) > 0 || req.QuestionID != nil {
+			return fmt.Errorf("%w: an age quota only has an age range", ErrInvalidQuota)
+		}
+	case models.QuotaAnswer:
+		if req.QuestionID == nil {
+			return fmt.Errorf("%w: an answer quota needs a screening question", ErrInvalidQuota)
+		}
+		if len(req.Values) == 0 {
+			return fmt.Errorf("%w: an answer quota needs the answers it counts", ErrInvalidQuota)
+		}
+		if req.MinAge != nil || req.MaxAge != nil {
+			return fmt.Errorf("%w: an answer quota has no age range", ErrInvalidQuota)
+		}
+		q, ok := questions.ToMap()[*req.QuestionID]
+		if !ok {
+			return fmt.Errorf("%w: question %d is not in this survey", ErrInvalidQuota, *req.QuestionID)
+		}
+		if len(q.Choices) > 0 {
+			for _, v := range req.Values {
+				if !hasChoiceText(q, v) {
+					return fmt.Errorf("%w: %q is not a choice of question %d", ErrInvalidQuota, v, q.ID)
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("%w: unknown attribute %q", ErrInvalidQuota, req.Attribute)
+	}
+	return nil
+}
+
+func hasChoiceText(q *dto.Question, text string) bool {
+	for _, c := range q.Choices {
+		if sameQuotaValue(c.Text, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParticipantAge is the age of a participant born on birth, ok is false when the birth date is unknown.
+func ParticipantAge(birth time.Time, now time.Time) (age int, ok bool) {
+	if birth.IsZero() {
+		return 0, false
+	}
+	age = now.Year() - birth.Year()
+	if now.Month() < birth.Month() || (now.Month() == birth.Month() && now.Day() < birth.Day()) {
+		age--
+	}
+	return age, true
+}
+
+// ParticipantQuotas returns the city and age quotas a participant of city born on birth falls in.
+// A participant with an unknown city or birth date falls in none of the quotas on it.
+func ParticipantQuotas(quotas []*dto.Quota, city string, birth time.Time, now time.Time) []*dto.Quota {
+	age, knownAge := ParticipantAge(birth, now)
+	matched := []*dto.Quota{}
+	for _, quota := range quotas {
+		switch models.QuotaAttribute(quota.Attribute) {
+		case models.QuotaCity:
+			if city != "" && containsQuotaValue(quota.Values, city) {
+				matched = append(matched, quota)
+			}
+		case models.QuotaAge:
+			if knownAge && (quota.MinAge == nil || age >= *quota.MinAge) && (quota.MaxAge == nil || age <= *quota.MaxAge) {
+				matched = append(matched, quota)
+			}
+		}
+	}
+	return matched
+}
+
+// AnswerQuotas splits the answer quotas of a screening question into the ones answers fall in and the others.
+func AnswerQuotas(quotas []*dto.Quota, questionId uint, answers []string) (matched []*dto.Quota, others []*dto.Quota) {
+	matched, others = []*dto.Quota{}, []*dto.Quota{}
+	for _, quota := range quotas {
+		if models.QuotaAttribute(quota.Attribute) != models.QuotaAnswer || quota.QuestionID == nil || *quota.QuestionID != questionId {
+			continue
+		}
+		found := false
+		for _, answer := range answers {
+			if containsQuotaValue(quota.Values, answer) {
+				found = true
+				break
+			}
+		}
+		if found {
+			matched = append(matched, quota)
+		} else {
+			others = append(others, quota)
+		}
+	}
+	return matched, others
+}
+
+// FullQuota returns the first of quotas that has no place left.
+func FullQuota(quotas []*dto.Quota) *dto.Quota {
+	for _, quota := range quotas {
+		if quota.Filled >= quota.Limit {
+			return quota
+		}
+	}
+	return nil
+}
+
+func quotaIds(quotas []*dto.Quota) []uint {
+	ids := []uint{}
+	for _, quota := range quotas {
+		ids = append(ids, quota.ID)
+	}
+	return ids
+}
+
+func containsQuotaValue(values []string, value string) bool {
+	for _, v := range values {
+		if sameQuotaValue(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameQuotaValue(a string, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/util"
+)
+
+// ValidateRandomization checks the randomization of a survey, sections and questions of sequential
+// surveys are asked in order because their rules and piping depend on it.
+func ValidateRandomization(randomization *dto.Randomization, sequential bool) error {
+	if randomization == nil || !sequential {
+		return nil
+	}
+	if randomization.Sections || randomization.Questions {
+		return fmt.Errorf("%w: sections and questions of sequential surveys can not be shuffled", ErrInvalidRandomization)
+	}
+	return nil
+}
+
+// RandomizeQuestions returns the questions in the order a participant sees them and the sections
+// in their order. The order only depends on seed, so it can be replayed: the sections are shuffled
+// first, then the questions inside each section and then the choices of each question in the
+// order they are asked. Pinned questions and anchored choices keep their position.
+func RandomizeQuestions(list dto.QuestionList, sections []*dto.Section, randomization dto.Randomization, seed int64) (dto.QuestionList, []*dto.Section) {
+	r := util.NewRandom(seed)
+	if randomization.Sections {
+		sections = util.ShuffleSliceWith(r, sections, nil)
+	}
+
+	ordered := orderBySection(list, sections)
+	if randomization.Questions {
+		pinned := func(q *dto.Question) bool { return q.Config.Pinned }
+		for start := 0; start < len(ordered); {
+			end := start
+			for end < len(ordered) && ordered[end].SectionID == ordered[start].SectionID {
+				end++
+			}
+			copy(ordered[start:end], util.ShuffleSliceWith(r, ordered[start:end], pinned))
+			start = end
+		}
+	}
+
+	anchored := func(choice dto.Choice) bool { return choice.Anchored }
+	for i, q := range ordered {
+		shuffle := randomization.Choices
+		if q.Config.RandomizeChoices != nil {
+			shuffle = *q.Config.RandomizeChoices
+		}
+		if shuffle && len(q.Choices) > 1 {
+			shuffled := *q
+			shuffled.Choices = util.ShuffleSliceWith(r, q.Choices, anchored)
+			ordered[i] = &shuffled
+		}
+	}
+	return ordered, sections
+}
@@ -1,13 +1,18 @@
 package service
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"strconv"
+	"time"
 
 	"github.com/G9QBootcamp/qoli-survey/internal/config"
 	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
 	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
 	"github.com/G9QBootcamp/qoli-survey/internal/survey/repository"
+	"github.com/G9QBootcamp/qoli-survey/internal/util"
 	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
 	"golang.org/x/net/context"
 )
@@ -23,6 +28,13 @@ type IReportService interface {
 	GetSurveyReport(ctx context.Context, surveyId uint) (*dto.ReportResponse, error)
 	GetAllSurveys(ctx context.Context) ([]models.Survey, error)
 	GetAccessibleSurveys(ctx context.Context, userID uint, permission string) ([]models.Survey, error)
+	GetVersionReport(ctx context.Context, surveyId uint, version int) (*dto.VersionReport, error)
+	GetMergedReport(ctx context.Context, surveyId uint, req dto.MergedReportRequest) (*dto.MergedReport, error)
+	GetQuestionAggregates(ctx context.Context, surveyId uint) ([]dto.QuestionAggregate, error)
+	GetSectionReports(ctx context.Context, surveyId uint) ([]dto.SectionReport, error)
+	GetBankQuestionReport(ctx context.Context, bank *dto.BankQuestion, userId uint, allSurveys bool) (*dto.BankQuestionReport, error)
+	GetScoreDistribution(ctx context.Context, surveyId uint) (*dto.ScoreDistribution, error)
+	GetQuestionTimes(ctx context.Context, surveyId uint) ([]dto.QuestionTime, error)
 }
 type ReportService struct {
 	conf   *config.Config
@@ -34,11 +46,18 @@ func NewReportService(conf *config.Config, repo repository.IReportRepository, lo
 	return &ReportService{conf: conf, repo: repo, logger: logger}
 }
 
+// GetTotalParticipationPercentage is the percentage of the users allowed to vote that took part,
+// guests are not among the allowed users so their participations are left out.
 func (s *ReportService) GetTotalParticipationPercentage(ctx context.Context, surveyId uint) (uint, error) {
 	participated, err := s.repo.GetTotalParticipatesForSurvey(ctx, surveyId)
 	if err != nil {
 		return 0, err
 	}
+	guests, err := s.repo.GetGuestParticipationCount(ctx, surveyId)
+	if err != nil {
+		return 0, err
+	}
+	participated -= guests
 	allowed, err := s.repo.GetSurveyParticipantsCountByPermissionId(ctx, surveyId, 1)
 	if err != nil {
 		return 0, err
@@ -73,7 +92,7 @@ func (s *ReportService) GetCorrectAnswerPercentage(ctx context.Context, surveyId
 				})
 				continue
 			}
-			correctAnsCount, err := s.repo.GetGivenAnswerCountByQuestionID(ctx, q.ID, correctAns.Text)
+			correctAnsCount, err := s.repo.GetChoiceVoteCount(ctx, *correctAns)
 			if err != nil {
 				return nil, err
 			}
@@ -107,7 +126,12 @@ func (s *ReportService) GetMultipleParticipationCount(ctx context.Context, surve
 			})
 		}
 	}
-	return res, err
+
+	guests, err := s.repo.GetMultipleGuestParticipations(ctx, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	return append(res, guests...), nil
 }
 
 func (s *ReportService) SuddenlyFinishedParticipationPercentage(ctx context.Context, surveyId uint) (float64, error) {
@@ -145,32 +169,251 @@ func (s *ReportService) GetChoicesByPercentage(ctx context.Context, surveyId uin
 			if err != nil {
 				return nil, err
 			}
+			respondents, err := s.repo.GetQuestionRespondentsCount(ctx, q.ID)
+			if err != nil {
+				return nil, err
+			}
+			questionReport.Respondents = respondents
+			questionReport.Selections = totalVotesCount
 			for _, choice := range choices {
 				if totalVotesCount == 0 {
 					questionReport.ChoiceReport = append(questionReport.ChoiceReport, dto.ChoiceReport{
-						ID:         choice.ID,
-						Text:       choice.Text,
-						Percentage: "0 %",
+						ID:                  choice.ID,
+						Text:                choice.Text,
+						Percentage:          "0 %",
+						SelectionPercentage: "0 %",
 					})
 					continue
 				}
 
-				chosenCount, err := s.repo.GetGivenAnswerCountByQuestionID(ctx, q.ID, choice.Text)
+				chosenCount, err := s.repo.GetChoiceVoteCount(ctx, choice)
 				if err != nil {
 					return nil, err
 				}
 				questionReport.ChoiceReport = append(questionReport.ChoiceReport, dto.ChoiceReport{
-					ID:         choice.ID,
-					Text:       choice.Text,
-					Percentage: strconv.Itoa(int(100*float64(chosenCount)/float64(totalVotesCount))) + "%",
+					ID:                  choice.ID,
+					Text:                choice.Text,
+					Percentage:          percentage(chosenCount, respondents),
+					SelectionPercentage: percentage(chosenCount, totalVotesCount),
 				})
 			}
 			res = append(res, questionReport)
 		}
 	}
+	minGroupSize, err := s.minGroupSize(ctx, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	return SuppressQuestionReports(res, minGroupSize), nil
+}
+
+func (s *ReportService) GetQuestionAggregates(ctx context.Context, surveyId uint) ([]dto.QuestionAggregate, error) {
+	qs, err := s.repo.GetQuestionsBySurveyID(ctx, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	questions := []dto.Question{}
+	if err := util.ConvertTypes(s.logger, qs, &questions); err != nil {
+		return nil, err
+	}
+
+	res := make([]dto.QuestionAggregate, 0)
+	for _, q := range questions {
+		switch q.QuestionType() {
+		case dto.TextQuestion, dto.MultipleChoiceQuestion:
+			// choice questions are reported by GetChoicesByPercentage
+			continue
+		}
+		answers, err := s.repo.GetAnswersByQuestionID(ctx, q.ID)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, aggregateAnswers(q, answers))
+	}
+	minGroupSize, err := s.minGroupSize(ctx, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	return SuppressAggregates(res, minGroupSize), nil
+}
+
+// minGroupSize returns the smallest group of respondents the reports of a survey may break its
+// answers down to, 0 unless the survey is anonymous.
+func (s *ReportService) minGroupSize(ctx context.Context, surveyId uint) (int64, error) {
+	survey, err := s.repo.GetSurvey(ctx, surveyId)
+	if err != nil || survey == nil {
+		return 0, err
+	}
+	return MinGroupSize((*dto.AnonymitySettings)(survey.Anonymity)), nil
+}
+
+// GetSectionReports splits the question reports of a survey by section, questions outside any
+// section are reported under a section with id 0.
+func (s *ReportService) GetSectionReports(ctx context.Context, surveyId uint) ([]dto.SectionReport, error) {
+	sections, err := s.repo.GetSectionsBySurveyID(ctx, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	qs, err := s.repo.GetQuestionsBySurveyID(ctx, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	choices, err := s.GetChoicesByPercentage(ctx, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	aggregates, err := s.GetQuestionAggregates(ctx, surveyId)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := []dto.SectionReport{{Title: "No section"}}
+	index := map[uint]int{0: 0}
+	for _, section := range sections {
+		index[section.ID] = len(reports)
+		reports = append(reports, dto.SectionReport{SectionID: section.ID, Title: section.Title})
+	}
+
+	sectionOf := map[uint]int{}
+	questionIds := make([][]uint, len(reports))
+	for _, q := range qs {
+		i, ok := index[q.SectionID]
+		if !ok {
+			i = 0
+		}
+		sectionOf[q.ID] = i
+		questionIds[i] = append(questionIds[i], q.ID)
+	}
+	for _, report := range choices {
+		i := sectionOf[report.QuestionID]
+		reports[i].ChoicesPercentage = append(reports[i].ChoicesPercentage, report)
+	}
+	for _, aggregate := range aggregates {
+		i := sectionOf[aggregate.QuestionID]
+		reports[i].QuestionAggregates = append(reports[i].QuestionAggregates, aggregate)
+	}
+
+	res := make([]dto.SectionReport, 0)
+	for i, report := range reports {
+		if i == 0 && len(questionIds[i]) == 0 {
+			continue
+		}
+		report.Questions = len(questionIds[i])
+		report.Respondents, err = s.repo.GetRespondentsCount(ctx, questionIds[i])
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, report)
+	}
 	return res, nil
 }
 
+// GetBankQuestionReport compares the answers to every copy of a bank question in the surveys whose
+// reports the user can see. Choices are matched by text since every survey has its own choices.
+func (s *ReportService) GetBankQuestionReport(ctx context.Context, bank *dto.BankQuestion, userId uint, allSurveys bool) (*dto.BankQuestionReport, error) {
+	usages, err := s.repo.GetQuestionsByBankQuestionID(ctx, bank.ID)
+	if err != nil {
+		return nil, err
+	}
+	accessible := map[uint]bool{}
+	if !allSurveys {
+		surveys, err := s.repo.GetAccessibleSurveys(ctx, userId, "view_survey_reports")
+		if err != nil {
+			return nil, err
+		}
+		for _, survey := range surveys {
+			accessible[survey.ID] = true
+		}
+	}
+
+	combined := dto.Question{Text: bank.Text, Type: bank.Type, Config: bank.Config, HasMultipleChoice: bank.HasMultipleChoice, Choices: bank.Choices}
+	report := &dto.BankQuestionReport{BankQuestionID: bank.ID, Text: bank.Text, Surveys: []dto.BankQuestionUsage{}}
+	choiceTexts := []string{}
+	choiceCounts := map[string]int64{}
+	for _, choice := range bank.Choices {
+		choiceTexts = append(choiceTexts, choice.Text)
+	}
+	var respondents, selections int64
+	allAnswers := []string{}
+	// the combined usage is held to the largest minimum group size of the anonymous surveys in it
+	var combinedMinGroupSize int64
+
+	for _, usage := range usages {
+		if !allSurveys && usage.Survey.OwnerID != userId && !accessible[usage.SurveyID] {
+			continue
+		}
+		q := dto.Question{}
+		if err := util.ConvertTypes(s.logger, usage, &q); err != nil {
+			return nil, err
+		}
+		item := dto.BankQuestionUsage{SurveyID: usage.SurveyID, SurveyTitle: usage.Survey.Title, QuestionID: usage.ID}
+		minGroupSize := MinGroupSize((*dto.AnonymitySettings)(usage.Survey.Anonymity))
+		if minGroupSize > combinedMinGroupSize {
+			combinedMinGroupSize = minGroupSize
+		}
+
+		if q.QuestionType() == dto.MultipleChoiceQuestion {
+			questionReport := dto.QuestionReport{QuestionID: q.ID, ChoiceReport: make([]dto.ChoiceReport, 0)}
+			if questionReport.Respondents, err = s.repo.GetQuestionRespondentsCount(ctx, q.ID); err != nil {
+				return nil, err
+			}
+			if questionReport.Selections, err = s.repo.GetTotalVotesToQuestionCount(ctx, q.ID); err != nil {
+				return nil, err
+			}
+			for _, choice := range q.Choices {
+				count, err := s.repo.GetGivenAnswerCountByQuestionID(ctx, q.ID, choice.Text)
+				if err != nil {
+					return nil, err
+				}
+				if !containsAll(choiceTexts, []string{choice.Text}) {
+					choiceTexts = append(choiceTexts, choice.Text)
+				}
+				choiceCounts[choice.Text] += count
+				questionReport.ChoiceReport = append(questionReport.ChoiceReport, dto.ChoiceReport{
+					ID:                  choice.ID,
+					Text:                choice.Text,
+					Percentage:          percentage(count, questionReport.Respondents),
+					SelectionPercentage: percentage(count, questionReport.Selections),
+				})
+			}
+			respondents += questionReport.Respondents
+			selections += questionReport.Selections
+			item.Choices = &SuppressQuestionReports([]dto.QuestionReport{questionReport}, minGroupSize)[0]
+		} else {
+			answers, err := s.repo.GetAnswersByQuestionID(ctx, q.ID)
+			if err != nil {
+				return nil, err
+			}
+			aggregate := aggregateAnswers(q, answers)
+			if aggregate.Type == dto.TextQuestion {
+				aggregate.Count = len(answers)
+			}
+			allAnswers = append(allAnswers, answers...)
+			item.Aggregate = &SuppressAggregates([]dto.QuestionAggregate{aggregate}, minGroupSize)[0]
+		}
+		report.Surveys = append(report.Surveys, item)
+	}
+
+	if combined.QuestionType() == dto.MultipleChoiceQuestion {
+		questionReport := dto.QuestionReport{Respondents: respondents, Selections: selections, ChoiceReport: make([]dto.ChoiceReport, 0)}
+		for _, text := range choiceTexts {
+			questionReport.ChoiceReport = append(questionReport.ChoiceReport, dto.ChoiceReport{
+				Text:                text,
+				Percentage:          percentage(choiceCounts[text], respondents),
+				SelectionPercentage: percentage(choiceCounts[text], selections),
+			})
+		}
+		report.Combined.Choices = &SuppressQuestionReports([]dto.QuestionReport{questionReport}, combinedMinGroupSize)[0]
+	} else {
+		aggregate := aggregateAnswers(combined, allAnswers)
+		if aggregate.Type == dto.TextQuestion {
+			aggregate.Count = len(allAnswers)
+		}
+		report.Combined.Aggregate = &SuppressAggregates([]dto.QuestionAggregate{aggregate}, combinedMinGroupSize)[0]
+	}
+	return report, nil
+}
+
 func (s *ReportService) GetAverageResponseTime(ctx context.Context, surveyId uint) (float64, error) {
 	return s.repo.GetAverageResponseTime(ctx, surveyId)
 }
@@ -213,6 +456,11 @@ func (s *ReportService) GetSurveyReport(ctx context.Context, surveyId uint) (*dt
 		return nil, err
 	}
 
+	guestParticipations, err := s.repo.GetGuestParticipationCount(ctx, surveyId)
+	if err != nil {
+		return nil, err
+	}
+
 	choicesPercentage, err := s.GetChoicesByPercentage(ctx, surveyId)
 	if err != nil {
 		return nil, err
@@ -228,14 +476,33 @@ func (s *ReportService) GetSurveyReport(ctx context.Context, surveyId uint) (*dt
 		return nil, err
 	}
 
+	questionAggregates, err := s.GetQuestionAggregates(ctx, surveyId)
+	if err != nil {
+		return nil, err
+	}
+
+	scoreDistribution, err := s.GetScoreDistribution(ctx, surveyId)
+	if err != nil {
+		return nil, err
+	}
+
+	questionTimes, err := s.GetQuestionTimes(ctx, surveyId)
+	if err != nil {
+		return nil, err
+	}
+
 	return &dto.ReportResponse{
 		SurveyParticipation:           fmt.Sprintf("%d%%", participation),
 		CorrectAnswers:                correctAnswers,
 		MultipleParticipationCount:    multipleParticipation,
 		SuddenlyFinishedParticipation: fmt.Sprintf("%.2f%%", suddenlyFinished),
+		GuestParticipations:           guestParticipations,
 		ChoicesPercentage:             choicesPercentage,
 		AverageResponseTime:           fmt.Sprintf("%.2f", averageResponseTime),
 		DispersionResponseByHour:      dispersionByHour,
+		QuestionAggregates:            questionAggregates,
+		ScoreDistribution:             scoreDistribution,
+		QuestionTimes:                 questionTimes,
 	}, nil
 }
 
@@ -246,3 +513,299 @@ func (s *ReportService) GetAllSurveys(ctx context.Context) ([]models.Survey, err
 func (s *ReportService) GetAccessibleSurveys(ctx context.Context, userID uint, permission string) ([]models.Survey, error) {
 	return s.repo.GetAccessibleSurveys(ctx, userID, permission)
 }
+
+func (s *ReportService) GetVersionReport(ctx context.Context, surveyId uint, version int) (*dto.VersionReport, error) {
+	definition, err := s.getVersionDefinition(ctx, surveyId, version)
+	if err != nil {
+		return nil, err
+	}
+
+	participations, err := s.repo.GetParticipationCountByVersions(ctx, surveyId, []int{version})
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.repo.GetChoiceVoteCounts(ctx, definition.Questions.GetIds(), []int{version})
+	if err != nil {
+		return nil, err
+	}
+
+	respondents, err := s.repo.GetQuestionRespondentCounts(ctx, definition.Questions.GetIds(), []int{version})
+	if err != nil {
+		return nil, err
+	}
+
+	minGroupSize, err := s.minGroupSize(ctx, surveyId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.VersionReport{
+		Version:           version,
+		Participations:    participations,
+		ChoicesPercentage: SuppressQuestionReports(choiceReportsFromCounts(definition, counts, respondents, nil), minGroupSize),
+	}, nil
+}
+
+func (s *ReportService) GetMergedReport(ctx context.Context, surveyId uint, req dto.MergedReportRequest) (*dto.MergedReport, error) {
+	if len(req.Versions) == 0 {
+		return nil, errors.New("at least one version is required")
+	}
+
+	target := req.TargetVersion
+	if target == 0 {
+		for _, v := range req.Versions {
+			if v > target {
+				target = v
+			}
+		}
+	}
+
+	definition, err := s.getVersionDefinition(ctx, surveyId, target)
+	if err != nil {
+		return nil, err
+	}
+
+	participations, err := s.repo.GetParticipationCountByVersions(ctx, surveyId, req.Versions)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.repo.GetChoiceVoteCounts(ctx, definition.Questions.GetIds(), req.Versions)
+	if err != nil {
+		return nil, err
+	}
+
+	respondents, err := s.repo.GetQuestionRespondentCounts(ctx, definition.Questions.GetIds(), req.Versions)
+	if err != nil {
+		return nil, err
+	}
+
+	minGroupSize, err := s.minGroupSize(ctx, surveyId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.MergedReport{
+		TargetVersion:     target,
+		Versions:          req.Versions,
+		Participations:    participations,
+		ChoicesPercentage: SuppressQuestionReports(choiceReportsFromCounts(definition, counts, respondents, req.ChoiceMapping), minGroupSize),
+	}, nil
+}
+
+func (s *ReportService) getVersionDefinition(ctx context.Context, surveyId uint, version int) (*dto.SurveyDefinition, error) {
+	v, err := s.repo.GetSurveyVersion(ctx, surveyId, version)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, fmt.Errorf("version %d of survey not found", version)
+	}
+
+	definition := dto.SurveyDefinition{}
+	if err := json.Unmarshal([]byte(v.Definition), &definition); err != nil {
+		s.logger.Error(logging.Internal, logging.FailedConvertDto, "error in decoding survey version definition", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return nil, err
+	}
+	return &definition, nil
+}
+
+// choiceReportsFromCounts lays the vote counts out on the choices of the given definition.
+// Votes are matched by choice id after applying mapping, votes without a choice id fall back
+// to the choice text. Votes that match no choice are reported as unmapped.
+func choiceReportsFromCounts(definition *dto.SurveyDefinition, counts []dto.ChoiceVoteCount, respondentCounts []dto.QuestionRespondentCount, mapping map[uint]uint) []dto.QuestionReport {
+	respondents := map[uint]int64{}
+	for _, row := range respondentCounts {
+		respondents[row.QuestionID] = row.Respondents
+	}
+
+	res := make([]dto.QuestionReport, 0)
+	for _, q := range definition.Questions {
+		if !q.HasMultipleChoice {
+			continue
+		}
+
+		choiceIds := map[uint]bool{}
+		choiceTexts := map[string]uint{}
+		for _, choice := range q.Choices {
+			choiceIds[choice.ID] = true
+			choiceTexts[choice.Text] = choice.ID
+		}
+
+		chosen := map[uint]int64{}
+		var total, unmapped int64
+		for _, row := range counts {
+			if row.QuestionID != q.ID {
+				continue
+			}
+			total += row.Count
+
+			id := row.ChoiceID
+			if target, ok := mapping[id]; ok && id != 0 {
+				id = target
+			}
+			if id == 0 {
+				id = choiceTexts[row.Answer]
+			}
+			if !choiceIds[id] {
+				unmapped += row.Count
+				continue
+			}
+			chosen[id] += row.Count
+		}
+
+		questionReport := dto.QuestionReport{QuestionID: q.ID, Respondents: respondents[q.ID], Selections: total, ChoiceReport: make([]dto.ChoiceReport, 0), Unmapped: unmapped}
+		for _, choice := range q.Choices {
+			questionReport.ChoiceReport = append(questionReport.ChoiceReport, dto.ChoiceReport{
+				ID:                  choice.ID,
+				Text:                choice.Text,
+				Percentage:          percentage(chosen[choice.ID], respondents[q.ID]),
+				SelectionPercentage: percentage(chosen[choice.ID], total),
+			})
+		}
+		res = append(res, questionReport)
+	}
+	return res
+}
+
+// aggregateAnswers builds the aggregate of a typed question from its stored answers,
+// answers that do not fit the type (e.g. stored before the type changed) are skipped.
+func aggregateAnswers(q dto.Question, answers []string) dto.QuestionAggregate {
+	aggregate := dto.QuestionAggregate{QuestionID: q.ID, Type: q.QuestionType()}
+
+	switch aggregate.Type {
+	case dto.RatingQuestion, dto.NumericQuestion, dto.LikertQuestion:
+		if aggregate.Type != dto.NumericQuestion {
+			aggregate.Distribution = map[string]int{}
+		}
+		var sum float64
+		numbers := 0
+		for _, answer := range answers {
+			if aggregate.Type == dto.LikertQuestion {
+				aggregate.Distribution[answer]++
+				aggregate.Count++
+				continue
+			}
+			n, err := strconv.ParseFloat(answer, 64)
+			if err != nil {
+				continue
+			}
+			if aggregate.Distribution != nil {
+				aggregate.Distribution[answer]++
+			}
+			if aggregate.Min == nil || n < *aggregate.Min {
+				min := n
+				aggregate.Min = &min
+			}
+			if aggregate.Max == nil || n > *aggregate.Max {
+				max := n
+				aggregate.Max = &max
+			}
+			sum += n
+			numbers++
+			aggregate.Count++
+		}
+		if numbers > 0 {
+			average := sum / float64(numbers)
+			aggregate.Average = &average
+		}
+	case dto.DateQuestion:
+		for _, answer := range answers {
+			if _, err := time.Parse(dateLayout, answer); err != nil {
+				continue
+			}
+			// dates are stored as YYYY-MM-DD so they compare as strings
+			if aggregate.Earliest == "" || answer < aggregate.Earliest {
+				aggregate.Earliest = answer
+			}
+			if aggregate.Latest == "" || answer > aggregate.Latest {
+				aggregate.Latest = answer
+			}
+			aggregate.Count++
+		}
+	case dto.RankingQuestion:
+		sums := map[string]int{}
+		for _, answer := range answers {
+			ranking := []string{}
+			if err := json.Unmarshal([]byte(answer), &ranking); err != nil {
+				continue
+			}
+			for i, item := range ranking {
+				sums[item] += i + 1
+			}
+			aggregate.Count++
+		}
+		if aggregate.Count > 0 {
+			aggregate.AverageRank = map[string]float64{}
+			for item, sum := range sums {
+				aggregate.AverageRank[item] = float64(sum) / float64(aggregate.Count)
+			}
+		}
+	case dto.MatrixQuestion:
+		aggregate.Matrix = map[string]map[string]int{}
+		for _, row := range q.Config.Rows {
+			aggregate.Matrix[row] = map[string]int{}
+		}
+		for _, answer := range answers {
+			cells := map[string]string{}
+			if err := json.Unmarshal([]byte(answer), &cells); err != nil {
+				continue
+			}
+			for row, column := range cells {
+				if aggregate.Matrix[row] == nil {
+					aggregate.Matrix[row] = map[string]int{}
+				}
+				aggregate.Matrix[row][column]++
+			}
+			aggregate.Count++
+		}
+	}
+	return aggregate
+}
+
+func percentage(count int64, total int64) string {
+	if total == 0 {
+		return "0%"
+	}
+	return strconv.Itoa(int(100*float64(count)/float64(total))) + "%"
+}
+
+// GetScoreDistribution returns the score distribution of a quiz, nil when no participation was scored.
+func (s *ReportService) GetScoreDistribution(ctx context.Context, surveyId uint) (*dto.ScoreDistribution, error) {
+	participations, err := s.repo.GetScoredParticipations(ctx, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	if len(participations) == 0 {
+		return nil, nil
+	}
+
+	results := []dto.QuizResult{}
+	for _, p := range participations {
+		result := dto.QuizResult{Score: *p.Score}
+		if p.MaxScore != nil && *p.MaxScore > 0 {
+			result.MaxScore = *p.MaxScore
+			result.Percentage = math.Round(10000*result.Score/result.MaxScore) / 100
+		}
+		result.Passed = p.Passed != nil && *p.Passed
+		results = append(results, result)
+	}
+	distribution := DistributeScores(results)
+	return &distribution, nil
+}
+
+// GetQuestionTimes returns the average time spent on each question, rounded to tenths of a second.
+func (s *ReportService) GetQuestionTimes(ctx context.Context, surveyId uint) ([]dto.QuestionTime, error) {
+	times, err := s.repo.GetQuestionTimes(ctx, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	for i := range times {
+		times[i].AverageSeconds = math.Round(10*times[i].AverageSeconds) / 10
+	}
+	if times == nil {
+		times = []dto.QuestionTime{}
+	}
+	return times, nil
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"github.com/G9QBootcamp/qoli-survey/internal/util"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"golang.org/x/net/context"
+)
+
+// ResumeGracePeriod is how long a participation whose participant lost the connection waits to
+// be resumed. After it the participation is ended and counts as an attempt.
+const ResumeGracePeriod = 10 * time.Minute
+
+// ParticipationSuspended is the code of the error a participant gets when starting a survey while
+// a participation of theirs waits to be resumed.
+const ParticipationSuspended = "participation_suspended"
+
+// SuspendedParticipationError tells that a participant has an interrupted participation to resume
+// instead of starting a new one.
+type SuspendedParticipationError struct {
+	ParticipationID uint
+}
+
+func (e *SuspendedParticipationError) Error() string {
+	return fmt.Sprintf("%s: resume participation %d", ErrParticipationSuspended, e.ParticipationID)
+}
+
+func (e *SuspendedParticipationError) Unwrap() error {
+	return ErrParticipationSuspended
+}
+
+// ResumeExpired tells whether the participant of a participation that was disconnected at
+// disconnectedAt can no longer resume it.
+func ResumeExpired(disconnectedAt time.Time, now time.Time) bool {
+	return now.Sub(disconnectedAt) > ResumeGracePeriod
+}
+
+// QuestionProgress is the progress of a participation that was sent the questions of asked one at a time.
+func QuestionProgress(asked []*dto.Question, timer *AnswerTimer, timeLeft time.Duration) dto.ParticipationProgress {
+	return dto.ParticipationProgress{
+		Mode:     dto.ParticipationModeQuestion,
+		Asked:    dto.QuestionList(asked).GetIds(),
+		Spent:    spentMilliseconds(timer),
+		TimeLeft: progressSeconds(timeLeft),
+	}
+}
+
+// PageProgress is the progress of a participation that finished pages and is on page.
+func PageProgress(pages [][]*dto.Question, page []*dto.Question, timer *AnswerTimer, timeLeft time.Duration) dto.ParticipationProgress {
+	progress := dto.ParticipationProgress{
+		Mode:     dto.ParticipationModePage,
+		Pages:    [][]uint{},
+		Page:     dto.QuestionList(page).GetIds(),
+		Spent:    spentMilliseconds(timer),
+		TimeLeft: progressSeconds(timeLeft),
+	}
+	for _, finished := range pages {
+		progress.Pages = append(progress.Pages, dto.QuestionList(finished).GetIds())
+	}
+	return progress
+}
+
+// ProgressSpent returns the time spent on every question of a progress.
+func ProgressSpent(progress dto.ParticipationProgress) map[uint]time.Duration {
+	spent := map[uint]time.Duration{}
+	for id, milliseconds := range progress.Spent {
+		spent[id] = time.Duration(milliseconds) * time.Millisecond
+	}
+	return spent
+}
+
+// ProgressFits tells whether a progress can be resumed on flow, every question it was shown must
+// still be in the survey.
+func ProgressFits(flow *BranchEngine, progress dto.ParticipationProgress) bool {
+	fits := func(ids []uint) bool {
+		return len(ids) > 0 && len(flow.Questions(ids)) == len(ids)
+	}
+	switch progress.Mode {
+	case dto.ParticipationModeQuestion:
+		return fits(progress.Asked)
+	case dto.ParticipationModePage:
+		for _, page := range progress.Pages {
+			if !fits(page) {
+				return false
+			}
+		}
+		// one that answered every page has none on screen while it waits to be committed
+		return fits(progress.Page) || (len(progress.Page) == 0 && len(progress.Pages) > 0)
+	}
+	return false
+}
+
+// progressQuestions returns the questions a participant was shown in a progress.
+func progressQuestions(progress dto.ParticipationProgress) map[uint]bool {
+	shown := map[uint]bool{}
+	ids := append([]uint{}, progress.Asked...)
+	ids = append(ids, progress.Page...)
+	for _, page := range progress.Pages {
+		ids = append(ids, page...)
+	}
+	for _, id := range ids {
+		shown[id] = true
+	}
+	return shown
+}
+
+func spentMilliseconds(timer *AnswerTimer) map[uint]int64 {
+	spent := map[uint]int64{}
+	for id, d := range timer.SpentTimes() {
+		spent[id] = d.Milliseconds()
+	}
+	return spent
+}
+
+func progressSeconds(d time.Duration) int {
+	return int((max(0, d) + time.Second - 1) / time.Second)
+}
+
+// SaveProgress stores where a live participation got to.
+func (s *SurveyService) SaveProgress(c context.Context, participationId uint, progress dto.ParticipationProgress) error {
+	return s.repo.SaveParticipationProgress(c, participationId, (*models.ParticipationProgress)(&progress))
+}
+
+// SuspendParticipation keeps a participation whose participant lost the connection or that waits
+// for the next request until suspensionExpired, progress is where it got to.
+func (s *SurveyService) SuspendParticipation(c context.Context, participationId uint, progress dto.ParticipationProgress) error {
+	return s.repo.SuspendParticipation(c, participationId, (*models.ParticipationProgress)(&progress), time.Now())
+}
+
+// ResumeParticipation picks up a suspended participation of a respondent on a new connection.
+// Only one connection can resume a participation, a participation that waited too long or whose
+// survey changed meanwhile is ended instead, see suspensionExpired.
+func (s *SurveyService) ResumeParticipation(c context.Context, respondent models.Respondent, surveyId uint, participationId uint) (*dto.ParticipationResume, error) {
+	return s.resumeParticipation(c, respondent, surveyId, participationId, nil)
+}
+
+// resumeParticipation claims a suspended participation, stepToken is nil on a new connection and
+// the step token of the request otherwise. The progress of an anonymous participation is only in
+// its step token, so it can only be picked up over requests.
+func (s *SurveyService) resumeParticipation(c context.Context, respondent models.Respondent, surveyId uint, participationId uint, stepToken *string) (*dto.ParticipationResume, error) {
+	p, err := s.repo.GetUserParticipation(c, participationId)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil || p.SurveyID != surveyId || !p.Respondent().Is(respondent) {
+		return nil, ErrParticipationNotFound
+	}
+	if p.CommittedAt != nil || p.EndAt != nil {
+		return nil, fmt.Errorf("%w: it is finished", ErrNotResumable)
+	}
+	if p.Progress == nil {
+		return nil, fmt.Errorf("%w: it has no progress to resume from", ErrNotResumable)
+	}
+	if p.DisconnectedAt == nil {
+		return nil, fmt.Errorf("%w: it is still connected", ErrNotResumable)
+	}
+
+	survey, err := s.repo.GetSurveyByID(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	if survey == nil {
+		return nil, ErrSurveyNotFound
+	}
+	var step *dto.ParticipationStep
+	if survey.Anonymity != nil {
+		if stepToken == nil {
+			return nil, fmt.Errorf("%w: it is anonymous, continue it over requests", ErrNotResumable)
+		}
+		step, err = ParseStepToken(s.conf.JWT.SecretKey, *stepToken)
+		if err != nil {
+			return nil, err
+		}
+		// a token of an earlier step would answer questions again
+		if step.ParticipationID != p.ID || !step.SuspendedAt.Equal(*p.DisconnectedAt) {
+			return nil, ErrInvalidStepToken
+		}
+	}
+
+	if err := s.syncSurveyStatus(c, survey); err != nil {
+		return nil, err
+	}
+	cause, reason := ErrNotResumable, ""
+	switch {
+	case suspensionExpired(p, time.Now()):
+		reason = "the time to resume it has passed"
+		if p.Progress.OverRequests {
+			cause, reason = ErrTimeLimitReached, "the answer time ran out since the last request"
+		}
+	case survey.Status != models.SurveyStatusOpen:
+		reason = fmt.Sprintf("survey is %s", survey.Status)
+	case survey.CurrentVersion != p.SurveyVersion:
+		reason = "the survey changed since it started"
+	}
+	if reason != "" {
+		ended, err := s.endSuspendedParticipation(c, p)
+		if err != nil {
+			return nil, err
+		}
+		if !ended {
+			return nil, fmt.Errorf("%w: it was resumed already", ErrNotResumable)
+		}
+		return nil, fmt.Errorf("%w: %s", cause, reason)
+	}
+
+	claimed, err := s.repo.ClaimParticipation(c, p.ID, *p.DisconnectedAt)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, fmt.Errorf("%w: it was resumed already", ErrNotResumable)
+	}
+
+	resume := &dto.ParticipationResume{Progress: dto.ParticipationProgress(*p.Progress), Answers: map[uint][]string{}, SuspendedAt: *p.DisconnectedAt}
+	if err := util.ConvertTypes(s.logger, p, &resume.Participation); err != nil {
+		return nil, err
+	}
+	if step != nil {
+		resume.Progress, resume.ResponseID, resume.Votes = step.Progress, step.ResponseID, step.Votes
+		for _, vote := range step.Votes {
+			resume.Answers[vote.QuestionID] = append(resume.Answers[vote.QuestionID], vote.Answer)
+		}
+		return resume, nil
+	}
+
+	votes, err := s.repo.GetRespondentVotes(c, surveyId, respondent)
+	if err != nil {
+		return nil, err
+	}
+	// votes of earlier participations on questions this one was not asked yet are left out
+	shown := progressQuestions(resume.Progress)
+	for _, vote := range votes {
+		if shown[vote.QuestionID] && !vote.UpdatedAt.Before(p.StartAt) {
+			resume.Answers[vote.QuestionID] = append(resume.Answers[vote.QuestionID], vote.Answer)
+		}
+	}
+	return resume, nil
+}
+
+// suspensionExpired tells whether a suspended participation can no longer be picked up. One that
+// lost its connection waits ResumeGracePeriod, one answered over requests waits as long as it has
+// answer time left, since the time between requests counts as answer time.
+func suspensionExpired(p *models.UserSurveyParticipation, now time.Time) bool {
+	if p.Progress != nil && p.Progress.OverRequests {
+		return now.Sub(*p.DisconnectedAt) >= time.Duration(p.Progress.TimeLeft)*time.Second
+	}
+	return ResumeExpired(*p.DisconnectedAt, now)
+}
+
+// SuspendedAnswerTime returns the answer time a participation used up while it was suspended since
+// suspendedAt. Only the time between requests counts, a lost connection does not use up answer time.
+func SuspendedAnswerTime(progress dto.ParticipationProgress, suspendedAt time.Time, now time.Time) time.Duration {
+	if !progress.OverRequests {
+		return 0
+	}
+	return now.Sub(suspendedAt)
+}
+
+// endSuspendedParticipation ends a suspended participation and gives its quota places to other
+// participants. It reports false when the participation was resumed or ended meanwhile.
+func (s *SurveyService) endSuspendedParticipation(c context.Context, p *models.UserSurveyParticipation) (bool, error) {
+	ended, err := s.repo.EndSuspendedParticipation(c, p.ID, *p.DisconnectedAt, time.Now())
+	if err != nil || !ended {
+		return false, err
+	}
+	survey, err := s.repo.GetSurveyByID(c, p.SurveyID)
+	if err != nil {
+		return true, err
+	}
+	if err := s.concealParticipation(c, survey, p.ID); err != nil {
+		return true, err
+	}
+	return true, s.repo.ReleaseQuotas(c, p.ID, nil)
+}
+
+// endExpiredParticipations ends the suspended participations that were not resumed in time, their
+// participants may never come back to start the survey again.
+func (s *SurveyService) endExpiredParticipations(c context.Context) {
+	participations, err := s.repo.GetSuspendedParticipations(c)
+	if err != nil {
+		s.logger.Error(logging.Internal, logging.Update, "error in getting suspended participations", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return
+	}
+	now := time.Now()
+	for i := range participations {
+		p := &participations[i]
+		if !suspensionExpired(p, now) {
+			continue
+		}
+		if _, err := s.endSuspendedParticipation(c, p); err != nil {
+			s.logger.Error(logging.Internal, logging.Update, fmt.Sprintf("error in ending the suspended participation %d", p.ID), map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// SchedulerInterval is how often the scheduler runs.
+const SchedulerInterval = time.Minute
+
+// RunScheduler persists the status transitions caused by the start and end times of surveys every
+// interval until c is done. Reads show the status a survey should have on their own, so they never
+// write and a due survey only waits for the next run to be stored. It also ends the suspended
+// participations that were not resumed in time, so they stop holding their quota places.
+func (s *SurveyService) RunScheduler(c context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.syncDueSurveys(c)
+		s.endExpiredParticipations(c)
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/repository"
+	"github.com/G9QBootcamp/qoli-survey/internal/util"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"golang.org/x/net/context"
+)
+
+type ISectionService interface {
+	GetSections(c context.Context, surveyId uint) ([]*dto.Section, error)
+	CreateSection(c context.Context, surveyId uint, req dto.SectionCreateRequest) (*dto.Section, error)
+	UpdateSection(c context.Context, surveyId uint, id uint, req dto.SectionUpdateRequest) (*dto.Section, error)
+	DeleteSection(c context.Context, surveyId uint, id uint) error
+}
+
+type SectionService struct {
+	conf           *config.Config
+	repo           repository.ISurveyRepository
+	logger         logging.Logger
+	versionService IVersionService
+}
+
+func NewSectionService(conf *config.Config, repo repository.ISurveyRepository, logger logging.Logger) *SectionService {
+	return &SectionService{conf: conf, repo: repo, logger: logger, versionService: NewVersionService(conf, repo, logger)}
+}
+
+func (s *SectionService) GetSections(c context.Context, surveyId uint) ([]*dto.Section, error) {
+	sections, err := s.repo.GetSections(c, surveyId)
+	if err != nil {
+		return []*dto.Section{}, err
+	}
+	response := []*dto.Section{}
+	return response, util.ConvertTypes(s.logger, sections, &response)
+}
+
+// CreateSection appends a new section after the existing ones.
+func (s *SectionService) CreateSection(c context.Context, surveyId uint, req dto.SectionCreateRequest) (*dto.Section, error) {
+	survey, err := s.editableSurvey(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+
+	sections, err := s.repo.GetSections(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	order := 1
+	for _, v := range sections {
+		if v.Order >= order {
+			order = v.Order + 1
+		}
+	}
+
+	section := models.Section{SurveyID: surveyId, Title: req.Title, Intro: req.Intro, MediaUrl: req.MediaUrl, Order: order}
+	if err := s.repo.CreateSection(c, &section); err != nil {
+		return nil, err
+	}
+	return s.afterChange(c, survey, &section)
+}
+
+func (s *SectionService) UpdateSection(c context.Context, surveyId uint, id uint, req dto.SectionUpdateRequest) (*dto.Section, error) {
+	section, err := s.repo.GetSectionByID(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if section == nil || section.SurveyID != surveyId {
+		return nil, nil
+	}
+
+	survey, err := s.repo.GetSurveyByID(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	if survey == nil {
+		return nil, ErrSurveyNotFound
+	}
+	// like questions, sections of open surveys only accept wording fixes
+	if !survey.IsEditable() && (survey.CurrentStatus(time.Now()) != models.SurveyStatusOpen || req.Order != section.Order) {
+		return nil, ErrSurveyNotEditable
+	}
+
+	section.Title = req.Title
+	section.Intro = req.Intro
+	section.MediaUrl = req.MediaUrl
+	section.Order = req.Order
+	if err := s.repo.UpdateSection(c, section); err != nil {
+		return nil, err
+	}
+	return s.afterChange(c, survey, section)
+}
+
+// DeleteSection removes a section, its questions are kept without a section.
+func (s *SectionService) DeleteSection(c context.Context, surveyId uint, id uint) error {
+	section, err := s.repo.GetSectionByID(c, id)
+	if err != nil {
+		return err
+	}
+	if section == nil || section.SurveyID != surveyId {
+		return nil
+	}
+
+	survey, err := s.editableSurvey(c, surveyId)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteSection(c, id); err != nil {
+		return err
+	}
+	_, err = s.afterChange(c, survey, nil)
+	return err
+}
+
+func (s *SectionService) editableSurvey(c context.Context, surveyId uint) (*models.Survey, error) {
+	survey, err := s.repo.GetSurveyByID(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	if survey == nil {
+		return nil, ErrSurveyNotFound
+	}
+	if !survey.IsEditable() {
+		return nil, ErrSurveyNotEditable
+	}
+	return survey, nil
+}
+
+func (s *SectionService) afterChange(c context.Context, survey *models.Survey, section *models.Section) (*dto.Section, error) {
+	if survey.Status != models.SurveyStatusDraft {
+		if _, err := s.versionService.PublishVersion(c, survey.ID); err != nil {
+			return nil, err
+		}
+	}
+	if section == nil {
+		return nil, nil
+	}
+	response := dto.Section{}
+	return &response, util.ConvertTypes(s.logger, section, &response)
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/repository"
+	"github.com/G9QBootcamp/qoli-survey/internal/util"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"golang.org/x/net/context"
+)
+
+var shareLinkSlug = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,62}[a-z0-9]$`)
+
+type IShareLinkService interface {
+	GetShareLink(c context.Context, surveyId uint) (*dto.ShareLink, error)
+	UpdateShareLink(c context.Context, surveyId uint, req dto.ShareLinkRequest) (*dto.ShareLink, error)
+	OpenShareLink(c context.Context, slug string, guestToken string) (*dto.ShareLinkPreview, error)
+	GetGuest(c context.Context, slug string, guestToken string) (surveyId uint, guestId uint, err error)
+}
+
+type ShareLinkService struct {
+	conf   *config.Config
+	repo   repository.ISurveyRepository
+	logger logging.Logger
+}
+
+func NewShareLinkService(conf *config.Config, repo repository.ISurveyRepository, logger logging.Logger) *ShareLinkService {
+	return &ShareLinkService{conf: conf, repo: repo, logger: logger}
+}
+
+func (s *ShareLinkService) GetShareLink(c context.Context, surveyId uint) (*dto.ShareLink, error) {
+	link, err := s.repo.GetShareLink(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return nil, ErrShareLinkNotFound
+	}
+	response := dto.ShareLink{}
+	return &response, util.ConvertTypes(s.logger, link, &response)
+}
+
+// UpdateShareLink creates or changes the share link of a survey, a disabled link keeps its slug.
+func (s *ShareLinkService) UpdateShareLink(c context.Context, surveyId uint, req dto.ShareLinkRequest) (*dto.ShareLink, error) {
+	survey, err := s.repo.GetSurveyByID(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	if survey == nil {
+		return nil, ErrSurveyNotFound
+	}
+	if survey.IsTemplate {
+		return nil, ErrSurveyIsTemplate
+	}
+
+	link, err := s.repo.GetShareLink(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		nonce, err := newTokenNonce()
+		if err != nil {
+			return nil, err
+		}
+		link = &models.ShareLink{SurveyID: surveyId, Slug: nonce[:10]}
+	}
+	if slug := strings.ToLower(strings.TrimSpace(req.Slug)); slug != "" && slug != link.Slug {
+		if err := ValidateShareLinkSlug(slug); err != nil {
+			return nil, err
+		}
+		taken, err := s.repo.GetShareLinkBySlug(c, slug)
+		if err != nil {
+			return nil, err
+		}
+		if taken != nil {
+			return nil, ErrShareLinkTaken
+		}
+		link.Slug = slug
+	}
+	link.Enabled = req.Enabled
+	link.GuestParticipationLimit = req.GuestParticipationLimit
+	if link.GuestParticipationLimit == 0 {
+		link.GuestParticipationLimit = 1
+	}
+	if err := s.repo.SaveShareLink(c, link); err != nil {
+		return nil, err
+	}
+
+	response := dto.ShareLink{}
+	return &response, util.ConvertTypes(s.logger, link, &response)
+}
+
+// OpenShareLink shows the survey of a share link, guestToken is the token the browser got before.
+// A new guest session is started when the browser has none.
+func (s *ShareLinkService) OpenShareLink(c context.Context, slug string, guestToken string) (*dto.ShareLinkPreview, error) {
+	link, err := s.enabledLink(c, slug)
+	if err != nil {
+		return nil, err
+	}
+	survey, err := s.repo.GetSurveyByID(c, link.SurveyID)
+	if err != nil {
+		return nil, err
+	}
+	if survey == nil {
+		return nil, ErrShareLinkNotFound
+	}
+
+	guest, err := s.tokenGuest(c, guestToken)
+	if errors.Is(err, ErrGuestSessionNotFound) {
+		guest = &models.GuestSession{LastSeenAt: time.Now()}
+		if guest.Nonce, err = newTokenNonce(); err == nil {
+			err = s.repo.CreateGuestSession(c, guest)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ShareLinkPreview{
+		SurveyID:    survey.ID,
+		SurveyTitle: survey.Title,
+		StartTime:   survey.StartTime,
+		EndTime:     survey.EndTime,
+		GuestToken:  SignGuestToken(s.conf.JWT.SecretKey, guest.ID, guest.Nonce),
+	}, nil
+}
+
+// GetGuest returns the survey of a share link and the guest session of guestToken.
+func (s *ShareLinkService) GetGuest(c context.Context, slug string, guestToken string) (uint, uint, error) {
+	link, err := s.enabledLink(c, slug)
+	if err != nil {
+		return 0, 0, err
+	}
+	guest, err := s.tokenGuest(c, guestToken)
+	if err != nil {
+		return 0, 0, err
+	}
+	return link.SurveyID, guest.ID, nil
+}
+
+func (s *ShareLinkService) enabledLink(c context.Context, slug string) (*models.ShareLink, error) {
+	link, err := s.repo.GetShareLinkBySlug(c, strings.ToLower(slug))
+	if err != nil {
+		return nil, err
+	}
+	if link == nil || !link.Enabled {
+		return nil, ErrShareLinkNotFound
+	}
+	return link, nil
+}
+
+// tokenGuest returns the guest session a token was signed for and records that it was seen.
+func (s *ShareLinkService) tokenGuest(c context.Context, token string) (*models.GuestSession, error) {
+	id, nonce, err := ParseGuestToken(s.conf.JWT.SecretKey, token)
+	if err != nil {
+		return nil, err
+	}
+	guest, err := s.repo.GetGuestSession(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if guest == nil || !hmac.Equal([]byte(guest.Nonce), []byte(nonce)) {
+		return nil, ErrGuestSessionNotFound
+	}
+	return guest, s.repo.TouchGuestSession(c, guest.ID)
+}
+
+// ValidateShareLinkSlug checks that a slug has 3 to 64 lowercase letters, digits and inner hyphens.
+func ValidateShareLinkSlug(slug string) error {
+	if !shareLinkSlug.MatchString(slug) {
+		return fmt.Errorf("%w: the slug must have 3 to 64 lowercase letters, digits and hyphens, and can not start or end with a hyphen", ErrInvalidShareLink)
+	}
+	return nil
+}
+
+// SignGuestToken returns the token of a guest session, it is signed with secret so the id and
+// nonce in it can not be forged.
+func SignGuestToken(secret string, id uint, nonce string) string {
+	payload := fmt.Sprintf("guest.%d.%s", id, nonce)
+	return payload + "." + tokenSignature(secret, payload)
+}
+
+// ParseGuestToken returns the guest session id and nonce of a token signed with secret.
+func ParseGuestToken(secret string, token string) (uint, string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 || parts[0] != "guest" {
+		return 0, "", ErrGuestSessionNotFound
+	}
+	payload := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(parts[3]), []byte(tokenSignature(secret, payload))) {
+		return 0, "", ErrGuestSessionNotFound
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0, "", ErrGuestSessionNotFound
+	}
+	return uint(id), parts[2], nil
+}
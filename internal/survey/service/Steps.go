@@ -0,0 +1,71 @@
+package service
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"golang.org/x/net/context"
+)
+
+// SuspendStep stores where a participation answered over requests got to once a request is answered,
+// until the next request the participation waits as long as it has answer time left. An anonymous
+// participation only stores the answer time it has left, the rest of the step comes back with the
+// next request in the step token that is returned.
+func (s *SurveyService) SuspendStep(c context.Context, step dto.ParticipationStep) (string, error) {
+	step.Progress.OverRequests = true
+	if step.ResponseID == "" {
+		return "", s.SuspendParticipation(c, step.ParticipationID, step.Progress)
+	}
+
+	// the time is stored to the microsecond, the token must carry the same one
+	step.SuspendedAt = time.Now().Truncate(time.Microsecond)
+	progress := models.ParticipationProgress{Mode: step.Progress.Mode, TimeLeft: step.Progress.TimeLeft, OverRequests: true}
+	if err := s.repo.SuspendParticipation(c, step.ParticipationID, &progress, step.SuspendedAt); err != nil {
+		return "", err
+	}
+	return SignStepToken(s.conf.JWT.SecretKey, step)
+}
+
+// ContinueParticipation picks up a participation answered over requests for the next request,
+// stepToken is the token the last step of an anonymous participation returned. A participation
+// whose answer time ran out since the last request is ended with ErrTimeLimitReached.
+func (s *SurveyService) ContinueParticipation(c context.Context, respondent models.Respondent, surveyId uint, participationId uint, stepToken string) (*dto.ParticipationResume, error) {
+	return s.resumeParticipation(c, respondent, surveyId, participationId, &stepToken)
+}
+
+// SignStepToken returns the step token of an anonymous participation, it is signed with secret so
+// the progress and votes in it can not be forged.
+func SignStepToken(secret string, step dto.ParticipationStep) (string, error) {
+	b, err := json.Marshal(step)
+	if err != nil {
+		return "", err
+	}
+	payload := "step." + base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + tokenSignature(secret, payload), nil
+}
+
+// ParseStepToken returns the step of an anonymous participation in a token signed with secret.
+func ParseStepToken(secret string, token string) (*dto.ParticipationStep, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != "step" {
+		return nil, ErrInvalidStepToken
+	}
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(tokenSignature(secret, payload))) {
+		return nil, ErrInvalidStepToken
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidStepToken
+	}
+	var step dto.ParticipationStep
+	if err := json.Unmarshal(b, &step); err != nil {
+		return nil, ErrInvalidStepToken
+	}
+	return &step, nil
+}
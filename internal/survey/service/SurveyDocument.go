@@ -0,0 +1,363 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"golang.org/x/net/context"
+)
+
+// ExportSurvey returns the portable document of a survey, see dto.SurveyDocument for its schema.
+func (s *SurveyService) ExportSurvey(c context.Context, surveyId uint) (*dto.SurveyDocument, error) {
+	survey, err := s.repo.GetSurveyByID(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	if survey == nil {
+		return nil, ErrSurveyNotFound
+	}
+
+	doc := &dto.SurveyDocument{
+		SchemaVersion:      dto.SurveyDocumentSchemaVersion,
+		Title:              survey.Title,
+		TitleTranslations:  survey.TitleTranslations,
+		StartTime:          survey.StartTime,
+		EndTime:            survey.EndTime,
+		IsSequential:       survey.IsSequential,
+		Randomization:      (*dto.Randomization)(survey.Randomization),
+		Quiz:               (*dto.QuizSettings)(survey.Quiz),
+		Anonymity:          (*dto.AnonymitySettings)(survey.Anonymity),
+		AllowReturn:        survey.AllowReturn,
+		ParticipationLimit: survey.ParticipationLimit,
+		AnswerTimeLimit:    survey.AnswerTimeLimit,
+		Questions:          []dto.DocumentQuestion{},
+	}
+
+	options, err := s.GetOptions(c, dto.SurveyOptionsGetRequest{SurveyId: surveyId})
+	if err != nil {
+		return nil, err
+	}
+	for _, option := range options {
+		doc.Options = append(doc.Options, dto.DocumentOption{Name: option.Name, Value: option.Value})
+	}
+
+	sections, err := s.getSections(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	sectionTitles := map[uint]string{}
+	for _, section := range sections {
+		sectionTitles[section.ID] = section.Title
+		doc.Sections = append(doc.Sections, dto.DocumentSection{Title: section.Title, Intro: section.Intro, MediaUrl: section.MediaUrl})
+	}
+
+	questions, err := s.getOrderedQuestions(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	positions := map[uint]uint{}
+	conditions := map[uint]*dto.DocumentCondition{}
+	for i, q := range questions {
+		positions[q.ID] = uint(i + 1)
+		for _, choice := range q.Choices {
+			if choice.LinkedQuestionID != 0 && conditions[choice.LinkedQuestionID] == nil {
+				conditions[choice.LinkedQuestionID] = &dto.DocumentCondition{QuestionText: q.Text, Answer: choice.Text}
+			}
+		}
+	}
+	for _, q := range questions {
+		// piping refers to questions by position like rules do
+		question := dto.DocumentQuestion{
+			Text:         RemapPiping(q.Text, positions),
+			Translations: remapTranslations(q.TextTranslations, positions),
+			Type:         q.QuestionType(),
+			MediaUrl:     q.MediaUrl,
+			Section:      sectionTitles[q.SectionID],
+			Condition:    conditions[q.ID],
+		}
+		if !reflect.DeepEqual(q.Config, dto.QuestionConfig{}) {
+			config := q.Config
+			config.ChoicesFrom = positions[config.ChoicesFrom]
+			question.Config = &config
+		}
+		for _, choice := range q.Choices {
+			question.Choices = append(question.Choices, dto.DocumentChoice{
+				Text:         RemapPiping(choice.Text, positions),
+				Translations: remapTranslations(choice.TextTranslations, positions),
+				IsCorrect:    choice.IsCorrect,
+				Anchored:     choice.Anchored,
+				Points:       choice.Points,
+			})
+		}
+		doc.Questions = append(doc.Questions, question)
+	}
+
+	rules, err := s.GetBranchRules(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		doc.Rules = append(doc.Rules, dto.DocumentRule{
+			Question:       int(positions[rule.QuestionID]),
+			Priority:       rule.Priority,
+			Condition:      remapExpression(rule.Condition, positions),
+			TargetQuestion: int(positions[rule.TargetQuestionID]),
+			EndSurvey:      rule.EndSurvey,
+		})
+	}
+	return doc, nil
+}
+
+// ImportSurvey creates a survey owned by ownerId from a document. Every problem of the document is
+// reported at once and nothing is stored when there is one, a dry run only reports the problems.
+// A survey that fails to be stored completely is removed again.
+func (s *SurveyService) ImportSurvey(c context.Context, ownerId uint, doc dto.SurveyDocument, dryRun bool) (*dto.SurveyImportResponse, error) {
+	response := &dto.SurveyImportResponse{DryRun: dryRun, Problems: ValidateSurveyDocument(doc)}
+	response.Valid = len(response.Problems) == 0
+	if !response.Valid || dryRun {
+		return response, nil
+	}
+
+	req := dto.SurveyCreateRequest{
+		Title:              doc.Title,
+		TitleTranslations:  doc.TitleTranslations,
+		StartTime:          doc.StartTime,
+		EndTime:            doc.EndTime,
+		IsSequential:       doc.IsSequential,
+		Randomization:      doc.Randomization,
+		Quiz:               doc.Quiz,
+		Anonymity:          doc.Anonymity,
+		AllowReturn:        doc.AllowReturn,
+		ParticipationLimit: doc.ParticipationLimit,
+		AnswerTimeLimit:    doc.AnswerTimeLimit,
+		OwnerID:            ownerId,
+	}
+	for _, section := range doc.Sections {
+		req.Sections = append(req.Sections, dto.SectionCreateRequest{Title: section.Title, Intro: section.Intro, MediaUrl: section.MediaUrl})
+	}
+	for _, q := range doc.Questions {
+		question := dto.QuestionCreateRequest{
+			Text:              q.Text,
+			TextTranslations:  q.Translations,
+			Type:              q.Type,
+			HasMultipleChoice: q.Type == dto.MultipleChoiceQuestion,
+			MediaUrl:          q.MediaUrl,
+			Section:           q.Section,
+		}
+		if q.Config != nil {
+			question.Config = *q.Config
+		}
+		if q.Condition != nil {
+			question.Condition = dto.Condition{QuestionText: q.Condition.QuestionText, Answer: q.Condition.Answer}
+		}
+		for _, choice := range q.Choices {
+			question.Choices = append(question.Choices, dto.ChoiceCreateRequest{Text: choice.Text, TextTranslations: choice.Translations, IsCorrect: choice.IsCorrect, Anchored: choice.Anchored, Points: choice.Points})
+		}
+		req.Questions = append(req.Questions, question)
+	}
+
+	survey, err := s.CreateSurvey(c, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.importSurveyDetails(c, ownerId, survey.SurveyID, doc); err != nil {
+		s.discardSurvey(c, survey.SurveyID)
+		return nil, err
+	}
+	response.Survey = survey
+	return response, nil
+}
+
+// importSurveyDetails stores the options and rules of a document for the survey created from it.
+func (s *SurveyService) importSurveyDetails(c context.Context, ownerId uint, surveyId uint, doc dto.SurveyDocument) error {
+	for _, option := range doc.Options {
+		if _, err := s.CreateOption(c, ownerId, surveyId, dto.SurveyOptionCreateRequest{Name: option.Name, Value: option.Value}); err != nil {
+			return err
+		}
+	}
+
+	if len(doc.Rules) > 0 {
+		// questions are created in the order of the document
+		questions, err := s.getQuestions(c, surveyId)
+		if err != nil {
+			return err
+		}
+		ids := map[uint]uint{}
+		for i, q := range questions {
+			ids[uint(i+1)] = q.ID
+		}
+		rules := dto.BranchRulesUpdateRequest{}
+		for _, rule := range doc.Rules {
+			rules.Rules = append(rules.Rules, dto.BranchRule{
+				QuestionID:       ids[uint(rule.Question)],
+				Priority:         rule.Priority,
+				Condition:        remapExpression(rule.Condition, ids),
+				TargetQuestionID: ids[uint(rule.TargetQuestion)],
+				EndSurvey:        rule.EndSurvey,
+			})
+		}
+		if _, err := s.UpdateBranchRules(c, surveyId, rules); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateSurveyDocument returns every problem that would stop a document from being imported.
+func ValidateSurveyDocument(doc dto.SurveyDocument) []string {
+	problems := []string{}
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if doc.SchemaVersion != dto.SurveyDocumentSchemaVersion {
+		add("schema_version %d is not supported, expected %d", doc.SchemaVersion, dto.SurveyDocumentSchemaVersion)
+	}
+	if strings.TrimSpace(doc.Title) == "" {
+		add("title is required")
+	}
+	if doc.StartTime.IsZero() || doc.EndTime.IsZero() {
+		add("start_time and end_time are required")
+	} else if !doc.EndTime.After(doc.StartTime) {
+		add("end_time must be after start_time")
+	}
+	if doc.ParticipationLimit <= 0 {
+		add("participation_limit must be positive")
+	}
+	if doc.AnswerTimeLimit <= 0 {
+		add("answer_time_limit must be positive")
+	}
+	for i, option := range doc.Options {
+		if option.Name == "" || option.Value == "" {
+			add("option %d: name and value are required", i+1)
+		} else if err := ValidateOption(option.Name, option.Value); err != nil {
+			add("option %d: %s", i+1, strings.TrimPrefix(err.Error(), ErrInvalidOption.Error()+": "))
+		}
+	}
+
+	sections := map[string]bool{}
+	for i, section := range doc.Sections {
+		if section.Title == "" {
+			add("section %d: title is required", i+1)
+		} else if sections[section.Title] {
+			add("section %d: title '%s' is used twice", i+1, section.Title)
+		}
+		sections[section.Title] = true
+	}
+
+	questions := map[string]dto.DocumentQuestion{}
+	for i, q := range doc.Questions {
+		if _, ok := questions[q.Text]; ok && q.Text != "" {
+			add("question %d: text '%s' is used twice", i+1, q.Text)
+		}
+		questions[q.Text] = q
+	}
+	for i, q := range doc.Questions {
+		name := fmt.Sprintf("question %d", i+1)
+		if strings.TrimSpace(q.Text) == "" {
+			add("%s: text is required", name)
+		}
+		if q.Section != "" && !sections[q.Section] {
+			add("%s: section '%s' not found", name, q.Section)
+		}
+		config := dto.QuestionConfig{}
+		if q.Config != nil {
+			config = *q.Config
+		}
+		questionType := q.Type
+		if questionType == "" {
+			questionType = dto.TextQuestion
+		}
+		if _, _, err := ResolveQuestionType(questionType, questionType == dto.MultipleChoiceQuestion, config); err != nil {
+			add("%s: %s", name, err.Error())
+		}
+		if questionType != dto.MultipleChoiceQuestion && len(q.Choices) > 0 {
+			add("%s: only multiple_choice questions can have choices", name)
+		}
+		seen := map[string]bool{}
+		for _, choice := range q.Choices {
+			if seen[strings.ToLower(choice.Text)] {
+				add("%s: choice '%s' is used twice", name, choice.Text)
+			}
+			seen[strings.ToLower(choice.Text)] = true
+		}
+
+		if q.Condition == nil {
+			continue
+		}
+		parent, ok := questions[q.Condition.QuestionText]
+		switch {
+		case !ok:
+			add("%s: condition question '%s' not found", name, q.Condition.QuestionText)
+		case q.Condition.QuestionText == q.Text:
+			add("%s: condition refers to the question itself", name)
+		default:
+			found := false
+			for _, choice := range parent.Choices {
+				found = found || choice.Text == q.Condition.Answer
+			}
+			if !found {
+				add("%s: condition answer '%s' is not a choice of question '%s'", name, q.Condition.Answer, q.Condition.QuestionText)
+			}
+		}
+	}
+
+	listed := []*dto.Question{}
+	for i, q := range doc.Questions {
+		question := &dto.Question{ID: uint(i + 1), Text: q.Text, TextTranslations: q.Translations, Type: q.Type}
+		if q.Config != nil {
+			question.Config = *q.Config
+		}
+		for _, choice := range q.Choices {
+			question.Choices = append(question.Choices, dto.Choice{Text: choice.Text, TextTranslations: choice.Translations})
+		}
+		listed = append(listed, question)
+	}
+	problems = append(problems, ValidatePiping(listed, doc.IsSequential)...)
+	if err := ValidateRandomization(doc.Randomization, doc.IsSequential); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if err := ValidateQuiz(doc.Quiz); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if err := ValidateAnonymity(doc.Anonymity, doc.Quiz); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if len(doc.Rules) > 0 {
+		positions := []*dto.Question{}
+		for i := range doc.Questions {
+			positions = append(positions, &dto.Question{ID: uint(i + 1)})
+		}
+		rules := []dto.BranchRule{}
+		for _, rule := range doc.Rules {
+			rules = append(rules, dto.BranchRule{
+				QuestionID:       uint(rule.Question),
+				Priority:         rule.Priority,
+				Condition:        rule.Condition,
+				TargetQuestionID: uint(rule.TargetQuestion),
+				EndSurvey:        rule.EndSurvey,
+			})
+		}
+		problems = append(problems, ValidateBranchRules(positions, rules, doc.IsSequential)...)
+	}
+	return problems
+}
+
+// remapExpression returns a copy of a rule condition that refers to the questions in ids instead.
+func remapExpression(e dto.RuleExpression, ids map[uint]uint) dto.RuleExpression {
+	remapped := dto.RuleExpression{Operator: e.Operator, Value: e.Value, QuestionID: ids[e.QuestionID]}
+	for _, child := range e.And {
+		remapped.And = append(remapped.And, remapExpression(child, ids))
+	}
+	for _, child := range e.Or {
+		remapped.Or = append(remapped.Or, remapExpression(child, ids))
+	}
+	if e.Not != nil {
+		not := remapExpression(*e.Not, ids)
+		remapped.Not = &not
+	}
+	return remapped
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+)
+
+const (
+	OptionVoteDeletionLimitHours = "vote_deletion_limit_hours"
+	OptionVotesVisibility        = "votes_visibility"
+	OptionCity                   = "city"
+	OptionMinimumAge             = "minimum_age"
+	OptionInvitationOnly         = "invitation_only"
+)
+
+const (
+	VotesVisibilityPublic    = "public"
+	VotesVisibilityAdmin     = "admin"
+	VotesVisibilityInvisible = "invisible"
+)
+
+// surveyOptions is the registry of the options a survey can have, options that are not in it are rejected.
+var surveyOptions = map[string]dto.SurveyOptionSchema{
+	OptionVoteDeletionLimitHours: {
+		Name:        OptionVoteDeletionLimitHours,
+		Type:        dto.OptionTypeInt,
+		Description: "hours after voting in which a participant can still delete a vote, no limit when unset",
+		Min:         intPointer(0),
+	},
+	OptionVotesVisibility: {
+		Name:        OptionVotesVisibility,
+		Type:        dto.OptionTypeEnum,
+		Description: "who can watch the votes of the survey",
+		Allowed:     []string{VotesVisibilityPublic, VotesVisibilityAdmin, VotesVisibilityInvisible},
+		Default:     VotesVisibilityPublic,
+	},
+	OptionInvitationOnly: {
+		Name:        OptionInvitationOnly,
+		Type:        dto.OptionTypeBool,
+		Description: "only invitees that accepted their invitation can take the survey",
+		Default:     "false",
+	},
+	OptionCity: {
+		Name:        OptionCity,
+		Type:        dto.OptionTypeString,
+		Description: "the only city participants can be from",
+		Deprecated:  "use the eligibility rule of the survey",
+	},
+	OptionMinimumAge: {
+		Name:        OptionMinimumAge,
+		Type:        dto.OptionTypeInt,
+		Description: "the age participants must have at least",
+		Min:         intPointer(0),
+		Deprecated:  "use the eligibility rule of the survey",
+	},
+}
+
+// SurveyOptionSchemas lists the options a survey can have, ordered by name.
+func SurveyOptionSchemas() []dto.SurveyOptionSchema {
+	schemas := []dto.SurveyOptionSchema{}
+	for _, schema := range surveyOptions {
+		schemas = append(schemas, schema)
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].Name < schemas[j].Name })
+	return schemas
+}
+
+// ValidateOption checks that an option is known and that its value has the type of the option.
+func ValidateOption(name string, value string) error {
+	schema, ok := surveyOptions[name]
+	if !ok {
+		return fmt.Errorf("%w: unknown option %q", ErrInvalidOption, name)
+	}
+	switch schema.Type {
+	case dto.OptionTypeInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%w: %s must be a whole number", ErrInvalidOption, name)
+		}
+		if schema.Min != nil && n < *schema.Min {
+			return fmt.Errorf("%w: %s must be at least %d", ErrInvalidOption, name, *schema.Min)
+		}
+	case dto.OptionTypeEnum:
+		for _, allowed := range schema.Allowed {
+			if value == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s must be one of %v", ErrInvalidOption, name, schema.Allowed)
+	case dto.OptionTypeBool:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("%w: %s must be true or false", ErrInvalidOption, name)
+		}
+	case dto.OptionTypeString:
+		if value == "" {
+			return fmt.Errorf("%w: %s can not be empty", ErrInvalidOption, name)
+		}
+	}
+	return nil
+}
+
+// OptionValue returns the value of an option of a survey, or the default of the option when it is not set.
+func OptionValue(options []dto.SurveyOptionResponse, name string) string {
+	for _, option := range options {
+		if option.Name == name {
+			return option.Value
+		}
+	}
+	return surveyOptions[name].Default
+}
+
+// OptionInt returns the value of an int option of a survey, ok is false when it is not set.
+func OptionInt(options []dto.SurveyOptionResponse, name string) (value int, ok bool) {
+	n, err := strconv.Atoi(OptionValue(options, name))
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// OptionBool tells whether a bool option of a survey is true.
+func OptionBool(options []dto.SurveyOptionResponse, name string) bool {
+	return OptionValue(options, name) == "true"
+}
+
+func optionResponses(options []models.SurveyOption) []dto.SurveyOptionResponse {
+	responses := []dto.SurveyOptionResponse{}
+	for _, option := range options {
+		responses = append(responses, dto.SurveyOptionResponse{Id: option.ID, Name: option.Name, Value: option.Value})
+	}
+	return responses
+}
+
+func intPointer(n int) *int {
+	return &n
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"mime/multipart"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -22,22 +23,41 @@ import (
 
 type ISurveyService interface {
 	CreateSurvey(c context.Context, req dto.SurveyCreateRequest) (*dto.SurveyResponse, error)
+	CloneSurvey(c context.Context, userId uint, surveyId uint, req dto.SurveyCloneRequest) (*dto.SurveyResponse, error)
+	ExportSurvey(c context.Context, surveyId uint) (*dto.SurveyDocument, error)
+	ImportSurvey(c context.Context, ownerId uint, doc dto.SurveyDocument, dryRun bool) (*dto.SurveyImportResponse, error)
 	UpdateSurvey(c context.Context, id uint, req dto.SurveyUpdateRequest) (*dto.SurveyResponse, error)
 	GetSurvey(c context.Context, id uint) (*dto.SurveyResponse, error)
 	GetVote(c context.Context, id uint) (*dto.GetVoteResponse, error)
 	GetSurveys(c context.Context, req dto.SurveysGetRequest) ([]*dto.SurveyResponse, error)
 	DeleteSurvey(c context.Context, id uint) error
+	ChangeSurveyStatus(c context.Context, id uint, req dto.SurveyStatusUpdateRequest) (*dto.SurveyResponse, error)
 	CanUserParticipateToSurvey(c context.Context, userId uint, surveyId uint) (bool, error)
-	Participate(c context.Context, userId uint, surveyId uint) (*dto.UserSurveyParticipationResponse, error)
+	CanGuestParticipateToSurvey(c context.Context, guestId uint, surveyId uint) (bool, error)
+	Participate(c context.Context, respondent models.Respondent, surveyId uint, language string, seed int64) (*dto.UserSurveyParticipationResponse, error)
 	EndParticipation(c context.Context, participationId uint) error
-	CommitParticipation(c context.Context, participationId uint) error
+	CommitParticipation(c context.Context, participationId uint, asked []uint) (*dto.QuizResult, error)
+	SaveProgress(c context.Context, participationId uint, progress dto.ParticipationProgress) error
+	SuspendParticipation(c context.Context, participationId uint, progress dto.ParticipationProgress) error
+	ResumeParticipation(c context.Context, respondent models.Respondent, surveyId uint, participationId uint) (*dto.ParticipationResume, error)
+	SuspendStep(c context.Context, step dto.ParticipationStep) (string, error)
+	ContinueParticipation(c context.Context, respondent models.Respondent, surveyId uint, participationId uint, stepToken string) (*dto.ParticipationResume, error)
 	CanUserVoteOnSurvey(c context.Context, userId uint, surveyId uint) (bool, error)
 	CommitVote(c context.Context, vote models.Vote) error
+	CommitVotes(c context.Context, respondent models.Respondent, questionId uint, votes []models.Vote) error
+	CommitResponse(c context.Context, votes []models.Vote) error
+	CheckAnswerQuotas(c context.Context, participationId uint, questionId uint, answers []string) error
+	RecordQuestionTime(c context.Context, participationId uint, questionId uint, spent time.Duration, timedOut bool) error
 	DeleteVote(c context.Context, id uint) error
-	GetSurveyQuestionsInOrder(c context.Context, surveyId uint) (questionsAnswerMap dto.QuestionsAnswerMap, err error)
+	GetSurveyFlow(c context.Context, surveyId uint, seed int64) (*BranchEngine, error)
+	GetParticipationOrder(c context.Context, surveyId uint, participationId uint) (*dto.ParticipationOrderResponse, error)
+	GetBranchRules(c context.Context, surveyId uint) ([]dto.BranchRule, error)
+	UpdateBranchRules(c context.Context, surveyId uint, req dto.BranchRulesUpdateRequest) ([]dto.BranchRule, error)
 	GetVotes(surveyID, viewerID, respondentID uint) ([]map[string]interface{}, error)
 	GetVisibleVoteUsers(surveyID, viewerID uint) ([]map[string]interface{}, error)
 	GetSurveyVotes(c context.Context, surveyId uint) ([]dto.GetVoteResponse, error)
+	GetSurveyVersions(c context.Context, surveyId uint) ([]*dto.SurveyVersionResponse, error)
+	GetSurveyVersion(c context.Context, surveyId uint, version int) (*dto.SurveyVersionResponse, error)
 
 	CreateOption(c context.Context, userId uint, surveyId uint, req dto.SurveyOptionCreateRequest) (*dto.SurveyOptionResponse, error)
 	UpdateOption(c context.Context, id uint, req dto.SurveyOptionCreateRequest) (*dto.SurveyOptionResponse, error)
@@ -51,10 +71,12 @@ type SurveyService struct {
 	repo                repository.ISurveyRepository
 	logger              logging.Logger
 	notificationService notification.INotificationService
+	versionService      IVersionService
+	eligibilityService  IEligibilityService
 }
 
 func NewSurveyService(conf *config.Config, repo repository.ISurveyRepository, logger logging.Logger, notificationService notification.INotificationService) *SurveyService {
-	return &SurveyService{conf: conf, repo: repo, logger: logger, notificationService: notificationService}
+	return &SurveyService{conf: conf, repo: repo, logger: logger, notificationService: notificationService, versionService: NewVersionService(conf, repo, logger), eligibilityService: NewEligibilityService(conf, repo, logger)}
 }
 
 func (s *SurveyService) UpdateSurvey(c context.Context, id uint, req dto.SurveyUpdateRequest) (response *dto.SurveyResponse, err error) {
@@ -62,13 +84,62 @@ func (s *SurveyService) UpdateSurvey(c context.Context, id uint, req dto.SurveyU
 	if err != nil {
 		return nil, err
 	}
+	if survey == nil {
+		return nil, ErrSurveyNotFound
+	}
+	if !survey.IsEditable() {
+		return nil, ErrSurveyNotEditable
+	}
 
 	survey.Title = req.Title
+	survey.TitleTranslations = req.TitleTranslations
 	survey.StartTime = req.StartTime
 	survey.EndTime = req.EndTime
 	survey.AllowReturn = req.AllowReturn
 	survey.ParticipationLimit = req.ParticipationLimit
 	survey.AnswerTimeLimit = req.AnswerTimeLimit
+	if req.IsTemplate && survey.Status != models.SurveyStatusDraft {
+		return nil, fmt.Errorf("%w: only draft surveys can become templates", ErrSurveyNotEditable)
+	}
+	survey.IsTemplate = req.IsTemplate
+	if err := ValidateRandomization(req.Randomization, req.IsSequential); err != nil {
+		return nil, err
+	}
+	survey.Randomization = (*models.Randomization)(req.Randomization)
+	if err := ValidateQuiz(req.Quiz); err != nil {
+		return nil, err
+	}
+	survey.Quiz = (*models.QuizSettings)(req.Quiz)
+	if err := ValidateAnonymity(req.Anonymity, req.Quiz); err != nil {
+		return nil, err
+	}
+	if req.Anonymity != nil && survey.Anonymity == nil {
+		// answer quotas place participations by their answers
+		quotas, err := s.getQuotas(c, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, quota := range quotas {
+			if models.QuotaAttribute(quota.Attribute) == models.QuotaAnswer {
+				return nil, fmt.Errorf("%w: remove the answer quotas of the survey first", ErrInvalidAnonymity)
+			}
+		}
+	}
+	survey.Anonymity = (*models.AnonymitySettings)(req.Anonymity)
+	if survey.IsSequential != req.IsSequential {
+		// sequential surveys fall through in order, so existing rules may now form a cycle
+		if err := s.validateStoredBranchRules(c, id, req.IsSequential); err != nil {
+			return nil, err
+		}
+		// and only they ask questions in a known order, which piping needs
+		list, err := s.getOrderedQuestions(c, id)
+		if err != nil {
+			return nil, err
+		}
+		if problems := ValidatePiping(list, req.IsSequential); len(problems) > 0 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPiping, strings.Join(problems, "; "))
+		}
+	}
 	survey.IsSequential = req.IsSequential
 
 	err = s.repo.UpdateSurvey(c, survey)
@@ -76,45 +147,237 @@ func (s *SurveyService) UpdateSurvey(c context.Context, id uint, req dto.SurveyU
 		return nil, err
 	}
 
+	err = s.syncSurveyStatus(c, survey)
+	if err != nil {
+		return nil, err
+	}
+
+	if survey.Status != models.SurveyStatusDraft {
+		version, err := s.versionService.PublishVersion(c, survey.ID)
+		if err != nil {
+			return nil, err
+		}
+		survey.CurrentVersion = version.Version
+	}
+
 	return response, util.ConvertTypes(s.logger, survey, &response)
 
 }
 
-func (s *SurveyService) CreateSurvey(c context.Context, req dto.SurveyCreateRequest) (*dto.SurveyResponse, error) {
+func (s *SurveyService) ChangeSurveyStatus(c context.Context, id uint, req dto.SurveyStatusUpdateRequest) (response *dto.SurveyResponse, err error) {
+	status := models.SurveyStatus(req.Status)
+	if !status.IsValid() {
+		return nil, ErrInvalidSurveyStatus
+	}
+
+	survey, err := s.repo.GetSurveyByID(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if survey == nil {
+		return nil, ErrSurveyNotFound
+	}
+
+	err = s.syncSurveyStatus(c, survey)
+	if err != nil {
+		return nil, err
+	}
+
+	// opening a scheduled survey by hand starts it right away
+	if status == models.SurveyStatusOpen && survey.StartTime.After(time.Now()) {
+		survey.StartTime = time.Now()
+	}
+
+	err = s.transitSurvey(c, survey, status)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.syncSurveyStatus(c, survey)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, util.ConvertTypes(s.logger, survey, &response)
+}
+
+func (s *SurveyService) transitSurvey(c context.Context, survey *models.Survey, status models.SurveyStatus) error {
+	if !survey.Status.CanTransitionTo(status) {
+		return fmt.Errorf("%w: from %s to %s", ErrInvalidStatusTransition, survey.Status, status)
+	}
+	if survey.IsTemplate {
+		// templates stay drafts, they are only used through clones
+		return ErrSurveyIsTemplate
+	}
+
+	if survey.Status == models.SurveyStatusDraft {
+		version, err := s.versionService.PublishVersion(c, survey.ID)
+		if err != nil {
+			return err
+		}
+		survey.CurrentVersion = version.Version
+	}
+
+	previous := survey.Status
+	survey.Status = status
+	err := s.repo.UpdateSurvey(c, survey)
+	if err != nil {
+		survey.Status = previous
+		return err
+	}
+
+	_, err = s.notificationService.Notify(c, survey.OwnerID, fmt.Sprintf("your survey with name: %s changed from %s to %s", survey.Title, previous, status))
+	if err != nil {
+		s.logger.Error(logging.Internal, logging.FailedToSendNotify, "error in sending notify in survey service", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+	return nil
+}
+
+// syncSurveyStatus persists the transitions caused by the survey start and end times.
+func (s *SurveyService) syncSurveyStatus(c context.Context, survey *models.Survey) error {
+	for survey.Status != survey.CurrentStatus(time.Now()) {
+		next := models.SurveyStatusOpen
+		if survey.Status == models.SurveyStatusOpen {
+			next = models.SurveyStatusClosed
+		}
+		if err := s.transitSurvey(c, survey, next); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncDueSurveys persists the transitions of every survey whose start or end time passed. A survey
+// that fails is logged and tried again on the next run.
+func (s *SurveyService) syncDueSurveys(c context.Context) {
+	surveys, err := s.repo.GetSurveysDueForStatusChange(c, time.Now())
+	if err != nil {
+		s.logger.Error(logging.Internal, logging.Update, "error in getting surveys due for status change", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return
+	}
+	for _, survey := range surveys {
+		if err := s.syncSurveyStatus(c, survey); err != nil {
+			s.logger.Error(logging.Internal, logging.Update, fmt.Sprintf("error in changing the status of survey %d", survey.ID), map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		}
+	}
+}
+
+func (s *SurveyService) CreateSurvey(c context.Context, req dto.SurveyCreateRequest) (response *dto.SurveyResponse, err error) {
+	// question types and sections are checked before anything is stored
+	sectionTitles := map[string]bool{}
+	for _, sectionReq := range req.Sections {
+		if sectionTitles[sectionReq.Title] {
+			return nil, fmt.Errorf("section '%s' is defined twice", sectionReq.Title)
+		}
+		sectionTitles[sectionReq.Title] = true
+	}
+	for i := range req.Questions {
+		if err := applyBankQuestion(c, s.repo, s.logger, req.OwnerID, &req.Questions[i]); err != nil {
+			return nil, err
+		}
+		questionReq := req.Questions[i]
+		if questionReq.Section != "" && !sectionTitles[questionReq.Section] {
+			return nil, fmt.Errorf("section '%s' of question '%s' not found", questionReq.Section, questionReq.Text)
+		}
+		questionType, config, err := ResolveQuestionType(questionReq.Type, questionReq.HasMultipleChoice, questionReq.Config)
+		if err != nil {
+			return nil, fmt.Errorf("question '%s': %w", questionReq.Text, err)
+		}
+		req.Questions[i].Type = questionType
+		req.Questions[i].Config = config
+		req.Questions[i].HasMultipleChoice = questionType == dto.MultipleChoiceQuestion
+	}
+	// piping in a new survey refers to questions by their 1 based position in the request
+	requested := requestedQuestions(req)
+	if problems := ValidatePiping(requested, req.IsSequential); len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPiping, strings.Join(problems, "; "))
+	}
+	if err := ValidateRandomization(req.Randomization, req.IsSequential); err != nil {
+		return nil, err
+	}
+	if err := ValidateQuiz(req.Quiz); err != nil {
+		return nil, err
+	}
+	if err := ValidateAnonymity(req.Anonymity, req.Quiz); err != nil {
+		return nil, err
+	}
+
 	survey := models.Survey{
 		Title:              req.Title,
+		TitleTranslations:  req.TitleTranslations,
 		OwnerID:            req.OwnerID,
+		Status:             models.SurveyStatusDraft,
 		StartTime:          req.StartTime,
 		EndTime:            req.EndTime,
 		IsSequential:       req.IsSequential,
+		Randomization:      (*models.Randomization)(req.Randomization),
+		Quiz:               (*models.QuizSettings)(req.Quiz),
+		Anonymity:          (*models.AnonymitySettings)(req.Anonymity),
 		AllowReturn:        req.AllowReturn,
 		ParticipationLimit: req.ParticipationLimit,
 		AnswerTimeLimit:    req.AnswerTimeLimit,
+		IsTemplate:         req.IsTemplate,
 	}
 
 	if err := s.repo.CreateSurvey(c, &survey); err != nil {
 		return nil, err
 	}
+	// a survey that is not stored completely is removed with everything stored for it
+	defer func() {
+		if err != nil {
+			s.discardSurvey(c, survey.ID)
+		}
+	}()
 
 	surveyResponseDTO := &dto.SurveyResponse{
 		SurveyID:           survey.ID,
+		UserId:             survey.OwnerID,
 		Title:              survey.Title,
+		TitleTranslations:  survey.TitleTranslations,
+		Status:             string(survey.Status),
 		StartTime:          survey.StartTime.Format("2006-01-02 15:04:05"), // Format as string
 		EndTime:            survey.EndTime.Format("2006-01-02 15:04:05"),   // Format as string
 		IsSequential:       survey.IsSequential,
+		Randomization:      req.Randomization,
+		Quiz:               req.Quiz,
+		Anonymity:          req.Anonymity,
 		AllowReturn:        survey.AllowReturn,
 		ParticipationLimit: survey.ParticipationLimit,
 		AnswerTimeLimit:    survey.AnswerTimeLimit,
+		IsTemplate:         survey.IsTemplate,
+	}
+
+	sectionMap := make(map[string]uint)
+	for i, sectionReq := range req.Sections {
+		section := models.Section{
+			SurveyID: survey.ID,
+			Title:    sectionReq.Title,
+			Intro:    sectionReq.Intro,
+			MediaUrl: sectionReq.MediaUrl,
+			Order:    i + 1,
+		}
+		if err := s.repo.CreateSection(c, &section); err != nil {
+			return nil, err
+		}
+		sectionMap[section.Title] = section.ID
 	}
 
 	questionMap := make(map[string]*models.Question)
+	questionIds := map[uint]uint{}
 	questionOrder := 1
-	for _, questionReq := range req.Questions {
+	for i, questionReq := range req.Questions {
 		question := models.Question{
 			SurveyID:          survey.ID,
 			Text:              questionReq.Text,
+			TextTranslations:  questionReq.TextTranslations,
+			Type:              string(questionReq.Type),
 			HasMultipleChoice: questionReq.HasMultipleChoice,
 			MediaUrl:          questionReq.MediaUrl,
+			SectionID:         sectionMap[questionReq.Section],
+			BankQuestionID:    questionReq.BankQuestionID,
+		}
+		if err := util.ConvertTypes(s.logger, questionReq.Config, &question.Config); err != nil {
+			return nil, err
 		}
 
 		if survey.IsSequential {
@@ -129,9 +392,12 @@ func (s *SurveyService) CreateSurvey(c context.Context, req dto.SurveyCreateRequ
 		if question.HasMultipleChoice {
 			for _, choiceReq := range questionReq.Choices {
 				choice := models.Choice{
-					QuestionID: question.ID,
-					Text:       choiceReq.Text,
-					IsCorrect:  choiceReq.IsCorrect,
+					QuestionID:       question.ID,
+					Text:             choiceReq.Text,
+					TextTranslations: choiceReq.TextTranslations,
+					IsCorrect:        choiceReq.IsCorrect,
+					Anchored:         choiceReq.Anchored,
+					Points:           choiceReq.Points,
 				}
 
 				if err := s.repo.CreateChoice(c, &choice); err != nil {
@@ -142,6 +408,14 @@ func (s *SurveyService) CreateSurvey(c context.Context, req dto.SurveyCreateRequ
 		}
 
 		questionMap[question.Text] = &question
+		questionIds[uint(i+1)] = question.ID
+	}
+	for _, q := range requested {
+		if len(PipingReferences(q)) > 0 {
+			if err := s.remapStoredPiping(c, questionIds[q.ID], questionIds); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	for _, q := range req.Questions {
@@ -169,6 +443,148 @@ func (s *SurveyService) CreateSurvey(c context.Context, req dto.SurveyCreateRequ
 	return surveyResponseDTO, nil
 }
 
+// discardSurvey removes a survey that failed to be created, its rows are deleted for good.
+func (s *SurveyService) discardSurvey(c context.Context, surveyId uint) {
+	if err := s.repo.PurgeSurvey(c, surveyId); err != nil {
+		s.logger.Error(logging.Internal, logging.Delete, fmt.Sprintf("error in removing the incomplete survey %d", surveyId), map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+}
+
+// CloneSurvey deep copies a survey into a new draft owned by userId, votes, participations
+// and versions are not copied.
+func (s *SurveyService) CloneSurvey(c context.Context, userId uint, surveyId uint, req dto.SurveyCloneRequest) (*dto.SurveyResponse, error) {
+	source, err := s.repo.GetSurveyByID(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil {
+		return nil, ErrSurveyNotFound
+	}
+
+	clone := models.Survey{
+		Title:              source.Title,
+		TitleTranslations:  source.TitleTranslations,
+		OwnerID:            userId,
+		Status:             models.SurveyStatusDraft,
+		StartTime:          req.StartTime,
+		EndTime:            req.EndTime,
+		IsSequential:       source.IsSequential,
+		Randomization:      source.Randomization,
+		Quiz:               source.Quiz,
+		Anonymity:          source.Anonymity,
+		Eligibility:        source.Eligibility,
+		AllowReturn:        source.AllowReturn,
+		ParticipationLimit: source.ParticipationLimit,
+		AnswerTimeLimit:    source.AnswerTimeLimit,
+		IsTemplate:         req.IsTemplate,
+	}
+	if req.Title != "" {
+		clone.Title = req.Title
+	}
+	questionIds, err := s.repo.CloneSurvey(c, source.ID, &clone)
+	if err != nil {
+		return nil, err
+	}
+	// the copies pipe the answers of the copied questions, not of the source survey
+	for _, id := range questionIds {
+		if err := s.remapStoredPiping(c, id, questionIds); err != nil {
+			s.discardSurvey(c, clone.ID)
+			return nil, err
+		}
+	}
+
+	response := &dto.SurveyResponse{}
+	return response, util.ConvertTypes(s.logger, clone, response)
+}
+
+// requestedQuestions returns the questions of a create request in the order they are asked,
+// with their position in the request as id.
+func requestedQuestions(req dto.SurveyCreateRequest) dto.QuestionList {
+	sections := []*dto.Section{}
+	sectionIds := map[string]uint{}
+	for i, section := range req.Sections {
+		sections = append(sections, &dto.Section{ID: uint(i + 1), Title: section.Title})
+		sectionIds[section.Title] = uint(i + 1)
+	}
+	list := dto.QuestionList{}
+	for i, q := range req.Questions {
+		question := &dto.Question{
+			ID:                uint(i + 1),
+			Text:              q.Text,
+			TextTranslations:  q.TextTranslations,
+			Type:              q.Type,
+			Config:            q.Config,
+			HasMultipleChoice: q.HasMultipleChoice,
+			SectionID:         sectionIds[q.Section],
+		}
+		for _, choice := range q.Choices {
+			question.Choices = append(question.Choices, dto.Choice{Text: choice.Text, TextTranslations: choice.TextTranslations})
+		}
+		list = append(list, question)
+	}
+	return orderBySection(list, sections)
+}
+
+// remapStoredPiping rewrites the piping references of a stored question and its choices with ids,
+// a question that pipes nothing is left as it is.
+func (s *SurveyService) remapStoredPiping(c context.Context, questionId uint, ids map[uint]uint) error {
+	question, err := s.repo.GetQuestionByID(c, questionId)
+	if err != nil || question == nil {
+		return err
+	}
+	current := dto.Question{}
+	if err := util.ConvertTypes(s.logger, question, &current); err != nil {
+		return err
+	}
+	if len(PipingReferences(&current)) == 0 {
+		return nil
+	}
+	question.Text = RemapPiping(question.Text, ids)
+	question.TextTranslations = remapTranslations(question.TextTranslations, ids)
+	if question.Config.ChoicesFrom != 0 {
+		question.Config.ChoicesFrom = ids[question.Config.ChoicesFrom]
+	}
+	// the choices are saved one by one
+	updated := *question
+	updated.Choices = nil
+	if _, err := s.repo.UpdateQuestion(c, &updated); err != nil {
+		return err
+	}
+	for _, choice := range question.Choices {
+		choice.Text = RemapPiping(choice.Text, ids)
+		choice.TextTranslations = remapTranslations(choice.TextTranslations, ids)
+		if err := s.repo.UpdateChoice(c, &choice); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyBankQuestion copies the content of the bank question a survey question refers to,
+// the reference is kept so answers can be compared across surveys.
+func applyBankQuestion(c context.Context, repo repository.ISurveyRepository, logger logging.Logger, userId uint, req *dto.QuestionCreateRequest) error {
+	if req.BankQuestionID == 0 {
+		return nil
+	}
+	bank, err := repo.GetBankQuestionByID(c, req.BankQuestionID)
+	if err != nil {
+		return err
+	}
+	if bank == nil || (bank.OwnerID != userId && !bank.Shared) {
+		return fmt.Errorf("question %d: %w", req.BankQuestionID, ErrBankQuestionNotFound)
+	}
+
+	req.Text = bank.Text
+	req.Type = dto.QuestionType(bank.Type)
+	req.HasMultipleChoice = bank.HasMultipleChoice
+	req.MediaUrl = bank.MediaUrl
+	req.Choices = []dto.ChoiceCreateRequest{}
+	for _, choice := range bank.Choices {
+		req.Choices = append(req.Choices, dto.ChoiceCreateRequest{Text: choice.Text, IsCorrect: choice.IsCorrect, Anchored: choice.Anchored, Points: choice.Points})
+	}
+	return util.ConvertTypes(logger, bank.Config, &req.Config)
+}
+
 func (s *SurveyService) DeleteSurvey(c context.Context, id uint) error {
 
 	survey, err := s.repo.GetSurveyByID(c, id)
@@ -194,10 +610,24 @@ func (s *SurveyService) DeleteVote(c context.Context, id uint) error {
 		return errors.New("vote not found")
 	}
 
+	question, err := s.repo.GetQuestionByID(c, vote.QuestionID)
+	if err != nil {
+		return err
+	}
+	if question != nil {
+		if err := s.checkSurveyWritable(c, question.SurveyID); err != nil {
+			return err
+		}
+	}
+
 	err = s.repo.DeleteVote(c, vote.ID)
 	if err != nil {
 		return err
 	}
+	if vote.VoterID == 0 {
+		// votes of guests have no one to notify
+		return nil
+	}
 	_, err = s.notificationService.Notify(c, vote.VoterID, fmt.Sprintf("your vote with this answer removed: %s", vote.Answer))
 	return err
 }
@@ -210,17 +640,34 @@ func (s *SurveyService) GetSurveys(c context.Context, req dto.SurveysGetRequest)
 
 	filter := dto.RepositoryFilter{Field: "title", Operator: "LIKE", Value: req.Title}
 	filters := []*dto.RepositoryFilter{&filter}
-	if req.UserId > 0 {
+	isTemplate := false
+	if req.Template != "" {
+		isTemplate, err = strconv.ParseBool(req.Template)
+		if err != nil {
+			return []*dto.SurveyResponse{}, fmt.Errorf("%w: template must be true or false", ErrInvalidSurveyFilter)
+		}
+		filters = append(filters, &dto.RepositoryFilter{Field: "is_template", Operator: "=", Value: strconv.FormatBool(isTemplate)})
+	}
+	// templates of every user can be listed, they are published to be cloned
+	if req.UserId > 0 && !isTemplate {
 		filters = append(filters, &dto.RepositoryFilter{Field: "owner_id", Operator: "=", Value: strconv.Itoa(req.UserId)})
 	}
+	if req.Status != "" && !models.SurveyStatus(req.Status).IsValid() {
+		return []*dto.SurveyResponse{}, ErrInvalidSurveyStatus
+	}
 
 	sort := dto.RepositorySort{Field: "created_at", SortType: "desc"}
 	repo_req := dto.RepositoryRequest{Limit: uint(limit), Offset: uint(offset), Filters: filters, Sorts: []*dto.RepositorySort{&sort}}
 
-	surveys, err := s.repo.GetSurveys(c, &repo_req)
+	// the scheduler stores the transitions, reads filter on and show the status surveys have now
+	now := time.Now()
+	surveys, err := s.repo.GetSurveys(c, &repo_req, models.SurveyStatus(req.Status), now)
 	if err != nil {
 		return []*dto.SurveyResponse{}, err
 	}
+	for _, survey := range surveys {
+		survey.Status = survey.CurrentStatus(now)
+	}
 
 	return response, util.ConvertTypes(s.logger, surveys, &response)
 }
@@ -236,6 +683,8 @@ func (s *SurveyService) GetSurvey(c context.Context, id uint) (*dto.SurveyRespon
 	if survey == nil {
 		return nil, nil
 	}
+	survey.Status = survey.CurrentStatus(time.Now())
+
 	sResponse := dto.SurveyResponse{}
 
 	err = util.ConvertTypes(s.logger, survey, &sResponse)
@@ -280,32 +729,160 @@ func (s *SurveyService) CanUserParticipateToSurvey(c context.Context, userId uin
 	if survey == nil {
 		return false, errors.New("survey does not exists")
 	}
-	if len(userParticipationList) >= survey.ParticipationLimit {
-		return false, errors.New("user participation limit reached ")
-	}
-	if !time.Now().After(survey.StartTime) {
-		return false, errors.New("its not time to start the questionnaire")
+	limit := survey.ParticipationLimit
+	if survey.Anonymity != nil {
+		// the answers of an anonymous survey can not be told apart, so every user answers once
+		limit = 1
 	}
-	if !time.Now().Before(survey.EndTime) {
-		return false, errors.New("questionnaire time ended before")
+	if err := s.checkParticipations(c, survey, userParticipationList, limit); err != nil {
+		return false, err
 	}
 
-	for _, v := range userParticipationList {
-		if !v.StartAt.IsZero() && v.CommittedAt == nil && v.EndAt == nil {
-			return false, errors.New("user participation in this survey has not ended")
+	if OptionBool(optionResponses(survey.Options), OptionInvitationOnly) {
+		invitation, err := s.repo.GetUserInvitation(c, surveyId, userId)
+		if err != nil {
+			return false, err
+		}
+		if invitation == nil {
+			return false, ErrNotInvited
 		}
 	}
 
+	eligibility, err := s.eligibilityService.CheckEligibility(c, userId, surveyId)
+	if err != nil {
+		return false, err
+	}
+	if !eligibility.Eligible {
+		return false, fmt.Errorf("%w: %s", ErrNotEligible, strings.Join(eligibility.Reasons, ", "))
+	}
+
+	// the place in the quotas is only taken when the participation starts, this spares
+	// participants of full quotas from starting at all
+	quotas, err := s.participantQuotas(c, models.UserRespondent(userId), surveyId)
+	if err != nil {
+		return false, err
+	}
+	if FullQuota(quotas) != nil {
+		return false, ErrQuotaFull
+	}
+
 	return true, nil
 
 }
-func (s *SurveyService) Participate(c context.Context, userId uint, surveyId uint) (*dto.UserSurveyParticipationResponse, error) {
-	p, err := s.repo.CreateUserParticipation(c, &models.UserSurveyParticipation{UserId: userId, SurveyID: surveyId, StartAt: time.Now()})
+
+// CanGuestParticipateToSurvey checks that a guest can take a survey through its share link. Guests
+// can not be invited and tell nothing about themselves, so surveys that are only open to invitees
+// or to eligible participants need an account.
+func (s *SurveyService) CanGuestParticipateToSurvey(c context.Context, guestId uint, surveyId uint) (bool, error) {
+	link, err := s.repo.GetShareLink(c, surveyId)
+	if err != nil {
+		return false, err
+	}
+	if link == nil || !link.Enabled {
+		return false, ErrShareLinkNotFound
+	}
+	guestParticipationList, err := s.repo.GetGuestParticipationList(c, guestId, surveyId)
+	if err != nil {
+		return false, err
+	}
+	survey, err := s.repo.GetSurveyByID(c, surveyId)
+	if err != nil {
+		return false, err
+	}
+	if survey == nil {
+		return false, ErrSurveyNotFound
+	}
+	if err := s.checkParticipations(c, survey, guestParticipationList, link.GuestParticipationLimit); err != nil {
+		return false, err
+	}
+
+	options := optionResponses(survey.Options)
+	if OptionBool(options, OptionInvitationOnly) {
+		return false, ErrNotInvited
+	}
+	if survey.Eligibility != nil || OptionsEligibility(options) != nil {
+		return false, fmt.Errorf("%w: sign in to check that you are eligible", ErrNotEligible)
+	}
+	return true, nil
+}
+
+// checkParticipations checks that a survey is open now and that participations, the earlier
+// participations of a participant, are ended and fewer than limit. A participation that waits
+// to be resumed must be resumed instead, once it waited too long it is ended.
+func (s *SurveyService) checkParticipations(c context.Context, survey *models.Survey, participations []models.UserSurveyParticipation, limit int) error {
+	if survey.IsTemplate {
+		return ErrSurveyIsTemplate
+	}
+	if err := s.syncSurveyStatus(c, survey); err != nil {
+		return err
+	}
+	if survey.Status != models.SurveyStatusOpen {
+		return fmt.Errorf("%w: survey is %s", ErrSurveyNotOpen, survey.Status)
+	}
+	for _, v := range participations {
+		if v.StartAt.IsZero() || v.CommittedAt != nil || v.EndAt != nil {
+			continue
+		}
+		if v.DisconnectedAt == nil {
+			return errors.New("user participation in this survey has not ended")
+		}
+		if !suspensionExpired(&v, time.Now()) {
+			return &SuspendedParticipationError{ParticipationID: v.ID}
+		}
+		// it was not resumed in time, so it ends as an attempt
+		ended, err := s.endSuspendedParticipation(c, &v)
+		if err != nil {
+			return err
+		}
+		if !ended {
+			return errors.New("user participation in this survey has not ended")
+		}
+	}
+	if len(participations) >= limit {
+		return errors.New("user participation limit reached ")
+	}
+	if !time.Now().After(survey.StartTime) {
+		return errors.New("its not time to start the questionnaire")
+	}
+	if !time.Now().Before(survey.EndTime) {
+		return errors.New("questionnaire time ended before")
+	}
+	return nil
+}
+
+// Participate starts a participation of a user or a guest, language is the one the questions are shown in,
+// empty for the untranslated texts. seed is the seed of the order the questions are shown in.
+func (s *SurveyService) Participate(c context.Context, respondent models.Respondent, surveyId uint, language string, seed int64) (*dto.UserSurveyParticipationResponse, error) {
+	survey, err := s.repo.GetSurveyByID(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	if survey == nil {
+		return nil, ErrSurveyNotFound
+	}
+
+	quotas, err := s.participantQuotas(c, respondent, surveyId)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &models.UserSurveyParticipation{UserId: respondent.UserID, GuestID: respondent.GuestID, SurveyID: surveyId, SurveyVersion: survey.CurrentVersion, Language: language, Seed: seed, StartAt: time.Now()}
+	var full *models.SurveyQuota
+	if len(quotas) > 0 {
+		// the participation is only created when it gets a place in every quota the participant falls in
+		full, err = s.repo.CreateQuotaParticipation(c, p, quotaIds(quotas))
+	} else {
+		p, err = s.repo.CreateUserParticipation(c, p)
+	}
 
 	if err != nil {
 		s.logger.Error(logging.Internal, logging.FailedToCreateParticipation, "error in participation user to survey", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
 		return nil, err
 	}
+	if full != nil {
+		return nil, ErrQuotaFull
+	}
+	s.trackInvitation(c, surveyId, respondent, models.InvitationStarted)
 
 	response := dto.UserSurveyParticipationResponse{}
 
@@ -328,20 +905,92 @@ func (s *SurveyService) EndParticipation(c context.Context, participationId uint
 	}
 	now := time.Now()
 	pr.EndAt = &now
-	return s.repo.UpdateUserParticipation(c, pr)
+	if err := s.repo.UpdateUserParticipation(c, pr); err != nil {
+		return err
+	}
+	survey, err := s.repo.GetSurveyByID(c, pr.SurveyID)
+	if err != nil {
+		return err
+	}
+	if err := s.concealParticipation(c, survey, pr.ID); err != nil {
+		return err
+	}
+	if pr.CommittedAt != nil {
+		return nil
+	}
+	// an abandoned participation gives its quota places to other participants
+	return s.repo.ReleaseQuotas(c, pr.ID, nil)
 }
 
-func (s *SurveyService) CommitParticipation(c context.Context, participationId uint) error {
+// CommitParticipation commits a participation, asked are the questions the participant was asked.
+// Participations of quizzes are scored, the result is returned when the participant may see it.
+func (s *SurveyService) CommitParticipation(c context.Context, participationId uint, asked []uint) (*dto.QuizResult, error) {
 
 	pr, err := s.repo.GetUserParticipation(c, participationId)
 	if err != nil {
 		s.logger.Error(logging.Internal, logging.FailedToGetParticipation, "error in get user participation", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
 
-		return err
+		return nil, err
+	}
+	if pr == nil {
+		return nil, ErrParticipationNotFound
 	}
 	now := time.Now()
 	pr.CommittedAt = &now
-	return s.repo.UpdateUserParticipation(c, pr)
+
+	survey, err := s.repo.GetSurveyByID(c, pr.SurveyID)
+	if err != nil {
+		return nil, err
+	}
+	var result *dto.QuizResult
+	if survey != nil && survey.Quiz != nil {
+		if result, err = s.scoreParticipation(c, pr, asked, dto.QuizSettings(*survey.Quiz)); err != nil {
+			return nil, err
+		}
+		pr.Score, pr.MaxScore, pr.Passed = &result.Score, &result.MaxScore, &result.Passed
+	}
+	if err := s.repo.UpdateUserParticipation(c, pr); err != nil {
+		return nil, err
+	}
+	if err := s.concealParticipation(c, survey, pr.ID); err != nil {
+		return nil, err
+	}
+	if survey != nil {
+		s.completeQuotas(c, survey, pr.ID)
+	}
+	s.trackInvitation(c, pr.SurveyID, pr.Respondent(), models.InvitationCompleted)
+	if result == nil || !survey.Quiz.ShowScore {
+		return nil, nil
+	}
+	return result, nil
+}
+
+func (s *SurveyService) scoreParticipation(c context.Context, pr *models.UserSurveyParticipation, asked []uint, quiz dto.QuizSettings) (*dto.QuizResult, error) {
+	list, err := s.getQuestions(c, pr.SurveyID)
+	if err != nil {
+		return nil, err
+	}
+	byId := list.ToMap()
+	askedQuestions := []*dto.Question{}
+	for _, id := range asked {
+		if q, ok := byId[id]; ok {
+			askedQuestions = append(askedQuestions, q)
+		}
+	}
+
+	votes, err := s.repo.GetRespondentVotes(c, pr.SurveyID, pr.Respondent())
+	if err != nil {
+		return nil, err
+	}
+	picked := map[uint][]uint{}
+	for _, vote := range votes {
+		if vote.ChoiceID != 0 {
+			picked[vote.QuestionID] = append(picked[vote.QuestionID], vote.ChoiceID)
+		}
+	}
+
+	result := ScoreQuiz(askedQuestions, picked, quiz)
+	return &result, nil
 }
 
 func (s *SurveyService) CanUserVoteOnSurvey(c context.Context, userId uint, surveyId uint) (bool, error) {
@@ -362,7 +1011,7 @@ func (s *SurveyService) CanUserVoteOnSurvey(c context.Context, userId uint, surv
 
 func (s *SurveyService) CommitVote(c context.Context, vote models.Vote) error {
 
-	v, err := s.repo.GetUserSurveyVote(c, vote.VoterID, vote.QuestionID)
+	v, err := s.repo.GetRespondentVote(c, models.Respondent{UserID: vote.VoterID, GuestID: vote.GuestID}, vote.QuestionID)
 	if err != nil {
 		return err
 	}
@@ -376,82 +1025,329 @@ func (s *SurveyService) CommitVote(c context.Context, vote models.Vote) error {
 
 }
 
-func (s *SurveyService) GetSurveyQuestionsInOrder(c context.Context, surveyId uint) (questionsAnswerMap dto.QuestionsAnswerMap, err error) {
+// CommitVotes replaces the votes of a user or a guest on a question, a multi select answer is stored as one vote per choice.
+func (s *SurveyService) CommitVotes(c context.Context, respondent models.Respondent, questionId uint, votes []models.Vote) error {
+	if respondent.IsAnonymous() {
+		votes = anonymousVotes(votes)
+	}
+	return s.repo.ReplaceQuestionVotes(c, respondent, questionId, votes)
+}
 
-	survey, err := s.repo.GetSurveyByID(c, surveyId)
+// CommitResponse stores the votes of a committed anonymous response at once and in random order,
+// so neither their ids nor their times tell the order they were given in.
+func (s *SurveyService) CommitResponse(c context.Context, votes []models.Vote) error {
+	if len(votes) == 0 {
+		return nil
+	}
+	votes = util.ShuffleSliceWith(util.NewRandom(util.NewSeed()), anonymousVotes(votes), nil)
+	return s.repo.CreateVotes(c, votes)
+}
+
+// CheckAnswerQuotas places a participation in the quotas of a screening question its answers fall in and gives
+// back its places in the quotas of the question they no longer fall in. When one of the quotas is full the
+// participation is screened out and ErrQuotaFull is returned.
+func (s *SurveyService) CheckAnswerQuotas(c context.Context, participationId uint, questionId uint, answers []string) error {
+	pr, err := s.repo.GetUserParticipation(c, participationId)
+	if err != nil {
+		return err
+	}
+	if pr == nil {
+		return ErrParticipationNotFound
+	}
+	quotas, err := s.getQuotas(c, pr.SurveyID)
+	if err != nil {
+		return err
+	}
+	matched, others := AnswerQuotas(quotas, questionId, answers)
+	if len(others) > 0 {
+		if err := s.repo.ReleaseQuotas(c, pr.ID, quotaIds(others)); err != nil {
+			return err
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+	full, err := s.repo.ReserveQuotas(c, pr.ID, quotaIds(matched))
+	if err != nil {
+		return err
+	}
+	if full == nil {
+		return nil
+	}
+
+	now := time.Now()
+	pr.ScreenedOutAt = &now
+	pr.EndAt = &now
+	if err := s.repo.UpdateUserParticipation(c, pr); err != nil {
+		return err
+	}
+	survey, err := s.repo.GetSurveyByID(c, pr.SurveyID)
+	if err != nil {
+		return err
+	}
+	if err := s.concealParticipation(c, survey, pr.ID); err != nil {
+		return err
+	}
+	if err := s.repo.ReleaseQuotas(c, pr.ID, nil); err != nil {
+		return err
+	}
+	return ErrQuotaFull
+}
 
+// participantQuotas returns the city and age quotas of a survey a user falls in, guests
+// fall in none of them.
+func (s *SurveyService) participantQuotas(c context.Context, respondent models.Respondent, surveyId uint) ([]*dto.Quota, error) {
+	if respondent.UserID == nil {
+		return []*dto.Quota{}, nil
+	}
+	quotas, err := s.getQuotas(c, surveyId)
+	if err != nil || len(quotas) == 0 {
+		return []*dto.Quota{}, err
+	}
+	user, err := s.repo.GetParticipant(c, *respondent.UserID)
 	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return []*dto.Quota{}, nil
+	}
+	return ParticipantQuotas(quotas, user.City, user.DateOfBirth, time.Now()), nil
+}
 
+func (s *SurveyService) getQuotas(c context.Context, surveyId uint) ([]*dto.Quota, error) {
+	quotas, err := s.repo.GetQuotas(c, surveyId)
+	if err != nil {
 		return nil, err
 	}
+	response := []*dto.Quota{}
+	return response, util.ConvertTypes(s.logger, quotas, &response)
+}
 
+// completeQuotas counts a committed participation in its quotas, the owner is notified of every quota it fills.
+func (s *SurveyService) completeQuotas(c context.Context, survey *models.Survey, participationId uint) {
+	completed, err := s.repo.CompleteQuotas(c, participationId)
+	if err != nil {
+		s.logger.Error(logging.Internal, logging.Update, "error in completing quotas in survey service", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return
+	}
+	for _, quota := range completed {
+		_, err = s.notificationService.Notify(c, survey.OwnerID, fmt.Sprintf("quota %s of your survey with name: %s is full", quota.Name, survey.Title))
+		if err != nil {
+			s.logger.Error(logging.Internal, logging.FailedToSendNotify, "error in sending notify in survey service", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		}
+	}
+}
+
+// trackInvitation moves the invitation of a participant along, participating never fails on it.
+// Guests have no invitations.
+func (s *SurveyService) trackInvitation(c context.Context, surveyId uint, respondent models.Respondent, status models.InvitationStatus) {
+	if respondent.UserID == nil {
+		return
+	}
+	if err := s.repo.TrackInvitation(c, surveyId, *respondent.UserID, status); err != nil {
+		s.logger.Error(logging.Internal, logging.Update, "error in tracking invitation in survey service", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+	}
+}
+
+// RecordQuestionTime stores the time a participant spent on a question so far.
+func (s *SurveyService) RecordQuestionTime(c context.Context, participationId uint, questionId uint, spent time.Duration, timedOut bool) error {
+	return s.repo.SaveQuestionTiming(c, &models.QuestionTiming{ParticipationID: participationId, QuestionID: questionId, Milliseconds: spent.Milliseconds(), TimedOut: timedOut})
+}
+
+// GetSurveyFlow returns the branch engine that walks a participant through the survey, sections,
+// questions and choices are shuffled as the survey is randomized, in the order seed gives.
+func (s *SurveyService) GetSurveyFlow(c context.Context, surveyId uint, seed int64) (*BranchEngine, error) {
+	survey, err := s.repo.GetSurveyByID(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
 	if survey == nil {
-		return dto.QuestionsAnswerMap{}, nil
+		return nil, ErrSurveyNotFound
 	}
 
-	filter := dto.RepositoryFilter{Field: "survey_id", Operator: "=", Value: strconv.Itoa(int(surveyId))}
-	sort := dto.RepositorySort{Field: "\"order\"", SortType: "asc"}
+	list, err := s.getQuestions(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	sections, err := s.getSections(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	list, sections = RandomizeQuestions(list, sections, dto.Randomization(survey.RandomizationSettings()), seed)
 
-	questions, err := s.repo.GetQuestions(c,
-		&dto.RepositoryRequest{
-			Filters: []*dto.RepositoryFilter{&filter},
-			Sorts:   []*dto.RepositorySort{&sort},
-			With:    "Choices"})
+	rules, err := s.GetBranchRules(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
 
+	return NewBranchEngine(list, sections, rules), nil
+}
+
+// GetParticipationOrder replays the order the questions and choices were shown in to a participant,
+// from the survey version the participation started on when there is one.
+func (s *SurveyService) GetParticipationOrder(c context.Context, surveyId uint, participationId uint) (*dto.ParticipationOrderResponse, error) {
+	participation, err := s.repo.GetUserParticipation(c, participationId)
+	if err != nil {
+		return nil, err
+	}
+	if participation == nil || participation.SurveyID != surveyId {
+		return nil, ErrParticipationNotFound
+	}
+	if participation.Seed == 0 {
+		return nil, fmt.Errorf("%w: the participation started before its order was recorded", ErrParticipationNotFound)
+	}
+	survey, err := s.repo.GetSurveyByID(c, surveyId)
 	if err != nil {
-		return dto.QuestionsAnswerMap{}, err
+		return nil, err
+	}
+	if survey == nil {
+		return nil, ErrSurveyNotFound
 	}
 
-	if len(questions) < 1 {
-		return dto.QuestionsAnswerMap{}, err
+	randomization := dto.Randomization(survey.RandomizationSettings())
+	var list dto.QuestionList
+	var sections []*dto.Section
+	version, err := s.versionService.GetVersion(c, surveyId, participation.SurveyVersion)
+	if err != nil {
+		return nil, err
+	}
+	if version != nil {
+		list = version.Definition.Questions
+		for i := range version.Definition.Sections {
+			sections = append(sections, &version.Definition.Sections[i])
+		}
+		if version.Definition.Randomization != nil {
+			randomization = *version.Definition.Randomization
+		}
+	} else {
+		if list, err = s.getQuestions(c, surveyId); err != nil {
+			return nil, err
+		}
+		if sections, err = s.getSections(c, surveyId); err != nil {
+			return nil, err
+		}
 	}
 
-	if !survey.IsSequential {
-		questions = util.ShuffleSlice(questions)
+	list, sections = RandomizeQuestions(list, sections, randomization, participation.Seed)
+	response := &dto.ParticipationOrderResponse{
+		ParticipationID: participation.ID,
+		SurveyVersion:   participation.SurveyVersion,
+		Seed:            participation.Seed,
+		Questions:       list,
 	}
+	for _, section := range sections {
+		response.Sections = append(response.Sections, section.ID)
+	}
+	return response, nil
+}
 
-	list := dto.QuestionList{}
-	err = util.ConvertTypes(s.logger, questions, &list)
+func (s *SurveyService) GetBranchRules(c context.Context, surveyId uint) ([]dto.BranchRule, error) {
+	rules, err := s.repo.GetBranchRules(c, surveyId)
 	if err != nil {
-		return dto.QuestionsAnswerMap{}, err
+		return nil, err
 	}
+	response := []dto.BranchRule{}
+	return response, util.ConvertTypes(s.logger, rules, &response)
+}
 
-	mapQuestions := list.ToMap()
-	savedQuestionIds := map[uint]bool{}
+// UpdateBranchRules replaces all branch rules of a survey, the rules are rejected as a whole
+// when any of them points to a missing question or they form a cycle.
+func (s *SurveyService) UpdateBranchRules(c context.Context, surveyId uint, req dto.BranchRulesUpdateRequest) ([]dto.BranchRule, error) {
+	survey, err := s.repo.GetSurveyByID(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	if survey == nil {
+		return nil, ErrSurveyNotFound
+	}
+	if !survey.IsEditable() {
+		return nil, ErrSurveyNotEditable
+	}
 
-	tempQuestionAnswer := map[dto.Answer]*dto.Question{}
-	for _, v := range list {
+	list, err := s.getOrderedQuestions(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	if problems := ValidateBranchRules(list, req.Rules, survey.IsSequential); len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidBranchRule, strings.Join(problems, "; "))
+	}
 
-		_, e := savedQuestionIds[v.ID]
-		if e {
-			continue
-		}
-		questionsAnswerMap = append(questionsAnswerMap, map[dto.Answer]*dto.Question{dto.NoAnswer: v})
-		savedQuestionIds[v.ID] = true
-		if len(v.Choices) > 0 {
-			for _, z := range v.Choices {
-				if z.LinkedQuestionID > 0 {
-					qid, e := mapQuestions[z.LinkedQuestionID]
-					if e {
-						tempQuestionAnswer[dto.Answer(z.Text)] = qid
-						savedQuestionIds[qid.ID] = true
-
-					}
-				}
+	rules := []*models.BranchRule{}
+	if err := util.ConvertTypes(s.logger, req.Rules, &rules); err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		rule.ID = 0
+		rule.SurveyID = surveyId
+	}
+	if err := s.repo.ReplaceBranchRules(c, surveyId, rules); err != nil {
+		return nil, err
+	}
 
-			}
+	if survey.Status != models.SurveyStatusDraft {
+		if _, err := s.versionService.PublishVersion(c, surveyId); err != nil {
+			return nil, err
 		}
+	}
+	return s.GetBranchRules(c, surveyId)
+}
 
-		if len(tempQuestionAnswer) > 0 {
-			questionsAnswerMap = append(questionsAnswerMap, tempQuestionAnswer)
-			tempQuestionAnswer = map[dto.Answer]*dto.Question{}
-		}
+func (s *SurveyService) validateStoredBranchRules(c context.Context, surveyId uint, sequential bool) error {
+	rules, err := s.GetBranchRules(c, surveyId)
+	if err != nil {
+		return err
+	}
+	list, err := s.getOrderedQuestions(c, surveyId)
+	if err != nil {
+		return err
 	}
+	if problems := ValidateBranchRules(list, rules, sequential); len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidBranchRule, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// getOrderedQuestions returns the questions of a survey page by page in their sequential order.
+func (s *SurveyService) getOrderedQuestions(c context.Context, surveyId uint) (dto.QuestionList, error) {
+	list, err := s.getQuestions(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	sections, err := s.getSections(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	return orderBySection(list, sections), nil
+}
 
-	return questionsAnswerMap, nil
+func (s *SurveyService) getSections(c context.Context, surveyId uint) ([]*dto.Section, error) {
+	sections, err := s.repo.GetSections(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	response := []*dto.Section{}
+	return response, util.ConvertTypes(s.logger, sections, &response)
+}
+
+func (s *SurveyService) getQuestions(c context.Context, surveyId uint) (dto.QuestionList, error) {
+	filter := dto.RepositoryFilter{Field: "survey_id", Operator: "=", Value: strconv.Itoa(int(surveyId))}
+	questions, err := s.repo.GetQuestions(c, &dto.RepositoryRequest{
+		Filters: []*dto.RepositoryFilter{&filter},
+		Sorts:   []*dto.RepositorySort{{Field: "\"order\"", SortType: "asc"}, {Field: "id", SortType: "asc"}},
+		With:    "Choices",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	list := dto.QuestionList{}
+	return list, util.ConvertTypes(s.logger, questions, &list)
 }
 
 func (s *SurveyService) GetVotes(surveyID, viewerID, respondentID uint) ([]map[string]interface{}, error) {
+	if err := s.checkNotAnonymous(context.Background(), surveyID); err != nil {
+		return nil, err
+	}
 	hasPermission, err := s.repo.CheckVoteVisibility(surveyID, viewerID, respondentID)
 	if err != nil {
 		return nil, err
@@ -477,6 +1373,9 @@ func (s *SurveyService) GetVotes(surveyID, viewerID, respondentID uint) ([]map[s
 }
 
 func (s *SurveyService) GetVisibleVoteUsers(surveyID, viewerID uint) ([]map[string]interface{}, error) {
+	if err := s.checkNotAnonymous(context.Background(), surveyID); err != nil {
+		return nil, err
+	}
 	users, err := s.repo.GetVisibleVoteUsers(surveyID, viewerID)
 	if err != nil {
 		return nil, err
@@ -485,7 +1384,47 @@ func (s *SurveyService) GetVisibleVoteUsers(surveyID, viewerID uint) ([]map[stri
 	return users, nil
 }
 
+// checkNotAnonymous rejects looking up the answers of a participant of an anonymous survey.
+func (s *SurveyService) checkNotAnonymous(c context.Context, surveyId uint) error {
+	survey, err := s.repo.GetSurveyByID(c, surveyId)
+	if err != nil {
+		return err
+	}
+	if survey != nil && survey.Anonymity != nil {
+		return ErrSurveyAnonymous
+	}
+	return nil
+}
+
+// checkSurveyWritable rejects changes to the answers and options of archived surveys.
+func (s *SurveyService) checkSurveyWritable(c context.Context, surveyId uint) error {
+	survey, err := s.repo.GetSurveyByID(c, surveyId)
+	if err != nil {
+		return err
+	}
+	if survey == nil {
+		return ErrSurveyNotFound
+	}
+	if survey.Status == models.SurveyStatusArchived {
+		return ErrSurveyReadOnly
+	}
+	return nil
+}
+
 func (s *SurveyService) CreateOption(c context.Context, userId uint, surveyId uint, req dto.SurveyOptionCreateRequest) (response *dto.SurveyOptionResponse, err error) {
+	if err := s.checkSurveyWritable(c, surveyId); err != nil {
+		return nil, err
+	}
+	if err := ValidateOption(req.Name, req.Value); err != nil {
+		return nil, err
+	}
+	existing, err := s.GetOptions(c, dto.SurveyOptionsGetRequest{SurveyId: surveyId, Name: req.Name})
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("%w: %s is already set, update it instead", ErrInvalidOption, req.Name)
+	}
 	option, err := s.repo.CreateOption(c, &models.SurveyOption{SurveyId: surveyId, Name: req.Name, Value: req.Value, UserId: userId})
 	if err != nil {
 		return nil, err
@@ -502,6 +1441,21 @@ func (s *SurveyService) UpdateOption(c context.Context, id uint, req dto.SurveyO
 	if option == nil {
 		return nil, nil
 	}
+	if err := s.checkSurveyWritable(c, option.SurveyId); err != nil {
+		return nil, err
+	}
+	if err := ValidateOption(req.Name, req.Value); err != nil {
+		return nil, err
+	}
+	if req.Name != option.Name {
+		existing, err := s.GetOptions(c, dto.SurveyOptionsGetRequest{SurveyId: option.SurveyId, Name: req.Name})
+		if err != nil {
+			return nil, err
+		}
+		if len(existing) > 0 {
+			return nil, fmt.Errorf("%w: %s is already set, update it instead", ErrInvalidOption, req.Name)
+		}
+	}
 	option.Name = req.Name
 	option.Value = req.Value
 
@@ -514,6 +1468,16 @@ func (s *SurveyService) UpdateOption(c context.Context, id uint, req dto.SurveyO
 
 }
 func (s *SurveyService) DeleteOption(c context.Context, id uint) error {
+	option, err := s.repo.GetOptionByID(c, id)
+	if err != nil {
+		return err
+	}
+	if option == nil {
+		return nil
+	}
+	if err := s.checkSurveyWritable(c, option.SurveyId); err != nil {
+		return err
+	}
 	return s.repo.DeleteOption(c, id)
 }
 func (s *SurveyService) GetOptions(c context.Context, req dto.SurveyOptionsGetRequest) (response []*dto.SurveyOptionResponse, err error) {
@@ -522,7 +1486,7 @@ func (s *SurveyService) GetOptions(c context.Context, req dto.SurveyOptionsGetRe
 		filters = append(filters, &dto.RepositoryFilter{Field: "survey_id", Operator: "=", Value: strconv.Itoa(int(req.SurveyId))})
 	}
 	if req.Name != "" {
-		filters = append(filters, &dto.RepositoryFilter{Field: "survey_id", Operator: "=", Value: req.Name})
+		filters = append(filters, &dto.RepositoryFilter{Field: "name", Operator: "=", Value: req.Name})
 
 	}
 	options, err := s.repo.GetOptions(c, &dto.RepositoryRequest{Filters: filters})
@@ -541,6 +1505,14 @@ func (s *SurveyService) GetSurveyVotes(c context.Context, surveyId uint) (respon
 	return response, util.ConvertTypes(s.logger, votes, &response)
 }
 
+func (s *SurveyService) GetSurveyVersions(c context.Context, surveyId uint) ([]*dto.SurveyVersionResponse, error) {
+	return s.versionService.GetVersions(c, surveyId)
+}
+
+func (s *SurveyService) GetSurveyVersion(c context.Context, surveyId uint, version int) (*dto.SurveyVersionResponse, error) {
+	return s.versionService.GetVersion(c, surveyId, version)
+}
+
 func (s *SurveyService) UploadMedia(fileHeader *multipart.FileHeader) (string, error) {
 	allowedExtensions := map[string]bool{".jpg": true, ".png": true, ".mp4": true, ".mp3": true}
 	fileExt := strings.ToLower(filepath.Ext(fileHeader.Filename))
@@ -562,3 +1534,15 @@ func (s *SurveyService) UploadMedia(fileHeader *multipart.FileHeader) (string, e
 
 	return s.repo.SaveFile(fileHeader.Filename, buf.Bytes())
 }
+
+// orderBySection groups questions by the order of sections, questions without a section come first.
+// The order of questions inside a section is kept.
+func orderBySection(list dto.QuestionList, sections []*dto.Section) dto.QuestionList {
+	rank := map[uint]int{}
+	for i, section := range sections {
+		rank[section.ID] = i + 1
+	}
+	ordered := append(dto.QuestionList{}, list...)
+	sort.SliceStable(ordered, func(i, j int) bool { return rank[ordered[i].SectionID] < rank[ordered[j].SectionID] })
+	return ordered
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// tokenSignature signs the payload of an invitation, guest or step token. Their payloads differ
+// in the number of parts or in the first one, so one can not be passed as another.
+func tokenSignature(secret string, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// newTokenNonce returns a random nonce for a token, it fails when the system has no randomness to give.
+func newTokenNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
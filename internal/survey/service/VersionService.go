@@ -0,0 +1,165 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/repository"
+	"github.com/G9QBootcamp/qoli-survey/internal/util"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"golang.org/x/net/context"
+)
+
+type IVersionService interface {
+	PublishVersion(c context.Context, surveyId uint) (*dto.SurveyVersionResponse, error)
+	GetVersions(c context.Context, surveyId uint) ([]*dto.SurveyVersionResponse, error)
+	GetVersion(c context.Context, surveyId uint, version int) (*dto.SurveyVersionResponse, error)
+}
+
+type VersionService struct {
+	conf   *config.Config
+	repo   repository.ISurveyRepository
+	logger logging.Logger
+}
+
+func NewVersionService(conf *config.Config, repo repository.ISurveyRepository, logger logging.Logger) *VersionService {
+	return &VersionService{conf: conf, repo: repo, logger: logger}
+}
+
+// PublishVersion freezes the current survey definition as a new version.
+// When nothing changed since the latest version, that version is returned as is.
+func (v *VersionService) PublishVersion(c context.Context, surveyId uint) (*dto.SurveyVersionResponse, error) {
+	survey, err := v.repo.GetSurveyByID(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+	if survey == nil {
+		return nil, ErrSurveyNotFound
+	}
+
+	definition, err := v.buildDefinition(c, survey)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := v.repo.GetLatestSurveyVersion(c, surveyId)
+	if err != nil {
+		return nil, err
+	}
+
+	number := 1
+	if latest != nil {
+		latestDefinition, err := canonicalDefinition(latest.Definition)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(latestDefinition, definition) {
+			return v.toResponse(latest)
+		}
+		number = latest.Version + 1
+	}
+
+	version, err := v.repo.CreateSurveyVersion(c, &models.SurveyVersion{SurveyID: surveyId, Version: number, Definition: string(definition)})
+	if err != nil {
+		return nil, err
+	}
+
+	err = v.repo.SetSurveyCurrentVersion(c, surveyId, number)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.toResponse(version)
+}
+
+func (v *VersionService) GetVersions(c context.Context, surveyId uint) ([]*dto.SurveyVersionResponse, error) {
+	versions, err := v.repo.GetSurveyVersions(c, surveyId)
+	if err != nil {
+		return []*dto.SurveyVersionResponse{}, err
+	}
+
+	response := []*dto.SurveyVersionResponse{}
+	for _, version := range versions {
+		r, err := v.toResponse(version)
+		if err != nil {
+			return []*dto.SurveyVersionResponse{}, err
+		}
+		response = append(response, r)
+	}
+	return response, nil
+}
+
+func (v *VersionService) GetVersion(c context.Context, surveyId uint, version int) (*dto.SurveyVersionResponse, error) {
+	m, err := v.repo.GetSurveyVersion(c, surveyId, version)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, nil
+	}
+	return v.toResponse(m)
+}
+
+func (v *VersionService) buildDefinition(c context.Context, survey *models.Survey) ([]byte, error) {
+	filter := dto.RepositoryFilter{Field: "survey_id", Operator: "=", Value: strconv.Itoa(int(survey.ID))}
+	questions, err := v.repo.GetQuestions(c, &dto.RepositoryRequest{
+		Filters: []*dto.RepositoryFilter{&filter},
+		Sorts:   []*dto.RepositorySort{{Field: "\"order\"", SortType: "asc"}, {Field: "id", SortType: "asc"}},
+		With:    "Choices",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	list := dto.QuestionList{}
+	err = util.ConvertTypes(v.logger, questions, &list)
+	if err != nil {
+		return nil, err
+	}
+	for _, q := range list {
+		sort.Slice(q.Choices, func(i, j int) bool { return q.Choices[i].ID < q.Choices[j].ID })
+	}
+
+	rules, err := v.repo.GetBranchRules(c, survey.ID)
+	if err != nil {
+		return nil, err
+	}
+	sections, err := v.repo.GetSections(c, survey.ID)
+	if err != nil {
+		return nil, err
+	}
+	randomization := dto.Randomization(survey.RandomizationSettings())
+	definition := dto.SurveyDefinition{Title: survey.Title, TitleTranslations: survey.TitleTranslations, Randomization: &randomization, Questions: list}
+	if err := util.ConvertTypes(v.logger, rules, &definition.Rules); err != nil {
+		return nil, err
+	}
+	if err := util.ConvertTypes(v.logger, sections, &definition.Sections); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(definition)
+}
+
+// canonicalDefinition re-encodes a stored definition so it can be compared byte by byte,
+// the database does not keep the original key order of jsonb values.
+func canonicalDefinition(stored string) ([]byte, error) {
+	definition := dto.SurveyDefinition{}
+	if err := json.Unmarshal([]byte(stored), &definition); err != nil {
+		return nil, err
+	}
+	return json.Marshal(definition)
+}
+
+func (v *VersionService) toResponse(m *models.SurveyVersion) (*dto.SurveyVersionResponse, error) {
+	response := dto.SurveyVersionResponse{ID: m.ID, SurveyID: m.SurveyID, Version: m.Version, CreatedAt: m.CreatedAt}
+	if err := json.Unmarshal([]byte(m.Definition), &response.Definition); err != nil {
+		v.logger.Error(logging.Internal, logging.FailedConvertDto, "error in decoding survey version definition", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return nil, err
+	}
+	return &response, nil
+}
@@ -0,0 +1,56 @@
+package service
+
+import "errors"
+
+var (
+	ErrSurveyNotFound          = errors.New("survey not found")
+	ErrInvalidSurveyStatus     = errors.New("invalid survey status")
+	ErrInvalidStatusTransition = errors.New("survey status transition is not allowed")
+	ErrSurveyNotEditable       = errors.New("survey can not be edited in its current status")
+	ErrSurveyNotOpen           = errors.New("survey is not open for participation")
+	ErrSurveyReadOnly          = errors.New("survey is archived and read only")
+	ErrInvalidQuestionConfig   = errors.New("invalid question config")
+	ErrInvalidAnswer           = errors.New("invalid answer")
+	ErrInvalidBranchRule       = errors.New("invalid branch rules")
+	ErrInvalidSection          = errors.New("section does not belong to this survey")
+	ErrInvalidChoice           = errors.New("choice does not belong to this question")
+	ErrInvalidSurveyFilter     = errors.New("invalid survey filter")
+	ErrSurveyIsTemplate        = errors.New("survey is a template, clone it to use it")
+	ErrBankQuestionNotFound    = errors.New("bank question not found")
+	ErrBankQuestionNotOwned    = errors.New("only the owner can change a bank question")
+	ErrInvalidBankQuery        = errors.New("invalid bank question query")
+	ErrInvalidQuestionOrder    = errors.New("the new order must list every question of the survey once")
+	ErrInvalidRandomization    = errors.New("invalid randomization")
+	ErrInvalidQuiz             = errors.New("invalid quiz settings")
+	ErrParticipationNotFound   = errors.New("participation not found")
+	ErrInvalidPiping           = errors.New("invalid answer piping")
+	ErrInvalidQuota            = errors.New("invalid quota")
+	ErrInvalidOption           = errors.New("invalid survey option")
+	ErrInvalidEligibility      = errors.New("invalid eligibility rule")
+	ErrNotEligible             = errors.New("you can not take this survey")
+	ErrAudienceNotFound        = errors.New("audience not found")
+	ErrInvalidAudience         = errors.New("invalid audience")
+	ErrInvitationNotFound      = errors.New("invitation not found")
+	ErrInvalidInvitation       = errors.New("invalid invitation")
+	ErrInvitationUsed          = errors.New("invitation is already used")
+	ErrInvitationExpired       = errors.New("invitation has expired")
+	ErrNotInvited              = errors.New("this survey is only open to invited participants")
+	ErrShareLinkNotFound       = errors.New("share link not found")
+	ErrInvalidShareLink        = errors.New("invalid share link")
+	ErrShareLinkTaken          = errors.New("share link slug is already taken")
+	ErrGuestSessionNotFound    = errors.New("guest session not found, open the share link of the survey first")
+	ErrInvalidAnonymity        = errors.New("invalid anonymity settings")
+	ErrSurveyAnonymous         = errors.New("survey is anonymous, its answers can not be linked to participants")
+	ErrParticipationSuspended  = errors.New("a participation of yours was interrupted, resume it instead of starting a new one")
+	ErrNotResumable            = errors.New("participation can not be resumed")
+	ErrInvalidStepToken        = errors.New("invalid step token, send the one the last step returned")
+	ErrWrongQuestion           = errors.New("invalid question id")
+	ErrFirstQuestion           = errors.New("this is first question")
+	ErrFirstPage               = errors.New("this is first page")
+	ErrReturnNotAllowed        = errors.New("you are not allowed to return in this survey")
+	ErrNothingToAnswer         = errors.New("every question is answered, commit the participation")
+	ErrNotFinished             = errors.New("participation still has questions to answer")
+	ErrTimeLimitReached        = errors.New("time limit reached")
+	ErrAnswerNotSaved          = errors.New("your answer could not be saved, send it again")
+	ErrQuotaFull               = errors.New("thank you for your interest, we already have enough answers from participants like you")
+)
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -109,6 +110,9 @@ func (h *AccessHandler) CreateVoteVisibility(c echo.Context) error {
 		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "validation failed"})
 	}
 	res, err := h.service.CreateVoteVisibility(c.Request().Context(), uint(surveyID), uint(viewerID), req)
+	if errors.Is(err, service.ErrAnonymousSurvey) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	}
 	if err != nil {
 		// Handle other errors as internal server errors
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
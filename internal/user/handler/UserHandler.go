@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -194,6 +195,9 @@ func (h *UserHandler) BuyVote(c echo.Context) error {
 	}
 
 	voterId, err := h.service.GetVoterID(c.Request().Context(), uint(voteID))
+	if errors.Is(err, service.ErrAnonymousSurvey) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	}
 	if err != nil || voterId != uint(sellerID) {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user is not voter of the vote"})
 	}
@@ -227,6 +231,9 @@ func (h *UserHandler) SellVote(c echo.Context) error {
 	}
 
 	voterId, err := h.service.GetVoterID(c.Request().Context(), uint(voteID))
+	if errors.Is(err, service.ErrAnonymousSurvey) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	}
 	if err != nil || voterId != sellerID {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user is not voter of the vote"})
 	}
@@ -22,6 +22,7 @@ type IAccessRepository interface {
 	GetVoteVisibilityById(ctx context.Context, id uint) (models.VoteVisibility, error)
 	GetVoteVisibilityBySurveyId(ctx context.Context, surveyId uint) ([]models.VoteVisibility, error)
 	DeleteVoteVisibilityById(ctx context.Context, id uint) error
+	IsAnonymousSurvey(ctx context.Context, surveyID uint) (bool, error)
 }
 
 type AccessRepository struct {
@@ -138,3 +139,14 @@ func (r *AccessRepository) DeleteVoteVisibilityById(ctx context.Context, id uint
 	}
 	return err
 }
+
+// IsAnonymousSurvey tells whether a survey is anonymous, nobody can see the votes of its participants.
+func (r *AccessRepository) IsAnonymousSurvey(ctx context.Context, surveyID uint) (bool, error) {
+	var count int64
+	err := r.db.GetDb().WithContext(ctx).Table("surveys").Where("id = ? AND anonymity IS NOT NULL", surveyID).Count(&count).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "IsAnonymousSurvey error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return false, err
+	}
+	return count > 0, nil
+}
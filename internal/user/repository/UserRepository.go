@@ -30,6 +30,7 @@ type IUserRepository interface {
 	Transfer(ctx context.Context, senderID, receiverID uint, amount float64) error
 	GetVoterID(ctx context.Context, voteID uint) (uint, error)
 	UpdateVoteVoter(ctx context.Context, voterID uint, voteID uint) error
+	IsAnonymousVote(ctx context.Context, voteID uint) (bool, error)
 }
 
 type UserRepository struct {
@@ -310,11 +311,27 @@ func (r *UserRepository) GetVoterID(ctx context.Context, voteID uint) (uint, err
 	var vote surveyModels.Vote
 	err := r.db.GetDb().WithContext(ctx).First(&vote, voteID).Error
 
-	if errors.Is(err, gorm.ErrRecordNotFound) {
+	if errors.Is(err, gorm.ErrRecordNotFound) || vote.VoterID == nil {
+		// votes of guests have no voter
 		return 0, err
 	}
 
-	return vote.VoterID, err
+	return *vote.VoterID, err
+}
+
+// IsAnonymousVote tells whether a vote was given on an anonymous survey.
+func (r *UserRepository) IsAnonymousVote(ctx context.Context, voteID uint) (bool, error) {
+	var count int64
+	err := r.db.GetDb().WithContext(ctx).Model(&surveyModels.Vote{}).
+		Joins("inner join questions on questions.id = votes.question_id").
+		Joins("inner join surveys on surveys.id = questions.survey_id").
+		Where("votes.id = ? AND surveys.anonymity IS NOT NULL", voteID).
+		Count(&count).Error
+	if err != nil {
+		r.logger.Error(logging.Database, logging.Select, "is anonymous vote error in repository ", map[logging.ExtraKey]interface{}{logging.ErrorMessage: err.Error()})
+		return false, err
+	}
+	return count > 0, nil
 }
 
 func (r *UserRepository) UpdateVoteVoter(ctx context.Context, voterID uint, voteID uint) error {
@@ -127,6 +127,13 @@ func (s *AccessService) DeleteUserSurveyRole(c context.Context, surveyID uint, u
 	return s.repo.DeleteUserSurveyRole(c, surveyID, userID, roleID)
 }
 func (s *AccessService) CreateVoteVisibility(c context.Context, surveyID uint, viewerID uint, req dto.VoteVisibilityCreateRequest) (dto.VoteVisibilityResponse, error) {
+	anonymous, err := s.repo.IsAnonymousSurvey(c, surveyID)
+	if err != nil {
+		return dto.VoteVisibilityResponse{}, err
+	}
+	if anonymous {
+		return dto.VoteVisibilityResponse{}, ErrAnonymousSurvey
+	}
 	for _, respondentID := range req.RespondentIDs {
 		_, err := s.repo.CreateVoteVisibility(c, surveyID, viewerID, uint(respondentID))
 		if err != nil {
@@ -182,7 +182,15 @@ func (s *UserService) BuyVote(ctx context.Context, buyerID, sellerID uint, voteI
 }
 
 func (s *UserService) SellVote(ctx context.Context, sellerID, buyerID uint, voteID uint, amount float64) error {
-	err := s.repo.Withdraw(ctx, buyerID, amount)
+	anonymous, err := s.repo.IsAnonymousVote(ctx, voteID)
+	if err != nil {
+		return err
+	}
+	if anonymous {
+		return ErrAnonymousSurvey
+	}
+
+	err = s.repo.Withdraw(ctx, buyerID, amount)
 	if err != nil {
 		return err
 	}
@@ -201,6 +209,13 @@ func (s *UserService) SellVote(ctx context.Context, sellerID, buyerID uint, vote
 }
 
 func (s *UserService) GetVoterID(ctx context.Context, voteID uint) (uint, error) {
+	anonymous, err := s.repo.IsAnonymousVote(ctx, voteID)
+	if err != nil {
+		return 0, err
+	}
+	if anonymous {
+		return 0, ErrAnonymousSurvey
+	}
 	return s.repo.GetVoterID(ctx, voteID)
 }
 
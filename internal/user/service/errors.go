@@ -0,0 +1,7 @@
+package service
+
+import "errors"
+
+var (
+	ErrAnonymousSurvey = errors.New("survey is anonymous, its votes can not be linked to participants")
+)
@@ -17,14 +17,35 @@ func GenerateNumericString(length int) string {
 	return string(numericString)
 }
 
-func ShuffleSlice[T any](slice []T) []T {
-	rand.Seed(uint64(time.Now().UnixNano()))
+// NewSeed returns a seed for NewRandom, it is never zero so a zero seed can mean none was recorded.
+func NewSeed() int64 {
+	r := rand.New(rand.NewSource(uint64(time.Now().UnixNano())))
+	for {
+		if seed := r.Int63(); seed != 0 {
+			return seed
+		}
+	}
+}
+
+// NewRandom returns a generator whose numbers only depend on seed, so a shuffle can be replayed.
+func NewRandom(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(uint64(seed)))
+}
+
+// ShuffleSliceWith shuffles a copy of slice with r, the elements fixed reports keep their position.
+func ShuffleSliceWith[T any](r *rand.Rand, slice []T, fixed func(T) bool) []T {
 	shuffled := make([]T, len(slice))
 	copy(shuffled, slice)
 
-	for i := len(shuffled) - 1; i > 0; i-- {
-		j := rand.Intn(i + 1)
-		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	free := []int{}
+	for i, v := range shuffled {
+		if fixed == nil || !fixed(v) {
+			free = append(free, i)
+		}
+	}
+	for i := len(free) - 1; i > 0; i-- {
+		j := r.Intn(i + 1)
+		shuffled[free[i]], shuffled[free[j]] = shuffled[free[j]], shuffled[free[i]]
 	}
 
 	return shuffled
@@ -0,0 +1,13 @@
+package util
+
+// InsertAt returns a copy of list with item at the 1 based position, a position of 0 or after
+// the last element appends it. The position item ended up at is returned too.
+func InsertAt[T any](list []T, item T, position int) ([]T, int) {
+	if position <= 0 || position > len(list) {
+		position = len(list) + 1
+	}
+	inserted := make([]T, 0, len(list)+1)
+	inserted = append(inserted, list[:position-1]...)
+	inserted = append(inserted, item)
+	return append(inserted, list[position-1:]...), position
+}
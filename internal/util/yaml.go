@@ -0,0 +1,59 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// JSONToYAML converts a json document to yaml, keys keep the names of the json tags.
+func JSONToYAML(data []byte) ([]byte, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(value)
+}
+
+// YAMLToJSON converts a yaml document to json so it can be decoded with the json tags of a struct.
+func YAMLToJSON(data []byte) ([]byte, error) {
+	var value interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	value, err := stringKeys(value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+func stringKeys(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for key, item := range v {
+			name, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("yaml key %v is not a string", key)
+			}
+			converted, err := stringKeys(item)
+			if err != nil {
+				return nil, err
+			}
+			m[name] = converted
+		}
+		return m, nil
+	case []interface{}:
+		for i, item := range v {
+			converted, err := stringKeys(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+		return v, nil
+	}
+	return value, nil
+}
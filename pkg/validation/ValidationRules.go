@@ -8,8 +8,28 @@ import (
 	"github.com/go-playground/validator"
 )
 
+var phonePattern = regexp.MustCompile(`^(\+98|0098|0)?9\d{9}$`)
+
+// formats validates single values with the same rules request fields use.
+var formats = newFormatValidator()
+
+func newFormatValidator() *validator.Validate {
+	v := validator.New()
+	RegisterCustomValidation(v)
+	return v
+}
+
+// ValidateFormat reports whether value matches a format tag such as email, phone or national_id.
+func ValidateFormat(value string, tag string) bool {
+	return formats.Var(value, tag) == nil
+}
+
 func ValidateIranianNationalID(fl validator.FieldLevel) bool {
-	nationalID := fl.Field().String()
+	return IsIranianNationalID(fl.Field().String())
+}
+
+// IsIranianNationalID checks the length and control digit of an Iranian national id.
+func IsIranianNationalID(nationalID string) bool {
 	if len(nationalID) != 10 {
 		return false
 	}
@@ -34,6 +54,11 @@ func ValidateIranianNationalID(fl validator.FieldLevel) bool {
 	return true
 }
 
+// ValidatePhoneNumber accepts Iranian mobile numbers with or without the country code.
+func ValidatePhoneNumber(fl validator.FieldLevel) bool {
+	return phonePattern.MatchString(fl.Field().String())
+}
+
 func ValidateDateFormat(fl validator.FieldLevel) bool {
 	dateStr := fl.Field().String()
 
@@ -44,5 +69,6 @@ func ValidateDateFormat(fl validator.FieldLevel) bool {
 func RegisterCustomValidation(v *validator.Validate) {
 	v.RegisterValidation("national_id", ValidateIranianNationalID)
 	v.RegisterValidation("date", ValidateDateFormat)
+	v.RegisterValidation("phone", ValidatePhoneNumber)
 
 }
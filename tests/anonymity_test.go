@@ -0,0 +1,47 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAnonymity(t *testing.T) {
+	assert.NoError(t, service.ValidateAnonymity(nil, &dto.QuizSettings{}))
+	assert.NoError(t, service.ValidateAnonymity(&dto.AnonymitySettings{MinGroupSize: 3}, nil))
+
+	err := service.ValidateAnonymity(&dto.AnonymitySettings{MinGroupSize: -1}, nil)
+	assert.True(t, errors.Is(err, service.ErrInvalidAnonymity))
+	err = service.ValidateAnonymity(&dto.AnonymitySettings{}, &dto.QuizSettings{})
+	assert.True(t, errors.Is(err, service.ErrInvalidAnonymity), "an anonymous survey can not be scored")
+}
+
+func TestMinGroupSize(t *testing.T) {
+	assert.Equal(t, int64(0), service.MinGroupSize(nil))
+	assert.Equal(t, int64(service.DefaultMinGroupSize), service.MinGroupSize(&dto.AnonymitySettings{}))
+	assert.Equal(t, int64(10), service.MinGroupSize(&dto.AnonymitySettings{MinGroupSize: 10}))
+}
+
+func TestSuppressSmallGroups(t *testing.T) {
+	reports := service.SuppressQuestionReports([]dto.QuestionReport{
+		{QuestionID: 1, Respondents: 5, Selections: 5, ChoiceReport: []dto.ChoiceReport{{ID: 1, Percentage: "100.00"}}},
+		{QuestionID: 2, Respondents: 2, Selections: 3, Unmapped: 1, ChoiceReport: []dto.ChoiceReport{{ID: 2, Percentage: "50.00"}}},
+	}, 5)
+	assert.False(t, reports[0].Suppressed)
+	assert.Len(t, reports[0].ChoiceReport, 1)
+	assert.Equal(t, dto.QuestionReport{QuestionID: 2, Respondents: 2, ChoiceReport: []dto.ChoiceReport{}, Suppressed: true}, reports[1])
+
+	average := 4.5
+	aggregates := service.SuppressAggregates([]dto.QuestionAggregate{
+		{QuestionID: 3, Type: dto.RatingQuestion, Count: 4, Average: &average, Distribution: map[string]int{"4": 2, "5": 2}},
+		{QuestionID: 4, Type: dto.RatingQuestion, Count: 6, Average: &average},
+	}, 5)
+	assert.Equal(t, dto.QuestionAggregate{QuestionID: 3, Type: dto.RatingQuestion, Count: 4, Suppressed: true}, aggregates[0])
+	assert.Equal(t, &average, aggregates[1].Average)
+
+	unchanged := service.SuppressQuestionReports([]dto.QuestionReport{{QuestionID: 5, Respondents: 1, Selections: 1}}, 0)
+	assert.False(t, unchanged[0].Suppressed, "reports of surveys that are not anonymous are not suppressed")
+}
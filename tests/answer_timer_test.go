@@ -0,0 +1,49 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnswerTimer(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	timer := service.NewAnswerTimer(func() time.Time { return now })
+	q1 := &dto.Question{ID: 1, Config: dto.QuestionConfig{TimeLimit: 30}}
+	q2 := &dto.Question{ID: 2}
+	q3 := &dto.Question{ID: 3, Config: dto.QuestionConfig{TimeLimit: 20}}
+
+	timer.Show(q1)
+	now = now.Add(10 * time.Second)
+	remaining, ok := timer.Remaining()
+	assert.True(t, ok)
+	assert.Equal(t, 20*time.Second, remaining)
+
+	now = now.Add(500 * time.Millisecond)
+	assert.Equal(t, 20, timer.RemainingSeconds(), "seconds are rounded up")
+
+	timer.Show(q2)
+	_, ok = timer.Remaining()
+	assert.False(t, ok, "questions without a limit have no remaining time")
+	assert.Equal(t, 0, timer.RemainingSeconds())
+	now = now.Add(5 * time.Second)
+	assert.Equal(t, 10500*time.Millisecond, timer.Spent(1))
+	assert.Equal(t, 5*time.Second, timer.Spent(2))
+
+	timer.Show(q1)
+	remaining, _ = timer.Remaining()
+	assert.Equal(t, 19500*time.Millisecond, remaining, "going back keeps the time spent before")
+	now = now.Add(time.Minute)
+	remaining, _ = timer.Remaining()
+	assert.Equal(t, time.Duration(0), remaining)
+
+	timer.Show(q2, q3)
+	assert.Equal(t, 20*time.Second, timer.Limit(), "a page has the limits of its questions together")
+	now = now.Add(4 * time.Second)
+	remaining, _ = timer.Remaining()
+	assert.Equal(t, 11*time.Second, remaining)
+	assert.Equal(t, 2*time.Second, timer.Spent(3), "questions shown together share the time")
+}
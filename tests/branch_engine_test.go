@@ -0,0 +1,150 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateExpression(t *testing.T) {
+	answers := map[uint][]string{2: {"A"}, 5: {"4"}, 7: {"red", "blue"}}
+	expression := dto.RuleExpression{And: []dto.RuleExpression{
+		{QuestionID: 2, Operator: dto.OperatorEqual, Value: "A"},
+		{QuestionID: 5, Operator: dto.OperatorGreater, Value: "3"},
+	}}
+	assert.True(t, service.EvaluateExpression(expression, answers))
+
+	answers[5] = []string{"10"}
+	assert.True(t, service.EvaluateExpression(expression, answers), "numbers are not compared as text")
+	answers[5] = []string{"2"}
+	assert.False(t, service.EvaluateExpression(expression, answers))
+
+	assert.True(t, service.EvaluateExpression(dto.RuleExpression{Or: []dto.RuleExpression{
+		{QuestionID: 9, Operator: dto.OperatorAnswered},
+		{QuestionID: 7, Operator: dto.OperatorEqual, Value: "blue"},
+	}}, answers))
+	assert.True(t, service.EvaluateExpression(dto.RuleExpression{Not: &dto.RuleExpression{QuestionID: 9, Operator: dto.OperatorAnswered}}, answers))
+	assert.False(t, service.EvaluateExpression(dto.RuleExpression{QuestionID: 9, Operator: dto.OperatorNotEqual, Value: "x"}, answers))
+	assert.True(t, service.EvaluateExpression(dto.RuleExpression{}, answers))
+}
+
+func TestBranchEngineNext(t *testing.T) {
+	q1 := &dto.Question{ID: 1, HasMultipleChoice: true, Choices: []dto.Choice{{ID: 1, Text: "yes", LinkedQuestionID: 3}, {ID: 2, Text: "no"}}}
+	q2 := &dto.Question{ID: 2}
+	q3 := &dto.Question{ID: 3}
+	q4 := &dto.Question{ID: 4}
+	rules := []dto.BranchRule{
+		{QuestionID: 2, Condition: dto.RuleExpression{QuestionID: 2, Operator: dto.OperatorEqual, Value: "stop"}, EndSurvey: true},
+	}
+	engine := service.NewBranchEngine([]*dto.Question{q1, q2, q3, q4}, nil, rules)
+
+	first := engine.Next(nil, nil)
+	assert.Equal(t, uint(1), first.ID)
+
+	engine.SetAnswer(1, []string{"yes"})
+	assert.Equal(t, uint(3), engine.Next(q1, []*dto.Question{q1}).ID, "linked question follows its choice")
+
+	engine.SetAnswer(1, []string{"no"})
+	assert.Equal(t, uint(2), engine.Next(q1, []*dto.Question{q1}).ID)
+
+	engine.SetAnswer(2, []string{"go on"})
+	assert.Equal(t, uint(4), engine.Next(q2, []*dto.Question{q1, q2}).ID, "unpicked linked question is skipped")
+
+	engine.SetAnswer(2, []string{"stop"})
+	assert.Nil(t, engine.Next(q2, []*dto.Question{q1, q2}))
+}
+
+func TestValidateBranchRules(t *testing.T) {
+	questions := []*dto.Question{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	assert.Empty(t, service.ValidateBranchRules(questions, []dto.BranchRule{{QuestionID: 1, TargetQuestionID: 3}}, true))
+
+	problems := service.ValidateBranchRules(questions, []dto.BranchRule{
+		{QuestionID: 1, TargetQuestionID: 9},
+		{QuestionID: 2, EndSurvey: true, Condition: dto.RuleExpression{QuestionID: 8, Operator: "like", Value: "x"}},
+	}, true)
+	assert.Len(t, problems, 3)
+
+	assert.NotEmpty(t, service.ValidateBranchRules(questions, []dto.BranchRule{{QuestionID: 3, TargetQuestionID: 1}}, true))
+	assert.Empty(t, service.ValidateBranchRules(questions, []dto.BranchRule{{QuestionID: 3, TargetQuestionID: 1}}, false))
+	assert.NotEmpty(t, service.ValidateBranchRules(questions, []dto.BranchRule{{QuestionID: 1, TargetQuestionID: 2}, {QuestionID: 2, TargetQuestionID: 1}}, false))
+}
+
+func TestBranchEnginePages(t *testing.T) {
+	q1 := &dto.Question{ID: 1, SectionID: 1}
+	q2 := &dto.Question{ID: 2, SectionID: 1}
+	q3 := &dto.Question{ID: 3, SectionID: 2}
+	q4 := &dto.Question{ID: 4, SectionID: 2}
+	q5 := &dto.Question{ID: 5, SectionID: 3}
+	sections := []*dto.Section{{ID: 1, Title: "About you"}, {ID: 2, Title: "Work"}, {ID: 3, Title: "Feedback"}}
+	rules := []dto.BranchRule{
+		{QuestionID: 1, Condition: dto.RuleExpression{QuestionID: 1, Operator: dto.OperatorEqual, Value: "student"}, TargetQuestionID: 5},
+	}
+	engine := service.NewBranchEngine([]*dto.Question{q1, q2, q3, q4, q5}, sections, rules)
+
+	page := engine.Page(engine.Next(nil, nil), nil)
+	assert.Equal(t, []*dto.Question{q1, q2}, page)
+	assert.Equal(t, "About you", engine.Section(page[0]).Title)
+
+	engine.SetAnswer(1, []string{"employed"})
+	asked := []*dto.Question{q1, q2}
+	next := engine.NextAfterPage(page, asked)
+	assert.Equal(t, []*dto.Question{q3, q4}, engine.Page(next, asked))
+
+	engine.SetAnswer(1, []string{"student"})
+	assert.Equal(t, uint(5), engine.NextAfterPage(page, asked).ID, "a rule on the page decides the next page")
+}
+
+func TestRuleExpressionRemapQuestions(t *testing.T) {
+	expression := models.RuleExpression{And: []models.RuleExpression{
+		{QuestionID: 1, Operator: "eq", Operand: "yes"},
+		{Not: &models.RuleExpression{QuestionID: 2, Operator: "answered"}},
+	}}
+	remapped := expression.RemapQuestions(map[uint]uint{1: 11, 2: 12})
+
+	assert.Equal(t, uint(11), remapped.And[0].QuestionID)
+	assert.Equal(t, "yes", remapped.And[0].Operand)
+	assert.Equal(t, uint(12), remapped.And[1].Not.QuestionID)
+	assert.Equal(t, uint(2), expression.And[1].Not.QuestionID, "the original expression is not changed")
+}
+
+func TestBranchEngineRender(t *testing.T) {
+	q1 := &dto.Question{ID: 1, HasMultipleChoice: true, Choices: []dto.Choice{
+		{ID: 1, Text: "tea", TextTranslations: map[string]string{"fa": "چای"}},
+		{ID: 2, Text: "coffee"},
+		{ID: 3, Text: "milk"},
+	}}
+	q2 := &dto.Question{ID: 2, Text: "Why do you like {{q1}}?"}
+	q3 := &dto.Question{ID: 3, HasMultipleChoice: true, Text: "Which one is best?", Config: dto.QuestionConfig{ChoicesFrom: 1},
+		Choices: []dto.Choice{{ID: 4, Text: "tea"}, {ID: 5, Text: "coffee"}, {ID: 6, Text: "milk"}}}
+	engine := service.NewBranchEngine([]*dto.Question{q1, q2, q3}, nil, nil)
+
+	assert.Equal(t, "Why do you like ?", engine.Render(q2, "").Text, "unanswered references are empty")
+
+	engine.SetAnswer(1, []string{"tea", "milk"})
+	assert.Equal(t, "Why do you like tea, milk?", engine.Render(q2, "").Text)
+	assert.Equal(t, "Why do you like چای, milk?", engine.Render(q2, "fa").Text)
+	assert.Equal(t, "Why do you like {{q1}}?", q2.Text, "the original question is not changed")
+
+	rendered := engine.Render(q3, "")
+	assert.Len(t, rendered.Choices, 2)
+	assert.Equal(t, uint(4), rendered.Choices[0].ID)
+	assert.Equal(t, uint(6), rendered.Choices[1].ID)
+}
+
+func TestValidatePiping(t *testing.T) {
+	q1 := &dto.Question{ID: 1, HasMultipleChoice: true}
+	q2 := &dto.Question{ID: 2, Text: "About {{q1}}"}
+	q3 := &dto.Question{ID: 3, HasMultipleChoice: true, Config: dto.QuestionConfig{ChoicesFrom: 2}}
+
+	assert.Empty(t, service.ValidatePiping([]*dto.Question{q1, q2}, true))
+	assert.Len(t, service.ValidatePiping([]*dto.Question{q1, q2}, false), 1, "piping needs a sequential survey")
+	assert.Len(t, service.ValidatePiping([]*dto.Question{q2, q1}, true), 1, "references must be asked before")
+	assert.Len(t, service.ValidatePiping([]*dto.Question{q2}, true), 1, "references must exist")
+	assert.Len(t, service.ValidatePiping([]*dto.Question{q1, q2, q3}, true), 1, "choices only come from multiple choice questions")
+
+	assert.Equal(t, "About {{q11}} and {{q5}}", service.RemapPiping("About {{ q1 }} and {{q5}}", map[uint]uint{1: 11}))
+}
@@ -0,0 +1,95 @@
+package test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateEligibility(t *testing.T) {
+	eighteen, ten := 18, 10
+	valid := dto.EligibilityRule{And: []dto.EligibilityRule{
+		{Type: "city", Values: []string{"Tehran"}},
+		{Or: []dto.EligibilityRule{{Type: "email_verified"}, {Type: "audience", AudienceID: 3}}},
+		{Type: "age", MinAge: &eighteen},
+	}}
+	assert.NoError(t, service.ValidateEligibility(valid))
+
+	invalid := []dto.EligibilityRule{
+		{},
+		{Type: "planet"},
+		{Type: "city"},
+		{Type: "age"},
+		{Type: "age", MinAge: &eighteen, MaxAge: &ten},
+		{Type: "account_age"},
+		{Type: "participated"},
+		{And: []dto.EligibilityRule{{Type: "email_verified"}}, Or: []dto.EligibilityRule{{Type: "email_verified"}}},
+		{Type: "city", And: []dto.EligibilityRule{{Type: "email_verified"}}},
+		{And: []dto.EligibilityRule{{Type: "audience"}}},
+	}
+	for i, rule := range invalid {
+		assert.True(t, errors.Is(service.ValidateEligibility(rule), service.ErrInvalidEligibility), i)
+	}
+}
+
+func TestEvaluateEligibility(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	eighteen, thirty := 18, 30
+	rule := dto.EligibilityRule{And: []dto.EligibilityRule{
+		{Type: "city", Values: []string{"Tehran", "Shiraz"}},
+		{Type: "age", MinAge: &eighteen, MaxAge: &thirty},
+		{Or: []dto.EligibilityRule{
+			{Type: "email_domain", Values: []string{"@uni.ac.ir"}},
+			{Type: "audience", AudienceID: 7},
+		}},
+	}}
+	facts := service.EligibilityFacts{City: "shiraz ", Email: "ali@UNI.ac.ir", DateOfBirth: time.Date(2006, 6, 15, 0, 0, 0, 0, time.UTC)}
+
+	eligible, reasons := service.EvaluateEligibility(rule, facts, now)
+	assert.True(t, eligible, "the age counts from the full birth date")
+	assert.Empty(t, reasons)
+
+	facts.DateOfBirth = time.Date(2006, 6, 16, 0, 0, 0, 0, time.UTC)
+	facts.Email = "ali@mail.com"
+	eligible, reasons = service.EvaluateEligibility(rule, facts, now)
+	assert.False(t, eligible)
+	assert.Equal(t, []string{
+		"your age must be between 18 and 30",
+		"one of these is needed: your email must be at @uni.ac.ir, or the survey is only for an invited audience",
+	}, reasons)
+
+	facts.DateOfBirth = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	facts.Audiences = map[uint]bool{7: true}
+	eligible, _ = service.EvaluateEligibility(rule, facts, now)
+	assert.True(t, eligible)
+
+	eligible, reasons = service.EvaluateEligibility(dto.EligibilityRule{Type: "account_age", MinAccountDays: 30}, service.EligibilityFacts{RegisteredAt: now.AddDate(0, 0, -29)}, now)
+	assert.False(t, eligible)
+	assert.Equal(t, []string{"your account must be at least 30 days old"}, reasons)
+}
+
+func TestEligibilityCondition(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	thirty := 30
+	condition, args := service.EligibilityCondition(dto.EligibilityRule{Or: []dto.EligibilityRule{
+		{Type: "city", Values: []string{" Tehran"}},
+		{And: []dto.EligibilityRule{{Type: "age", MaxAge: &thirty}, {Type: "participated", SurveyID: 4}}},
+	}}, now)
+	assert.Equal(t, "(LOWER(TRIM(users.city)) IN ? OR ((users.date_of_birth > ? AND users.date_of_birth > ?) AND users.id IN (SELECT user_id FROM user_survey_participations WHERE survey_id = ? AND committed_at IS NOT NULL AND deleted_at IS NULL)))", condition)
+	assert.Equal(t, []interface{}{[]string{"tehran"}, time.Time{}, time.Date(1993, 6, 15, 12, 0, 0, 0, time.UTC), uint(4)}, args)
+}
+
+func TestOptionsEligibility(t *testing.T) {
+	assert.Nil(t, service.OptionsEligibility([]dto.SurveyOptionResponse{{Name: "theme", Value: "dark"}}))
+
+	rule := service.OptionsEligibility([]dto.SurveyOptionResponse{{Name: "city", Value: "Tehran"}, {Name: "minimum_age", Value: "18"}})
+	eighteen := 18
+	assert.Equal(t, &dto.EligibilityRule{And: []dto.EligibilityRule{
+		{Type: "city", Values: []string{"Tehran"}},
+		{Type: "age", MinAge: &eighteen},
+	}}, rule)
+}
@@ -0,0 +1,419 @@
+package test
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/repository"
+	"github.com/G9QBootcamp/qoli-survey/internal/util"
+)
+
+// fakeSurveyRepository keeps surveys in memory for the services that are tested without a
+// database, the methods it does not implement panic through the nil embedded interface.
+type fakeSurveyRepository struct {
+	repository.ISurveyRepository
+	surveys   map[uint]*models.Survey
+	questions map[uint]*models.Question
+	sections  []*models.Section
+	rules     []*models.BranchRule
+	versions  []*models.SurveyVersion
+	bank      []*models.BankQuestion
+	// participations are by id, released lists the participations that gave back their quota places
+	// and votes are the stored votes. lock guards them for requests that run at once, beforeClaim
+	// runs before a participation is claimed.
+	participations map[uint]*models.UserSurveyParticipation
+	released       []uint
+	votes          []models.Vote
+	beforeClaim    func()
+	lock           sync.Mutex
+	nextId         uint
+}
+
+func newFakeSurveyRepository(surveys ...*models.Survey) *fakeSurveyRepository {
+	r := &fakeSurveyRepository{surveys: map[uint]*models.Survey{}, questions: map[uint]*models.Question{}, participations: map[uint]*models.UserSurveyParticipation{}, nextId: 100}
+	for _, survey := range surveys {
+		r.surveys[survey.ID] = survey
+	}
+	return r
+}
+
+func (r *fakeSurveyRepository) id() uint {
+	r.nextId++
+	return r.nextId
+}
+
+// addQuestion stores q at the end of its survey.
+func (r *fakeSurveyRepository) addQuestion(q *models.Question) *models.Question {
+	if q.ID == 0 {
+		q.ID = r.id()
+	}
+	q.Order = len(r.surveyQuestions(q.SurveyID)) + 1
+	for i := range q.Choices {
+		if q.Choices[i].ID == 0 {
+			q.Choices[i].ID = r.id()
+		}
+		q.Choices[i].QuestionID = q.ID
+	}
+	r.questions[q.ID] = q
+	return q
+}
+
+func (r *fakeSurveyRepository) surveyQuestions(surveyId uint) []*models.Question {
+	list := []*models.Question{}
+	for _, q := range r.questions {
+		if q.SurveyID == surveyId {
+			list = append(list, q)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Order != list[j].Order {
+			return list[i].Order < list[j].Order
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+func (r *fakeSurveyRepository) orderIds(surveyId uint) []uint {
+	ids := []uint{}
+	for _, q := range r.surveyQuestions(surveyId) {
+		ids = append(ids, q.ID)
+	}
+	return ids
+}
+
+func (r *fakeSurveyRepository) GetSurveyByID(ctx context.Context, surveyId uint) (*models.Survey, error) {
+	return r.surveys[surveyId], nil
+}
+
+func (r *fakeSurveyRepository) UpdateSurvey(ctx context.Context, survey *models.Survey) error {
+	r.surveys[survey.ID] = survey
+	return nil
+}
+
+// GetQuestions only knows the survey_id filter the services use.
+func (r *fakeSurveyRepository) GetQuestions(ctx context.Context, req *dto.RepositoryRequest) ([]*models.Question, error) {
+	for _, filter := range req.Filters {
+		if filter.Field == "survey_id" {
+			id, _ := strconv.Atoi(filter.Value)
+			return r.surveyQuestions(uint(id)), nil
+		}
+	}
+	return []*models.Question{}, nil
+}
+
+func (r *fakeSurveyRepository) GetQuestionByID(ctx context.Context, id uint) (*models.Question, error) {
+	return r.questions[id], nil
+}
+
+func (r *fakeSurveyRepository) GetSections(ctx context.Context, surveyId uint) ([]*models.Section, error) {
+	sections := []*models.Section{}
+	for _, section := range r.sections {
+		if section.SurveyID == surveyId {
+			sections = append(sections, section)
+		}
+	}
+	return sections, nil
+}
+
+func (r *fakeSurveyRepository) GetBranchRules(ctx context.Context, surveyId uint) ([]*models.BranchRule, error) {
+	rules := []*models.BranchRule{}
+	for _, rule := range r.rules {
+		if rule.SurveyID == surveyId {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func (r *fakeSurveyRepository) GetLatestSurveyVersion(ctx context.Context, surveyId uint) (*models.SurveyVersion, error) {
+	var latest *models.SurveyVersion
+	for _, version := range r.versions {
+		if version.SurveyID == surveyId && (latest == nil || version.Version > latest.Version) {
+			latest = version
+		}
+	}
+	return latest, nil
+}
+
+func (r *fakeSurveyRepository) CreateSurveyVersion(ctx context.Context, version *models.SurveyVersion) (*models.SurveyVersion, error) {
+	version.ID = r.id()
+	r.versions = append(r.versions, version)
+	return version, nil
+}
+
+func (r *fakeSurveyRepository) SetSurveyCurrentVersion(ctx context.Context, surveyId uint, version int) error {
+	r.surveys[surveyId].CurrentVersion = version
+	return nil
+}
+
+// UpdateQuestion keeps the choices of a question stored without them, as saving it does.
+func (r *fakeSurveyRepository) UpdateQuestion(ctx context.Context, question *models.Question) (*models.Question, error) {
+	if stored, exists := r.questions[question.ID]; exists && question.Choices == nil {
+		question.Choices = stored.Choices
+	}
+	r.questions[question.ID] = question
+	return question, nil
+}
+
+func (r *fakeSurveyRepository) GetSectionByID(ctx context.Context, id uint) (*models.Section, error) {
+	for _, section := range r.sections {
+		if section.ID == id {
+			return section, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeSurveyRepository) CreateBankQuestion(ctx context.Context, question *models.BankQuestion) error {
+	question.ID = r.id()
+	r.bank = append(r.bank, question)
+	return nil
+}
+
+func (r *fakeSurveyRepository) UpdateBankQuestion(ctx context.Context, question *models.BankQuestion) error {
+	return nil
+}
+
+func (r *fakeSurveyRepository) DeleteBankQuestion(ctx context.Context, id uint) error {
+	for i, question := range r.bank {
+		if question.ID == id {
+			r.bank = append(r.bank[:i], r.bank[i+1:]...)
+		}
+	}
+	return nil
+}
+
+func (r *fakeSurveyRepository) GetBankQuestionByID(ctx context.Context, id uint) (*models.BankQuestion, error) {
+	for _, question := range r.bank {
+		if question.ID == id {
+			return question, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeSurveyRepository) CreateSurvey(ctx context.Context, survey *models.Survey) error {
+	survey.ID = r.id()
+	r.surveys[survey.ID] = survey
+	return nil
+}
+
+// PurgeSurvey removes what the database would delete in cascade.
+func (r *fakeSurveyRepository) PurgeSurvey(ctx context.Context, id uint) error {
+	delete(r.surveys, id)
+	for _, q := range r.surveyQuestions(id) {
+		delete(r.questions, q.ID)
+	}
+	sections := []*models.Section{}
+	for _, section := range r.sections {
+		if section.SurveyID != id {
+			sections = append(sections, section)
+		}
+	}
+	r.sections = sections
+	return r.ReplaceBranchRules(ctx, id, nil)
+}
+
+func (r *fakeSurveyRepository) CreateSection(ctx context.Context, section *models.Section) error {
+	section.ID = r.id()
+	r.sections = append(r.sections, section)
+	return nil
+}
+
+func (r *fakeSurveyRepository) CreateQuestion(ctx context.Context, question *models.Question) error {
+	question.ID = r.id()
+	r.questions[question.ID] = question
+	return nil
+}
+
+func (r *fakeSurveyRepository) CreateChoice(ctx context.Context, choice *models.Choice) error {
+	choice.ID = r.id()
+	q := r.questions[choice.QuestionID]
+	q.Choices = append(q.Choices, *choice)
+	return nil
+}
+
+func (r *fakeSurveyRepository) GetChoiceByTextAndQuestion(ctx context.Context, text string, questionID uint) (*models.Choice, error) {
+	for _, choice := range r.questions[questionID].Choices {
+		if choice.Text == text {
+			return &choice, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeSurveyRepository) UpdateChoice(ctx context.Context, choice *models.Choice) error {
+	q := r.questions[choice.QuestionID]
+	for i := range q.Choices {
+		if q.Choices[i].ID == choice.ID {
+			q.Choices[i] = *choice
+		}
+	}
+	return nil
+}
+
+func (r *fakeSurveyRepository) ReplaceBranchRules(ctx context.Context, surveyId uint, rules []*models.BranchRule) error {
+	kept := []*models.BranchRule{}
+	for _, rule := range r.rules {
+		if rule.SurveyID != surveyId {
+			kept = append(kept, rule)
+		}
+	}
+	for _, rule := range rules {
+		rule.ID = r.id()
+		kept = append(kept, rule)
+	}
+	r.rules = kept
+	return nil
+}
+
+func (r *fakeSurveyRepository) InsertQuestion(ctx context.Context, question *models.Question, position int) error {
+	ids := r.orderIds(question.SurveyID)
+	r.addQuestion(question)
+	ids, question.Order = util.InsertAt(ids, question.ID, position)
+	return r.ReorderQuestions(ctx, question.SurveyID, ids)
+}
+
+func (r *fakeSurveyRepository) ReorderQuestions(ctx context.Context, surveyId uint, ids []uint) error {
+	for i, id := range ids {
+		r.questions[id].Order = i + 1
+	}
+	return nil
+}
+
+func (r *fakeSurveyRepository) DeleteQuestion(ctx context.Context, id uint) error {
+	delete(r.questions, id)
+	return nil
+}
+
+func (r *fakeSurveyRepository) DeleteQuestionChoices(ctx context.Context, questionId uint) error {
+	return nil
+}
+
+func (r *fakeSurveyRepository) UnlinkQuestion(ctx context.Context, questionId uint) error {
+	for _, q := range r.questions {
+		if q.LinkedQuestionID == questionId {
+			q.LinkedQuestionID = 0
+		}
+		for i := range q.Choices {
+			if q.Choices[i].LinkedQuestionID == questionId {
+				q.Choices[i].LinkedQuestionID = 0
+			}
+		}
+	}
+	return nil
+}
+
+func (r *fakeSurveyRepository) GetSurveysDueForStatusChange(ctx context.Context, now time.Time) ([]*models.Survey, error) {
+	due := []*models.Survey{}
+	for _, survey := range r.surveys {
+		if survey.Status != survey.CurrentStatus(now) {
+			due = append(due, survey)
+		}
+	}
+	return due, nil
+}
+
+func (r *fakeSurveyRepository) GetUserParticipation(ctx context.Context, participationId uint) (*models.UserSurveyParticipation, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	p := r.participations[participationId]
+	if p == nil {
+		return nil, nil
+	}
+	found := *p
+	return &found, nil
+}
+
+func (r *fakeSurveyRepository) SuspendParticipation(ctx context.Context, participationId uint, progress *models.ParticipationProgress, at time.Time) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	p := r.participations[participationId]
+	if p != nil && p.CommittedAt == nil && p.EndAt == nil {
+		stored := *progress
+		p.Progress, p.DisconnectedAt = &stored, &at
+	}
+	return nil
+}
+
+func (r *fakeSurveyRepository) ClaimParticipation(ctx context.Context, participationId uint, disconnectedAt time.Time) (bool, error) {
+	if r.beforeClaim != nil {
+		r.beforeClaim()
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	p := r.participations[participationId]
+	if p == nil || p.CommittedAt != nil || p.EndAt != nil || p.DisconnectedAt == nil || !p.DisconnectedAt.Equal(disconnectedAt) {
+		return false, nil
+	}
+	p.DisconnectedAt = nil
+	return true, nil
+}
+
+func (r *fakeSurveyRepository) GetRespondentVotes(ctx context.Context, surveyId uint, respondent models.Respondent) ([]models.Vote, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]models.Vote{}, r.votes...), nil
+}
+
+func (r *fakeSurveyRepository) GetSuspendedParticipations(ctx context.Context) ([]models.UserSurveyParticipation, error) {
+	suspended := []models.UserSurveyParticipation{}
+	for _, p := range r.participations {
+		if p.CommittedAt == nil && p.EndAt == nil && p.DisconnectedAt != nil {
+			suspended = append(suspended, *p)
+		}
+	}
+	return suspended, nil
+}
+
+func (r *fakeSurveyRepository) EndSuspendedParticipation(ctx context.Context, participationId uint, disconnectedAt time.Time, at time.Time) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	p := r.participations[participationId]
+	if p == nil || p.CommittedAt != nil || p.EndAt != nil || p.DisconnectedAt == nil || !p.DisconnectedAt.Equal(disconnectedAt) {
+		return false, nil
+	}
+	p.EndAt = &at
+	return true, nil
+}
+
+func (r *fakeSurveyRepository) ReleaseQuotas(ctx context.Context, participationId uint, quotaIds []uint) error {
+	r.released = append(r.released, participationId)
+	return nil
+}
+
+func (r *fakeSurveyRepository) DeleteChoice(ctx context.Context, id uint) error {
+	for _, q := range r.questions {
+		for i := range q.Choices {
+			if q.Choices[i].ID == id {
+				q.Choices = append(q.Choices[:i], q.Choices[i+1:]...)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// CloneSurvey only copies the questions and their choices.
+func (r *fakeSurveyRepository) CloneSurvey(ctx context.Context, sourceId uint, clone *models.Survey) (map[uint]uint, error) {
+	if err := r.CreateSurvey(ctx, clone); err != nil {
+		return nil, err
+	}
+	questionIds := map[uint]uint{}
+	for _, q := range r.surveyQuestions(sourceId) {
+		copied := *q
+		copied.ID, copied.SurveyID = 0, clone.ID
+		copied.Choices = append([]models.Choice{}, q.Choices...)
+		for i := range copied.Choices {
+			copied.Choices[i].ID = 0
+		}
+		questionIds[q.ID] = r.addQuestion(&copied).ID
+	}
+	return questionIds, nil
+}
@@ -0,0 +1,45 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvitationToken(t *testing.T) {
+	token := service.SignInvitationToken("secret", 42, "abc123")
+
+	id, nonce, err := service.ParseInvitationToken("secret", token)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(42), id)
+	assert.Equal(t, "abc123", nonce)
+
+	_, _, err = service.ParseInvitationToken("another secret", token)
+	assert.True(t, errors.Is(err, service.ErrInvitationNotFound), "tokens only hold for the secret they were signed with")
+
+	forged := strings.Replace(token, "42.", "43.", 1)
+	_, _, err = service.ParseInvitationToken("secret", forged)
+	assert.True(t, errors.Is(err, service.ErrInvitationNotFound), "the id can not be changed")
+
+	_, _, err = service.ParseInvitationToken("secret", "42.abc123")
+	assert.True(t, errors.Is(err, service.ErrInvitationNotFound))
+}
+
+func TestInvitationEmails(t *testing.T) {
+	emails, err := service.InvitationEmails([]string{" Ali@Mail.com", "sara@mail.com", "ali@mail.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ali@mail.com", "sara@mail.com"}, emails)
+
+	for _, email := range []string{"", "ali", "@mail.com", "ali@", "ali@a@mail.com", "ali rezaei@mail.com"} {
+		_, err := service.InvitationEmails([]string{email})
+		assert.True(t, errors.Is(err, service.ErrInvalidInvitation), email)
+	}
+}
+
+func TestInvitationEmail(t *testing.T) {
+	body := service.InvitationEmail("Coffee habits", " We value your opinion. ", "http://localhost:8080/invitations/1.a.b")
+	assert.Equal(t, "Hello,\n\nYou are invited to take the survey Coffee habits.\n\nWe value your opinion.\n\nFollow this link to take part, it can only be used once:\nhttp://localhost:8080/invitations/1.a.b", body)
+}
@@ -0,0 +1,43 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateLanguage(t *testing.T) {
+	available := service.SurveyLanguages(map[string]string{"en": "Feedback", "fa": "بازخورد"})
+
+	assert.Equal(t, "en", service.NegotiateLanguage("", "de;q=0.9, en-US;q=0.8, fa;q=0.5", available))
+	assert.Equal(t, "fa", service.NegotiateLanguage("FA", "en", available), "the participant preference comes first")
+	assert.Equal(t, "fa", service.NegotiateLanguage("", "fa-IR", available))
+	assert.Equal(t, "", service.NegotiateLanguage("", "de, fr;q=0.5", available))
+	assert.Equal(t, "", service.NegotiateLanguage("", "en;q=0", available))
+}
+
+func TestLocalizeQuestion(t *testing.T) {
+	q := &dto.Question{
+		ID:                1,
+		Text:              "رنگ مورد علاقه؟",
+		TextTranslations:  map[string]string{"en": "Favourite colour?"},
+		HasMultipleChoice: true,
+		Choices: []dto.Choice{
+			{ID: 4, Text: "آبی", TextTranslations: map[string]string{"en": "Blue"}},
+			{ID: 5, Text: "سبز"},
+		},
+	}
+
+	localized := service.LocalizeQuestion(q, "en")
+	assert.Equal(t, "Favourite colour?", localized.Text)
+	assert.Equal(t, "Blue", localized.Choices[0].Text)
+	assert.Equal(t, uint(4), localized.Choices[0].ID)
+	assert.Equal(t, "سبز", localized.Choices[1].Text, "untranslated choices keep their text")
+	assert.Equal(t, "آبی", q.Choices[0].Text, "the original question is not changed")
+
+	selected, err := service.SelectChoices(q, dto.VoteRequest{Answer: "Blue"})
+	assert.NoError(t, err)
+	assert.Equal(t, "آبی", selected[0].Text, "votes keep the untranslated choice")
+}
@@ -0,0 +1,209 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+// fakeParticipationStore keeps the answers of one participation, votes fail with fail when it is set.
+type fakeParticipationStore struct {
+	votes    map[uint]string
+	timedOut []uint
+	asked    []uint
+	response []models.Vote
+	fail     error
+}
+
+func (s *fakeParticipationStore) CommitVote(c context.Context, vote models.Vote) error {
+	if s.fail != nil {
+		return s.fail
+	}
+	s.votes[vote.QuestionID] = vote.Answer
+	return nil
+}
+
+func (s *fakeParticipationStore) CommitVotes(c context.Context, respondent models.Respondent, questionId uint, votes []models.Vote) error {
+	if s.fail != nil {
+		return s.fail
+	}
+	delete(s.votes, questionId)
+	for _, vote := range votes {
+		s.votes[questionId] = vote.Answer
+	}
+	return nil
+}
+
+func (s *fakeParticipationStore) CommitResponse(c context.Context, votes []models.Vote) error {
+	s.response = votes
+	return nil
+}
+
+func (s *fakeParticipationStore) CheckAnswerQuotas(c context.Context, participationId uint, questionId uint, answers []string) error {
+	return nil
+}
+
+func (s *fakeParticipationStore) RecordQuestionTime(c context.Context, participationId uint, questionId uint, spent time.Duration, timedOut bool) error {
+	if timedOut {
+		s.timedOut = append(s.timedOut, questionId)
+	}
+	return nil
+}
+
+func (s *fakeParticipationStore) CommitParticipation(c context.Context, participationId uint, asked []uint) (*dto.QuizResult, error) {
+	s.asked = asked
+	return nil, nil
+}
+
+func TestParticipationEngine(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	questions := []*dto.Question{{ID: 1}, {ID: 2}, {ID: 3, Config: dto.QuestionConfig{TimeLimit: 10}}}
+	store := &fakeParticipationStore{votes: map[uint]string{}}
+	participation := dto.UserSurveyParticipationResponse{ID: 7}
+	engine := service.NewParticipationEngine(store, nil, participation, models.UserRespondent(1), service.NewBranchEngine(questions, nil, nil), false, true, clock)
+	c := context.Background()
+
+	engine.Start()
+	assert.Equal(t, uint(1), engine.Response("", "").Question.ID)
+	assert.True(t, errors.Is(engine.Back(), service.ErrFirstQuestion))
+	assert.True(t, errors.Is(engine.Answer(c, dto.VoteRequest{QuestionId: 2, Answer: "a"}), service.ErrWrongQuestion))
+	assert.True(t, errors.Is(engine.Answer(c, dto.VoteRequest{QuestionId: 1}), service.ErrInvalidAnswer), "an answer is required")
+
+	assert.NoError(t, engine.Answer(c, dto.VoteRequest{QuestionId: 1, Answer: "a"}))
+	assert.Equal(t, uint(2), engine.Response("", "").Question.ID)
+	_, err := engine.Commit(c)
+	assert.True(t, errors.Is(err, service.ErrNotFinished))
+
+	assert.NoError(t, engine.Back())
+	assert.Equal(t, uint(1), engine.Response("", "").Question.ID)
+	assert.NoError(t, engine.Answer(c, dto.VoteRequest{QuestionId: 1, Answer: "b"}))
+	assert.NoError(t, engine.Answer(c, dto.VoteRequest{QuestionId: 2, Answer: "c"}))
+	assert.Equal(t, 10, engine.Response("", "").TimeLeft)
+
+	// the progress stored between requests picks up on the question with the time already spent on it
+	now = now.Add(4 * time.Second)
+	progress := engine.Progress(time.Minute)
+	assert.Equal(t, []uint{1, 2, 3}, progress.Asked)
+	assert.False(t, progress.Finished)
+	restored := service.NewParticipationEngine(store, nil, participation, models.UserRespondent(1), service.NewBranchEngine(questions, nil, nil), false, false, clock)
+	restored.Restore(progress, 6*time.Second)
+	assert.True(t, restored.Expired())
+	assert.True(t, errors.Is(restored.Back(), service.ErrReturnNotAllowed))
+
+	restored.Expire(c)
+	assert.True(t, restored.Finished())
+	assert.Equal(t, []uint{3}, store.timedOut)
+	assert.True(t, errors.Is(restored.Answer(c, dto.VoteRequest{QuestionId: 3, Answer: "d"}), service.ErrNothingToAnswer))
+	assert.True(t, restored.Progress(time.Minute).Finished)
+
+	finished := service.NewParticipationEngine(store, nil, participation, models.UserRespondent(1), service.NewBranchEngine(questions, nil, nil), false, false, clock)
+	finished.Restore(restored.Progress(time.Minute), 0)
+	assert.True(t, finished.Finished())
+	_, err = finished.Commit(c)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{1, 2, 3}, store.asked)
+	assert.Equal(t, map[uint]string{1: "b", 2: "c"}, store.votes)
+}
+
+func TestParticipationEnginePages(t *testing.T) {
+	optional := dto.QuestionConfig{Validation: &dto.AnswerValidation{Optional: true}}
+	questions := []*dto.Question{{ID: 1, SectionID: 1}, {ID: 2, SectionID: 1, Config: optional}, {ID: 3, SectionID: 2}}
+	sections := []*dto.Section{{ID: 1}, {ID: 2}}
+	store := &fakeParticipationStore{votes: map[uint]string{}}
+	engine := service.NewParticipationEngine(store, nil, dto.UserSurveyParticipationResponse{ID: 7}, models.UserRespondent(1), service.NewBranchEngine(questions, sections, nil), true, true, nil)
+	c := context.Background()
+
+	engine.Start()
+	assert.Len(t, engine.PageResponse("", nil).Questions, 2)
+	assert.True(t, errors.Is(engine.Back(), service.ErrFirstPage))
+
+	pageErrors, err := engine.AnswerPage(c, dto.PageVoteRequest{Answers: []dto.VoteRequest{{QuestionId: 3, Answer: "x"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{3, 1}, []uint{pageErrors[0].QuestionID, pageErrors[1].QuestionID}, "question 3 is not on the page and question 1 is required")
+
+	pageErrors, err = engine.AnswerPage(c, dto.PageVoteRequest{Answers: []dto.VoteRequest{{QuestionId: 1, Answer: "a"}}})
+	assert.NoError(t, err)
+	assert.Empty(t, pageErrors)
+	assert.Equal(t, uint(3), engine.PageResponse("", nil).Questions[0].ID)
+	assert.Equal(t, dto.ParticipationProgress{Mode: dto.ParticipationModePage, Pages: [][]uint{{1, 2}}, Page: []uint{3}, Spent: engine.Progress(0).Spent}, engine.Progress(0))
+}
+
+func TestParticipationEngineAnonymous(t *testing.T) {
+	store := &fakeParticipationStore{votes: map[uint]string{}}
+	questions := []*dto.Question{{ID: 1}, {ID: 2}}
+	engine := service.NewParticipationEngine(store, nil, dto.UserSurveyParticipationResponse{ID: 7}, models.AnonymousRespondent("r1"), service.NewBranchEngine(questions, nil, nil), false, true, nil)
+	c := context.Background()
+
+	engine.Start()
+	assert.NoError(t, engine.Answer(c, dto.VoteRequest{QuestionId: 1, Answer: "a"}))
+	assert.NoError(t, engine.Back())
+	assert.NoError(t, engine.Answer(c, dto.VoteRequest{QuestionId: 1, Answer: "b"}))
+	assert.NoError(t, engine.Answer(c, dto.VoteRequest{QuestionId: 2, Answer: "c"}))
+	assert.Empty(t, store.votes, "the votes of an anonymous response are not stored step by step")
+	assert.Nil(t, store.response)
+
+	_, err := engine.Commit(c)
+	assert.NoError(t, err)
+	assert.Len(t, store.response, 2)
+	assert.Equal(t, "b", store.response[0].Answer)
+	assert.Equal(t, "r1", store.response[1].ResponseID)
+}
+
+func TestParticipationEngineAnswerNotSaved(t *testing.T) {
+	store := &fakeParticipationStore{votes: map[uint]string{}, fail: errors.New("connection reset")}
+	questions := []*dto.Question{{ID: 1, SectionID: 1}, {ID: 2, SectionID: 1}, {ID: 3, SectionID: 2}}
+	engine := service.NewParticipationEngine(store, quietLogger{}, dto.UserSurveyParticipationResponse{ID: 7}, models.UserRespondent(1), service.NewBranchEngine(questions, nil, nil), false, true, nil)
+	c := context.Background()
+
+	engine.Start()
+	err := engine.Answer(c, dto.VoteRequest{QuestionId: 1, Answer: "a"})
+	assert.True(t, errors.Is(err, service.ErrAnswerNotSaved))
+	assert.Equal(t, service.StepInternalError, service.StepErrorCode(err))
+	assert.Equal(t, uint(1), engine.Response("", "").Question.ID, "an answer that was not stored is not taken")
+	store.fail = nil
+	assert.NoError(t, engine.Answer(c, dto.VoteRequest{QuestionId: 1, Answer: "a"}))
+	assert.Equal(t, map[uint]string{1: "a"}, store.votes)
+
+	store.fail = errors.New("connection reset")
+	pages := service.NewParticipationEngine(store, quietLogger{}, dto.UserSurveyParticipationResponse{ID: 8}, models.UserRespondent(1), service.NewBranchEngine(questions, []*dto.Section{{ID: 1}, {ID: 2}}, nil), true, true, nil)
+	pages.Start()
+	pageErrors, err := pages.AnswerPage(c, dto.PageVoteRequest{Answers: []dto.VoteRequest{{QuestionId: 1, Answer: "a"}, {QuestionId: 2, Answer: "b"}}})
+	assert.Empty(t, pageErrors)
+	assert.True(t, errors.Is(err, service.ErrAnswerNotSaved))
+	assert.Len(t, pages.PageResponse("", nil).Questions, 2, "the page is answered again")
+}
+
+func TestParticipationEngineStatus(t *testing.T) {
+	q1 := &dto.Question{ID: 1, HasMultipleChoice: true, Choices: []dto.Choice{{ID: 1, Text: "yes"}, {ID: 2, Text: "no"}}}
+	rules := []dto.BranchRule{{QuestionID: 1, Condition: dto.RuleExpression{QuestionID: 1, Operator: dto.OperatorEqual, Value: "no"}, EndSurvey: true}}
+	flow := service.NewBranchEngine([]*dto.Question{q1, {ID: 2}, {ID: 3}}, nil, rules)
+	engine := service.NewParticipationEngine(&fakeParticipationStore{votes: map[uint]string{}}, nil, dto.UserSurveyParticipationResponse{ID: 7}, models.UserRespondent(1), flow, false, true, nil)
+	c := context.Background()
+
+	engine.Start()
+	assert.Equal(t, dto.ProgressStatus{Answered: 0, Current: 1, EstimatedTotal: 3}, engine.Status())
+	assert.NoError(t, engine.Answer(c, dto.VoteRequest{QuestionId: 1, ChoiceIds: []uint{1}}))
+	assert.Equal(t, dto.ProgressStatus{Answered: 1, Current: 2, EstimatedTotal: 3}, engine.Status())
+
+	// answering no ends the survey, the estimate follows the answer
+	assert.NoError(t, engine.Back())
+	assert.NoError(t, engine.Answer(c, dto.VoteRequest{QuestionId: 1, ChoiceIds: []uint{2}}))
+	assert.True(t, engine.Finished())
+	assert.Equal(t, dto.ProgressStatus{Answered: 1, EstimatedTotal: 1}, engine.Status())
+}
+
+func TestStepErrorCode(t *testing.T) {
+	assert.Equal(t, service.StepWrongQuestion, service.StepErrorCode(service.ErrWrongQuestion))
+	assert.Equal(t, service.StepReturnNotAllowed, service.StepErrorCode(service.ErrReturnNotAllowed))
+	assert.Equal(t, service.AnswerRequired, service.StepErrorCode(&service.AnswerError{Code: service.AnswerRequired, Message: "an answer is required"}))
+	assert.Equal(t, service.AnswerInvalid, service.StepErrorCode(fmt.Errorf("%w: rating must be a whole number", service.ErrInvalidAnswer)))
+	assert.Equal(t, service.StepInternalError, service.StepErrorCode(errors.New("connection refused")))
+}
@@ -0,0 +1,328 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/config"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/handler"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/G9QBootcamp/qoli-survey/pkg/logging"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// quietLogger drops the logs of the handlers under test.
+type quietLogger struct {
+	logging.Logger
+}
+
+func (quietLogger) Info(cat logging.Category, sub logging.SubCategory, msg string, extra map[logging.ExtraKey]interface{}) {
+}
+
+func (quietLogger) Warn(cat logging.Category, sub logging.SubCategory, msg string, extra map[logging.ExtraKey]interface{}) {
+}
+
+func (quietLogger) Error(cat logging.Category, sub logging.SubCategory, msg string, extra map[logging.ExtraKey]interface{}) {
+}
+
+// fakeParticipationService picks up and suspends participations on a SurveyService over the fake
+// repository, it starts participations and stores their answers itself.
+type fakeParticipationService struct {
+	*service.SurveyService
+	repo      *fakeSurveyRepository
+	survey    dto.SurveyResponse
+	questions []dto.Question
+	sections  []*dto.Section
+	// asked are the questions of the committed participation, response the votes of a committed anonymous response
+	asked    []uint
+	response []models.Vote
+}
+
+func newFakeParticipationService(survey dto.SurveyResponse, questions []dto.Question, sections []*dto.Section) *fakeParticipationService {
+	now := time.Now()
+	stored := &models.Survey{ID: survey.SurveyID, Status: models.SurveyStatusOpen, StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour)}
+	if survey.Anonymity != nil {
+		stored.Anonymity = &models.AnonymitySettings{MinGroupSize: survey.Anonymity.MinGroupSize}
+	}
+	repo := newFakeSurveyRepository(stored)
+	conf := &config.Config{JWT: config.JWTConfig{SecretKey: "secret"}}
+	return &fakeParticipationService{SurveyService: service.NewSurveyService(conf, repo, nil, nil), repo: repo, survey: survey, questions: questions, sections: sections}
+}
+
+func (s *fakeParticipationService) GetSurvey(c context.Context, id uint) (*dto.SurveyResponse, error) {
+	survey := s.survey
+	return &survey, nil
+}
+
+func (s *fakeParticipationService) CanUserParticipateToSurvey(c context.Context, userId uint, surveyId uint) (bool, error) {
+	return true, nil
+}
+
+func (s *fakeParticipationService) GetSurveyFlow(c context.Context, surveyId uint, seed int64) (*service.BranchEngine, error) {
+	questions := []*dto.Question{}
+	for _, q := range s.questions {
+		q := q
+		questions = append(questions, &q)
+	}
+	return service.NewBranchEngine(questions, s.sections, nil), nil
+}
+
+func (s *fakeParticipationService) Participate(c context.Context, respondent models.Respondent, surveyId uint, language string, seed int64) (*dto.UserSurveyParticipationResponse, error) {
+	s.repo.lock.Lock()
+	defer s.repo.lock.Unlock()
+	p := &models.UserSurveyParticipation{ID: s.repo.id(), UserId: respondent.UserID, GuestID: respondent.GuestID, SurveyID: surveyId, Seed: seed, StartAt: time.Now()}
+	s.repo.participations[p.ID] = p
+	return &dto.UserSurveyParticipationResponse{ID: p.ID, SurveyID: surveyId, Seed: seed, StartAt: p.StartAt}, nil
+}
+
+func (s *fakeParticipationService) EndParticipation(c context.Context, participationId uint) error {
+	s.repo.lock.Lock()
+	defer s.repo.lock.Unlock()
+	now := time.Now()
+	s.repo.participations[participationId].EndAt = &now
+	return nil
+}
+
+func (s *fakeParticipationService) CommitVote(c context.Context, vote models.Vote) error {
+	return s.CommitVotes(c, models.Respondent{}, vote.QuestionID, []models.Vote{vote})
+}
+
+func (s *fakeParticipationService) CommitVotes(c context.Context, respondent models.Respondent, questionId uint, votes []models.Vote) error {
+	s.repo.lock.Lock()
+	defer s.repo.lock.Unlock()
+	kept := []models.Vote{}
+	for _, vote := range s.repo.votes {
+		if vote.QuestionID != questionId {
+			kept = append(kept, vote)
+		}
+	}
+	for _, vote := range votes {
+		vote.UpdatedAt = time.Now()
+		kept = append(kept, vote)
+	}
+	s.repo.votes = kept
+	return nil
+}
+
+func (s *fakeParticipationService) CommitResponse(c context.Context, votes []models.Vote) error {
+	s.response = votes
+	return nil
+}
+
+func (s *fakeParticipationService) CheckAnswerQuotas(c context.Context, participationId uint, questionId uint, answers []string) error {
+	return nil
+}
+
+func (s *fakeParticipationService) RecordQuestionTime(c context.Context, participationId uint, questionId uint, spent time.Duration, timedOut bool) error {
+	return nil
+}
+
+func (s *fakeParticipationService) CommitParticipation(c context.Context, participationId uint, asked []uint) (*dto.QuizResult, error) {
+	s.repo.lock.Lock()
+	defer s.repo.lock.Unlock()
+	now := time.Now()
+	s.repo.participations[participationId].CommittedAt = &now
+	s.asked = asked
+	return nil, nil
+}
+
+// participation returns the stored participation.
+func (s *fakeParticipationService) participation(id uint) models.UserSurveyParticipation {
+	p, _ := s.repo.GetUserParticipation(context.Background(), id)
+	return *p
+}
+
+// pause moves the time a participation was suspended at back by d, as if the next request came d later.
+func (s *fakeParticipationService) pause(id uint, d time.Duration) {
+	s.repo.lock.Lock()
+	defer s.repo.lock.Unlock()
+	at := s.repo.participations[id].DisconnectedAt.Add(-d)
+	s.repo.participations[id].DisconnectedAt = &at
+}
+
+// participationCall is a request of user 1 to a participation handler.
+type participationCall struct {
+	participationId uint
+	query           string
+	body            interface{}
+	stepToken       string
+}
+
+// send runs the request on handle and decodes its answer into response, it returns the status.
+func (r participationCall) send(t *testing.T, handle echo.HandlerFunc, response interface{}) int {
+	body, err := json.Marshal(r.body)
+	assert.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/"+r.query, bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if r.stepToken != "" {
+		req.Header.Set("X-Step-Token", r.stepToken)
+	}
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("survey_id", "participation_id")
+	c.SetParamValues("1", strconv.Itoa(int(r.participationId)))
+	c.Set("userID", uint(1))
+	assert.NoError(t, handle(c))
+	if response != nil {
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), response))
+	}
+	return rec.Code
+}
+
+func TestParticipationHandlerSteps(t *testing.T) {
+	surveys := newFakeParticipationService(dto.SurveyResponse{SurveyID: 1, AllowReturn: true, AnswerTimeLimit: 3600}, []dto.Question{{ID: 1}, {ID: 2}, {ID: 3}}, nil)
+	h := handler.NewParticipationHandlerWithService(nil, quietLogger{}, surveys)
+
+	var step dto.VoteResponse
+	assert.Equal(t, http.StatusCreated, participationCall{}.send(t, h.StartParticipation, &step))
+	assert.Equal(t, uint(1), step.Question.ID)
+	id := step.ParticipationID
+	stored := surveys.participation(id)
+	assert.NotNil(t, stored.DisconnectedAt, "the participation waits for the next request")
+	assert.True(t, stored.Progress.OverRequests)
+
+	assert.Equal(t, http.StatusOK, participationCall{participationId: id}.send(t, h.NextQuestion, &step))
+	assert.Equal(t, uint(1), step.Question.ID)
+	assert.Equal(t, http.StatusUnprocessableEntity, participationCall{participationId: id, body: dto.VoteRequest{QuestionId: 2, Answer: "a"}}.send(t, h.AnswerQuestion, &step))
+	assert.Equal(t, service.StepWrongQuestion, step.Code)
+	assert.Equal(t, uint(1), step.Question.ID)
+
+	assert.Equal(t, http.StatusOK, participationCall{participationId: id, body: dto.VoteRequest{QuestionId: 1, Answer: "a"}}.send(t, h.AnswerQuestion, &step))
+	assert.Equal(t, uint(2), step.Question.ID)
+	assert.Equal(t, http.StatusOK, participationCall{participationId: id}.send(t, h.GoBack, &step))
+	assert.Equal(t, uint(1), step.Question.ID)
+	assert.Equal(t, http.StatusOK, participationCall{participationId: id, body: dto.VoteRequest{QuestionId: 1, Answer: "b"}}.send(t, h.AnswerQuestion, &step))
+	assert.Equal(t, http.StatusOK, participationCall{participationId: id, body: dto.VoteRequest{QuestionId: 2, Answer: "c"}}.send(t, h.AnswerQuestion, &step))
+	assert.Equal(t, uint(3), step.Question.ID)
+
+	assert.Equal(t, http.StatusConflict, participationCall{participationId: id}.send(t, h.CommitParticipation, &step))
+	assert.Equal(t, service.StepNotFinished, step.Code)
+	assert.Equal(t, http.StatusOK, participationCall{participationId: id, body: dto.VoteRequest{QuestionId: 3, Answer: "d"}}.send(t, h.AnswerQuestion, &step))
+	assert.Nil(t, step.Question, "every question is answered")
+	assert.Equal(t, http.StatusOK, participationCall{participationId: id}.send(t, h.CommitParticipation, &step))
+	assert.Equal(t, "survey answers committed successfully", step.Message)
+	assert.Equal(t, []uint{1, 2, 3}, surveys.asked)
+	answers := map[uint]string{}
+	for _, vote := range surveys.repo.votes {
+		answers[vote.QuestionID] = vote.Answer
+	}
+	assert.Equal(t, map[uint]string{1: "b", 2: "c", 3: "d"}, answers, "the answer given after going back replaced the first one")
+
+	assert.Equal(t, http.StatusConflict, participationCall{participationId: id}.send(t, h.NextQuestion, nil), "a committed participation is over")
+}
+
+func TestParticipationHandlerTimeout(t *testing.T) {
+	questions := []dto.Question{{ID: 1}, {ID: 2, Config: dto.QuestionConfig{TimeLimit: 30}}, {ID: 3}}
+	surveys := newFakeParticipationService(dto.SurveyResponse{SurveyID: 1, AnswerTimeLimit: 3600}, questions, nil)
+	h := handler.NewParticipationHandlerWithService(nil, quietLogger{}, surveys)
+
+	var step dto.VoteResponse
+	assert.Equal(t, http.StatusCreated, participationCall{}.send(t, h.StartParticipation, &step))
+	id := step.ParticipationID
+
+	// a pause longer than the grace period of a lost connection only uses up answer time
+	surveys.pause(id, service.ResumeGracePeriod+time.Minute)
+	assert.Equal(t, http.StatusOK, participationCall{participationId: id}.send(t, h.NextQuestion, &step))
+	assert.Equal(t, uint(1), step.Question.ID)
+	assert.InDelta(t, 3600-660, surveys.participation(id).Progress.TimeLeft, 2)
+
+	assert.Equal(t, http.StatusOK, participationCall{participationId: id, body: dto.VoteRequest{QuestionId: 1, Answer: "a"}}.send(t, h.AnswerQuestion, &step))
+	assert.Equal(t, uint(2), step.Question.ID)
+	surveys.pause(id, 40*time.Second)
+	assert.Equal(t, http.StatusConflict, participationCall{participationId: id, body: dto.VoteRequest{QuestionId: 2, Answer: "b"}}.send(t, h.AnswerQuestion, &step), "the answer came after its question ran out of time")
+	assert.Equal(t, service.AnswerTimedOut, step.Code)
+	assert.Equal(t, uint(3), step.Question.ID)
+
+	surveys.pause(id, time.Hour)
+	var failure map[string]string
+	assert.Equal(t, http.StatusConflict, participationCall{participationId: id}.send(t, h.NextQuestion, &failure))
+	assert.Equal(t, service.StepTimeLimitReached, failure["code"])
+	assert.NotNil(t, surveys.participation(id).EndAt, "the participation ends once its answer time runs out")
+	assert.Equal(t, []uint{id}, surveys.repo.released)
+}
+
+func TestParticipationHandlerClaim(t *testing.T) {
+	surveys := newFakeParticipationService(dto.SurveyResponse{SurveyID: 1, AnswerTimeLimit: 3600}, []dto.Question{{ID: 1}, {ID: 2}}, nil)
+	h := handler.NewParticipationHandlerWithService(nil, quietLogger{}, surveys)
+
+	var step dto.VoteResponse
+	assert.Equal(t, http.StatusCreated, participationCall{}.send(t, h.StartParticipation, &step))
+	id := step.ParticipationID
+
+	// both requests pick up the participation before either claims it
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	surveys.repo.beforeClaim = func() {
+		arrived.Done()
+		arrived.Wait()
+	}
+	statuses := make([]int, 2)
+	var done sync.WaitGroup
+	for i, answer := range []string{"a", "b"} {
+		done.Add(1)
+		go func(i int, answer string) {
+			defer done.Done()
+			statuses[i] = participationCall{participationId: id, body: dto.VoteRequest{QuestionId: 1, Answer: answer}}.send(t, h.AnswerQuestion, nil)
+		}(i, answer)
+	}
+	done.Wait()
+	surveys.repo.beforeClaim = nil
+
+	sort.Ints(statuses)
+	assert.Equal(t, []int{http.StatusOK, http.StatusConflict}, statuses, "only one request takes the step")
+	assert.Len(t, surveys.repo.votes, 1)
+	assert.Equal(t, http.StatusOK, participationCall{participationId: id}.send(t, h.NextQuestion, &step))
+	assert.Equal(t, uint(2), step.Question.ID, "the participation goes on from the step that won")
+}
+
+func TestParticipationHandlerAnonymousPages(t *testing.T) {
+	questions := []dto.Question{{ID: 1, SectionID: 1}, {ID: 2, SectionID: 1}, {ID: 3, SectionID: 2}}
+	sections := []*dto.Section{{ID: 1}, {ID: 2}}
+	survey := dto.SurveyResponse{SurveyID: 1, AnswerTimeLimit: 3600, Anonymity: &dto.AnonymitySettings{}}
+	surveys := newFakeParticipationService(survey, questions, sections)
+	h := handler.NewParticipationHandlerWithService(nil, quietLogger{}, surveys)
+
+	var page dto.PageResponse
+	assert.Equal(t, http.StatusCreated, participationCall{query: "?mode=page"}.send(t, h.StartParticipation, &page))
+	assert.Len(t, page.Questions, 2)
+	assert.NotEmpty(t, page.StepToken)
+	id := page.ParticipationID
+	progress := surveys.participation(id).Progress
+	assert.Equal(t, dto.ParticipationModePage, progress.Mode)
+	assert.Empty(t, progress.Page, "the path of an anonymous participation is only in its step token")
+
+	assert.Equal(t, http.StatusUnprocessableEntity, participationCall{participationId: id}.send(t, h.NextQuestion, nil), "the step token is required")
+
+	first := page.StepToken
+	answers := dto.PageVoteRequest{Answers: []dto.VoteRequest{{QuestionId: 1, Answer: "a"}}}
+	assert.Equal(t, http.StatusUnprocessableEntity, participationCall{participationId: id, body: answers, stepToken: first}.send(t, h.AnswerQuestion, &page))
+	assert.Len(t, page.Errors, 1, "the second question of the page is required")
+	assert.Len(t, page.Questions, 2)
+
+	answers.Answers = append(answers.Answers, dto.VoteRequest{QuestionId: 2, Answer: "b"})
+	assert.Equal(t, http.StatusOK, participationCall{participationId: id, body: answers, stepToken: page.StepToken}.send(t, h.AnswerQuestion, &page))
+	assert.Equal(t, uint(3), page.Questions[0].ID)
+	assert.Empty(t, surveys.repo.votes, "anonymous answers are only stored on commit")
+	assert.Equal(t, http.StatusUnprocessableEntity, participationCall{participationId: id, stepToken: first}.send(t, h.NextQuestion, nil), "a step token can not be used again")
+
+	answers = dto.PageVoteRequest{Answers: []dto.VoteRequest{{QuestionId: 3, Answer: "c"}}}
+	assert.Equal(t, http.StatusOK, participationCall{participationId: id, body: answers, stepToken: page.StepToken}.send(t, h.AnswerQuestion, &page))
+	assert.Equal(t, http.StatusOK, participationCall{participationId: id, stepToken: page.StepToken}.send(t, h.CommitParticipation, &page))
+	assert.Len(t, surveys.response, 3)
+	for _, vote := range surveys.response {
+		assert.NotEmpty(t, vote.ResponseID)
+		assert.Nil(t, vote.VoterID, "the answers are not linked to the participant")
+	}
+	assert.Equal(t, surveys.response[0].ResponseID, surveys.response[2].ResponseID)
+}
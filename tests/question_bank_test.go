@@ -0,0 +1,137 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/repository"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBankReportRepository has the answers to the copies of one bank question.
+type fakeBankReportRepository struct {
+	repository.IReportRepository
+	usages      []models.Question
+	accessible  []models.Survey
+	respondents map[uint]int64
+	answers     map[uint]map[string]int64
+}
+
+func (r *fakeBankReportRepository) GetQuestionsByBankQuestionID(ctx context.Context, bankQuestionId uint) ([]models.Question, error) {
+	return r.usages, nil
+}
+
+func (r *fakeBankReportRepository) GetAccessibleSurveys(ctx context.Context, userID uint, permission string) ([]models.Survey, error) {
+	return r.accessible, nil
+}
+
+func (r *fakeBankReportRepository) GetQuestionRespondentsCount(ctx context.Context, qid uint) (int64, error) {
+	return r.respondents[qid], nil
+}
+
+func (r *fakeBankReportRepository) GetTotalVotesToQuestionCount(ctx context.Context, qid uint) (int64, error) {
+	var total int64
+	for _, count := range r.answers[qid] {
+		total += count
+	}
+	return total, nil
+}
+
+func (r *fakeBankReportRepository) GetGivenAnswerCountByQuestionID(ctx context.Context, qid uint, answer string) (int64, error) {
+	return r.answers[qid][answer], nil
+}
+
+func TestBankQuestionAccess(t *testing.T) {
+	bank := service.NewQuestionBankService(nil, newFakeSurveyRepository(), &fakeBankReportRepository{}, nil)
+	ctx := context.Background()
+
+	_, err := bank.CreateBankQuestion(ctx, 1, dto.BankQuestionCreateRequest{Text: "Age?", Type: dto.NumericQuestion, Choices: []dto.ChoiceCreateRequest{{Text: "young"}}})
+	assert.True(t, errors.Is(err, service.ErrInvalidQuestionConfig), "only choice questions have choices")
+	_, err = bank.CreateBankQuestion(ctx, 1, dto.BankQuestionCreateRequest{Text: "Colour?", Type: dto.MultipleChoiceQuestion, Choices: []dto.ChoiceCreateRequest{{Text: "Red"}, {Text: "red"}}})
+	assert.True(t, errors.Is(err, service.ErrInvalidQuestionConfig), "choices are compared without case")
+
+	private, err := bank.CreateBankQuestion(ctx, 1, dto.BankQuestionCreateRequest{Text: "Colour?", Type: dto.MultipleChoiceQuestion, Tags: []string{" Colours", "colours", "", "taste"}, Choices: []dto.ChoiceCreateRequest{{Text: "red"}, {Text: "blue"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"colours", "taste"}, private.Tags)
+	assert.True(t, private.HasMultipleChoice)
+	shared, err := bank.CreateBankQuestion(ctx, 1, dto.BankQuestionCreateRequest{Text: "Why?", Shared: true})
+	assert.NoError(t, err)
+
+	found, err := bank.GetBankQuestion(ctx, 2, private.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, found, "private bank questions are only seen by their owner")
+	found, err = bank.GetBankQuestion(ctx, 2, shared.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Why?", found.Text)
+
+	_, err = bank.UpdateBankQuestion(ctx, 2, shared.ID, dto.BankQuestionUpdateRequest{Text: "Why not?"})
+	assert.True(t, errors.Is(err, service.ErrBankQuestionNotOwned))
+	assert.True(t, errors.Is(bank.DeleteBankQuestion(ctx, 2, private.ID), service.ErrBankQuestionNotFound))
+	updated, err := bank.UpdateBankQuestion(ctx, 1, shared.ID, dto.BankQuestionUpdateRequest{Text: "Why not?", Shared: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "Why not?", updated.Text)
+	assert.NoError(t, bank.DeleteBankQuestion(ctx, 1, private.ID))
+
+	_, err = bank.GetBankQuestions(ctx, 1, dto.BankQuestionsGetRequest{Scope: "everyone"})
+	assert.True(t, errors.Is(err, service.ErrInvalidBankQuery))
+}
+
+func TestBankQuestionReport(t *testing.T) {
+	choices := func(ids ...uint) []models.Choice {
+		texts := []string{"red", "blue", "green"}
+		list := []models.Choice{}
+		for i, id := range ids {
+			list = append(list, models.Choice{ID: id, Text: texts[i]})
+		}
+		return list
+	}
+	reports := &fakeBankReportRepository{
+		usages: []models.Question{
+			{ID: 11, SurveyID: 10, Type: string(dto.MultipleChoiceQuestion), HasMultipleChoice: true, Survey: models.Survey{ID: 10, OwnerID: 1, Title: "mine"}, Choices: choices(111, 112)},
+			{ID: 21, SurveyID: 20, Type: string(dto.MultipleChoiceQuestion), HasMultipleChoice: true, Survey: models.Survey{ID: 20, OwnerID: 2, Title: "shared with me", Anonymity: &models.AnonymitySettings{MinGroupSize: 10}}, Choices: choices(211, 212, 213)},
+			{ID: 31, SurveyID: 30, Type: string(dto.MultipleChoiceQuestion), HasMultipleChoice: true, Survey: models.Survey{ID: 30, OwnerID: 3, Title: "not mine"}, Choices: choices(311, 312)},
+		},
+		accessible:  []models.Survey{{ID: 20}},
+		respondents: map[uint]int64{11: 4, 21: 6, 31: 5},
+		answers: map[uint]map[string]int64{
+			11: {"red": 3, "blue": 1},
+			21: {"red": 2, "blue": 2, "green": 2},
+			31: {"red": 5},
+		},
+	}
+	repo := newFakeSurveyRepository()
+	repo.bank = []*models.BankQuestion{{ID: 5, OwnerID: 1, Text: "Colour?", Type: string(dto.MultipleChoiceQuestion), HasMultipleChoice: true, Choices: []models.BankChoice{{Text: "red"}, {Text: "blue"}}}}
+	bank := service.NewQuestionBankService(nil, repo, reports, nil)
+	ctx := context.Background()
+
+	report, err := bank.GetBankQuestionReport(ctx, 1, 5, false)
+	assert.NoError(t, err)
+	assert.Len(t, report.Surveys, 2, "surveys the user can not see the reports of are left out")
+	assert.Equal(t, []dto.ChoiceReport{
+		{ID: 111, Text: "red", Percentage: "75%", SelectionPercentage: "75%"},
+		{ID: 112, Text: "blue", Percentage: "25%", SelectionPercentage: "25%"},
+	}, report.Surveys[0].Choices.ChoiceReport)
+	assert.True(t, report.Surveys[1].Choices.Suppressed, "the anonymous survey has fewer respondents than its minimum group")
+
+	// the combined usage counts choices by text and is held to the largest minimum group
+	combined := report.Combined.Choices
+	assert.False(t, combined.Suppressed)
+	assert.Equal(t, int64(10), combined.Respondents)
+	assert.Equal(t, []dto.ChoiceReport{
+		{Text: "red", Percentage: "50%", SelectionPercentage: "50%"},
+		{Text: "blue", Percentage: "30%", SelectionPercentage: "30%"},
+		{Text: "green", Percentage: "20%", SelectionPercentage: "20%"},
+	}, combined.ChoiceReport)
+
+	report, err = bank.GetBankQuestionReport(ctx, 1, 5, true)
+	assert.NoError(t, err)
+	assert.Len(t, report.Surveys, 3)
+	assert.Equal(t, int64(15), report.Combined.Choices.Respondents)
+
+	_, err = bank.GetBankQuestionReport(ctx, 2, 5, true)
+	assert.True(t, errors.Is(err, service.ErrBankQuestionNotFound), "the bank question is private")
+}
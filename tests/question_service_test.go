@@ -0,0 +1,167 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/G9QBootcamp/qoli-survey/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateQuestionKeepsSection(t *testing.T) {
+	now := time.Now()
+	open := &models.Survey{ID: 1, Status: models.SurveyStatusOpen, StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour)}
+	repo := newFakeSurveyRepository(open)
+	repo.sections = []*models.Section{{ID: 5, SurveyID: 1, Title: "About you"}}
+	question := repo.addQuestion(&models.Question{SurveyID: 1, Text: "Were are you from?", Type: string(dto.TextQuestion), SectionID: 5})
+	questions := service.NewQuestionService(nil, repo, nil)
+	ctx := context.Background()
+
+	// a wording fix without section_id is not structural
+	updated, err := questions.UpdateQuestion(ctx, question.ID, dto.QuestionUpdateRequest{Text: "Where are you from?"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Where are you from?", updated.Text)
+	assert.Equal(t, uint(5), repo.questions[question.ID].SectionID)
+
+	out := uint(0)
+	_, err = questions.UpdateQuestion(ctx, question.ID, dto.QuestionUpdateRequest{Text: "Where are you from?", SectionID: &out})
+	assert.True(t, errors.Is(err, service.ErrSurveyNotEditable), "moving a question of an open survey is structural")
+
+	open.Status = models.SurveyStatusDraft
+	_, err = questions.UpdateQuestion(ctx, question.ID, dto.QuestionUpdateRequest{Text: "Where are you from?", SectionID: &out})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), repo.questions[question.ID].SectionID)
+}
+
+func TestUpdateQuestionMatchesChoices(t *testing.T) {
+	repo := newFakeSurveyRepository(&models.Survey{ID: 1, Status: models.SurveyStatusDraft})
+	question := repo.addQuestion(&models.Question{SurveyID: 1, Text: "Pick one", Type: string(dto.MultipleChoiceQuestion), HasMultipleChoice: true,
+		Choices: []models.Choice{{Text: "Yes"}, {Text: "Yes"}, {Text: "No"}}})
+	first, second, no := question.Choices[0].ID, question.Choices[1].ID, question.Choices[2].ID
+	questions := service.NewQuestionService(nil, repo, nil)
+	ctx := context.Background()
+
+	// a reworded choice keeps its id, choices with the same text stay apart and a choice without an id is matched on its text
+	_, err := questions.UpdateQuestion(ctx, question.ID, dto.QuestionUpdateRequest{Text: "Pick one", HasMultipleChoice: true,
+		Choices: []dto.ChoiceUpdateRequest{{ID: second, Text: "Yes"}, {ID: first, Text: "Yes, always"}, {Text: "No"}, {Text: "Maybe"}}})
+	assert.NoError(t, err)
+	texts := map[uint]string{}
+	for _, choice := range repo.questions[question.ID].Choices {
+		texts[choice.ID] = choice.Text
+	}
+	assert.Len(t, texts, 4)
+	assert.Equal(t, "Yes, always", texts[first])
+	assert.Equal(t, "Yes", texts[second])
+	assert.Equal(t, "No", texts[no])
+
+	// a dropped choice is deleted
+	_, err = questions.UpdateQuestion(ctx, question.ID, dto.QuestionUpdateRequest{Text: "Pick one", HasMultipleChoice: true,
+		Choices: []dto.ChoiceUpdateRequest{{ID: first, Text: "Yes, always"}, {ID: no, Text: "No"}}})
+	assert.NoError(t, err)
+	assert.Len(t, repo.questions[question.ID].Choices, 2)
+
+	_, err = questions.UpdateQuestion(ctx, question.ID, dto.QuestionUpdateRequest{Text: "Pick one", HasMultipleChoice: true,
+		Choices: []dto.ChoiceUpdateRequest{{ID: second, Text: "Yes"}}})
+	assert.True(t, errors.Is(err, service.ErrInvalidChoice), "the choice was deleted")
+	_, err = questions.UpdateQuestion(ctx, question.ID, dto.QuestionUpdateRequest{Text: "Pick one", HasMultipleChoice: true,
+		Choices: []dto.ChoiceUpdateRequest{{ID: first, Text: "Yes"}, {ID: first, Text: "No"}}})
+	assert.True(t, errors.Is(err, service.ErrInvalidChoice), "a choice is updated once")
+}
+
+func TestInsertAt(t *testing.T) {
+	tests := []struct {
+		position int
+		want     []int
+		at       int
+	}{
+		{position: 1, want: []int{9, 1, 2, 3}, at: 1},
+		{position: 3, want: []int{1, 2, 9, 3}, at: 3},
+		{position: 4, want: []int{1, 2, 3, 9}, at: 4},
+		{position: 0, want: []int{1, 2, 3, 9}, at: 4},
+		{position: -2, want: []int{1, 2, 3, 9}, at: 4},
+		{position: 10, want: []int{1, 2, 3, 9}, at: 4},
+	}
+	for _, tt := range tests {
+		list := []int{1, 2, 3}
+		got, at := util.InsertAt(list, 9, tt.position)
+		assert.Equal(t, tt.want, got, "position %d", tt.position)
+		assert.Equal(t, tt.at, at, "position %d", tt.position)
+		assert.Equal(t, []int{1, 2, 3}, list, "the list itself is not changed")
+	}
+}
+
+func TestAddQuestionPosition(t *testing.T) {
+	repo := newFakeSurveyRepository(&models.Survey{ID: 1, Status: models.SurveyStatusDraft})
+	first := repo.addQuestion(&models.Question{SurveyID: 1, Text: "First?"})
+	second := repo.addQuestion(&models.Question{SurveyID: 1, Text: "Second?"})
+	questions := service.NewQuestionService(nil, repo, nil)
+	ctx := context.Background()
+
+	add := func(text string, position int) uint {
+		added, err := questions.AddQuestion(ctx, 1, 1, dto.QuestionAddRequest{QuestionCreateRequest: dto.QuestionCreateRequest{Text: text}, Position: position})
+		assert.NoError(t, err)
+		return added.ID
+	}
+	top := add("Top?", 1)
+	last := add("Last?", 0)
+	beyond := add("Beyond?", 99)
+	assert.Equal(t, []uint{top, first.ID, second.ID, last, beyond}, repo.orderIds(1))
+}
+
+func TestReorderQuestions(t *testing.T) {
+	repo := newFakeSurveyRepository(&models.Survey{ID: 1, Status: models.SurveyStatusDraft, IsSequential: true})
+	q1 := repo.addQuestion(&models.Question{SurveyID: 1, Text: "One?"}).ID
+	q2 := repo.addQuestion(&models.Question{SurveyID: 1, Text: "Two?"}).ID
+	q3 := repo.addQuestion(&models.Question{SurveyID: 1, Text: "Three?"}).ID
+	repo.rules = []*models.BranchRule{{ID: 1, SurveyID: 1, QuestionID: q2, Condition: models.RuleExpression{QuestionID: q2, Operator: string(dto.OperatorEqual), Operand: "skip"}, TargetQuestionID: q3}}
+	questions := service.NewQuestionService(nil, repo, nil)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		ids  []uint
+		err  error
+	}{
+		{name: "a question is listed twice", ids: []uint{q1, q1, q2, q3}, err: service.ErrInvalidQuestionOrder},
+		{name: "a question of another survey", ids: []uint{q1, q2, q3, 999}, err: service.ErrInvalidQuestionOrder},
+		{name: "a question is not listed", ids: []uint{q1, q2}, err: service.ErrInvalidQuestionOrder},
+		{name: "the rule would jump back to an earlier question", ids: []uint{q3, q1, q2}, err: service.ErrInvalidBranchRule},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := questions.ReorderQuestions(ctx, 1, dto.QuestionsReorderRequest{QuestionIDs: tt.ids})
+			assert.True(t, errors.Is(err, tt.err), "got %v", err)
+			assert.Equal(t, []uint{q1, q2, q3}, repo.orderIds(1), "a rejected order is not stored")
+		})
+	}
+
+	reordered, err := questions.ReorderQuestions(ctx, 1, dto.QuestionsReorderRequest{QuestionIDs: []uint{q2, q3, q1}})
+	assert.NoError(t, err)
+	assert.Equal(t, q2, reordered[0].ID)
+	assert.Equal(t, []uint{q2, q3, q1}, repo.orderIds(1))
+}
+
+func TestDeleteQuestionUnlinks(t *testing.T) {
+	repo := newFakeSurveyRepository(&models.Survey{ID: 1, Status: models.SurveyStatusDraft})
+	q1 := repo.addQuestion(&models.Question{SurveyID: 1, Text: "Customer?", Type: string(dto.MultipleChoiceQuestion), HasMultipleChoice: true, Choices: []models.Choice{{Text: "yes"}, {Text: "no"}}})
+	q2 := repo.addQuestion(&models.Question{SurveyID: 1, Text: "Why not?"}).ID
+	q3 := repo.addQuestion(&models.Question{SurveyID: 1, Text: "Anything else?"}).ID
+	q1.Choices[1].LinkedQuestionID = q2
+	yes := models.RuleExpression{QuestionID: q1.ID, Operator: string(dto.OperatorEqual), Operand: "yes"}
+	repo.rules = []*models.BranchRule{
+		{ID: 1, SurveyID: 1, QuestionID: q1.ID, Condition: yes, TargetQuestionID: q2},
+		{ID: 2, SurveyID: 1, QuestionID: q1.ID, Priority: 1, Condition: yes, TargetQuestionID: q3},
+	}
+	questions := service.NewQuestionService(nil, repo, nil)
+
+	assert.NoError(t, questions.DeleteQuestion(context.Background(), q2))
+	assert.Nil(t, repo.questions[q2])
+	assert.Equal(t, uint(0), q1.Choices[1].LinkedQuestionID, "the choice no longer leads to the deleted question")
+	assert.Len(t, repo.rules, 1, "rules that jump to the deleted question are removed")
+	assert.Equal(t, q3, repo.rules[0].TargetQuestionID)
+}
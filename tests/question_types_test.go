@@ -0,0 +1,112 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveQuestionType(t *testing.T) {
+	questionType, _, err := service.ResolveQuestionType("", true, dto.QuestionConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, dto.MultipleChoiceQuestion, questionType)
+
+	max := 5.0
+	_, config, err := service.ResolveQuestionType(dto.RatingQuestion, false, dto.QuestionConfig{Max: &max})
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, *config.Min)
+
+	_, config, err = service.ResolveQuestionType(dto.LikertQuestion, false, dto.QuestionConfig{})
+	assert.NoError(t, err)
+	assert.Len(t, config.Labels, 5)
+
+	_, _, err = service.ResolveQuestionType(dto.RatingQuestion, false, dto.QuestionConfig{})
+	assert.True(t, errors.Is(err, service.ErrInvalidQuestionConfig))
+	_, _, err = service.ResolveQuestionType(dto.RankingQuestion, false, dto.QuestionConfig{Items: []string{"a", "a"}})
+	assert.True(t, errors.Is(err, service.ErrInvalidQuestionConfig))
+	_, _, err = service.ResolveQuestionType(dto.DateQuestion, true, dto.QuestionConfig{})
+	assert.True(t, errors.Is(err, service.ErrInvalidQuestionConfig))
+	_, _, err = service.ResolveQuestionType("slider", false, dto.QuestionConfig{})
+	assert.True(t, errors.Is(err, service.ErrInvalidQuestionConfig))
+}
+
+func TestNormalizeAnswer(t *testing.T) {
+	min, max := 1.0, 5.0
+	rating := &dto.Question{Type: dto.RatingQuestion, Config: dto.QuestionConfig{Min: &min, Max: &max}}
+	answer, err := service.NormalizeAnswer(rating, dto.VoteRequest{Answer: " 4 "})
+	assert.NoError(t, err)
+	assert.Equal(t, "4", answer)
+	_, err = service.NormalizeAnswer(rating, dto.VoteRequest{Answer: "6"})
+	assert.True(t, errors.Is(err, service.ErrInvalidAnswer))
+
+	numeric := &dto.Question{Type: dto.NumericQuestion, Config: dto.QuestionConfig{Integer: true}}
+	_, err = service.NormalizeAnswer(numeric, dto.VoteRequest{Answer: "2.5"})
+	assert.True(t, errors.Is(err, service.ErrInvalidAnswer))
+
+	date := &dto.Question{Type: dto.DateQuestion, Config: dto.QuestionConfig{MinDate: "2024-01-01"}}
+	_, err = service.NormalizeAnswer(date, dto.VoteRequest{Answer: "2023-12-31"})
+	assert.True(t, errors.Is(err, service.ErrInvalidAnswer))
+
+	ranking := &dto.Question{Type: dto.RankingQuestion, Config: dto.QuestionConfig{Items: []string{"a", "b", "c"}}}
+	answer, err = service.NormalizeAnswer(ranking, dto.VoteRequest{Ranking: []string{"c", "a", "b"}})
+	assert.NoError(t, err)
+	assert.Equal(t, `["c","a","b"]`, answer)
+	_, err = service.NormalizeAnswer(ranking, dto.VoteRequest{Ranking: []string{"a", "a", "b"}})
+	assert.True(t, errors.Is(err, service.ErrInvalidAnswer))
+
+	matrix := &dto.Question{Type: dto.MatrixQuestion, Config: dto.QuestionConfig{Rows: []string{"speed"}, Columns: []string{"bad", "good"}}}
+	_, err = service.NormalizeAnswer(matrix, dto.VoteRequest{Matrix: map[string]string{"speed": "great"}})
+	assert.True(t, errors.Is(err, service.ErrInvalidAnswer))
+}
+
+func TestSelectChoices(t *testing.T) {
+	choices := []dto.Choice{{ID: 1, Text: "red"}, {ID: 2, Text: "green"}, {ID: 3, Text: "blue"}}
+	single := &dto.Question{HasMultipleChoice: true, Choices: choices}
+	selected, err := service.SelectChoices(single, dto.VoteRequest{Answer: "green"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), selected[0].ID)
+	_, err = service.SelectChoices(single, dto.VoteRequest{ChoiceIds: []uint{1, 2}})
+	assert.True(t, errors.Is(err, service.ErrInvalidAnswer))
+
+	multi := &dto.Question{HasMultipleChoice: true, Choices: choices, Config: dto.QuestionConfig{MultiSelect: true, MinSelections: 2, MaxSelections: 2}}
+	selected, err = service.SelectChoices(multi, dto.VoteRequest{ChoiceIds: []uint{3, 1}})
+	assert.NoError(t, err)
+	assert.Len(t, selected, 2)
+	_, err = service.SelectChoices(multi, dto.VoteRequest{ChoiceIds: []uint{1}})
+	assert.True(t, errors.Is(err, service.ErrInvalidAnswer))
+	_, err = service.SelectChoices(multi, dto.VoteRequest{ChoiceIds: []uint{1, 2, 3}})
+	assert.True(t, errors.Is(err, service.ErrInvalidAnswer))
+	_, err = service.SelectChoices(multi, dto.VoteRequest{ChoiceIds: []uint{1, 1}})
+	assert.True(t, errors.Is(err, service.ErrInvalidAnswer))
+}
+
+func TestAnswerValidation(t *testing.T) {
+	text := &dto.Question{Type: dto.TextQuestion, Config: dto.QuestionConfig{Validation: &dto.AnswerValidation{MinLength: 3, Format: dto.EmailFormat}}}
+	_, err := service.NormalizeAnswer(text, dto.VoteRequest{Answer: "a@"})
+	assert.Equal(t, service.AnswerTooShort, service.AnswerErrorCode(err))
+	_, err = service.NormalizeAnswer(text, dto.VoteRequest{Answer: "not an email"})
+	assert.Equal(t, service.AnswerInvalidFormat, service.AnswerErrorCode(err))
+	assert.True(t, errors.Is(err, service.ErrInvalidAnswer))
+	_, err = service.NormalizeAnswer(text, dto.VoteRequest{Answer: "me@example.com"})
+	assert.NoError(t, err)
+
+	nationalID := &dto.Question{Type: dto.TextQuestion, Config: dto.QuestionConfig{Validation: &dto.AnswerValidation{Format: dto.NationalIDFormat, Message: "enter your national id"}}}
+	_, err = service.NormalizeAnswer(nationalID, dto.VoteRequest{Answer: "1234567890"})
+	assert.EqualError(t, err, "invalid answer: enter your national id")
+
+	skip, err := service.SkipsAnswer(text, dto.VoteRequest{Answer: "  "})
+	assert.False(t, skip)
+	assert.Equal(t, service.AnswerRequired, service.AnswerErrorCode(err))
+	text.Config.Validation.Optional = true
+	skip, err = service.SkipsAnswer(text, dto.VoteRequest{})
+	assert.True(t, skip)
+	assert.NoError(t, err)
+
+	_, _, err = service.ResolveQuestionType(dto.RatingQuestion, false, dto.QuestionConfig{Validation: &dto.AnswerValidation{Pattern: "^a"}})
+	assert.True(t, errors.Is(err, service.ErrInvalidQuestionConfig))
+	_, _, err = service.ResolveQuestionType(dto.TextQuestion, false, dto.QuestionConfig{Validation: &dto.AnswerValidation{Pattern: "("}})
+	assert.True(t, errors.Is(err, service.ErrInvalidQuestionConfig))
+}
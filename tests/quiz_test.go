@@ -0,0 +1,65 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreQuiz(t *testing.T) {
+	weight := 2.0
+	capital := &dto.Question{ID: 1, HasMultipleChoice: true, Choices: []dto.Choice{
+		{ID: 1, Text: "Tehran", IsCorrect: true}, {ID: 2, Text: "Tabriz"},
+	}}
+	primes := &dto.Question{ID: 2, HasMultipleChoice: true, Config: dto.QuestionConfig{MultiSelect: true, Weight: &weight}, Choices: []dto.Choice{
+		{ID: 3, Text: "2", Points: 0.5}, {ID: 4, Text: "3", Points: 0.5}, {ID: 5, Text: "4", Points: -1},
+	}}
+	opinion := &dto.Question{ID: 3, HasMultipleChoice: true, Choices: []dto.Choice{{ID: 6, Text: "yes"}, {ID: 7, Text: "no"}}}
+	essay := &dto.Question{ID: 4}
+	asked := []*dto.Question{capital, primes, opinion, essay}
+
+	result := service.ScoreQuiz(asked, map[uint][]uint{1: {1}, 2: {3, 4}, 3: {6}}, dto.QuizSettings{PassMark: 50})
+	assert.Equal(t, 3.0, result.Score)
+	assert.Equal(t, 3.0, result.MaxScore, "unscored questions do not count")
+	assert.Equal(t, 100.0, result.Percentage)
+	assert.True(t, result.Passed)
+
+	result = service.ScoreQuiz(asked, map[uint][]uint{1: {2}, 2: {3, 5}}, dto.QuizSettings{PassMark: 50})
+	assert.Equal(t, -1.0, result.Score, "wrong choices can take points away")
+	assert.False(t, result.Passed)
+
+	result = service.ScoreQuiz([]*dto.Question{capital}, map[uint][]uint{1: {1}}, dto.QuizSettings{PassMark: 50})
+	assert.Equal(t, 1.0, result.MaxScore, "questions that were not asked do not count")
+
+	primes.Config.MaxSelections = 1
+	result = service.ScoreQuiz([]*dto.Question{primes}, nil, dto.QuizSettings{})
+	assert.Equal(t, 1.0, result.MaxScore, "only as many choices as can be picked count")
+	assert.True(t, result.Passed, "a zero pass mark always passes")
+}
+
+func TestDistributeScores(t *testing.T) {
+	distribution := service.DistributeScores([]dto.QuizResult{
+		{Score: 10, MaxScore: 10, Percentage: 100, Passed: true},
+		{Score: 5, MaxScore: 10, Percentage: 50, Passed: true},
+		{Score: -2, MaxScore: 10, Percentage: -20},
+	})
+	assert.Equal(t, 3, distribution.Participations)
+	assert.Equal(t, 2, distribution.Passed)
+	assert.Equal(t, 4.33, distribution.AverageScore)
+	assert.Equal(t, -20.0, distribution.MinPercentage)
+	assert.Equal(t, 100.0, distribution.MaxPercentage)
+	assert.Len(t, distribution.Buckets, 10)
+	assert.Equal(t, 1, distribution.Buckets[0].Count)
+	assert.Equal(t, 1, distribution.Buckets[5].Count)
+	assert.Equal(t, 1, distribution.Buckets[9].Count)
+
+	assert.Equal(t, 0, service.DistributeScores(nil).Participations)
+}
+
+func TestValidateQuiz(t *testing.T) {
+	assert.NoError(t, service.ValidateQuiz(nil))
+	assert.NoError(t, service.ValidateQuiz(&dto.QuizSettings{PassMark: 60}))
+	assert.ErrorIs(t, service.ValidateQuiz(&dto.QuizSettings{PassMark: 120}), service.ErrInvalidQuiz)
+}
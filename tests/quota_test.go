@@ -0,0 +1,79 @@
+package test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateQuota(t *testing.T) {
+	ten, twenty := 10, 20
+	screening := uint(1)
+	other := uint(9)
+	questions := dto.QuestionList{{ID: 1, HasMultipleChoice: true, Choices: []dto.Choice{{ID: 1, Text: "Yes"}, {ID: 2, Text: "No"}}}}
+
+	valid := []dto.QuotaRequest{
+		{Name: "tehran", Attribute: "city", Values: []string{"Tehran"}, Limit: 100},
+		{Name: "young", Attribute: "age", MinAge: &ten, MaxAge: &twenty, Limit: 50},
+		{Name: "elder", Attribute: "age", MinAge: &twenty, Limit: 50},
+		{Name: "users", Attribute: "answer", QuestionID: &screening, Values: []string{"yes"}, Limit: 10},
+	}
+	for _, req := range valid {
+		assert.NoError(t, service.ValidateQuota(req, questions), req.Name)
+	}
+
+	invalid := []dto.QuotaRequest{
+		{Name: "no limit", Attribute: "city", Values: []string{"Tehran"}},
+		{Name: "no cities", Attribute: "city", Limit: 1},
+		{Name: "no range", Attribute: "age", Limit: 1},
+		{Name: "reversed range", Attribute: "age", MinAge: &twenty, MaxAge: &ten, Limit: 1},
+		{Name: "no question", Attribute: "answer", Values: []string{"yes"}, Limit: 1},
+		{Name: "other survey", Attribute: "answer", QuestionID: &other, Values: []string{"yes"}, Limit: 1},
+		{Name: "unknown choice", Attribute: "answer", QuestionID: &screening, Values: []string{"maybe"}, Limit: 1},
+		{Name: "unknown attribute", Attribute: "gender", Values: []string{"x"}, Limit: 1},
+	}
+	for _, req := range invalid {
+		err := service.ValidateQuota(req, questions)
+		assert.True(t, errors.Is(err, service.ErrInvalidQuota), req.Name)
+	}
+}
+
+func TestParticipantQuotas(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	age, ok := service.ParticipantAge(time.Date(2004, 6, 16, 0, 0, 0, 0, time.UTC), now)
+	assert.True(t, ok)
+	assert.Equal(t, 19, age, "the birthday has not come yet this year")
+	age, _ = service.ParticipantAge(time.Date(2004, 6, 15, 0, 0, 0, 0, time.UTC), now)
+	assert.Equal(t, 20, age)
+	_, ok = service.ParticipantAge(time.Time{}, now)
+	assert.False(t, ok)
+
+	eighteen, twentyFour := 18, 24
+	tehran := &dto.Quota{ID: 1, Attribute: "city", Values: []string{"Tehran"}, Limit: 2}
+	young := &dto.Quota{ID: 2, Attribute: "age", MinAge: &eighteen, MaxAge: &twentyFour, Limit: 2}
+	screening := uint(5)
+	users := &dto.Quota{ID: 3, Attribute: "answer", QuestionID: &screening, Values: []string{"Yes"}, Limit: 2}
+	quotas := []*dto.Quota{tehran, young, users}
+
+	matched := service.ParticipantQuotas(quotas, " tehran", time.Date(2004, 1, 1, 0, 0, 0, 0, time.UTC), now)
+	assert.Equal(t, []*dto.Quota{tehran, young}, matched, "answer quotas wait for the screening answer")
+	assert.Empty(t, service.ParticipantQuotas(quotas, "", time.Time{}, now), "unknown attributes fall in no quota")
+
+	assert.Nil(t, service.FullQuota(matched))
+	young.Filled = 2
+	assert.Equal(t, young, service.FullQuota(matched))
+
+	in, out := service.AnswerQuotas(quotas, 5, []string{"yes"})
+	assert.Equal(t, []*dto.Quota{users}, in)
+	assert.Empty(t, out)
+	in, out = service.AnswerQuotas(quotas, 5, []string{"No"})
+	assert.Empty(t, in)
+	assert.Equal(t, []*dto.Quota{users}, out, "a changed answer gives the place back")
+	in, out = service.AnswerQuotas(quotas, 6, []string{"yes"})
+	assert.Empty(t, in)
+	assert.Empty(t, out)
+}
@@ -0,0 +1,69 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func randomizationQuestions() (dto.QuestionList, []*dto.Section) {
+	list := dto.QuestionList{}
+	for i := uint(1); i <= 8; i++ {
+		section := uint(1)
+		if i > 4 {
+			section = 2
+		}
+		list = append(list, &dto.Question{ID: i, SectionID: section, HasMultipleChoice: true, Choices: []dto.Choice{
+			{ID: i*10 + 1, Text: "a"}, {ID: i*10 + 2, Text: "b"}, {ID: i*10 + 3, Text: "c"}, {ID: i*10 + 4, Text: "None of the above", Anchored: true},
+		}})
+	}
+	list[0].Config.Pinned = true
+	return list, []*dto.Section{{ID: 1}, {ID: 2}}
+}
+
+func TestRandomizeQuestions(t *testing.T) {
+	randomization := dto.Randomization{Sections: true, Questions: true, Choices: true}
+
+	list, sections := randomizationQuestions()
+	first, firstSections := service.RandomizeQuestions(list, sections, randomization, 42)
+	list, sections = randomizationQuestions()
+	replayed, replayedSections := service.RandomizeQuestions(list, sections, randomization, 42)
+	assert.Equal(t, first, replayed, "the same seed gives the same order")
+	assert.Equal(t, firstSections, replayedSections)
+
+	assert.NotEqual(t, list.GetIds(), first.GetIds())
+	changes := 0
+	for i, q := range first {
+		assert.Equal(t, "None of the above", q.Choices[3].Text, "anchored choices keep their position")
+		if i > 0 && first[i-1].SectionID != q.SectionID {
+			changes++
+		}
+		if q.ID == 1 {
+			assert.Equal(t, 0, i%4, "pinned questions keep their position in their section")
+		}
+	}
+	assert.Equal(t, 1, changes, "questions are only shuffled inside their section")
+
+	list, sections = randomizationQuestions()
+	fixed, _ := service.RandomizeQuestions(list, sections, dto.Randomization{}, 42)
+	assert.Equal(t, list, fixed, "nothing is shuffled without randomization")
+	assert.Equal(t, uint(11), list[0].Choices[0].ID, "the questions given are not changed")
+
+	override := false
+	list, sections = randomizationQuestions()
+	for _, q := range list {
+		q.Config.RandomizeChoices = &override
+	}
+	kept, _ := service.RandomizeQuestions(list, sections, dto.Randomization{Choices: true}, 42)
+	assert.Equal(t, list, kept, "a question can keep the order of its choices")
+}
+
+func TestValidateRandomization(t *testing.T) {
+	assert.NoError(t, service.ValidateRandomization(nil, true))
+	assert.NoError(t, service.ValidateRandomization(&dto.Randomization{Choices: true}, true))
+	assert.NoError(t, service.ValidateRandomization(&dto.Randomization{Questions: true}, false))
+	assert.ErrorIs(t, service.ValidateRandomization(&dto.Randomization{Questions: true}, true), service.ErrInvalidRandomization)
+	assert.ErrorIs(t, service.ValidateRandomization(&dto.Randomization{Sections: true}, true), service.ErrInvalidRandomization)
+}
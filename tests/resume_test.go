@@ -0,0 +1,91 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResumeExpired(t *testing.T) {
+	disconnected := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	assert.False(t, service.ResumeExpired(disconnected, disconnected.Add(service.ResumeGracePeriod)))
+	assert.True(t, service.ResumeExpired(disconnected, disconnected.Add(service.ResumeGracePeriod+time.Second)))
+}
+
+func TestSchedulerEndsExpiredParticipations(t *testing.T) {
+	now := time.Now()
+	repo := newFakeSurveyRepository(&models.Survey{ID: 1, Status: models.SurveyStatusOpen, StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour)})
+	expired := now.Add(-service.ResumeGracePeriod - time.Minute)
+	recent := now.Add(-time.Minute)
+	repo.participations[1] = &models.UserSurveyParticipation{ID: 1, SurveyID: 1, StartAt: expired, DisconnectedAt: &expired}
+	repo.participations[2] = &models.UserSurveyParticipation{ID: 2, SurveyID: 1, StartAt: recent, DisconnectedAt: &recent}
+	repo.participations[3] = &models.UserSurveyParticipation{ID: 3, SurveyID: 1, StartAt: expired}
+
+	// a done context runs the scheduler once
+	c, cancel := context.WithCancel(context.Background())
+	cancel()
+	service.NewSurveyService(nil, repo, nil, nil).RunScheduler(c, time.Minute)
+
+	assert.NotNil(t, repo.participations[1].EndAt, "a participation that was not resumed in time ends")
+	assert.Nil(t, repo.participations[2].EndAt, "a participation within its grace period waits")
+	assert.Nil(t, repo.participations[3].EndAt, "a connected participation is left alone")
+	assert.Equal(t, []uint{1}, repo.released, "the ended participation gives back its quota places")
+}
+
+func TestQuestionProgress(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	timer := service.NewAnswerTimer(func() time.Time { return now })
+	q1 := &dto.Question{ID: 1}
+	q2 := &dto.Question{ID: 2, Config: dto.QuestionConfig{TimeLimit: 30}}
+
+	timer.Show(q1)
+	now = now.Add(4 * time.Second)
+	timer.Show(q2)
+	now = now.Add(10 * time.Second)
+
+	progress := service.QuestionProgress([]*dto.Question{q1, q2}, timer, 90500*time.Millisecond)
+	assert.Equal(t, dto.ParticipationProgress{
+		Mode:     dto.ParticipationModeQuestion,
+		Asked:    []uint{1, 2},
+		Spent:    map[uint]int64{1: 4000, 2: 10000},
+		TimeLeft: 91,
+	}, progress)
+
+	// a new session picks up the time spent on the question it resumes on
+	resumed := service.NewAnswerTimer(func() time.Time { return now })
+	resumed.Restore(service.ProgressSpent(progress))
+	resumed.Show(q2)
+	remaining, ok := resumed.Remaining()
+	assert.True(t, ok)
+	assert.Equal(t, 20*time.Second, remaining)
+}
+
+func TestPageProgress(t *testing.T) {
+	timer := service.NewAnswerTimer(nil)
+	first := []*dto.Question{{ID: 1}, {ID: 2}}
+	second := []*dto.Question{{ID: 3}}
+
+	progress := service.PageProgress([][]*dto.Question{first}, second, timer, -time.Second)
+	assert.Equal(t, dto.ParticipationModePage, progress.Mode)
+	assert.Equal(t, [][]uint{{1, 2}}, progress.Pages)
+	assert.Equal(t, []uint{3}, progress.Page)
+	assert.Equal(t, 0, progress.TimeLeft)
+}
+
+func TestProgressFits(t *testing.T) {
+	flow := service.NewBranchEngine([]*dto.Question{{ID: 1}, {ID: 2}, {ID: 3}}, nil, nil)
+	assert.Equal(t, []uint{3, 1}, dto.QuestionList(flow.Questions([]uint{3, 9, 1})).GetIds())
+
+	assert.True(t, service.ProgressFits(flow, dto.ParticipationProgress{Mode: dto.ParticipationModeQuestion, Asked: []uint{1, 2}}))
+	assert.True(t, service.ProgressFits(flow, dto.ParticipationProgress{Mode: dto.ParticipationModePage, Pages: [][]uint{{1, 2}}, Page: []uint{3}}))
+
+	assert.False(t, service.ProgressFits(flow, dto.ParticipationProgress{Mode: dto.ParticipationModeQuestion}), "a progress is on a question")
+	assert.False(t, service.ProgressFits(flow, dto.ParticipationProgress{Mode: dto.ParticipationModeQuestion, Asked: []uint{1, 4}}), "question 4 was removed")
+	assert.False(t, service.ProgressFits(flow, dto.ParticipationProgress{Mode: dto.ParticipationModePage, Pages: [][]uint{{4}}, Page: []uint{3}}))
+	assert.False(t, service.ProgressFits(flow, dto.ParticipationProgress{Mode: "unknown", Asked: []uint{1}}))
+}
@@ -0,0 +1,41 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateShareLinkSlug(t *testing.T) {
+	for _, slug := range []string{"abc", "customer-feedback-2024", strings.Repeat("a", 64)} {
+		assert.NoError(t, service.ValidateShareLinkSlug(slug), slug)
+	}
+	for _, slug := range []string{"", "ab", "-abc", "abc-", "Feedback", "feed back", "feed_back", strings.Repeat("a", 65)} {
+		assert.True(t, errors.Is(service.ValidateShareLinkSlug(slug), service.ErrInvalidShareLink), slug)
+	}
+}
+
+func TestGuestToken(t *testing.T) {
+	token := service.SignGuestToken("secret", 7, "abc123")
+
+	id, nonce, err := service.ParseGuestToken("secret", token)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(7), id)
+	assert.Equal(t, "abc123", nonce)
+
+	_, _, err = service.ParseGuestToken("another secret", token)
+	assert.True(t, errors.Is(err, service.ErrGuestSessionNotFound))
+
+	forged := strings.Replace(token, ".7.", ".8.", 1)
+	_, _, err = service.ParseGuestToken("secret", forged)
+	assert.True(t, errors.Is(err, service.ErrGuestSessionNotFound), "the id can not be changed")
+
+	invitation := service.SignInvitationToken("secret", 7, "abc123")
+	_, _, err = service.ParseGuestToken("secret", invitation)
+	assert.True(t, errors.Is(err, service.ErrGuestSessionNotFound), "invitation tokens are not guest tokens")
+	_, _, err = service.ParseInvitationToken("secret", token)
+	assert.True(t, errors.Is(err, service.ErrInvitationNotFound), "guest tokens are not invitation tokens")
+}
@@ -0,0 +1,114 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/G9QBootcamp/qoli-survey/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func validSurveyDocument() dto.SurveyDocument {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	return dto.SurveyDocument{
+		SchemaVersion:      dto.SurveyDocumentSchemaVersion,
+		Title:              "Customer feedback",
+		StartTime:          start,
+		EndTime:            start.Add(24 * time.Hour),
+		ParticipationLimit: 1,
+		AnswerTimeLimit:    600,
+		Questions: []dto.DocumentQuestion{
+			{Text: "Are you a customer?", Type: dto.MultipleChoiceQuestion, Choices: []dto.DocumentChoice{{Text: "yes"}, {Text: "no"}}},
+			{Text: "Why not?", Type: dto.TextQuestion, Condition: &dto.DocumentCondition{QuestionText: "Are you a customer?", Answer: "no"}},
+		},
+		Rules: []dto.DocumentRule{
+			{Question: 1, Condition: dto.RuleExpression{QuestionID: 1, Operator: dto.OperatorEqual, Value: "yes"}, EndSurvey: true},
+		},
+	}
+}
+
+func TestValidateSurveyDocument(t *testing.T) {
+	assert.Empty(t, service.ValidateSurveyDocument(validSurveyDocument()))
+
+	doc := validSurveyDocument()
+	doc.SchemaVersion = 2
+	doc.Questions[1].Condition.Answer = "maybe"
+	doc.Questions = append(doc.Questions, dto.DocumentQuestion{Text: "Age", Type: dto.RatingQuestion, Section: "About you"})
+	doc.Rules[0].TargetQuestion = 3
+	problems := service.ValidateSurveyDocument(doc)
+	assert.Len(t, problems, 5, "every problem is reported at once: %v", problems)
+}
+
+func TestSurveyDocumentYAMLRoundTrip(t *testing.T) {
+	doc := validSurveyDocument()
+	data, err := json.Marshal(doc)
+	assert.NoError(t, err)
+	yamlData, err := util.JSONToYAML(data)
+	assert.NoError(t, err)
+	assert.Contains(t, string(yamlData), "schema_version: 1")
+
+	jsonData, err := util.YAMLToJSON(yamlData)
+	assert.NoError(t, err)
+	decoded := dto.SurveyDocument{}
+	assert.NoError(t, json.Unmarshal(jsonData, &decoded))
+	assert.Equal(t, doc.Questions, decoded.Questions)
+	assert.True(t, doc.StartTime.Equal(decoded.StartTime))
+}
+
+// brokenRulesRepository fails to store branch rules, the last step of an import.
+type brokenRulesRepository struct {
+	*fakeSurveyRepository
+}
+
+func (r *brokenRulesRepository) ReplaceBranchRules(ctx context.Context, surveyId uint, rules []*models.BranchRule) error {
+	return errors.New("connection reset")
+}
+
+func TestImportSurvey(t *testing.T) {
+	repo := newFakeSurveyRepository()
+	surveys := service.NewSurveyService(nil, repo, nil, nil)
+	ctx := context.Background()
+
+	imported, err := surveys.ImportSurvey(ctx, 1, validSurveyDocument(), false)
+	assert.NoError(t, err)
+	assert.True(t, imported.Valid)
+	id := imported.Survey.SurveyID
+	assert.Len(t, repo.surveyQuestions(id), 2)
+	assert.Len(t, repo.rules, 1)
+	assert.Equal(t, repo.surveyQuestions(id)[1].ID, repo.surveyQuestions(id)[0].Choices[1].LinkedQuestionID)
+
+	broken := &brokenRulesRepository{newFakeSurveyRepository()}
+	_, err = service.NewSurveyService(nil, broken, nil, nil).ImportSurvey(ctx, 1, validSurveyDocument(), false)
+	assert.Error(t, err)
+	assert.Empty(t, broken.surveys, "a survey that failed to be imported is removed")
+	assert.Empty(t, broken.questions)
+}
+
+func TestCloneSurveyRemapsPiping(t *testing.T) {
+	repo := newFakeSurveyRepository(&models.Survey{ID: 1, Title: "colours", Status: models.SurveyStatusOpen})
+	colour := repo.addQuestion(&models.Question{SurveyID: 1, Text: "Favourite colours?", Type: string(dto.MultipleChoiceQuestion), HasMultipleChoice: true,
+		Choices: []models.Choice{{Text: "red"}, {Text: "blue"}}})
+	ref := "{{q" + strconv.Itoa(int(colour.ID)) + "}}"
+	why := repo.addQuestion(&models.Question{SurveyID: 1, Text: "Why " + ref + "?", TextTranslations: models.Translations{"fa": "چرا " + ref + "؟"}, Type: string(dto.TextQuestion)})
+	repo.addQuestion(&models.Question{SurveyID: 1, Text: "Which one most?", Type: string(dto.MultipleChoiceQuestion), HasMultipleChoice: true,
+		Config: models.QuestionConfig{ChoicesFrom: colour.ID}, Choices: []models.Choice{{Text: "none of " + ref}}})
+	surveys := service.NewSurveyService(nil, repo, nil, nil)
+
+	clone, err := surveys.CloneSurvey(context.Background(), 2, 1, dto.SurveyCloneRequest{})
+	assert.NoError(t, err)
+	copies := repo.surveyQuestions(clone.SurveyID)
+	assert.Len(t, copies, 3)
+	copied := "{{q" + strconv.Itoa(int(copies[0].ID)) + "}}"
+	assert.Equal(t, "Why "+copied+"?", copies[1].Text)
+	assert.Equal(t, "چرا "+copied+"؟", copies[1].TextTranslations["fa"])
+	assert.Equal(t, copies[0].ID, copies[2].Config.ChoicesFrom)
+	assert.Equal(t, "none of "+copied, copies[2].Choices[0].Text)
+	assert.Equal(t, "Why "+ref+"?", why.Text, "the source survey is left as it is")
+}
@@ -0,0 +1,48 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateOption(t *testing.T) {
+	assert.NoError(t, service.ValidateOption("vote_deletion_limit_hours", "24"))
+	assert.NoError(t, service.ValidateOption("votes_visibility", "admin"))
+	assert.NoError(t, service.ValidateOption("city", "Tehran"))
+	assert.NoError(t, service.ValidateOption("invitation_only", "true"))
+
+	invalid := [][2]string{
+		{"vote_deletion_limit_hour", "24"},
+		{"vote_deletion_limit_hours", "a day"},
+		{"vote_deletion_limit_hours", "-1"},
+		{"votes_visibility", "Admin"},
+		{"city", ""},
+		{"invitation_only", "yes"},
+	}
+	for _, option := range invalid {
+		assert.True(t, errors.Is(service.ValidateOption(option[0], option[1]), service.ErrInvalidOption), option[0])
+	}
+}
+
+func TestOptionValue(t *testing.T) {
+	options := []dto.SurveyOptionResponse{{Name: "vote_deletion_limit_hours", Value: "12"}}
+
+	assert.Equal(t, "public", service.OptionValue(options, "votes_visibility"))
+	hours, ok := service.OptionInt(options, "vote_deletion_limit_hours")
+	assert.True(t, ok)
+	assert.Equal(t, 12, hours)
+	_, ok = service.OptionInt(options, "minimum_age")
+	assert.False(t, ok)
+}
+
+func TestSurveyOptionSchemas(t *testing.T) {
+	names := []string{}
+	for _, schema := range service.SurveyOptionSchemas() {
+		names = append(names, schema.Name)
+	}
+	assert.Equal(t, []string{"city", "invitation_only", "minimum_age", "vote_deletion_limit_hours", "votes_visibility"}, names)
+}
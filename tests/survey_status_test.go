@@ -0,0 +1,35 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSurveyStatusTransitions(t *testing.T) {
+	assert.True(t, models.SurveyStatusDraft.CanTransitionTo(models.SurveyStatusScheduled))
+	assert.True(t, models.SurveyStatusScheduled.CanTransitionTo(models.SurveyStatusDraft))
+	assert.True(t, models.SurveyStatusOpen.CanTransitionTo(models.SurveyStatusClosed))
+	assert.True(t, models.SurveyStatusClosed.CanTransitionTo(models.SurveyStatusArchived))
+
+	assert.False(t, models.SurveyStatusDraft.CanTransitionTo(models.SurveyStatusOpen))
+	assert.False(t, models.SurveyStatusClosed.CanTransitionTo(models.SurveyStatusOpen))
+	assert.False(t, models.SurveyStatusArchived.CanTransitionTo(models.SurveyStatusDraft))
+	assert.False(t, models.SurveyStatus("published").IsValid())
+}
+
+func TestSurveyCurrentStatus(t *testing.T) {
+	now := time.Now()
+	survey := models.Survey{Status: models.SurveyStatusScheduled, StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour)}
+	assert.Equal(t, models.SurveyStatusOpen, survey.CurrentStatus(now))
+	assert.False(t, survey.IsEditable())
+
+	survey.EndTime = now.Add(-time.Minute)
+	assert.Equal(t, models.SurveyStatusClosed, survey.CurrentStatus(now))
+
+	survey.Status = models.SurveyStatusDraft
+	assert.Equal(t, models.SurveyStatusDraft, survey.CurrentStatus(now))
+	assert.True(t, survey.IsEditable())
+}
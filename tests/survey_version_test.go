@@ -0,0 +1,173 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/dto"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/models"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/repository"
+	"github.com/G9QBootcamp/qoli-survey/internal/survey/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishVersion(t *testing.T) {
+	repo := newFakeSurveyRepository(&models.Survey{ID: 1, Title: "colours", Status: models.SurveyStatusOpen})
+	repo.addQuestion(&models.Question{SurveyID: 1, Text: "Favourite colour?", HasMultipleChoice: true, Choices: []models.Choice{{Text: "red"}, {Text: "blue"}}})
+	question := repo.addQuestion(&models.Question{SurveyID: 1, Text: "Why?"})
+	versions := service.NewVersionService(nil, repo, nil)
+	ctx := context.Background()
+
+	first, err := versions.PublishVersion(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, first.Version)
+	assert.Equal(t, 1, repo.surveys[1].CurrentVersion)
+	assert.Len(t, first.Definition.Questions, 2)
+
+	again, err := versions.PublishVersion(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, first.ID, again.ID, "nothing changed, the latest version is kept")
+
+	// the database gives jsonb back with its keys in another order
+	stored := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal([]byte(repo.versions[0].Definition), &stored))
+	reordered, _ := json.Marshal(stored)
+	repo.versions[0].Definition = string(reordered)
+	again, err = versions.PublishVersion(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, first.ID, again.ID, "the key order of the stored definition does not make a new version")
+	assert.Len(t, repo.versions, 1)
+
+	question.Text = "Why that one?"
+	second, err := versions.PublishVersion(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, second.Version)
+	assert.Equal(t, 2, repo.surveys[1].CurrentVersion)
+	assert.Equal(t, "Why that one?", second.Definition.Questions[1].Text)
+}
+
+// fakeVersionReportRepository has one stored version of survey 1 and the vote counts of its
+// questions, it records the versions reports asked for.
+type fakeVersionReportRepository struct {
+	repository.IReportRepository
+	version     *models.SurveyVersion
+	counts      []dto.ChoiceVoteCount
+	respondents []dto.QuestionRespondentCount
+	definitions []int
+	counted     []int
+}
+
+func (r *fakeVersionReportRepository) GetSurvey(ctx context.Context, surveyId uint) (*models.Survey, error) {
+	return &models.Survey{ID: surveyId}, nil
+}
+
+func (r *fakeVersionReportRepository) GetSurveyVersion(ctx context.Context, surveyId uint, version int) (*models.SurveyVersion, error) {
+	r.definitions = append(r.definitions, version)
+	if version != r.version.Version {
+		return nil, nil
+	}
+	return r.version, nil
+}
+
+func (r *fakeVersionReportRepository) GetParticipationCountByVersions(ctx context.Context, surveyId uint, versions []int) (int64, error) {
+	return 10, nil
+}
+
+func (r *fakeVersionReportRepository) GetChoiceVoteCounts(ctx context.Context, questionIds []uint, versions []int) ([]dto.ChoiceVoteCount, error) {
+	r.counted = versions
+	counts := []dto.ChoiceVoteCount{}
+	for _, count := range r.counts {
+		for _, v := range versions {
+			if count.SurveyVersion == v {
+				counts = append(counts, count)
+			}
+		}
+	}
+	return counts, nil
+}
+
+func (r *fakeVersionReportRepository) GetQuestionRespondentCounts(ctx context.Context, questionIds []uint, versions []int) ([]dto.QuestionRespondentCount, error) {
+	return r.respondents, nil
+}
+
+func TestVersionReports(t *testing.T) {
+	definition := dto.SurveyDefinition{Questions: dto.QuestionList{
+		{ID: 1, HasMultipleChoice: true, Choices: []dto.Choice{{ID: 1, Text: "red"}, {ID: 2, Text: "blue"}}},
+		{ID: 2, Text: "Why?"},
+	}}
+	stored, err := json.Marshal(definition)
+	assert.NoError(t, err)
+	respondents := []dto.QuestionRespondentCount{{QuestionID: 1, Respondents: 10}}
+
+	tests := []struct {
+		name     string
+		counts   []dto.ChoiceVoteCount
+		versions []int
+		mapping  map[uint]uint
+		red      dto.ChoiceReport
+		blue     dto.ChoiceReport
+		total    int64
+		unmapped int64
+	}{
+		{
+			name:     "votes of the version match by choice id",
+			counts:   []dto.ChoiceVoteCount{{QuestionID: 1, ChoiceID: 1, SurveyVersion: 2, Count: 6}, {QuestionID: 1, ChoiceID: 2, SurveyVersion: 2, Count: 4}},
+			versions: []int{2},
+			red:      dto.ChoiceReport{ID: 1, Text: "red", Percentage: "60%", SelectionPercentage: "60%"},
+			blue:     dto.ChoiceReport{ID: 2, Text: "blue", Percentage: "40%", SelectionPercentage: "40%"},
+			total:    10,
+		},
+		{
+			name:     "choices of an older version follow the choice mapping",
+			counts:   []dto.ChoiceVoteCount{{QuestionID: 1, ChoiceID: 1, SurveyVersion: 2, Count: 5}, {QuestionID: 1, ChoiceID: 7, SurveyVersion: 1, Count: 5}},
+			versions: []int{1, 2},
+			mapping:  map[uint]uint{7: 2},
+			red:      dto.ChoiceReport{ID: 1, Text: "red", Percentage: "50%", SelectionPercentage: "50%"},
+			blue:     dto.ChoiceReport{ID: 2, Text: "blue", Percentage: "50%", SelectionPercentage: "50%"},
+			total:    10,
+		},
+		{
+			name:     "votes without a choice id fall back to the choice text",
+			counts:   []dto.ChoiceVoteCount{{QuestionID: 1, Answer: "blue", SurveyVersion: 1, Count: 2}, {QuestionID: 1, ChoiceID: 2, SurveyVersion: 2, Count: 3}},
+			versions: []int{1, 2},
+			red:      dto.ChoiceReport{ID: 1, Text: "red", Percentage: "0%", SelectionPercentage: "0%"},
+			blue:     dto.ChoiceReport{ID: 2, Text: "blue", Percentage: "50%", SelectionPercentage: "100%"},
+			total:    5,
+		},
+		{
+			name: "votes that match no choice are unmapped",
+			counts: []dto.ChoiceVoteCount{
+				{QuestionID: 1, ChoiceID: 1, SurveyVersion: 2, Count: 2}, {QuestionID: 1, ChoiceID: 9, SurveyVersion: 1, Count: 1},
+				{QuestionID: 1, Answer: "green", SurveyVersion: 1, Count: 1}, {QuestionID: 2, Answer: "because", SurveyVersion: 2, Count: 8},
+			},
+			versions: []int{1, 2},
+			mapping:  map[uint]uint{0: 1},
+			red:      dto.ChoiceReport{ID: 1, Text: "red", Percentage: "20%", SelectionPercentage: "50%"},
+			blue:     dto.ChoiceReport{ID: 2, Text: "blue", Percentage: "0%", SelectionPercentage: "0%"},
+			total:    4,
+			unmapped: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeVersionReportRepository{version: &models.SurveyVersion{SurveyID: 1, Version: 2, Definition: string(stored)}, counts: tt.counts, respondents: respondents}
+			reports := service.NewReportService(nil, repo, nil)
+			var got []dto.QuestionReport
+			if len(tt.versions) == 1 {
+				report, err := reports.GetVersionReport(context.Background(), 1, 2)
+				assert.NoError(t, err)
+				got = report.ChoicesPercentage
+			} else {
+				report, err := reports.GetMergedReport(context.Background(), 1, dto.MergedReportRequest{Versions: tt.versions, ChoiceMapping: tt.mapping})
+				assert.NoError(t, err)
+				assert.Equal(t, 2, report.TargetVersion, "the latest version is the target")
+				got = report.ChoicesPercentage
+			}
+			assert.Equal(t, []int{2}, repo.definitions, "the votes are laid out on the target version")
+			assert.Equal(t, tt.versions, repo.counted)
+			assert.Len(t, got, 1, "only choice questions are reported")
+			assert.Equal(t, dto.QuestionReport{QuestionID: 1, Respondents: 10, Selections: tt.total, Unmapped: tt.unmapped, ChoiceReport: []dto.ChoiceReport{tt.red, tt.blue}}, got[0])
+		})
+	}
+}